@@ -1,83 +1,1201 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"animinterminal/internal/analogclock"
+	"animinterminal/internal/aquarium"
+	"animinterminal/internal/attractor"
 	"animinterminal/internal/aurora"
+	"animinterminal/internal/balls"
+	"animinterminal/internal/banner"
+	"animinterminal/internal/boids"
+	"animinterminal/internal/cast"
+	"animinterminal/internal/clock"
 	"animinterminal/internal/cloud"
+	"animinterminal/internal/color"
 	"animinterminal/internal/cybercube"
+	"animinterminal/internal/donut"
+	"animinterminal/internal/ecg"
+	"animinterminal/internal/fire"
+	"animinterminal/internal/fireworks"
+	"animinterminal/internal/galaxy"
+	"animinterminal/internal/globe"
+	"animinterminal/internal/helix"
+	"animinterminal/internal/lava"
+	"animinterminal/internal/life"
+	"animinterminal/internal/maze"
+	"animinterminal/internal/night"
 	"animinterminal/internal/ocean"
 	"animinterminal/internal/orbit"
+	"animinterminal/internal/pipes"
 	"animinterminal/internal/plasma"
+	"animinterminal/internal/radar"
 	"animinterminal/internal/rain"
+	"animinterminal/internal/render"
+	"animinterminal/internal/ripple"
+	"animinterminal/internal/sand"
 	"animinterminal/internal/skyline"
+	"animinterminal/internal/snow"
 	"animinterminal/internal/spectrum"
 	"animinterminal/internal/starfield"
+	"animinterminal/internal/storm"
+	"animinterminal/internal/term"
 	"animinterminal/internal/tunnel"
+	"animinterminal/internal/typer"
 )
 
+// animation is a mode the binary can run, under its name or any alias.
+// Adding a mode is one more entry in the table in main.
+type animation struct {
+	names []string
+	run   func() error
+}
+
 func main() {
-	mode := flag.String("mode", "cybercube", "cybercube | rain | spectrum | cloud | starfield | orbit | plasma | skyline | ocean | aurora | tunnel")
-	width := flag.Int("width", 0, "override character width")
-	height := flag.Int("height", 0, "override character height")
+	// The usage is the list of modes, filled in once the table below exists.
+	mode := flag.String("mode", "cybercube", "")
+	width := flag.Int("width", 0, "override character width (default: terminal width)")
+	height := flag.Int("height", 0, "override character height (default: terminal height)")
 	delay := flag.Duration("delay", 0, "override frame delay (e.g. 50ms)")
-	cubeLayout := flag.String("cube-layout", "multi", "cybercube layout: multi | single")
+	duration := flag.Duration("duration", 0, "stop after this long (e.g. 30s; default: run until q)")
+	frames := flag.Int("frames", 0, "stop after this many frames (default: run until q)")
+	seed := flag.Int64("seed", 0, "seed for the random parts, so runs repeat exactly (every mode with any; default: new each run)")
+	audioInput := flag.String("audio-input", "", "raw 16-bit mono 44.1kHz PCM file, FIFO or stdin to react to (tunnel, plasma, spectrum)")
+	reducedMotion := flag.Bool("reduced-motion", false, "tone down full-screen flashes")
+	eco := flag.Bool("eco", false, "save CPU: without -mode, show the calm night sky")
+	colorDepth := flag.String("color", "auto", "colors to use: auto | truecolor | 256 | 16 | mono")
+	record := flag.String("record", "", "also record the animation to this animated GIF file, then stop")
+	recordFrames := flag.Int("record-frames", 100, "frames to record (with -record)")
+	recordFPS := flag.Int("record-fps", 20, "frames per second to record at (with -record)")
+	castPath := flag.String("cast", "", "also write the animation to this asciicast v2 file for asciinema")
+	headless := flag.Bool("headless", false, "draw nothing on the terminal, only -record and -cast (needs -record, -duration or -frames to stop)")
+	charset := flag.String("charset", "ascii", "how finely to draw: ascii | blocks | braille (starfield, plasma)")
+	showStats := flag.Bool("stats", false, "show the frame rate, compute and write time per frame, and bytes per frame in the top left corner")
+	idleFPS := flag.Float64("idle-fps", 0, "slow to this many frames a second after -idle-after without a key press, until the next one (e.g. 1; default: off)")
+	idleAfter := flag.Duration("idle-after", 30*time.Second, "how long without a key press before -idle-fps slows the animation")
+	maxCPU := flag.Float64("max-cpu", 0, "hold frames back so drawing them takes at most this percent of the time (e.g. 25; default: off)")
+	cycleInterval := flag.Duration("cycle-interval", 30*time.Second, "cycle: how long each mode plays before the next")
+	cycleOrder := flag.String("cycle-order", "", "cycle: modes to play, comma separated, in order (default all)")
+	cycleShuffle := flag.Bool("cycle-shuffle", false, "cycle: play the modes in a random order")
+	cubeLayout := flag.String("cube-layout", "multi", "cybercube layout: multi | single | grid (as many as fit the terminal)")
+	cubeSpec := flag.String("cube-spec", "", "cybercube: custom layout, cubes separated by ; each as scale=,x=,y=,speed= e.g. \"scale=0.8,x=-0.5;scale=1.2,x=0.5\" (overrides -cube-layout)")
+	starfieldDrift := flag.Float64("starfield-drift", 0, "starfield: how far the vanishing point wanders, 0-0.85 of half the screen (0 keeps it centered)")
+	starfieldDriftSpeed := flag.Float64("starfield-drift-speed", 0, "starfield: how fast the vanishing point wanders, in radians per frame (default 0.01)")
+	orbitParticles := flag.Int("orbit-particles", 0, "orbit: number of orbiting particles, at least 48 (default 120)")
+	orbitHUD := flag.String("orbit-hud", "", "orbit: up to three lines of your own in the top left, separated by | (default: particle count and frame)")
+	skylineBanner := flag.String("skyline-banner", "", "skyline: banner text towed by the blimp")
+	skylineFlyers := flag.Duration("skyline-flyers", 0, "skyline: average interval between flying objects (e.g. 30s)")
+	oceanShip := flag.String("ocean-ship", "", "ocean: ship spec, e.g. dir=left,speed=0.2,every=30s")
+	oceanLife := flag.Float64("ocean-life", 0, "ocean: marine life density (e.g. 0.2 calm, 1.5 busy)")
+	oceanCycle := flag.Duration("ocean-cycle", 0, "ocean: length of a full day/night cycle (e.g. 2m)")
+	oceanPhase := flag.Float64("ocean-phase", -1, "ocean: lock the time of day (0 midnight, 0.25 sunrise, 0.5 noon, 0.75 sunset)")
+	oceanStorm := flag.Bool("ocean-storm", false, "ocean: whip up a storm with whitecaps, spray, rain and lightning")
+	oceanNight := flag.Bool("ocean-night", false, "ocean: moonlit night with bioluminescent plankton")
+	oceanAmplitude := flag.Float64("ocean-amplitude", 0, "ocean: wave height multiplier (0.3-1.8)")
+	oceanChop := flag.Float64("ocean-chop", -1, "ocean: weight of short choppy ripples (0-1)")
+	oceanSwell := flag.String("ocean-swell", "", "ocean: swell direction: left | right | calm | -1..1")
+	oceanLighthouse := flag.String("ocean-lighthouse", "off", "ocean: lighthouse side: left | right | off")
+	oceanBeam := flag.Duration("ocean-beam", 0, "ocean: lighthouse beam rotation period (e.g. 4s)")
+	oceanUnderwater := flag.Bool("ocean-underwater", false, "ocean: view the sea from below the surface")
+	oceanTide := flag.Duration("ocean-tide", 0, "ocean: tide period, how long the sea takes to rise and fall (e.g. 90s)")
+	oceanTideRange := flag.Int("ocean-tide-range", -1, "ocean: rows the waterline moves with the tide (0 disables)")
+	oceanSets := flag.Duration("ocean-sets", 0, "ocean: period of the bigger wave sets (e.g. 15s)")
+	oceanBirds := flag.Int("ocean-birds", -1, "ocean: number of seagulls (0 disables)")
+	oceanMouse := flag.Bool("ocean-mouse", false, "ocean: clicking sends up a burst of bubbles")
+	cloudWeather := flag.String("cloud-weather", "", "cloud: weather preset: clear | fair | overcast | storm")
+	cloudCycle := flag.Duration("cloud-cycle", 0, "cloud: morph through the weather presets, this long per change (e.g. 3m)")
+	cloudGround := flag.String("cloud-ground", "off", "cloud: ground silhouette: hills | city | sea | off")
+	cloudSun := flag.String("cloud-sun", "off", "cloud: body behind the clouds: sun | moon | off")
+	cloudSunPos := flag.String("cloud-sun-pos", "", "cloud: fixed sun/moon position as x,y in 0-1 (default: crosses the sky)")
+	cloudWind := flag.Float64("cloud-wind", 0, "cloud: wind speed multiplier, negative reverses (default 1)")
+	cloudGusts := flag.Bool("cloud-gusts", false, "cloud: random gusts that speed up and stretch the clouds")
+	cloudFlyovers := flag.Duration("cloud-flyovers", 0, "cloud: average interval between birds or planes crossing (e.g. 20s)")
+	cloudRealtime := flag.Bool("cloud-realtime", false, "cloud: tint the sky for the local time of day")
+	cloudHour := flag.Float64("cloud-hour", -1, "cloud: pretend it is this hour (0-24) for the time-of-day tint")
+	cloudDay := flag.Duration("cloud-day", 0, "cloud: run through a whole day and night this often, blending the sky from -cloud-hour or noon (e.g. 2m)")
+	cloudLayers := flag.Int("cloud-layers", 0, "cloud: number of cloud layers (1-6, default 3)")
+	auroraLake := flag.Bool("aurora-lake", false, "aurora: foreground lake reflecting the sky")
+	auroraActivity := flag.Float64("aurora-activity", -1, "aurora: average activity 0-1 (default 0.5)")
+	auroraColors := flag.String("aurora-colors", "green", "aurora: green | red | purple | rainbow | custom:<256-color codes, comma separated>")
+	auroraMeteors := flag.Float64("aurora-meteors", -1, "aurora: shooting stars per minute (0 disables, default 2)")
+	auroraTrees := flag.Float64("aurora-trees", -1, "aurora: density of the foreground pines 0-1 (0 disables, default 0.4)")
+	auroraCabin := flag.Bool("aurora-cabin", false, "aurora: add a cabin with a lit window and chimney smoke")
+	auroraStars := flag.Float64("aurora-stars", -1, "aurora: star density 0-1 (0 disables, default 0.3)")
+	auroraWind := flag.Float64("aurora-wind", 0, "aurora: curtain drift speed multiplier, negative reverses (default 1)")
+	auroraMoon := flag.String("aurora-moon", "off", "aurora: moon phase: full | half | crescent | auto (today's phase) | off")
+	tunnelShape := flag.String("tunnel-shape", "circle", "tunnel: cross-section: circle | square | hex | star")
+	tunnelTexture := flag.String("tunnel-texture", "smooth", "tunnel: wall texture: smooth | checker | stripes")
+	tunnelSway := flag.String("tunnel-sway", "", "tunnel: winding amount[,rate], e.g. 1.5 or 1,2 (0 flies straight, default 1)")
+	tunnelGates := flag.Float64("tunnel-gates", -1, "tunnel: gates and obstacles per minute (0 disables, default 12)")
+	tunnelBPM := flag.Float64("tunnel-bpm", 0, "tunnel: synthetic beats per minute when there is no audio input")
+	tunnelPalette := flag.String("tunnel-palette", "neon", "tunnel: color ramp: neon | ice | inferno | toxic | vaporwave")
+	tunnelFog := flag.Float64("tunnel-fog", -1, "tunnel: depth fog density 0-1 (0 disables, default 0.4)")
+	tunnelFogColor := flag.String("tunnel-fog-color", "", "tunnel: fog color as #rrggbb (default #0a0a1e)")
+	plasmaNoise := flag.String("plasma-noise", "hash", "plasma: noise mixed into the waves: hash | value | perlin | simplex | worley")
+	plasmaSymmetry := flag.String("plasma-symmetry", "", "plasma: kaleidoscope wedges N[,turns per minute], e.g. 6 or 8,0.5 (0 disables)")
+	plasmaMetaballs := flag.String("plasma-metaballs", "", "plasma: N[,radius[,speed]] bouncing metaballs instead of the waves, e.g. 6 or 8,4,1 (0 disables)")
+	plasmaCycle := flag.String("plasma-cycle", "forward", "plasma: palette scroll direction: forward | reverse | pingpong")
+	plasmaGradient := flag.String("plasma-gradient", "", "plasma: custom palette as 256-color stops, comma separated, e.g. 17,33,51,195")
+	plasmaPulse := flag.Float64("plasma-pulse", 0, "plasma: synthetic beats per minute when there is no audio input")
+	plasmaBlur := flag.Float64("plasma-blur", 0, "plasma: motion blur 0-0.9, blending each frame into the last (0 disables)")
+	plasmaMouse := flag.Bool("plasma-mouse", false, "plasma: the attractor follows the mouse pointer (arrow keys steer it, b drops one)")
+	rainCharset := flag.String("rain-charset", "ascii", "rain: what the streams are made of: ascii | katakana | binary | mixed (each stream picks)")
+	rainTheme := flag.String("rain-theme", "green", "rain: stream colors: green | cyan | amber | mono")
+	rainMouse := flag.Bool("rain-mouse", false, "rain: clicking brings lightning down and splashes the rain there")
+	spectrumInput := flag.String("spectrum-input", "none", "spectrum: raw 16-bit mono PCM for the bars to follow: none | stdin | a file or FIFO (default -audio-input)")
+	spectrumRate := flag.Int("spectrum-rate", 44100, "spectrum: sample rate of the input in Hz")
+	spectrumBins := flag.Int("spectrum-bins", 2048, "spectrum: FFT size, a power of two from 256 to 16384; larger is finer but slower to react")
+	spectrumBars := flag.Int("spectrum-bars", 0, "spectrum: number of bars, at most one per column (or row) (default: a third of the columns, or every row when horizontal)")
+	spectrumLayout := flag.String("spectrum-layout", "bars", "spectrum: bars | mirror (up and down from the middle) | horizontal (one per row) | horizontal-mirror")
+	fireIntensity := flag.Float64("fire-intensity", 0, "fire: how high the flames reach, 0-1 (default 0.7)")
+	fireWind := flag.Float64("fire-wind", 0, "fire: sideways bend of the flames, -1 (left) to 1 (right)")
+	snowDensity := flag.Float64("snow-density", 0, "snow: how thickly it snows, 0-1 (default 0.4)")
+	snowWind := flag.Float64("snow-wind", 0, "snow: sideways wind, -1 (left) to 1 (right) (default 0.2)")
+	snowMelt := flag.Float64("snow-melt", -1, "snow: rows of snowpack melting per second (0 keeps it, default 0.01)")
+	snowUnicode := flag.Bool("snow-unicode", false, "snow: draw the biggest flakes as unicode snowflakes")
+	fireworksRate := flag.Float64("fireworks-rate", 0, "fireworks: launches per minute (default 30)")
+	fireworksGravity := flag.Float64("fireworks-gravity", 0, "fireworks: gravity multiplier (default 1)")
+	fireworksPalette := flag.String("fireworks-palette", "mixed", "fireworks: shell colors: mixed | warm | cool | gold | neon")
+	fireworksShells := flag.String("fireworks-shells", "", "fireworks: shell types to use, comma separated: peony,ring,willow,crackle (default all)")
+	lifeRule := flag.String("life-rule", "B3/S23", "life: rule as B/S notation (e.g. B36/S23) or life | highlife | daynight | seeds | maze")
+	lifePattern := flag.String("life-pattern", "", "life: seed: soup | glider | rpentomino | acorn | gosper (default: a random pick on every reseed)")
+	lifeDensity := flag.Float64("life-density", 0, "life: share of live cells in a random soup, 0-1 (default 0.3)")
+	lifeWrap := flag.Bool("life-wrap", true, "life: wrap the edges into a torus")
+	pipesCount := flag.Int("pipes-count", 0, "pipes: how many pipes grow at once (default 4)")
+	pipesTurn := flag.Float64("pipes-turn", -1, "pipes: chance of turning at each step, 0-1 (default 0.15)")
+	pipesFade := flag.String("pipes-fade", "dissolve", "pipes: how the full screen clears: dissolve | wipe | clear")
+	pipesASCII := flag.Bool("pipes-ascii", false, "pipes: draw with + - | instead of box-drawing characters")
+	donutRatio := flag.Float64("donut-ratio", 0, "donut: ring radius over tube radius, above 1 (default 2)")
+	donutSpin := flag.String("donut-spin", "", "donut: rotation speeds about X and Z in radians per frame, as X,Z (default 0.04,0.02)")
+	donutPalette := flag.String("donut-palette", "neon", "donut: shading colors: mono | neon | fire | ice")
+	globeSpeed := flag.Float64("globe-speed", 0, "globe: rotation per frame in radians, negative spins westward (default 0.03)")
+	globeTilt := flag.Float64("globe-tilt", 23.4, "globe: axial tilt in degrees")
+	globeTerminator := flag.Bool("globe-terminator", false, "globe: light from the side to show night and city lights")
+	clock12h := flag.Bool("clock-12h", false, "clock: 12-hour time with AM/PM")
+	clockSeconds := flag.Bool("clock-seconds", true, "clock: show seconds")
+	clockDate := flag.Bool("clock-date", true, "clock: show the weekday and date under the time")
+	clockBackground := flag.String("clock-background", "rain", "clock: faint effect behind the digits: none | rain | plasma")
+	aclockRoman := flag.Bool("aclock-roman", false, "aclock: label the hours with Roman numerals")
+	aclockDate := flag.Bool("aclock-date", false, "aclock: show the day of the month in a window by the three")
+	aclockPendulum := flag.Bool("aclock-pendulum", true, "aclock: swing a pendulum under the face on terminals at least 40 rows tall")
+	aclockOffset := flag.Duration("aclock-offset", 0, "aclock: shift the system time, e.g. 9h or -30m")
+	lavaBlobs := flag.Int("lava-blobs", 0, "lava: how many blobs of wax float in the lamp (default 6)")
+	lavaViscosity := flag.Float64("lava-viscosity", -1, "lava: thickness of the liquid, 0-1; thicker is slower (default 0.5)")
+	lavaPalette := flag.String("lava-palette", "classic", "lava: wax colors: classic | blue | green | purple")
+	helixRadius := flag.Float64("helix-radius", 0, "dna: how far each strand swings from the axis, in columns (default 14)")
+	helixPitch := flag.Float64("helix-pitch", 0, "dna: rows per full turn of the helix (default 24)")
+	helixSpin := flag.Float64("helix-spin", 0, "dna: rotation per frame in radians (default 0.05)")
+	helixSeq := flag.String("helix-seq", "", "dna: read the bases from a text or FASTA file, or - for stdin, instead of random ones")
+	boidsCount := flag.Int("boids-count", 0, "boids: how many boids to start with, 10-600 (default 150)")
+	boidsRadius := flag.Float64("boids-radius", 0, "boids: how far a boid sees its neighbours, in columns (default 6)")
+	boidsSpeed := flag.Float64("boids-speed", 0, "boids: top speed in columns per frame (default 1.2)")
+	boidsPredator := flag.Bool("boids-predator", false, "boids: let two predators chase the flock")
+	boidsEdges := flag.String("boids-edges", "wrap", "boids: screen edge behaviour: wrap | bounce")
+	boidsColor := flag.String("boids-color", "flock", "boids: color by flock | speed")
+	sandSpouts := flag.Int("sand-spouts", -1, "sand: how many spouts pour along the top (default 3)")
+	sandWater := flag.Bool("sand-water", false, "sand: every other spout pours water")
+	sandMax := flag.Int("sand-max", 0, "sand: particle cap; past it the floor drains (default half the screen)")
+	attractorSystem := flag.String("attractor-system", "lorenz", "attractor: system to trace: lorenz | rossler | aizawa")
+	attractorParams := flag.String("attractor-params", "", "attractor: constants in order, e.g. sigma,rho,beta for lorenz (default 10,28,2.667)")
+	attractorTrail := flag.Int("attractor-trail", 0, "attractor: how many recent points stay on screen (default 1500)")
+	attractorSpin := flag.Float64("attractor-spin", 0, "attractor: view rotation per frame in radians (default 0.006)")
+	mazeCell := flag.Int("maze-cell", 0, "maze: passage width in columns (default 3)")
+	mazeGenerator := flag.String("maze-generator", "backtracker", "maze: how to build it: backtracker | prim | kruskal")
+	mazeSolver := flag.String("maze-solver", "bfs", "maze: how to solve it: bfs | astar")
+	mazeSpeed := flag.Int("maze-speed", 0, "maze: build/solve steps per frame (default scales with the maze)")
+	flag.Int64Var(seed, "maze-seed", 0, "maze: same as -seed")
+	rippleDamping := flag.Float64("ripple-damping", 0, "ripple: share of a wave lost per frame, lower lasts longer (default 0.02)")
+	rippleRain := flag.Float64("ripple-rain", -1, "ripple: raindrops per second, 0 for none (default 1.5)")
+	rippleEdges := flag.String("ripple-edges", "reflect", "ripple: what the sides do to waves: reflect | absorb")
+	rippleMouse := flag.Bool("ripple-mouse", false, "ripple: moving the mouse pointer trails ripples (d drops one anywhere)")
+	ballsCount := flag.Int("balls-count", 0, "balls: how many balls start in the box (default 8)")
+	ballsGravity := flag.Float64("balls-gravity", -1, "balls: downward pull per frame in cells, 0 for none (default 0.08)")
+	ballsBounce := flag.Float64("balls-bounce", 0, "balls: share of speed kept on each bounce, 0-1 (default 0.85)")
+	ballsTrail := flag.Int("balls-trail", -1, "balls: trail length per ball, 0 for none (default 6)")
+	bannerText := flag.String("banner-text", "", "banner: text to scroll, or - to read it from stdin (default \"ANIM IN TERMINAL\")")
+	bannerFont := flag.String("banner-font", "block", "banner: lettering: block | thin | shadow")
+	bannerSpeed := flag.Float64("banner-speed", 0, "banner: columns scrolled per frame (default 1)")
+	bannerEffects := flag.String("banner-effects", "", "banner: comma-separated effects from rainbow, wave, sparkle, matrix, or none (default rainbow,wave)")
+	aquariumFish := flag.Int("aquarium-fish", 0, "aquarium: how many fish swim in the tank (default 12)")
+	aquariumSpecies := flag.String("aquarium-species", "", "aquarium: comma-separated kinds from minnow, tropical, angel, shark (default all)")
+	aquariumPlants := flag.Float64("aquarium-plants", -1, "aquarium: weeds per ten columns of sand, 0 for none (default 1)")
+	galaxyArms := flag.Int("galaxy-arms", 0, "galaxy: number of spiral arms, 1-6 (default 2)")
+	galaxySpeed := flag.Float64("galaxy-speed", 0, "galaxy: rotation per frame at the rim in radians, negative turns the other way (default 0.008)")
+	galaxyStars := flag.Int("galaxy-stars", 0, "galaxy: how many stars to draw (default 8000)")
+	galaxyASCII := flag.Bool("galaxy-ascii", false, "galaxy: shade whole cells instead of drawing braille dots")
+	typerFile := flag.String("typer-file", "", "typer: type out this file, or - for stdin, instead of the built-in snippets")
+	typerWPM := flag.Float64("typer-wpm", 0, "typer: typing speed in words per minute (default 70)")
+	typerErrors := flag.Float64("typer-errors", -1, "typer: chance each letter is a typo that gets fixed, 0 for none (default 0.02)")
+	typerLang := flag.String("typer-lang", "auto", "typer: highlighting: auto | go | python | js | c | plain")
+	radarRPM := flag.Float64("radar-rpm", 0, "radar: sweep turns per minute (default 12)")
+	radarContacts := flag.Int("radar-contacts", -1, "radar: how many random contacts cruise about, 0 for none (default 6)")
+	radarLabels := flag.Bool("radar-labels", false, "radar: write each contact's name beside it")
+	radarFeed := flag.String("radar-feed", "", "radar: file of \"bearing range [label]\" lines, re-read every sweep, instead of random contacts")
+	ecgBPM := flag.Float64("ecg-bpm", 0, "ecg: resting heart rate in beats per minute (default 72)")
+	nightStars := flag.Float64("night-stars", 0, "night: share of the sky's cells holding a star (default 0.03)")
+	nightMoon := flag.Float64("night-moon", -1, "night: moon phase, 0 new, 0.5 full, 0.75 last quarter (default follows the real moon)")
+	nightMeteors := flag.Float64("night-meteors", -1, "night: shooting stars per minute, 0 for none (default 0.5)")
+	flag.Int64Var(seed, "night-seed", 0, "night: same as -seed")
+	stormRate := flag.Float64("storm-rate", 0, "storm: close lightning strikes per minute (default 8)")
+	stormBranches := flag.Float64("storm-branches", -1, "storm: how forked the bolts are, 0-1 (default 0.5)")
+	skylineHUD := flag.Bool("skyline-hud", false, "skyline: show the building/lit-window/FPS HUD")
+	skylineSnow := flag.Bool("skyline-snow", false, "skyline: let it snow on the city")
+	// output is where every mode draws; nil is the terminal. outputFor adds
+	// the -cast recording, which needs the mode's frame delay; it takes its
+	// size from the first frame, as the mode may not draw at the size asked.
+	var output io.Writer
+	// finishCast flushes and closes the -cast file once the mode is done.
+	var finishCast func()
+	// recording is the -cast writer once a mode has made it; with -mode
+	// cycle the modes after carry on in the same file.
+	var recording *cast.Writer
+	outputFor := func(width, height int, delay time.Duration) io.Writer {
+		if *castPath == "" {
+			return output
+		}
+		if recording != nil {
+			recording.SetDelay(delay)
+			return recording
+		}
+		f, err := os.Create(*castPath)
+		if err != nil {
+			fmt.Println(err)
+			return output
+		}
+		echo := output
+		if echo == nil {
+			echo = os.Stdout
+		}
+		w := cast.NewWriter(f, width, height, delay, echo)
+		finishCast = func() {
+			if err := w.Flush(); err != nil {
+				fmt.Println(err)
+			}
+			f.Close()
+		}
+		recording = w
+		return w
+	}
+	animations := []animation{
+		{names: []string{"cybercube", "cube"}, run: func() error {
+			cfg := cybercube.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if cubeLayout != nil {
+				applyCubeLayout(&cfg, *cubeLayout)
+			}
+			if *cubeSpec != "" {
+				if instances, err := cybercube.ParseInstances(*cubeSpec); err == nil {
+					cfg.Instances = instances
+				} else {
+					fmt.Printf("invalid cube-spec %q: %v\n", *cubeSpec, err)
+				}
+			}
+			return cybercube.Run(cfg)
+		}},
+		{names: []string{"rain", "neonrain"}, run: func() error {
+			cfg := rain.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if rain.IsCharset(*rainCharset) {
+				cfg.Charset = *rainCharset
+			} else {
+				fmt.Printf("unknown rain-charset %q (expected ascii | katakana | binary | mixed)\n", *rainCharset)
+			}
+			if rain.IsTheme(*rainTheme) {
+				cfg.Theme = *rainTheme
+			} else {
+				fmt.Printf("unknown rain-theme %q (expected green | cyan | amber | mono)\n", *rainTheme)
+			}
+			cfg.Mouse = *rainMouse
+			return rain.Run(cfg)
+		}},
+		{names: []string{"spectrum", "equalizer", "scope"}, run: func() error {
+			cfg := spectrum.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			cfg.InputSource = *spectrumInput
+			if cfg.InputSource == "none" && *audioInput != "" {
+				cfg.InputSource = *audioInput
+			}
+			if *spectrumRate > 0 {
+				cfg.SampleRate = *spectrumRate
+			} else {
+				fmt.Printf("invalid spectrum-rate %d (expected a positive number of Hz)\n", *spectrumRate)
+			}
+			if b := *spectrumBins; b >= 256 && b <= 16384 && b&(b-1) == 0 {
+				cfg.Bins = b
+			} else {
+				fmt.Printf("invalid spectrum-bins %d (expected a power of two from 256 to 16384)\n", b)
+			}
+			if *spectrumBars >= 0 {
+				cfg.BarCount = *spectrumBars
+			} else {
+				fmt.Printf("invalid spectrum-bars %d (expected 0 or more)\n", *spectrumBars)
+			}
+			applySpectrumLayout(&cfg, *spectrumLayout)
+			return spectrum.Run(cfg)
+		}},
+		{names: []string{"cloud", "clouds", "sky"}, run: func() error {
+			cfg := cloud.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			applyCloudWeather(&cfg, *cloudWeather)
+			applyCloudGround(&cfg, *cloudGround)
+			cfg.ReducedMotion = *reducedMotion
+			applyCloudSun(&cfg, *cloudSun, *cloudSunPos)
+			if *cloudWind != 0 {
+				cfg.Wind = *cloudWind
+			}
+			cfg.Gusts = *cloudGusts
+			cfg.Realtime = *cloudRealtime
+			if *cloudLayers > 0 {
+				cfg.Layers = *cloudLayers
+			}
+			if *cloudHour >= 0 {
+				cfg.Hour = *cloudHour
+			}
+			if *cloudFlyovers > 0 {
+				cfg.FlyoverInterval = *cloudFlyovers
+			}
+			if *cloudCycle > 0 {
+				cfg.Cycle = *cloudCycle
+			}
+			if *cloudDay > 0 {
+				cfg.DayCycle = *cloudDay
+			}
+			return cloud.Run(cfg)
+		}},
+		{names: []string{"starfield", "warp", "stars"}, run: func() error {
+			cfg := starfield.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			cfg.Charset = *charset
+			cfg.DriftAmplitude = *starfieldDrift
+			if *starfieldDriftSpeed > 0 {
+				cfg.DriftSpeed = *starfieldDriftSpeed
+			}
+			return starfield.Run(cfg)
+		}},
+		{names: []string{"orbit", "hud", "core", "particles"}, run: func() error {
+			cfg := orbit.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *orbitParticles > 0 {
+				cfg.ParticleCount = *orbitParticles
+			}
+			if *orbitHUD != "" {
+				cfg.HUDLines = strings.Split(*orbitHUD, "|")
+			}
+			return orbit.Run(cfg)
+		}},
+		{names: []string{"plasma", "grid", "energy"}, run: func() error {
+			cfg := plasma.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if plasma.IsNoise(*plasmaNoise) {
+				cfg.NoiseType = *plasmaNoise
+			} else {
+				fmt.Printf("unknown plasma-noise %q (expected hash | value | perlin | simplex | worley)\n", *plasmaNoise)
+			}
+			applyPlasmaSymmetry(&cfg, *plasmaSymmetry)
+			applyPlasmaMetaballs(&cfg, *plasmaMetaballs)
+			if plasma.IsCycle(*plasmaCycle) {
+				cfg.PaletteCycle = *plasmaCycle
+			} else {
+				fmt.Printf("unknown plasma-cycle %q (expected forward | reverse | pingpong)\n", *plasmaCycle)
+			}
+			if *plasmaGradient != "" {
+				stops, err := plasma.ParseGradient(*plasmaGradient)
+				if err != nil {
+					fmt.Printf("invalid plasma-gradient %q: %v\n", *plasmaGradient, err)
+				} else {
+					cfg.Gradient = stops
+				}
+			}
+			cfg.Mouse = *plasmaMouse
+			cfg.AudioInput = *audioInput
+			cfg.Pulse = *plasmaPulse
+			cfg.Blur = *plasmaBlur
+			cfg.Charset = *charset
+			return plasma.Run(cfg)
+		}},
+		{names: []string{"skyline", "city", "neon"}, run: func() error {
+			cfg := skyline.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			cfg.Banner = *skylineBanner
+			cfg.ShowHUD = *skylineHUD
+			cfg.Snow = *skylineSnow
+			if *skylineFlyers > 0 {
+				cfg.FlyerInterval = *skylineFlyers
+			}
+			return skyline.Run(cfg)
+		}},
+		{names: []string{"ocean", "currents", "sea", "waves"}, run: func() error {
+			cfg := ocean.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			applyShipSpec(&cfg, *oceanShip)
+			if *oceanLife > 0 {
+				cfg.Life = *oceanLife
+			}
+			cfg.Storm = *oceanStorm
+			cfg.Night = *oceanNight
+			cfg.Underwater = *oceanUnderwater
+			if *oceanAmplitude > 0 {
+				cfg.Amplitude = *oceanAmplitude
+			}
+			if *oceanChop >= 0 {
+				cfg.Choppiness = *oceanChop
+			}
+			applySwell(&cfg, *oceanSwell)
+			applyLighthouse(&cfg, *oceanLighthouse)
+			if *oceanBeam > 0 {
+				cfg.BeamPeriod = *oceanBeam
+			}
+			if *oceanTide > 0 {
+				cfg.TidePeriod = *oceanTide
+			}
+			if *oceanTideRange >= 0 {
+				cfg.TideRange = *oceanTideRange
+			}
+			if *oceanSets > 0 {
+				cfg.SetPeriod = *oceanSets
+			}
+			if *oceanBirds >= 0 {
+				cfg.Birds = *oceanBirds
+			}
+			if *oceanCycle > 0 {
+				cfg.Cycle = *oceanCycle
+			}
+			if *oceanPhase >= 0 {
+				cfg.Phase = *oceanPhase
+				cfg.LockPhase = true
+			}
+			cfg.Mouse = *oceanMouse
+			return ocean.Run(cfg)
+		}},
+		{names: []string{"aurora", "borealis", "polar", "northern-lights"}, run: func() error {
+			cfg := aurora.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			cfg.Lake = *auroraLake
+			if *auroraActivity >= 0 {
+				cfg.Activity = *auroraActivity
+			}
+			applyAuroraColors(&cfg, *auroraColors)
+			if *auroraMeteors >= 0 {
+				cfg.Meteors = *auroraMeteors
+			}
+			if *auroraTrees >= 0 {
+				cfg.Trees = *auroraTrees
+			}
+			cfg.Cabin = *auroraCabin
+			if aurora.IsMoon(*auroraMoon) {
+				cfg.Moon = *auroraMoon
+			} else {
+				fmt.Printf("unknown aurora-moon %q (expected full | half | crescent | auto | off)\n", *auroraMoon)
+			}
+			if *auroraWind != 0 {
+				cfg.Wind = *auroraWind
+			}
+			if *auroraStars >= 0 {
+				cfg.Stars = *auroraStars
+			}
+			cfg.ReducedMotion = *reducedMotion
+			return aurora.Run(cfg)
+		}},
+		{names: []string{"tunnel", "vortex", "warp-tunnel"}, run: func() error {
+			cfg := tunnel.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if tunnel.IsShape(*tunnelShape) {
+				cfg.Shape = *tunnelShape
+			} else {
+				fmt.Printf("unknown tunnel-shape %q (expected circle | square | hex | star)\n", *tunnelShape)
+			}
+			if tunnel.IsTexture(*tunnelTexture) {
+				cfg.Texture = *tunnelTexture
+			} else {
+				fmt.Printf("unknown tunnel-texture %q (expected smooth | checker | stripes)\n", *tunnelTexture)
+			}
+			applyTunnelSway(&cfg, *tunnelSway)
+			if tunnel.IsPalette(*tunnelPalette) {
+				cfg.Palette = *tunnelPalette
+			} else {
+				fmt.Printf("unknown tunnel-palette %q (expected neon | ice | inferno | toxic | vaporwave)\n", *tunnelPalette)
+			}
+			if *tunnelFog >= 0 {
+				cfg.Fog = *tunnelFog
+			}
+			if *tunnelFogColor != "" {
+				if tunnel.IsFogColor(*tunnelFogColor) {
+					cfg.FogColor = *tunnelFogColor
+				} else {
+					fmt.Printf("invalid tunnel-fog-color %q (expected #rrggbb)\n", *tunnelFogColor)
+				}
+			}
+			cfg.AudioInput = *audioInput
+			cfg.BPM = *tunnelBPM
+			if *tunnelGates >= 0 {
+				cfg.Gates = *tunnelGates
+			}
+			return tunnel.Run(cfg)
+		}},
+		{names: []string{"fire", "flames", "doom"}, run: func() error {
+			cfg := fire.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *fireIntensity > 0 {
+				cfg.Intensity = *fireIntensity
+			}
+			cfg.Wind = *fireWind
+			return fire.Run(cfg)
+		}},
+		{names: []string{"snow", "snowfall", "winter"}, run: func() error {
+			cfg := snow.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *snowDensity > 0 {
+				cfg.Density = *snowDensity
+			}
+			if *snowWind != 0 {
+				cfg.Wind = *snowWind
+			}
+			if *snowMelt >= 0 {
+				cfg.Melt = *snowMelt
+			}
+			cfg.Unicode = *snowUnicode
+			return snow.Run(cfg)
+		}},
+		{names: []string{"fireworks", "hanabi"}, run: func() error {
+			cfg := fireworks.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *fireworksRate > 0 {
+				cfg.Rate = *fireworksRate
+			}
+			if *fireworksGravity > 0 {
+				cfg.Gravity = *fireworksGravity
+			}
+			if fireworks.IsPalette(*fireworksPalette) {
+				cfg.Palette = *fireworksPalette
+			} else {
+				fmt.Printf("unknown fireworks-palette %q (expected mixed | warm | cool | gold | neon)\n", *fireworksPalette)
+			}
+			applyFireworksShells(&cfg, *fireworksShells)
+			return fireworks.Run(cfg)
+		}},
+		{names: []string{"life", "conway", "gol"}, run: func() error {
+			cfg := life.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if _, err := life.ParseRule(*lifeRule); err != nil {
+				fmt.Printf("invalid life-rule %q: %v\n", *lifeRule, err)
+			} else {
+				cfg.Rule = *lifeRule
+			}
+			if life.IsPattern(*lifePattern) {
+				cfg.Pattern = *lifePattern
+			} else {
+				fmt.Printf("unknown life-pattern %q (expected soup | glider | rpentomino | acorn | gosper)\n", *lifePattern)
+			}
+			if *lifeDensity > 0 {
+				cfg.Density = *lifeDensity
+			}
+			cfg.Wrap = *lifeWrap
+			return life.Run(cfg)
+		}},
+		{names: []string{"pipes", "pipe"}, run: func() error {
+			cfg := pipes.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *pipesCount > 0 {
+				cfg.Pipes = *pipesCount
+			}
+			if *pipesTurn >= 0 {
+				cfg.Turn = *pipesTurn
+			}
+			if pipes.IsFade(*pipesFade) {
+				cfg.Fade = *pipesFade
+			} else {
+				fmt.Printf("unknown pipes-fade %q (expected dissolve | wipe | clear)\n", *pipesFade)
+			}
+			cfg.ASCII = *pipesASCII
+			return pipes.Run(cfg)
+		}},
+		{names: []string{"donut", "torus"}, run: func() error {
+			cfg := donut.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if *donutRatio > 1 {
+				cfg.Ratio = *donutRatio
+			}
+			applyDonutSpin(&cfg, *donutSpin)
+			if donut.IsPalette(*donutPalette) {
+				cfg.Palette = *donutPalette
+			} else {
+				fmt.Printf("unknown donut-palette %q (expected mono | neon | fire | ice)\n", *donutPalette)
+			}
+			return donut.Run(cfg)
+		}},
+		{names: []string{"globe", "earth"}, run: func() error {
+			cfg := globe.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if *globeSpeed != 0 {
+				cfg.Speed = *globeSpeed
+			}
+			cfg.Tilt = *globeTilt
+			cfg.Terminator = *globeTerminator
+			return globe.Run(cfg)
+		}},
+		{names: []string{"clock"}, run: func() error {
+			cfg := clock.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			cfg.Hour12 = *clock12h
+			cfg.Seconds = *clockSeconds
+			cfg.Date = *clockDate
+			if clock.IsBackground(*clockBackground) {
+				cfg.Background = *clockBackground
+			} else {
+				fmt.Printf("unknown clock-background %q (expected none | rain | plasma)\n", *clockBackground)
+			}
+			return clock.Run(cfg)
+		}},
+		{names: []string{"aclock", "analogclock", "analog"}, run: func() error {
+			cfg := analogclock.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Roman = *aclockRoman
+			cfg.Date = *aclockDate
+			cfg.Pendulum = *aclockPendulum
+			cfg.Offset = *aclockOffset
+			return analogclock.Run(cfg)
+		}},
+		{names: []string{"lava", "lavalamp"}, run: func() error {
+			cfg := lava.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *lavaBlobs > 0 {
+				cfg.Blobs = *lavaBlobs
+			}
+			if *lavaViscosity >= 0 {
+				cfg.Viscosity = *lavaViscosity
+			}
+			if lava.IsPalette(*lavaPalette) {
+				cfg.Palette = *lavaPalette
+			} else {
+				fmt.Printf("unknown lava-palette %q (expected classic | blue | green | purple)\n", *lavaPalette)
+			}
+			return lava.Run(cfg)
+		}},
+		{names: []string{"dna", "helix"}, run: func() error {
+			cfg := helix.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *helixRadius > 0 {
+				cfg.Radius = *helixRadius
+			}
+			if *helixPitch > 0 {
+				cfg.Pitch = *helixPitch
+			}
+			if *helixSpin != 0 {
+				cfg.Spin = *helixSpin
+			}
+			applyHelixSequence(&cfg, *helixSeq)
+			return helix.Run(cfg)
+		}},
+		{names: []string{"boids", "flock"}, run: func() error {
+			cfg := boids.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *boidsCount > 0 {
+				cfg.Count = *boidsCount
+			}
+			if *boidsRadius > 0 {
+				cfg.Radius = *boidsRadius
+			}
+			if *boidsSpeed > 0 {
+				cfg.MaxSpeed = *boidsSpeed
+			}
+			cfg.Predator = *boidsPredator
+			if boids.IsEdges(*boidsEdges) {
+				cfg.Edges = *boidsEdges
+			} else {
+				fmt.Printf("unknown boids-edges %q (expected wrap | bounce)\n", *boidsEdges)
+			}
+			if boids.IsColorBy(*boidsColor) {
+				cfg.ColorBy = *boidsColor
+			} else {
+				fmt.Printf("unknown boids-color %q (expected flock | speed)\n", *boidsColor)
+			}
+			return boids.Run(cfg)
+		}},
+		{names: []string{"sand", "falling-sand"}, run: func() error {
+			cfg := sand.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *sandSpouts >= 0 {
+				cfg.Spouts = *sandSpouts
+			}
+			cfg.Water = *sandWater
+			if *sandMax > 0 {
+				cfg.MaxParticles = *sandMax
+			}
+			return sand.Run(cfg)
+		}},
+		{names: []string{"attractor", "lorenz"}, run: func() error {
+			cfg := attractor.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if attractor.IsSystem(*attractorSystem) {
+				cfg.System = *attractorSystem
+			} else {
+				fmt.Printf("unknown attractor-system %q (expected lorenz | rossler | aizawa)\n", *attractorSystem)
+			}
+			applyAttractorParams(&cfg, *attractorParams)
+			if *attractorTrail > 0 {
+				cfg.Trail = *attractorTrail
+			}
+			if *attractorSpin != 0 {
+				cfg.Spin = *attractorSpin
+			}
+			return attractor.Run(cfg)
+		}},
+		{names: []string{"maze"}, run: func() error {
+			cfg := maze.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if *mazeCell > 0 {
+				cfg.CellSize = *mazeCell
+			}
+			if maze.IsGenerator(*mazeGenerator) {
+				cfg.Generator = *mazeGenerator
+			} else {
+				fmt.Printf("unknown maze-generator %q (expected backtracker | prim | kruskal)\n", *mazeGenerator)
+			}
+			if maze.IsSolver(*mazeSolver) {
+				cfg.Solver = *mazeSolver
+			} else {
+				fmt.Printf("unknown maze-solver %q (expected bfs | astar)\n", *mazeSolver)
+			}
+			if *mazeSpeed > 0 {
+				cfg.Speed = *mazeSpeed
+			}
+			cfg.Seed = *seed
+			return maze.Run(cfg)
+		}},
+		{names: []string{"ripple", "pond"}, run: func() error {
+			cfg := ripple.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *rippleDamping > 0 {
+				cfg.Damping = *rippleDamping
+			}
+			if *rippleRain >= 0 {
+				cfg.Rain = *rippleRain
+			}
+			if ripple.IsEdges(*rippleEdges) {
+				cfg.Edges = *rippleEdges
+			} else {
+				fmt.Printf("unknown ripple-edges %q (expected reflect | absorb)\n", *rippleEdges)
+			}
+			cfg.Mouse = *rippleMouse
+			return ripple.Run(cfg)
+		}},
+		{names: []string{"balls"}, run: func() error {
+			cfg := balls.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *ballsCount > 0 {
+				cfg.Count = *ballsCount
+			}
+			if *ballsGravity >= 0 {
+				cfg.Gravity = *ballsGravity
+			}
+			if *ballsBounce > 0 {
+				cfg.Restitution = *ballsBounce
+			}
+			if *ballsTrail >= 0 {
+				cfg.Trail = *ballsTrail
+			}
+			return balls.Run(cfg)
+		}},
+		{names: []string{"banner", "marquee"}, run: func() error {
+			cfg := banner.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			applyBannerText(&cfg, *bannerText)
+			if banner.IsFont(*bannerFont) {
+				cfg.Font = *bannerFont
+			} else {
+				fmt.Printf("unknown banner-font %q (expected block | thin | shadow)\n", *bannerFont)
+			}
+			if *bannerSpeed > 0 {
+				cfg.Speed = *bannerSpeed
+			}
+			applyBannerEffects(&cfg, *bannerEffects)
+			return banner.Run(cfg)
+		}},
+		{names: []string{"aquarium", "fishtank"}, run: func() error {
+			cfg := aquarium.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *aquariumFish > 0 {
+				cfg.Fish = *aquariumFish
+			}
+			applyAquariumSpecies(&cfg, *aquariumSpecies)
+			if *aquariumPlants >= 0 {
+				cfg.Plants = *aquariumPlants
+			}
+			return aquarium.Run(cfg)
+		}},
+		{names: []string{"galaxy"}, run: func() error {
+			cfg := galaxy.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *galaxyArms > 0 {
+				cfg.Arms = *galaxyArms
+			}
+			if *galaxySpeed != 0 {
+				cfg.Speed = *galaxySpeed
+			}
+			if *galaxyStars > 0 {
+				cfg.Stars = *galaxyStars
+			}
+			cfg.ASCII = *galaxyASCII
+			return galaxy.Run(cfg)
+		}},
+		{names: []string{"typer", "typing"}, run: func() error {
+			cfg := typer.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			applyTyperFile(&cfg, *typerFile)
+			if *typerWPM > 0 {
+				cfg.WPM = *typerWPM
+			}
+			if *typerErrors >= 0 {
+				cfg.Errors = *typerErrors
+			}
+			if typer.IsLanguage(*typerLang) {
+				cfg.Language = *typerLang
+			} else {
+				fmt.Printf("unknown typer-lang %q (expected auto | go | python | js | c | plain)\n", *typerLang)
+			}
+			return typer.Run(cfg)
+		}},
+		{names: []string{"radar", "sonar"}, run: func() error {
+			cfg := radar.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *radarRPM > 0 {
+				cfg.RPM = *radarRPM
+			}
+			if *radarContacts >= 0 {
+				cfg.Contacts = *radarContacts
+			}
+			cfg.Labels = *radarLabels
+			applyRadarFeed(&cfg, *radarFeed)
+			return radar.Run(cfg)
+		}},
+		{names: []string{"ecg", "heartbeat"}, run: func() error {
+			cfg := ecg.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *ecgBPM > 0 {
+				cfg.BPM = *ecgBPM
+			}
+			return ecg.Run(cfg)
+		}},
+		{names: []string{"night", "starry"}, run: func() error {
+			cfg := night.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			if *nightStars > 0 {
+				cfg.Density = *nightStars
+			}
+			cfg.Phase = *nightMoon
+			if *nightMeteors >= 0 {
+				cfg.Meteors = *nightMeteors
+			}
+			cfg.Seed = *seed
+			return night.Run(cfg)
+		}},
+		{names: []string{"storm", "thunder"}, run: func() error {
+			cfg := storm.DefaultConfig()
+			applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
+			cfg.Duration, cfg.MaxFrames = *duration, *frames
+			cfg.Output = outputFor(cfg.Width, cfg.Height, cfg.FrameDelay)
+			cfg.Seed = *seed
+			if *stormRate > 0 {
+				cfg.Rate = *stormRate
+			}
+			if *stormBranches >= 0 {
+				cfg.Branchiness = *stormBranches
+			}
+			cfg.ReducedMotion = *reducedMotion
+			return storm.Run(cfg)
+		}},
+	}
+	flag.Lookup("mode").Usage = modeList(animations) + " | cycle (each in turn)"
+
 	flag.Parse()
+	// No mode has a use for a NaN or infinite number, and some hang or
+	// panic on one, so such a flag goes back to its default.
+	flag.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(flag.Getter).Get().(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			fmt.Printf("-%s %v is not a finite number; using %s\n", f.Name, v, f.DefValue)
+			f.Value.Set(f.DefValue)
+		}
+	})
+	if *eco {
+		chosen := false
+		flag.Visit(func(f *flag.Flag) { chosen = chosen || f.Name == "mode" })
+		if !chosen {
+			*mode = "night"
+		}
+	}
+	if profile, err := color.ParseProfile(*colorDepth); err == nil {
+		color.Use(profile)
+	} else {
+		fmt.Println(err)
+	}
+	if !render.IsCharset(*charset) {
+		fmt.Printf("unknown charset %q (expected ascii | blocks | braille)\n", *charset)
+		*charset = "ascii"
+	}
+	// stopped is why the last mode ended. Ctrl-C exits with status 1, once
+	// the deferred work such as saving a recording is done.
+	var stopped error
+	defer func() {
+		if errors.Is(stopped, term.ErrInterrupted) {
+			os.Exit(1)
+		}
+	}()
+	render.ShowStats(*showStats)
+	render.PowerSave(*idleAfter, *idleFPS)
+	render.MaxCPU(*maxCPU)
+	// A run cut short at a number of frames or written to a file goes a
+	// frame at a time, so it comes out the same every time; -record does
+	// so by itself, and -seed through each mode's Config.
+	render.SteadyClocks(*frames > 0 || *castPath != "" || *headless)
+	var rec *render.Recorder
+	if *record != "" {
+		rec = render.NewRecorder(*record, *recordFrames, *recordFPS)
+		render.Record(rec)
+		save := func() {
+			if err := rec.Close(); err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Printf("recorded %d frames to %s\n", rec.Frames(), *record)
+		}
+		// q or Ctrl-C before the recording is done still saves it, as the
+		// mode returns either way.
+		defer save()
+	}
+	if *headless {
+		if *record == "" && *duration == 0 && *frames == 0 {
+			fmt.Println("-headless needs -record, -duration or -frames to stop; drawing on the terminal")
+		} else {
+			output = io.Discard
+		}
+	}
+	// Fill the terminal unless told otherwise. When stdout is not a
+	// terminal each mode keeps its own default size.
+	if cols, rows, err := term.Size(); err == nil {
+		if *width == 0 {
+			*width = cols
+		}
+		if *height == 0 {
+			*height = rows
+		}
+	}
 
-	switch strings.ToLower(*mode) {
-	case "cybercube", "cube":
-		cfg := cybercube.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		if cubeLayout != nil {
-			applyCubeLayout(&cfg, *cubeLayout)
-		}
-		cybercube.Run(cfg)
-	case "rain", "neonrain":
-		cfg := rain.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		rain.Run(cfg)
-	case "spectrum", "equalizer", "scope":
-		cfg := spectrum.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		spectrum.Run(cfg)
-	case "cloud", "clouds", "sky":
-		cfg := cloud.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		cloud.Run(cfg)
-	case "starfield", "warp", "stars":
-		cfg := starfield.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		starfield.Run(cfg)
-	case "orbit", "hud", "core":
-		cfg := orbit.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		orbit.Run(cfg)
-	case "plasma", "grid", "energy":
-		cfg := plasma.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		plasma.Run(cfg)
-	case "skyline", "city", "neon":
-		cfg := skyline.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		skyline.Run(cfg)
-	case "ocean", "currents", "sea":
-		cfg := ocean.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		ocean.Run(cfg)
-	case "aurora", "borealis", "polar":
-		cfg := aurora.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		aurora.Run(cfg)
-	case "tunnel", "vortex":
-		cfg := tunnel.DefaultConfig()
-		applyOverrides(&cfg.Width, &cfg.Height, &cfg.FrameDelay, width, height, delay)
-		tunnel.Run(cfg)
-	default:
-		fmt.Printf("unknown mode %q (expected cybercube | rain | spectrum | cloud | starfield | orbit | plasma | skyline | ocean | aurora | tunnel)\n", *mode)
+	name := strings.ToLower(*mode)
+	if name == "cycle" {
+		if *cycleInterval <= 0 {
+			fmt.Printf("invalid cycle-interval %v (expected a positive duration)\n", *cycleInterval)
+			*cycleInterval = 30 * time.Second
+		}
+		var shuffle *rand.Rand
+		if *cycleShuffle {
+			s := *seed
+			if s == 0 {
+				s = time.Now().UnixNano()
+			}
+			shuffle = rand.New(rand.NewSource(s))
+		}
+		full := func() bool { return rec != nil && rec.Full() }
+		stopped = cycle(playlist(animations, *cycleOrder), *cycleInterval, duration, shuffle, full)
+		if finishCast != nil {
+			finishCast()
+		}
+		return
+	}
+	for _, a := range animations {
+		if slices.Contains(a.names, name) {
+			stopped = a.run()
+			if finishCast != nil {
+				finishCast()
+			}
+			return
+		}
 	}
+	fmt.Printf("unknown mode %q (expected %s)\n", *mode, modeList(animations))
+}
+
+// playlist is the animations named in order, comma separated, for -mode
+// cycle; an empty order is all of them. Unknown names are reported and left
+// out.
+func playlist(animations []animation, order string) []animation {
+	if strings.TrimSpace(order) == "" {
+		return slices.Clone(animations)
+	}
+	var list []animation
+	for _, name := range strings.Split(order, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		i := slices.IndexFunc(animations, func(a animation) bool { return slices.Contains(a.names, name) })
+		if i < 0 {
+			fmt.Printf("unknown mode %q in cycle-order (expected %s)\n", name, modeList(animations))
+			continue
+		}
+		list = append(list, animations[i])
+	}
+	if len(list) == 0 {
+		return slices.Clone(animations)
+	}
+	return list
+}
+
+// cycle plays each of list for interval, one after another and round again,
+// by setting the -duration every mode reads. A -duration already set is the
+// time for the whole cycle. shuffle, if not nil, reorders the list before
+// each round; stop ends the cycle between modes, as does a mode that was
+// quit, whose error it returns.
+func cycle(list []animation, interval time.Duration, duration *time.Duration, shuffle *rand.Rand, stop func() bool) error {
+	var deadline time.Time
+	if *duration > 0 {
+		deadline = time.Now().Add(*duration)
+	}
+	for {
+		if shuffle != nil {
+			shuffle.Shuffle(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] })
+		}
+		for _, a := range list {
+			*duration = interval
+			if !deadline.IsZero() {
+				left := time.Until(deadline)
+				if left <= 0 {
+					return nil
+				}
+				*duration = min(interval, left)
+			}
+			// Each mode hides the cursor and clears the screen as it starts,
+			// so nothing of the one before is left.
+			if err := a.run(); err != nil {
+				return err
+			}
+			if stop() {
+				return nil
+			}
+		}
+	}
+}
+
+func modeList(animations []animation) string {
+	names := make([]string, len(animations))
+	for i, a := range animations {
+		names[i] = a.names[0]
+	}
+	return strings.Join(names, " | ")
+}
+
+// parseFloat is strconv.ParseFloat turning down NaN and the infinities too.
+func parseFloat(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+		err = fmt.Errorf("%q is not a finite number", s)
+	}
+	return v, err
 }
 
 func applyOverrides(width *int, height *int, delay *time.Duration, wOpt *int, hOpt *int, dOpt *time.Duration) {
@@ -97,7 +1215,392 @@ func applyCubeLayout(cfg *cybercube.Config, layout string) {
 		// already multi
 	case "single", "solo", "one":
 		cfg.Instances = cybercube.SingleCubeInstances()
+	case "grid", "tiles":
+		cfg.Instances = cybercube.GridInstances(cfg.Width, cfg.Height)
+	default:
+		fmt.Printf("unknown cube-layout %q (expected multi | single | grid)\n", layout)
+	}
+}
+
+func applyShipSpec(cfg *ocean.Config, spec string) {
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(field, "=")
+		switch strings.ToLower(key) {
+		case "dir", "direction":
+			switch strings.ToLower(value) {
+			case "right", "east":
+				cfg.ShipDirection = 1
+			case "left", "west":
+				cfg.ShipDirection = -1
+			case "random", "any":
+				cfg.ShipDirection = 0
+			default:
+				fmt.Printf("unknown ocean-ship dir %q (expected left | right | random)\n", value)
+			}
+		case "speed":
+			if v, err := parseFloat(value); err == nil && v > 0 {
+				cfg.ShipSpeed = v
+			} else {
+				fmt.Printf("invalid ocean-ship speed %q\n", value)
+			}
+		case "every", "interval":
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				cfg.ShipInterval = d
+			} else {
+				fmt.Printf("invalid ocean-ship interval %q\n", value)
+			}
+		default:
+			fmt.Printf("unknown ocean-ship key %q (expected dir | speed | every)\n", key)
+		}
+	}
+}
+
+func applySwell(cfg *ocean.Config, swell string) {
+	switch strings.ToLower(swell) {
+	case "":
+		// keep default
+	case "left", "west":
+		cfg.SwellDirection = -1
+	case "right", "east":
+		cfg.SwellDirection = 1
+	case "calm", "standing", "none":
+		cfg.SwellDirection = 0
+	default:
+		v, err := parseFloat(swell)
+		if err != nil {
+			fmt.Printf("unknown ocean-swell %q (expected left | right | calm | -1..1)\n", swell)
+			return
+		}
+		cfg.SwellDirection = v
+	}
+}
+
+func applySpectrumLayout(cfg *spectrum.Config, layout string) {
+	switch strings.ToLower(layout) {
+	case "", "bars":
+		cfg.Mirror, cfg.Horizontal = false, false
+	case "mirror":
+		cfg.Mirror = true
+	case "horizontal":
+		cfg.Horizontal = true
+	case "horizontal-mirror":
+		cfg.Mirror, cfg.Horizontal = true, true
+	default:
+		fmt.Printf("unknown spectrum-layout %q (expected bars | mirror | horizontal | horizontal-mirror)\n", layout)
+	}
+}
+
+func applyLighthouse(cfg *ocean.Config, side string) {
+	switch strings.ToLower(side) {
+	case "", "off", "none":
+		cfg.LighthouseSide = 0
+	case "left":
+		cfg.LighthouseSide = -1
+	case "right":
+		cfg.LighthouseSide = 1
+	default:
+		fmt.Printf("unknown ocean-lighthouse %q (expected left | right | off)\n", side)
+	}
+}
+
+func applyCloudWeather(cfg *cloud.Config, name string) {
+	name = strings.ToLower(name)
+	switch {
+	case name == "":
+		// keep default
+	case cloud.IsWeather(name):
+		cfg.Weather = name
+	default:
+		fmt.Printf("unknown cloud-weather %q (expected clear | fair | overcast | storm)\n", name)
+	}
+}
+
+func applyCloudSun(cfg *cloud.Config, kind, pos string) {
+	switch strings.ToLower(kind) {
+	case "", "off", "none":
+		cfg.Light = ""
+		return
+	case "sun", "on":
+		cfg.Light = "sun"
+	case "moon":
+		cfg.Light = "moon"
+	default:
+		fmt.Printf("unknown cloud-sun %q (expected sun | moon | off)\n", kind)
+		return
+	}
+	if pos == "" {
+		return
+	}
+	xs, ys, ok := strings.Cut(pos, ",")
+	x, errX := parseFloat(strings.TrimSpace(xs))
+	y, errY := parseFloat(strings.TrimSpace(ys))
+	if !ok || errX != nil || errY != nil || x < 0 || y < 0 {
+		fmt.Printf("invalid cloud-sun-pos %q (expected x,y between 0 and 1)\n", pos)
+		return
+	}
+	cfg.LightX = x
+	cfg.LightY = y
+}
+
+func applyCloudGround(cfg *cloud.Config, ground string) {
+	ground = strings.ToLower(ground)
+	switch {
+	case ground == "off" || ground == "none":
+		cfg.Ground = ""
+	case cloud.IsGround(ground):
+		cfg.Ground = ground
 	default:
-		fmt.Printf("unknown cube-layout %q (expected multi | single)\n", layout)
+		fmt.Printf("unknown cloud-ground %q (expected hills | city | sea | off)\n", ground)
+	}
+}
+
+func applyAuroraColors(cfg *aurora.Config, spec string) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if codes, ok := strings.CutPrefix(spec, "custom:"); ok {
+		colors, err := aurora.ParseColors(codes)
+		if err != nil {
+			fmt.Printf("invalid aurora-colors %q: %v\n", spec, err)
+			return
+		}
+		cfg.CustomColors = colors
+		return
+	}
+	if !aurora.IsTheme(spec) {
+		fmt.Printf("unknown aurora-colors %q (expected green | red | purple | rainbow | custom:<codes>)\n", spec)
+		return
+	}
+	cfg.Colors = spec
+}
+
+func applyTunnelSway(cfg *tunnel.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	amounts, rates, hasRate := strings.Cut(spec, ",")
+	amount, err := parseFloat(strings.TrimSpace(amounts))
+	rate := 1.0
+	if err == nil && hasRate {
+		rate, err = parseFloat(strings.TrimSpace(rates))
+	}
+	if err != nil || amount < 0 || rate <= 0 {
+		fmt.Printf("invalid tunnel-sway %q (expected amount[,rate], e.g. 1.5 or 1,2)\n", spec)
+		return
+	}
+	cfg.Sway = amount
+	cfg.SwayRate = rate
+}
+
+func applyPlasmaSymmetry(cfg *plasma.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	ns, spins, hasSpin := strings.Cut(spec, ",")
+	n, err := strconv.Atoi(strings.TrimSpace(ns))
+	spin := 0.0
+	if err == nil && hasSpin {
+		spin, err = parseFloat(strings.TrimSpace(spins))
+	}
+	if err != nil || n < 0 {
+		fmt.Printf("invalid plasma-symmetry %q (expected N[,turns per minute], e.g. 6 or 8,0.5)\n", spec)
+		return
+	}
+	cfg.Symmetry = n
+	cfg.SymmetrySpin = spin
+}
+
+func applyPlasmaMetaballs(cfg *plasma.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	parts := strings.Split(spec, ",")
+	n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	values := []float64{cfg.MetaballRadius, cfg.MetaballSpeed}
+	for i, part := range parts[1:] {
+		if err != nil || i >= len(values) {
+			err = fmt.Errorf("too many values")
+			break
+		}
+		values[i], err = parseFloat(strings.TrimSpace(part))
+	}
+	if err != nil || n < 0 || values[0] <= 0 || values[1] < 0 {
+		fmt.Printf("invalid plasma-metaballs %q (expected N[,radius[,speed]], e.g. 6 or 8,4,1)\n", spec)
+		return
+	}
+	cfg.Metaballs = n
+	cfg.MetaballRadius = values[0]
+	cfg.MetaballSpeed = values[1]
+}
+
+func applyFireworksShells(cfg *fireworks.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	var shells []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !fireworks.IsShell(name) {
+			fmt.Printf("unknown fireworks shell %q (expected peony | ring | willow | crackle)\n", name)
+			continue
+		}
+		shells = append(shells, name)
+	}
+	cfg.Shells = shells
+}
+
+func applyDonutSpin(cfg *donut.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	parts := strings.Split(spec, ",")
+	if len(parts) != 2 {
+		fmt.Printf("invalid donut-spin %q (expected X,Z, e.g. 0.04,0.02)\n", spec)
+		return
+	}
+	x, errX := parseFloat(strings.TrimSpace(parts[0]))
+	z, errZ := parseFloat(strings.TrimSpace(parts[1]))
+	if errX != nil || errZ != nil {
+		fmt.Printf("invalid donut-spin %q (expected X,Z, e.g. 0.04,0.02)\n", spec)
+		return
+	}
+	cfg.SpinX = x
+	cfg.SpinZ = z
+}
+
+func applyHelixSequence(cfg *helix.Config, path string) {
+	if path == "" {
+		return
+	}
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("cannot read helix-seq: %v\n", err)
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	seq, err := helix.ReadSequence(r)
+	if err != nil {
+		fmt.Printf("cannot read helix-seq: %v\n", err)
+		return
+	}
+	if seq == "" {
+		fmt.Printf("no bases in helix-seq %q, using a random sequence\n", path)
+	}
+	cfg.Sequence = seq
+}
+
+func applyAttractorParams(cfg *attractor.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	var params []float64
+	for _, part := range strings.Split(spec, ",") {
+		v, err := parseFloat(strings.TrimSpace(part))
+		if err != nil {
+			fmt.Printf("invalid attractor-params %q (expected comma-separated numbers, e.g. 10,28,2.667)\n", spec)
+			return
+		}
+		params = append(params, v)
+	}
+	cfg.Params = params
+}
+
+func applyBannerText(cfg *banner.Config, text string) {
+	if text != "-" {
+		if text != "" {
+			cfg.Text = text
+		}
+		return
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Printf("cannot read banner-text: %v\n", err)
+		return
+	}
+	cfg.Text = string(data)
+}
+
+func applyBannerEffects(cfg *banner.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	cfg.Rainbow, cfg.Wave, cfg.Sparkle, cfg.Matrix = false, false, false, false
+	if spec == "none" {
+		return
+	}
+	for _, name := range strings.Split(spec, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "rainbow":
+			cfg.Rainbow = true
+		case "wave":
+			cfg.Wave = true
+		case "sparkle":
+			cfg.Sparkle = true
+		case "matrix":
+			cfg.Matrix = true
+		default:
+			fmt.Printf("unknown banner effect %q (expected rainbow | wave | sparkle | matrix | none)\n", name)
+		}
+	}
+}
+
+func applyAquariumSpecies(cfg *aquarium.Config, spec string) {
+	if spec == "" {
+		return
+	}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if !aquarium.IsSpecies(name) {
+			fmt.Printf("unknown aquarium species %q (expected minnow | tropical | angel | shark)\n", name)
+			continue
+		}
+		cfg.Species = append(cfg.Species, name)
+	}
+}
+
+func applyTyperFile(cfg *typer.Config, path string) {
+	if path == "" {
+		return
+	}
+	var r io.Reader = os.Stdin
+	cfg.Name = "stdin"
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("cannot read typer-file: %v\n", err)
+			return
+		}
+		defer f.Close()
+		r = f
+		cfg.Name = filepath.Base(path)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Printf("cannot read typer-file: %v\n", err)
+		return
+	}
+	cfg.Text = string(data)
+}
+
+func applyRadarFeed(cfg *radar.Config, path string) {
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("cannot read radar-feed: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := radar.ReadContacts(f); err != nil {
+		fmt.Printf("cannot read radar-feed: %v\n", err)
+		return
 	}
+	cfg.Feed = path
 }
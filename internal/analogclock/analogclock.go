@@ -0,0 +1,261 @@
+package analogclock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+	// pendulumHeight is the least height that leaves room for a pendulum
+	// under a face of useful size.
+	pendulumHeight = 40
+	// swingPeriod is a full swing there and back: a seconds pendulum beats
+	// once a second each way.
+	swingPeriod = 2 * time.Second
+)
+
+var (
+	rimColor    = "\x1b[38;5;60m"
+	tickColor   = "\x1b[38;5;103m"
+	hourTick    = "\x1b[38;5;189m"
+	numberColor = "\x1b[38;5;195m"
+	hourColor   = "\x1b[38;5;221m"
+	minuteColor = "\x1b[38;5;87m"
+	secondColor = "\x1b[38;5;203m"
+	dateColor   = "\x1b[38;5;223m"
+	rodColor    = "\x1b[38;5;137m"
+	bobColor    = "\x1b[38;5;179m"
+	glowColors  = []string{"\x1b[38;5;231m", "\x1b[38;5;223m", "\x1b[38;5;137m"}
+	romans      = []string{"XII", "I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI"}
+)
+
+// Config controls the analog clock.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Roman labels the hours I to XII instead of 1 to 12.
+	Roman bool
+	// Date shows the day of the month in a window by the three.
+	Date bool
+	// Pendulum swings a pendulum under the face when the terminal is tall
+	// enough.
+	Pendulum bool
+	// Offset is added to the system time, to show another zone or to check
+	// a given time.
+	Offset time.Duration
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Pendulum:   true,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	return c
+}
+
+// face is where the dial sits: its center and its radius in rows. It is
+// twice as wide in columns so it looks round.
+type face struct {
+	cx, cy float64
+	radius float64
+}
+
+// at is the cell r of the way out from the center toward angle, measured
+// clockwise from twelve.
+func (f face) at(angle, r float64) (int, int) {
+	x := f.cx + math.Sin(angle)*r*f.radius*cellAspect
+	y := f.cy - math.Cos(angle)*r*f.radius
+	return int(math.Round(x)), int(math.Round(y))
+}
+
+// Run launches the analog clock.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	pendulum := cfg.Pendulum && cfg.Height >= pendulumHeight
+	f := layout(cfg.Width, cfg.Height, pendulum)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		now := time.Now().Add(cfg.Offset)
+		grid.Clear()
+		if pendulum {
+			drawPendulum(grid, f, now)
+		}
+		drawDial(grid, f, cfg.Roman)
+		if cfg.Date {
+			drawDate(grid, f, now)
+		}
+		drawHands(grid, f, now)
+		drawGlow(grid, f)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// layout fits the face to the screen, leaving the lower third for the
+// pendulum when there is one.
+func layout(width, height int, pendulum bool) face {
+	rows := float64(height)
+	if pendulum {
+		rows = float64(height) * 0.62
+	}
+	radius := math.Min(rows/2-1, (float64(width)/2-2)/cellAspect)
+	return face{cx: float64(width) / 2, cy: rows / 2, radius: radius}
+}
+
+func drawDial(grid canvas.Grid, f face, roman bool) {
+	steps := int(f.radius * 16)
+	for i := 0; i < steps; i++ {
+		x, y := f.at(2*math.Pi*float64(i)/float64(steps), 1)
+		grid.Set(x, y, '.', rimColor)
+	}
+	for m := 0; m < 60; m++ {
+		angle := 2 * math.Pi * float64(m) / 60
+		x, y := f.at(angle, 0.92)
+		if m%5 == 0 {
+			grid.Set(x, y, '+', hourTick)
+			continue
+		}
+		grid.Set(x, y, '.', tickColor)
+	}
+	for h := 0; h < 12; h++ {
+		label := strconv.Itoa(h)
+		if h == 0 {
+			label = "12"
+		}
+		if roman {
+			label = romans[h]
+		}
+		x, y := f.at(2*math.Pi*float64(h)/12, 0.78)
+		grid.Text(x-len(label)/2, y, label, numberColor)
+	}
+}
+
+// drawDate puts the day of the month in a little window between the center
+// and the three.
+func drawDate(grid canvas.Grid, f face, now time.Time) {
+	x, y := f.at(math.Pi/2, 0.5)
+	grid.Text(x-2, y, fmt.Sprintf("[%2d]", now.Day()), dateColor)
+}
+
+// drawHands draws the hour and minute hands and a second hand that sweeps
+// smoothly rather than ticking.
+func drawHands(grid canvas.Grid, f face, now time.Time) {
+	seconds := float64(now.Second()) + float64(now.Nanosecond())/1e9
+	minutes := float64(now.Minute()) + seconds/60
+	hours := float64(now.Hour()%12) + minutes/60
+
+	drawHand(grid, f, 2*math.Pi*hours/12, 0.45, hourColor, '#')
+	drawHand(grid, f, 2*math.Pi*minutes/60, 0.7, minuteColor, 0)
+	drawHand(grid, f, 2*math.Pi*seconds/60, 0.85, secondColor, 0)
+}
+
+// drawHand draws a hand from the center out to length; a zero glyph picks
+// the line character that follows the hand's slope.
+func drawHand(grid canvas.Grid, f face, angle, length float64, color string, glyph rune) {
+	x0, y0 := int(math.Round(f.cx)), int(math.Round(f.cy))
+	x1, y1 := f.at(angle, length)
+	if glyph == 0 {
+		glyph = slopeGlyph(float64(x1-x0), float64(y1-y0))
+	}
+	grid.Line(x0, y0, x1, y1, glyph, color)
+}
+
+// slopeGlyph picks - | / or \ for a line running dx across and dy down,
+// allowing for cells being taller than wide.
+func slopeGlyph(dx, dy float64) rune {
+	angle := math.Atan2(-dy*cellAspect, dx)
+	octant := int(math.Round(angle/(math.Pi/4))+8) % 4
+	return rune("-/|\\"[octant])
+}
+
+func drawGlow(grid canvas.Grid, f face) {
+	cx, cy := int(math.Round(f.cx)), int(math.Round(f.cy))
+	for dx := -2; dx <= 2; dx++ {
+		ring := canvas.Abs(dx)
+		if ring == 0 {
+			continue
+		}
+		grid.Set(cx+dx, cy, rune("o."[min(ring-1, 1)]), glowColors[ring])
+	}
+	grid.Set(cx, cy-1, '.', glowColors[2])
+	grid.Set(cx, cy+1, '\'', glowColors[2])
+	grid.Set(cx, cy, '@', glowColors[0])
+}
+
+// drawPendulum swings a rod and bob from just under the face, in time with
+// the seconds.
+func drawPendulum(grid canvas.Grid, f face, now time.Time) {
+	height := len(grid)
+	pivotY := int(f.cy+f.radius) + 1
+	length := float64(height - pivotY - 2)
+	phase := float64(now.UnixNano()%int64(swingPeriod)) / float64(swingPeriod)
+	angle := 0.3 * math.Sin(2*math.Pi*phase)
+
+	x0 := int(math.Round(f.cx))
+	x1 := int(math.Round(f.cx + math.Sin(angle)*length*cellAspect))
+	y1 := pivotY + int(math.Round(math.Cos(angle)*length))
+	glyph := slopeGlyph(float64(x1-x0), float64(y1-pivotY))
+	grid.Line(x0, pivotY, x1, y1, glyph, rodColor)
+	grid.Text(x1-2, y1, "(@@)", bobColor)
+	grid.Text(x1-1, y1+1, "\"\"", bobColor)
+}
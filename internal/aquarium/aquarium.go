@@ -0,0 +1,293 @@
+package aquarium
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	maxFish   = 60
+	// sandRows is how deep the sand on the bottom is.
+	sandRows = 2
+	// vents is how many bubble columns rise from the sand.
+	vents = 3
+)
+
+var (
+	glassColor   = "\x1b[38;5;244m"
+	surfaceColor = "\x1b[38;5;39m"
+	bubbleColor  = "\x1b[38;5;153m"
+	crabColor    = "\x1b[38;5;203m"
+	sandColors   = []string{"\x1b[38;5;180m", "\x1b[38;5;179m", "\x1b[38;5;137m"}
+	sandGlyphs   = []rune{'.', ':', ',', '.', '\''}
+	plantColors  = []string{"\x1b[38;5;28m", "\x1b[38;5;34m", "\x1b[38;5;70m", "\x1b[38;5;35m"}
+)
+
+// Config controls the aquarium.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Fish is how many fish swim in the tank.
+	Fish int
+	// Species limits the fish to these kinds (minnow, tropical, angel,
+	// shark); empty means a mix of all of them.
+	Species []string
+	// Plants is how many strands of weed grow per ten columns of sand.
+	Plants float64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 60 * time.Millisecond,
+		Fish:       12,
+		Plants:     1,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 60 * time.Millisecond
+	}
+	if c.Fish <= 0 {
+		c.Fish = 12
+	}
+	if c.Fish > maxFish {
+		c.Fish = maxFish
+	}
+	var kinds []string
+	for _, name := range c.Species {
+		if IsSpecies(name) {
+			kinds = append(kinds, name)
+		}
+	}
+	c.Species = kinds
+	if c.Plants < 0 {
+		c.Plants = 1
+	}
+	return c
+}
+
+// Run launches the aquarium.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	t := tank{
+		left:   1,
+		right:  float64(cfg.Width - 1),
+		top:    1,
+		bottom: float64(cfg.Height - 1 - sandRows),
+	}
+	school := stock(cfg, t, rng)
+	plants := sow(cfg, t, rng)
+	var vent [vents]int
+	for i := range vent {
+		vent[i] = 2 + rng.Intn(cfg.Width-4)
+	}
+	var bubbles []bubble
+	c := crab{x: t.left + rng.Float64()*(t.right-t.left-10), vx: 0.15}
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		swim(school, t, rng)
+		c.walk(t, rng)
+		bubbles = rise(bubbles, vent[:], t, rng)
+
+		grid.Clear()
+		ft := float64(frame)
+		drawPlants(grid, plants, ft)
+		for _, b := range bubbles {
+			glyph := '.'
+			switch up := (t.bottom - b.y) / (t.bottom - t.top); {
+			case up > 0.7:
+				glyph = 'O'
+			case up > 0.3:
+				glyph = 'o'
+			}
+			grid.Set(int(math.Round(b.x)), int(b.y), glyph, bubbleColor)
+		}
+		for i := range school {
+			f := &school[i]
+			drawSprite(grid, f.sprite(), int(math.Round(f.x)), int(math.Round(f.y)), f.color)
+		}
+		drawSprite(grid, []string{c.sprite()}, int(c.x), int(t.bottom)-1, crabColor)
+		drawTank(grid, ft)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// stock fills the tank, spreading the fish evenly over the chosen species.
+func stock(cfg Config, t tank, rng *rand.Rand) []fish {
+	var kinds []*species
+	for i := range allSpecies {
+		s := &allSpecies[i]
+		if len(cfg.Species) == 0 {
+			kinds = append(kinds, s)
+			continue
+		}
+		for _, name := range cfg.Species {
+			if s.name == name {
+				kinds = append(kinds, s)
+			}
+		}
+	}
+	school := make([]fish, cfg.Fish)
+	for i := range school {
+		school[i] = newFish(kinds[i%len(kinds)], t, rng)
+	}
+	return school
+}
+
+// sow roots weeds at random along the sand, up to half the tank tall.
+func sow(cfg Config, t tank, rng *rand.Rand) []plant {
+	n := int(cfg.Plants * float64(cfg.Width) / 10)
+	plants := make([]plant, n)
+	water := int(t.bottom - t.top)
+	for i := range plants {
+		plants[i] = plant{
+			x:      2 + rng.Intn(cfg.Width-4),
+			height: 3 + rng.Intn(max(1, water/2-2)),
+			phase:  rng.Float64() * 2 * math.Pi,
+		}
+	}
+	return plants
+}
+
+// rise floats the bubbles up, lets the vents puff out new ones and drops
+// the ones that reached the surface.
+func rise(bubbles []bubble, vent []int, t tank, rng *rand.Rand) []bubble {
+	alive := bubbles[:0]
+	for _, b := range bubbles {
+		b.y -= 0.25
+		b.phase += 0.3
+		b.x += math.Sin(b.phase) * 0.3
+		if b.y > t.top {
+			alive = append(alive, b)
+		}
+	}
+	for _, x := range vent {
+		if rng.Float64() < 0.12 {
+			alive = append(alive, bubble{x: float64(x), y: t.bottom - 1, phase: rng.Float64() * 2 * math.Pi})
+		}
+	}
+	return alive
+}
+
+func drawPlants(grid canvas.Grid, plants []plant, t float64) {
+	base := len(grid) - 1 - sandRows
+	for i, p := range plants {
+		color := plantColors[i%len(plantColors)]
+		prev := 0
+		for s := 0; s < p.height; s++ {
+			lean := p.sway(s, t)
+			var glyph rune
+			switch {
+			case lean > prev:
+				glyph = '/'
+			case lean < prev:
+				glyph = '\\'
+			case s%2 == 1:
+				glyph = ')'
+			default:
+				glyph = '('
+			}
+			grid.Set(p.x+lean, base-1-s, glyph, color)
+			prev = lean
+		}
+	}
+}
+
+// drawSprite draws sprite with its top left at x, y. Blanks inside a row
+// hide what is behind them; those around the outline do not.
+func drawSprite(grid canvas.Grid, sprite []string, x, y int, color string) {
+	for dy, row := range sprite {
+		body := strings.TrimSpace(row)
+		start := strings.Index(row, body)
+		grid.Text(x+start, y+dy, body, color)
+	}
+}
+
+// drawTank draws the sand, the rippling surface and the glass around it
+// all, over anything that strayed onto them.
+func drawTank(grid canvas.Grid, t float64) {
+	height := len(grid)
+	width := len(grid[0])
+	for y := height - 1 - sandRows; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			h := uint32(x*73856093 ^ y*19349663)
+			grid.Set(x, y, sandGlyphs[h%uint32(len(sandGlyphs))], sandColors[h/7%uint32(len(sandColors))])
+		}
+	}
+	for x := 1; x < width-1; x++ {
+		glyph := '~'
+		if math.Sin(float64(x)*0.35+t*0.15) > 0.7 {
+			glyph = '-'
+		}
+		grid.Set(x, 1, glyph, surfaceColor)
+	}
+	for x := 0; x < width; x++ {
+		grid.Set(x, 0, '_', glassColor)
+		grid.Set(x, height-1, '=', glassColor)
+	}
+	for y := 1; y < height-1; y++ {
+		grid.Set(0, y, '|', glassColor)
+		grid.Set(width-1, y, '|', glassColor)
+	}
+}
@@ -0,0 +1,244 @@
+package aquarium
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// species is a kind of fish: its sprite facing right, how fast it cruises
+// and the colors it comes in.
+type species struct {
+	name   string
+	sprite []string
+	speed  float64
+	colors []string
+}
+
+var allSpecies = []species{
+	{
+		name:   "minnow",
+		sprite: []string{"><>"},
+		speed:  0.45,
+		colors: []string{"\x1b[38;5;87m", "\x1b[38;5;159m", "\x1b[38;5;250m"},
+	},
+	{
+		name:   "tropical",
+		sprite: []string{"><(((o>"},
+		speed:  0.3,
+		colors: []string{"\x1b[38;5;208m", "\x1b[38;5;220m", "\x1b[38;5;199m"},
+	},
+	{
+		name:   "angel",
+		sprite: []string{"  |\\ ", "><  o>", "  |/ "},
+		speed:  0.2,
+		colors: []string{"\x1b[38;5;229m", "\x1b[38;5;141m", "\x1b[38;5;214m"},
+	},
+	{
+		name:   "shark",
+		sprite: []string{"      |\\   ", "\\___/    o\\", "/   \\_____/"},
+		speed:  0.35,
+		colors: []string{"\x1b[38;5;246m", "\x1b[38;5;67m"},
+	},
+}
+
+// IsSpecies reports whether name is a fish species.
+func IsSpecies(name string) bool {
+	for _, s := range allSpecies {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mirror flips a right-facing sprite to face left.
+func mirror(sprite []string) []string {
+	swap := strings.NewReplacer("(", ")", ")", "(", "<", ">", ">", "<", "/", "\\", "\\", "/", "{", "}", "}", "{")
+	out := make([]string, len(sprite))
+	for i, row := range sprite {
+		b := []byte(row)
+		for l, r := 0, len(b)-1; l < r; l, r = l+1, r-1 {
+			b[l], b[r] = b[r], b[l]
+		}
+		out[i] = swap.Replace(string(b))
+	}
+	return out
+}
+
+// Fish behaviours.
+const (
+	wander = iota
+	chase
+	flee
+)
+
+// fish is one fish. It steers by easing its velocity toward a wanted one,
+// which its behaviour picks afresh whenever timer runs out.
+type fish struct {
+	kind     *species
+	right    []string
+	left     []string
+	color    string
+	x, y     float64
+	vx, vy   float64
+	wantX    float64
+	wantY    float64
+	behavior int
+	target   int
+	timer    int
+}
+
+func (f *fish) width() int {
+	return len(f.right[0])
+}
+
+func (f *fish) sprite() []string {
+	if f.vx < 0 {
+		return f.left
+	}
+	return f.right
+}
+
+// tank is the water the fish live in, in screen cells: left and right are
+// the inside of the glass, top the water surface and bottom the top of the
+// sand.
+type tank struct {
+	left, right float64
+	top, bottom float64
+}
+
+func newFish(kind *species, t tank, rng *rand.Rand) fish {
+	f := fish{
+		kind:  kind,
+		right: kind.sprite,
+		left:  mirror(kind.sprite),
+		color: kind.colors[rng.Intn(len(kind.colors))],
+	}
+	f.x = t.left + rng.Float64()*(t.right-t.left-float64(f.width()))
+	f.y = t.top + 1 + rng.Float64()*(t.bottom-t.top-float64(len(f.right))-1)
+	f.pickWander(rng)
+	f.vx = f.wantX
+	return f
+}
+
+// pickWander sets off in a new lazy direction for a while.
+func (f *fish) pickWander(rng *rand.Rand) {
+	f.behavior = wander
+	f.timer = 60 + rng.Intn(180)
+	dir := 1.0
+	if rng.Intn(2) == 0 {
+		dir = -1
+	}
+	f.wantX = dir * f.kind.speed * (0.4 + 0.6*rng.Float64())
+	f.wantY = (rng.Float64()*2 - 1) * f.kind.speed * 0.15
+}
+
+// swim updates every fish for one frame. A wandering fish now and then
+// starts chasing another, which flees once the chaser gets close.
+func swim(school []fish, t tank, rng *rand.Rand) {
+	for i := range school {
+		f := &school[i]
+		f.timer--
+		switch f.behavior {
+		case wander:
+			if f.timer <= 0 {
+				f.pickWander(rng)
+			}
+			if len(school) > 1 && rng.Float64() < 0.002 {
+				f.behavior = chase
+				f.target = (i + 1 + rng.Intn(len(school)-1)) % len(school)
+				f.timer = 60 + rng.Intn(60)
+			}
+		case chase:
+			prey := &school[f.target]
+			dx, dy := prey.x-f.x, prey.y-f.y
+			d := math.Max(1, math.Hypot(dx, dy))
+			f.wantX = dx / d * f.kind.speed * 2
+			f.wantY = dy / d * f.kind.speed
+			if d < 12 && prey.behavior != chase {
+				prey.behavior = flee
+				prey.target = i
+				prey.timer = 30
+			}
+			if f.timer <= 0 {
+				f.pickWander(rng)
+			}
+		case flee:
+			hunter := &school[f.target]
+			dx, dy := f.x-hunter.x, f.y-hunter.y
+			d := math.Max(1, math.Hypot(dx, dy))
+			f.wantX = dx / d * f.kind.speed * 2.5
+			f.wantY = dy / d * f.kind.speed
+			if f.timer <= 0 {
+				f.pickWander(rng)
+			}
+		}
+
+		// Turn around at the glass and keep off the surface and sand.
+		w, h := float64(f.width()), float64(len(f.right))
+		if f.x < t.left && f.wantX < 0 || f.x+w > t.right && f.wantX > 0 {
+			f.wantX = -f.wantX
+		}
+		if f.y < t.top+1 && f.wantY < 0 || f.y+h > t.bottom && f.wantY > 0 {
+			f.wantY = -f.wantY
+		}
+		f.vx += (f.wantX - f.vx) * 0.05
+		f.vy += (f.wantY - f.vy) * 0.05
+		f.x += f.vx
+		f.y += f.vy
+		f.x = math.Max(t.left-1, math.Min(t.right-w+1, f.x))
+		f.y = math.Max(t.top+1, math.Min(t.bottom-h, f.y))
+	}
+}
+
+// crab scuttles along the sand, stopping now and then.
+type crab struct {
+	x, vx float64
+	rest  int
+	step  int
+}
+
+var crabFrames = []string{"V(o,,o)V", "v(o,,o)v"}
+
+func (c *crab) walk(t tank, rng *rand.Rand) {
+	if c.rest > 0 {
+		c.rest--
+		return
+	}
+	if rng.Float64() < 0.01 {
+		c.rest = 20 + rng.Intn(60)
+	}
+	if rng.Float64() < 0.005 {
+		c.vx = -c.vx
+	}
+	c.x += c.vx
+	w := float64(len(crabFrames[0]))
+	if c.x < t.left && c.vx < 0 || c.x+w > t.right && c.vx > 0 {
+		c.vx = -c.vx
+	}
+	c.step++
+}
+
+func (c *crab) sprite() string {
+	return crabFrames[c.step/4%len(crabFrames)]
+}
+
+// bubble rises from a vent, wobbling, and pops at the surface.
+type bubble struct {
+	x, y  float64
+	phase float64
+}
+
+// plant is a strand of weed rooted in the sand that sways with the water.
+type plant struct {
+	x      int
+	height int
+	phase  float64
+}
+
+// sway is how far segment i of p leans at time t; the tip moves most.
+func (p plant) sway(i int, t float64) int {
+	lean := float64(i) / float64(p.height)
+	return int(math.Round(math.Sin(t*0.05+p.phase+float64(i)*0.4) * 1.5 * lean))
+}
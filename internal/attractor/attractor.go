@@ -0,0 +1,213 @@
+package attractor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/space"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cameraDistance is how far the camera sits from the attractor, in
+	// units of its size.
+	cameraDistance = 4.0
+	// aspectRatio squashes rows since cells are about twice as tall as
+	// wide.
+	aspectRatio = 0.5
+	// tilt looks down on the attractor a little.
+	tilt = 0.25
+	// nudge is how far 'p' pushes the shadow tracer off the main one.
+	nudge = 1e-6
+)
+
+var (
+	// trailGlyphs and the color ramps run from the oldest point of a trail
+	// to its head.
+	trailGlyphs  = []rune(".,:;+*#@")
+	trailColors  = []string{"\x1b[38;5;17m", "\x1b[38;5;18m", "\x1b[38;5;25m", "\x1b[38;5;32m", "\x1b[38;5;39m", "\x1b[38;5;45m", "\x1b[38;5;87m", "\x1b[38;5;195m"}
+	shadowColors = []string{"\x1b[38;5;52m", "\x1b[38;5;88m", "\x1b[38;5;124m", "\x1b[38;5;160m", "\x1b[38;5;202m", "\x1b[38;5;208m", "\x1b[38;5;214m", "\x1b[38;5;229m"}
+	hudColor     = "\x1b[38;5;244m"
+)
+
+// Config controls the attractor tracer.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// System is the attractor to trace: lorenz, rossler or aizawa.
+	System string
+	// Params override the system's constants in order: sigma, rho and
+	// beta for lorenz; a, b and c for rossler; a to f for aizawa. Missing
+	// ones keep their classic values.
+	Params []float64
+	// Trail is how many recent points stay on screen.
+	Trail int
+	// Steps is how many integration steps run each frame.
+	Steps int
+	// Spin turns the view each frame, in radians.
+	Spin float64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 30 * time.Millisecond,
+		System:     "lorenz",
+		Trail:      1500,
+		Steps:      5,
+		Spin:       0.006,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 30 * time.Millisecond
+	}
+	if !IsSystem(c.System) {
+		c.System = "lorenz"
+	}
+	if c.Trail <= 0 {
+		c.Trail = 1500
+	}
+	if c.Steps <= 0 {
+		c.Steps = 5
+	}
+	return c
+}
+
+// tracer is one trajectory and the trail it leaves, oldest first.
+type tracer struct {
+	at    space.Vec3
+	trail []space.Vec3
+}
+
+func (t *tracer) advance(s system, k []float64, limit int) {
+	t.at = s.step(t.at, k)
+	if len(t.trail) == limit {
+		t.trail = t.trail[1:]
+	}
+	t.trail = append(t.trail, t.at)
+}
+
+// Run launches the tracer. 'p' starts a shadow trajectory a hair away from
+// the main one so the two can be watched drifting apart; pressing it again
+// brings the shadow back.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	sys := systems[cfg.System]
+	k := append([]float64(nil), sys.params...)
+	copy(k, cfg.Params)
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	lead := &tracer{at: sys.start}
+	var shadow *tracer
+	// Let the trajectory settle onto the attractor before showing it.
+	for i := 0; i < 3000; i++ {
+		lead.at = sys.step(lead.at, k)
+	}
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		for i := 0; i < cfg.Steps; i++ {
+			lead.advance(sys, k, cfg.Trail)
+			if shadow != nil {
+				shadow.advance(sys, k, cfg.Trail)
+			}
+		}
+
+		angle := float64(frame) * cfg.Spin
+		grid.Clear()
+		if shadow != nil {
+			drawTrail(grid, sys, shadow, angle, shadowColors)
+		}
+		drawTrail(grid, sys, lead, angle, trailColors)
+		drawHUD(grid, cfg.System, k, lead, shadow)
+		grid.Render(screen)
+
+		for waiting := true; waiting; {
+			select {
+			case key := <-keys:
+				if key == 'p' || key == 'P' {
+					shadow = &tracer{at: space.Add(lead.at, space.Vec3{X: nudge})}
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+// drawTrail plots a trail oldest first, so newer and brighter points land
+// on top.
+func drawTrail(grid canvas.Grid, s system, t *tracer, angle float64, colors []string) {
+	height := len(grid)
+	width := len(grid[0])
+	scale := math.Min(3.6*float64(height), 1.8*float64(width))
+	for i, p := range t.trail {
+		v := space.Rotate(s.view(p), tilt, angle, 0)
+		x, y, _ := space.Project(v, cameraDistance, scale, aspectRatio, width, height)
+		age := float64(i+1) / float64(len(t.trail))
+		glyph := trailGlyphs[min(len(trailGlyphs)-1, int(age*float64(len(trailGlyphs))))]
+		grid.Set(x, y, glyph, colors[min(len(colors)-1, int(age*float64(len(colors))))])
+	}
+}
+
+func drawHUD(grid canvas.Grid, name string, k []float64, lead, shadow *tracer) {
+	parts := make([]string, len(k))
+	for i, v := range k {
+		parts[i] = fmt.Sprintf("%.3g", v)
+	}
+	hud := fmt.Sprintf(" %s %s  p: perturb", name, strings.Join(parts, ","))
+	if shadow != nil {
+		d := space.Subtract(lead.at, shadow.at)
+		hud += fmt.Sprintf("  divergence %.2e", math.Sqrt(space.Dot(d, d)))
+	}
+	y := len(grid) - 1
+	grid.Text(0, y, hud, hudColor)
+}
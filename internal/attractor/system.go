@@ -0,0 +1,88 @@
+package attractor
+
+import "animinterminal/internal/space"
+
+// system is one set of differential equations with the constants that make
+// it chaotic, and where it lives so the view can frame it.
+type system struct {
+	params []float64
+	deriv  func(p space.Vec3, k []float64) space.Vec3
+	dt     float64
+	start  space.Vec3
+	// center and size frame the attractor; the view is centered on center
+	// and scaled so size reaches the edge.
+	center space.Vec3
+	size   float64
+}
+
+var systems = map[string]system{
+	// Lorenz: sigma, rho, beta.
+	"lorenz": {
+		params: []float64{10, 28, 8.0 / 3},
+		deriv: func(p space.Vec3, k []float64) space.Vec3 {
+			return space.Vec3{
+				X: k[0] * (p.Y - p.X),
+				Y: p.X*(k[1]-p.Z) - p.Y,
+				Z: p.X*p.Y - k[2]*p.Z,
+			}
+		},
+		dt:     0.006,
+		start:  space.Vec3{X: 0.1, Y: 0, Z: 0},
+		center: space.Vec3{X: 0, Y: 0, Z: 26},
+		size:   29,
+	},
+	// Rössler: a, b, c.
+	"rossler": {
+		params: []float64{0.2, 0.2, 5.7},
+		deriv: func(p space.Vec3, k []float64) space.Vec3 {
+			return space.Vec3{
+				X: -p.Y - p.Z,
+				Y: p.X + k[0]*p.Y,
+				Z: k[1] + p.Z*(p.X-k[2]),
+			}
+		},
+		dt:     0.02,
+		start:  space.Vec3{X: 1, Y: 1, Z: 0},
+		center: space.Vec3{X: 1, Y: -1, Z: 8},
+		size:   14,
+	},
+	// Aizawa: a, b, c, d, e, f.
+	"aizawa": {
+		params: []float64{0.95, 0.7, 0.6, 3.5, 0.25, 0.1},
+		deriv: func(p space.Vec3, k []float64) space.Vec3 {
+			return space.Vec3{
+				X: (p.Z-k[1])*p.X - k[3]*p.Y,
+				Y: k[3]*p.X + (p.Z-k[1])*p.Y,
+				Z: k[2] + k[0]*p.Z - p.Z*p.Z*p.Z/3 - (p.X*p.X+p.Y*p.Y)*(1+k[4]*p.Z) + k[5]*p.Z*p.X*p.X*p.X,
+			}
+		},
+		dt:     0.01,
+		start:  space.Vec3{X: 0.1, Y: 0, Z: 0},
+		center: space.Vec3{X: 0, Y: 0, Z: 0.5},
+		size:   1.6,
+	},
+}
+
+// IsSystem reports whether name is an attractor the mode can trace.
+func IsSystem(name string) bool {
+	_, ok := systems[name]
+	return ok
+}
+
+// step advances p by one fourth-order Runge-Kutta step.
+func (s system) step(p space.Vec3, k []float64) space.Vec3 {
+	h := s.dt
+	k1 := s.deriv(p, k)
+	k2 := s.deriv(space.Add(p, space.Scale(k1, h/2)), k)
+	k3 := s.deriv(space.Add(p, space.Scale(k2, h/2)), k)
+	k4 := s.deriv(space.Add(p, space.Scale(k3, h)), k)
+	sum := space.Add(space.Add(k1, space.Scale(k2, 2)), space.Add(space.Scale(k3, 2), k4))
+	return space.Add(p, space.Scale(sum, h/6))
+}
+
+// view moves p into view space: centered, scaled to about -1..1 and turned
+// so the system's z axis points up the screen.
+func (s system) view(p space.Vec3) space.Vec3 {
+	d := space.Scale(space.Subtract(p, s.center), 1/s.size)
+	return space.Vec3{X: d.X, Y: d.Z, Z: d.Y}
+}
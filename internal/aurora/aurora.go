@@ -1,12 +1,15 @@
 package aurora
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -43,6 +46,44 @@ type Config struct {
 	Width      int
 	Height     int
 	FrameDelay time.Duration
+	// Lake puts a lake in the foreground that reflects the sky, with the
+	// mountains as its far shore.
+	Lake bool
+	// Activity is the average auroral activity, 0-1. Higher values mean a
+	// brighter quiet sky and more frequent, stronger substorms.
+	Activity float64
+	// Colors names the color scheme: green, red, purple or rainbow.
+	Colors string
+	// CustomColors, when set, are 256-color codes used for the curtains
+	// instead of the scheme's own ramp.
+	CustomColors []int
+	// Meteors is the average number of shooting stars per minute; 0 disables
+	// them.
+	Meteors float64
+	// Wind scales how fast the curtains drift sideways; negative values blow
+	// them the other way.
+	Wind float64
+	// Stars is the density of the star field, 0-1; 0 leaves the sky empty.
+	Stars float64
+	// Moon is the moon's phase: full, half, crescent, or auto to follow
+	// the real date. "" or off leaves it out.
+	Moon string
+	// Trees is how thickly pines line the bottom edge, 0-1; 0 leaves them
+	// out.
+	Trees float64
+	// Cabin adds a small cabin with a lit window and a smoking chimney.
+	Cabin bool
+	// ReducedMotion leaves out the meteors and their flashes.
+	ReducedMotion bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
 }
 
 // DefaultConfig returns a typical terminal preset.
@@ -51,10 +92,22 @@ func DefaultConfig() Config {
 		Width:      100,
 		Height:     34,
 		FrameDelay: 40 * time.Millisecond,
+		Activity:   0.5,
+		Colors:     "green",
+		Meteors:    2,
+		Trees:      0.4,
+		Stars:      0.3,
+		Wind:       1,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < 60 {
 		c.Width = 60
 	}
@@ -64,105 +117,183 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 45 * time.Millisecond
 	}
+	if c.Activity < 0 {
+		c.Activity = 0.5
+	}
+	if c.Activity > 1 {
+		c.Activity = 1
+	}
+	if !IsMoon(c.Moon) || c.Moon == "off" {
+		c.Moon = ""
+	}
+	if c.Wind == 0 {
+		c.Wind = 1
+	}
+	if c.Stars < 0 {
+		c.Stars = 0
+	}
+	if c.Stars > 1 {
+		c.Stars = 1
+	}
+	if c.Trees < 0 {
+		c.Trees = 0
+	}
+	if c.Trees > 1 {
+		c.Trees = 1
+	}
+	if c.Meteors < 0 {
+		c.Meteors = 0
+	}
+	if !IsTheme(c.Colors) {
+		c.Colors = "green"
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
+// Run launches the aurora animation.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
 }
 
-// Run launches the aurora animation.
-func Run(cfg Config) {
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	grid := newGrid(cfg.Width, cfg.Height)
+	grid := canvas.New(cfg.Width, cfg.Height)
+	th := newTheme(cfg.Colors, cfg.CustomColors)
+	storm := newSubstorm(cfg.Activity, cfg.FrameDelay, rng)
+	phase, drift := 0.0, 0.0
+	seed := rng.Float64() * 1000
+	meteors := newShower(cfg, rng)
+	woods := newForest(cfg, rng)
+	stars := newStars(cfg.Width, cfg.Height, cfg.Stars, rng)
+	moonPhase := moonPhase(cfg.Moon, time.Now())
+	shore := cfg.Height
+	if cfg.Lake {
+		shore -= lakeRows(cfg.Height)
+	}
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
-		drawSky(grid, frame)
-		drawStars(grid, frame)
-		drawAuroraCurtains(grid, frame)
-		drawMountains(grid, frame)
-		render(grid)
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		for x := range grid[y] {
-			grid[y][x] = cell{glyph: ' ', color: ""}
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		drawSky(grid, frame, th.sky)
+		drawStars(grid, stars, frame, th.stars)
+		mx, my := moonAt(cfg.Width, cfg.Height, frame, cfg.FrameDelay)
+		if cfg.Moon != "" {
+			drawMoon(grid, mx, my, moonPhase, th.sky)
+		}
+		intensity := storm.advance()
+		phase += 0.02 * (0.5 + intensity)
+		drift += 0.1 * cfg.Wind * (0.5 + intensity)
+		drawAuroraCurtains(grid, phase, drift, intensity, th, seed, rng)
+		if !cfg.ReducedMotion {
+			meteors.update(cfg.Width, cfg.Height)
+			meteors.draw(grid)
+		}
+		drawMountains(grid, frame, shore)
+		if meteors.glare > 0 {
+			lightPeaks(grid, shore)
+		}
+		if cfg.Lake {
+			drawLake(grid, frame, th.lake)
+		}
+		woods.update(frame, cfg.Height)
+		woods.draw(grid, frame)
+		if cfg.Moon != "" {
+			moonlight(grid, mx, cfg.Height/2)
+		}
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
-func drawSky(grid [][]cell, frame int) {
+func drawSky(grid canvas.Grid, frame int, palette []string) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height/2; y++ {
-		color := skyPalette[(y/2+frame/30)%len(skyPalette)]
+		color := palette[(y/2+frame/30)%len(palette)]
 		for x := 0; x < width; x++ {
-			grid[y][x] = cell{glyph: ' ', color: color}
-		}
-	}
-}
-
-func drawStars(grid [][]cell, frame int) {
-	height := len(grid)
-	width := len(grid[0])
-	for i := 0; i < width/4; i++ {
-		x := (i*17 + frame) % width
-		y := rand.Intn(height / 2)
-		color := starPalette[(x+y+frame/5)%len(starPalette)]
-		if (x+y+frame)%13 == 0 {
-			setCell(grid, x, y, '*', color)
-		} else if (x*3+y+frame)%19 == 0 {
-			setCell(grid, x, y, '+', color)
+			grid[y][x] = canvas.Cell{Glyph: ' ', Color: color}
 		}
 	}
 }
 
-func drawAuroraCurtains(grid [][]cell, frame int) {
+// drawAuroraCurtains draws each band as a field of vertical rays: the lower
+// edge sways on smooth noise, and every column rises from it with a height
+// from a slow octave and striations from a fine one, both drifting sideways
+// with the wind. intensity (0-1) widens the sway, lengthens the rays,
+// brightens the palette and, near a substorm peak, adds a pink crown and
+// upward streaks.
+func drawAuroraCurtains(grid canvas.Grid, t, drift, intensity float64, th theme, seed float64, rng *rand.Rand) {
 	height := len(grid)
 	width := len(grid[0])
 	base := height / 3
+	amplitude := 0.6 + 0.8*intensity
+	reach := float64(height) / 6 * (0.6 + intensity)
+	bias := int(math.Round((intensity - 0.5) * 3))
+	crown := int(math.Max(0, intensity-0.5) * 6)
 	for band := 0; band < 3; band++ {
+		ramp := th.bands[band]
+		offset := seed + float64(band)*37
 		for x := 0; x < width; x++ {
-			fx := float64(x) / float64(width)
-			phase := float64(frame)*0.02 + float64(band)*1.1
-			offset := math.Sin(fx*5+phase) * float64(6-band*2)
-			y := base + band*3 + int(offset)
-			if y < 0 || y >= height {
+			fx := float64(x) - drift*(1+float64(band)*0.3)
+			sway := (noise1(fx*0.04+t+offset)*2 - 1) * float64(6-band*2) * amplitude
+			edge := base + band*3 + int(sway)
+			if edge < 0 || edge >= height {
 				continue
 			}
-			value := (math.Sin(fx*12+phase*1.5) + 1) / 2
-			color := auroraPalette[(int(value*float64(len(auroraPalette)))+band)%len(auroraPalette)]
-			glyph := curtainGlyph(value)
-			setCell(grid, x, y, glyph, color)
-			if y+1 < height && rand.Intn(3) == 0 {
-				setCell(grid, x, y+1, glyph, color)
+			streak := noise1(fx*0.7 + offset*3)
+			rise := int(reach * (0.3 + noise1(fx*0.09+offset*2)))
+			glyph := curtainGlyph(streak)
+			index := min(len(ramp)-1, int(streak*float64(len(ramp))))
+			grid.Set(x, edge, glyph, ramp[max(0, min(len(ramp)-1, index+bias))])
+			for dy := 1; dy <= rise; dy++ {
+				y := edge - dy
+				if y < 0 {
+					break
+				}
+				fade := 1 - float64(dy)/float64(rise+1)
+				b := streak * fade
+				var glyph rune
+				switch {
+				case b > 0.45:
+					glyph = '|'
+				case b > 0.25:
+					glyph = ':'
+				case b > 0.1:
+					glyph = '.'
+				default:
+					continue
+				}
+				color := ramp[max(0, min(len(ramp)-1, int(b*float64(len(ramp)))+bias))]
+				if band == 0 && dy > rise-crown {
+					color = th.crown[min(len(th.crown)-1, dy-(rise-crown)-1)]
+				}
+				grid.Set(x, y, glyph, color)
+			}
+			if band == 0 && streak > 0.3 {
+				drawRays(grid, x, edge-rise, intensity, ramp[len(ramp)-1], th.crown, rng)
 			}
 		}
 	}
 }
 
-func curtainGlyph(v float64) byte {
+func curtainGlyph(v float64) rune {
 	switch {
 	case v < 0.2:
 		return '.'
@@ -175,60 +306,19 @@ func curtainGlyph(v float64) byte {
 	}
 }
 
-func drawMountains(grid [][]cell, frame int) {
-	height := len(grid)
+// drawMountains fills the range down to bottom, the screen edge or the lake shore.
+func drawMountains(grid canvas.Grid, frame int, bottom int) {
 	width := len(grid[0])
-	base := height - 6
+	base := bottom - 6
 	for x := 0; x < width; x++ {
 		offset := int(math.Sin(float64(x)/7+float64(frame)*0.005) * 4)
 		y := base - offset
 		color := mountainPalette[(x/5)%len(mountainPalette)]
-		for dy := 0; dy < height-y; dy++ {
-			if y+dy >= height {
+		for dy := 0; dy < bottom-y; dy++ {
+			if y+dy >= bottom {
 				break
 			}
-			setIfEmpty(grid, x, y+dy, '#', color)
-		}
-	}
-}
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[0]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[0]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
+			grid.SetIfEmpty(x, y+dy, '#', color)
 		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
 	}
-	fmt.Print(sb.String())
 }
@@ -0,0 +1,32 @@
+package aurora
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/golden"
+)
+
+// TestGoldenCurtains draws a few frames of the ray curtains in a strong
+// wind at a fixed seed and checks them against testdata/curtains.golden.
+// The moon is left out, as its phase follows the date.
+func TestGoldenCurtains(t *testing.T) {
+	// Colors otherwise follow the terminal the test runs in.
+	color.Use(color.ANSI256)
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 48, 20
+	cfg.FrameDelay = time.Millisecond
+	cfg.MaxFrames = 4
+	cfg.Seed = 1
+	cfg.Wind = 2
+	cfg.Moon = ""
+	cfg.Output = &out
+	if err := RunContext(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "curtains.golden", out.Bytes())
+}
@@ -0,0 +1,166 @@
+package aurora
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	treeColor   = "\x1b[38;5;233m"
+	trunkColor  = "\x1b[38;5;234m"
+	cabinColor  = "\x1b[38;5;236m"
+	windowGlow  = []string{"\x1b[38;5;214m", "\x1b[38;5;220m", "\x1b[38;5;208m"}
+	smokeColors = []string{
+		"\x1b[38;5;246m",
+		"\x1b[38;5;243m",
+		"\x1b[38;5;240m",
+		"\x1b[38;5;238m",
+	}
+
+	cabinArt = []string{
+		"  ____|#|",
+		" /      \\",
+		"/________\\",
+		" | [] |  |",
+		" |____|__|",
+	}
+)
+
+// chimneyX is the column of the chimney top in cabinArt.
+const chimneyX = 7
+
+type pine struct {
+	x, height int
+}
+
+type puff struct {
+	x, y float64
+	vx   float64
+	age  int
+	life int
+}
+
+// forest is the foreground: pines along the bottom edge and, optionally, a
+// cabin with a lit window and a smoking chimney. It is drawn last so it hides
+// the mountains and the lake.
+type forest struct {
+	pines  []pine
+	cabin  bool
+	cabinX int
+	smoke  []puff
+	rng    *rand.Rand
+}
+
+func newForest(cfg Config, rng *rand.Rand) *forest {
+	f := &forest{cabin: cfg.Cabin, cabinX: -1, rng: rng}
+	width := len(cabinArt[2])
+	if cfg.Cabin {
+		f.cabinX = cfg.Width/2 + rng.Intn(max(1, cfg.Width/3))
+		f.cabinX = min(f.cabinX, cfg.Width-width-1)
+	}
+	if cfg.Trees <= 0 {
+		return f
+	}
+	tallest := max(4, cfg.Height/4)
+	gap := int(math.Round(12 * (1 - cfg.Trees)))
+	for x := rng.Intn(3); x < cfg.Width; {
+		h := 3 + rng.Intn(tallest-2)
+		// Keep the trees clear of the cabin so it stays readable.
+		if cfg.Cabin && x+h/2 >= f.cabinX-1 && x-h/2 <= f.cabinX+width {
+			x = f.cabinX + width + tallest/2 + 2
+			continue
+		}
+		f.pines = append(f.pines, pine{x: x, height: h})
+		x += 2 + rng.Intn(3) + rng.Intn(gap+1)
+	}
+	return f
+}
+
+func (f *forest) update(frame, height int) {
+	if f.cabin && frame%6 == 0 {
+		top := height - len(cabinArt)
+		f.smoke = append(f.smoke, puff{
+			x:    float64(f.cabinX + chimneyX),
+			y:    float64(top - 1),
+			vx:   0.04 + f.rng.Float64()*0.04,
+			life: 30 + f.rng.Intn(20),
+		})
+	}
+	alive := f.smoke[:0]
+	for _, p := range f.smoke {
+		p.age++
+		// A slight breeze bends the column as it rises.
+		p.x += p.vx + math.Sin(float64(frame)*0.05+p.y*0.3)*0.03
+		p.y -= 0.18
+		if p.age < p.life && p.y >= 0 {
+			alive = append(alive, p)
+		}
+	}
+	f.smoke = alive
+}
+
+func (f *forest) draw(grid canvas.Grid, frame int) {
+	height := len(grid)
+	for _, p := range f.pines {
+		drawPine(grid, p, height-1)
+	}
+	if f.cabin {
+		drawCabin(grid, f.cabinX, height-len(cabinArt), frame, f.rng)
+	}
+	for _, p := range f.smoke {
+		fade := float64(p.age) / float64(p.life)
+		glyph := 'o'
+		switch {
+		case fade > 0.6:
+			glyph = '.'
+		case fade > 0.25:
+			glyph = '~'
+		}
+		color := smokeColors[min(len(smokeColors)-1, int(fade*float64(len(smokeColors))))]
+		grid.Set(int(math.Round(p.x)), int(math.Round(p.y)), glyph, color)
+	}
+}
+
+// drawPine stacks ragged tiers of '#' on a short trunk: each tier is wider
+// than the last, and every other row steps back in for a jagged edge.
+func drawPine(grid canvas.Grid, p pine, ground int) {
+	grid.Set(p.x, ground, '|', trunkColor)
+	for i := 0; i < p.height; i++ {
+		y := ground - p.height + i
+		half := (i + 1) / 2
+		if i%2 == 1 {
+			half--
+		}
+		for dx := -half; dx <= half; dx++ {
+			grid.Set(p.x+dx, y, '#', treeColor)
+		}
+	}
+	grid.Set(p.x, ground-p.height-1, '^', treeColor)
+}
+
+func drawCabin(grid canvas.Grid, x, y, frame int, rng *rand.Rand) {
+	glow := windowGlow[0]
+	if rng.Intn(8) == 0 || (frame/20)%5 == 0 {
+		glow = windowGlow[1+rng.Intn(len(windowGlow)-1)]
+	}
+	for dy, row := range cabinArt {
+		// Blanks inside the outline are the dark walls; outside it the sky
+		// shows through.
+		left := len(row) - len(strings.TrimLeft(row, " "))
+		right := len(strings.TrimRight(row, " "))
+		for dx := left; dx < right; dx++ {
+			glyph := rune(row[dx])
+			if glyph == ' ' {
+				glyph = '#'
+			}
+			color := cabinColor
+			if glyph == '[' || glyph == ']' {
+				glyph, color = '#', glow
+			}
+			grid.Set(x+dx, y+dy, glyph, color)
+		}
+	}
+}
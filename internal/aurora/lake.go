@@ -0,0 +1,120 @@
+package aurora
+
+import (
+	"fmt"
+	"math"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	lakeSkyPalette = []string{
+		"\x1b[38;5;16m",
+		"\x1b[38;5;17m",
+		"\x1b[38;5;17m",
+		"\x1b[38;5;53m",
+		"\x1b[38;5;54m",
+	}
+	lakeStarColor     = "\x1b[38;5;60m"
+	lakeMountainColor = "\x1b[38;5;234m"
+	rippleColor       = "\x1b[38;5;24m"
+)
+
+// lakeColorsFor maps each color in the theme to the dimmer one seen in the
+// water.
+func lakeColorsFor(th theme) map[string]string {
+	m := make(map[string]string)
+	for i, c := range th.sky {
+		m[c] = lakeSkyPalette[min(i, len(lakeSkyPalette)-1)]
+	}
+	for _, ramp := range th.bands {
+		for _, c := range ramp {
+			m[c] = dimColor(c)
+		}
+	}
+	for _, c := range th.crown {
+		m[c] = dimColor(c)
+	}
+	for _, c := range th.stars {
+		m[c] = lakeStarColor
+	}
+	for _, c := range []string{moonColor, moonMareColor, moonHaloColor} {
+		m[c] = dimColor(c)
+	}
+	for _, c := range mountainPalette {
+		m[c] = lakeMountainColor
+	}
+	return m
+}
+
+// dimColor halves each channel of a color-cube code and steps grays down.
+func dimColor(c string) string {
+	var code int
+	if _, err := fmt.Sscanf(c, "\x1b[38;5;%dm", &code); err != nil {
+		return lakeSkyPalette[0]
+	}
+	switch {
+	case code >= 232:
+		code = max(232, code-8)
+	case code >= 16:
+		n := code - 16
+		r, g, b := n/36, n/6%6, n%6
+		code = 16 + r/2*36 + g/2*6 + b/2
+	default:
+		code = 8
+	}
+	return fmt.Sprintf("\x1b[38;5;%dm", code)
+}
+
+// lakeRows is how much of the bottom of the screen the lake covers.
+func lakeRows(height int) int {
+	return max(4, height/5)
+}
+
+// drawLake mirrors the scene above the shore into the water. The sky is
+// squeezed so the curtains, not just the far shore, show up in the lake, and
+// every row sways sideways a little more the nearer it is to the viewer.
+func drawLake(grid canvas.Grid, frame int, colors map[string]string) {
+	height := len(grid)
+	width := len(grid[0])
+	rows := lakeRows(height)
+	shore := height - rows
+	stretch := float64(shore-height/4) / float64(rows)
+	for ly := 0; ly < rows; ly++ {
+		sy := shore - 1 - int(float64(ly)*stretch)
+		if sy < 0 {
+			sy = 0
+		}
+		for x := 0; x < width; x++ {
+			sway := math.Sin(float64(frame)*0.12+float64(ly)*1.7+float64(x)*0.08) * (1 + float64(ly)*0.4)
+			sx := x + int(math.Round(sway))
+			if sx < 0 || sx >= width {
+				sx = x
+			}
+			src := grid[sy][sx]
+			color, ok := colors[src.Color]
+			if !ok {
+				color = lakeSkyPalette[0]
+			}
+			glyph := mirrorGlyph(src.Glyph)
+			if (x*x*7+ly*31+frame/4)%23 == 0 {
+				glyph, color = '~', rippleColor
+			}
+			grid[shore+ly][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+}
+
+func mirrorGlyph(g rune) rune {
+	switch g {
+	case '/':
+		return '\\'
+	case '\\':
+		return '/'
+	case '*':
+		return '+'
+	case '#':
+		return '='
+	}
+	return g
+}
@@ -0,0 +1,174 @@
+package aurora
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	meteorTrail = []string{
+		"\x1b[38;5;231m",
+		"\x1b[38;5;230m",
+		"\x1b[38;5;187m",
+		"\x1b[38;5;145m",
+		"\x1b[38;5;102m",
+		"\x1b[38;5;59m",
+	}
+	fireballColor  = "\x1b[38;5;222m"
+	peakGlareColor = "\x1b[38;5;250m"
+)
+
+// meteor streaks away from the shower's radiant for life frames, then may
+// end in a short flash.
+type meteor struct {
+	x, y     float64
+	vx, vy   float64
+	age      int
+	life     int
+	flash    int
+	fireball bool
+}
+
+// shower spawns meteors that all share one radiant, somewhere above the top
+// of the screen, so their streaks line up the way a real shower's do.
+type shower struct {
+	rx, ry  float64
+	chance  float64
+	fps     float64
+	meteors []meteor
+	// glare counts down the frames a fireball lights the mountain tops.
+	glare int
+	rng   *rand.Rand
+}
+
+func newShower(cfg Config, rng *rand.Rand) *shower {
+	rx := float64(cfg.Width) * 0.15
+	if rng.Intn(2) == 0 {
+		rx = float64(cfg.Width) * 0.85
+	}
+	return &shower{
+		rx:     rx,
+		ry:     -float64(cfg.Height) * 0.4,
+		chance: cfg.Meteors * float64(cfg.FrameDelay) / float64(time.Minute),
+		fps:    float64(time.Second) / float64(cfg.FrameDelay),
+		rng:    rng,
+	}
+}
+
+func (s *shower) update(width, height int) {
+	if s.glare > 0 {
+		s.glare--
+	}
+	if s.rng.Float64() < s.chance {
+		s.spawn(width, height)
+	}
+	alive := s.meteors[:0]
+	for _, m := range s.meteors {
+		if m.age < m.life {
+			m.x += m.vx
+			m.y += m.vy
+			m.age++
+			if m.age == m.life && m.fireball {
+				s.glare = 2
+			}
+		} else {
+			m.flash--
+		}
+		if m.age < m.life || m.flash > 0 {
+			alive = append(alive, m)
+		}
+	}
+	s.meteors = alive
+}
+
+// spawn starts a meteor in the upper sky that crosses about a third of the
+// screen in well under a second.
+func (s *shower) spawn(width, height int) {
+	m := meteor{
+		x:        s.rng.Float64() * float64(width),
+		y:        s.rng.Float64() * float64(height) / 4,
+		life:     max(3, int(s.fps*(0.4+s.rng.Float64()*0.4))),
+		fireball: s.rng.Intn(10) == 0,
+	}
+	dx, dy := m.x-s.rx, (m.y-s.ry)*2
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return
+	}
+	speed := float64(width) / 3 / float64(m.life)
+	m.vx = dx / dist * speed
+	// Cells are about twice as tall as they are wide.
+	m.vy = dy / dist * speed / 2
+	if m.fireball || s.rng.Intn(3) == 0 {
+		m.flash = 2
+		if m.fireball {
+			m.flash = 3
+		}
+	}
+	s.meteors = append(s.meteors, m)
+}
+
+func (s *shower) draw(grid canvas.Grid) {
+	for _, m := range s.meteors {
+		x, y := int(math.Round(m.x)), int(math.Round(m.y))
+		if m.age >= m.life {
+			drawMeteorFlash(grid, x, y, m.fireball)
+			continue
+		}
+		// The trail lengthens as the meteor gets going.
+		tail := float64(min(m.age+1, len(meteorTrail)))
+		tx := int(math.Round(m.x - m.vx*tail))
+		ty := int(math.Round(m.y - m.vy*tail))
+		points := canvas.LinePoints(x, y, tx, ty)
+		for i, p := range points {
+			color := meteorTrail[min(len(meteorTrail)-1, i*len(meteorTrail)/len(points))]
+			grid.Set(p[0], p[1], meteorGlyph(m.vx, m.vy), color)
+		}
+		head := 'o'
+		color := meteorTrail[0]
+		if m.fireball {
+			head, color = '@', fireballColor
+		}
+		grid.Set(x, y, head, color)
+	}
+}
+
+func drawMeteorFlash(grid canvas.Grid, x, y int, fireball bool) {
+	grid.Set(x, y, '*', meteorTrail[0])
+	if !fireball {
+		return
+	}
+	for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-2, 0}, {2, 0}} {
+		grid.Set(x+d[0], y+d[1], '+', fireballColor)
+	}
+}
+
+func meteorGlyph(vx, vy float64) rune {
+	switch {
+	case math.Abs(vy) < math.Abs(vx)/3:
+		return '-'
+	case math.Abs(vx) < math.Abs(vy)/3:
+		return '|'
+	case (vx > 0) == (vy > 0):
+		return '\\'
+	default:
+		return '/'
+	}
+}
+
+// lightPeaks catches the fireball's glare on the first two rows of every
+// ridge.
+func lightPeaks(grid canvas.Grid, bottom int) {
+	for x := range grid[0] {
+		lit := 0
+		for y := 0; y < bottom && lit < 2; y++ {
+			if grid[y][x].Glyph == '#' {
+				grid[y][x].Color = peakGlareColor
+				lit++
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package aurora
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	// moonCross is how long the moon takes to drift across the sky.
+	moonCross    = 2 * time.Hour
+	synodicMonth = 2551443 * time.Second // 29.53 days
+	moonRadius   = 2
+)
+
+var (
+	moonColor     = "\x1b[38;5;230m"
+	moonMareColor = "\x1b[38;5;187m"
+	moonHaloColor = "\x1b[38;5;60m"
+	// knownNewMoon is the new moon of 6 January 2000.
+	knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+)
+
+// IsMoon reports whether name is a moon setting ("" or "off" for none).
+func IsMoon(name string) bool {
+	switch name {
+	case "", "off", "full", "half", "crescent", "auto":
+		return true
+	}
+	return false
+}
+
+// moonPhase maps a setting to the lunar cycle: 0 new, 0.25 first quarter,
+// 0.5 full, 0.75 last quarter. "auto" reads the phase off today's date.
+func moonPhase(name string, now time.Time) float64 {
+	switch name {
+	case "full":
+		return 0.5
+	case "half":
+		return 0.25
+	case "crescent":
+		return 0.15
+	}
+	age := now.Sub(knownNewMoon) % synodicMonth
+	if age < 0 {
+		age += synodicMonth
+	}
+	return float64(age) / float64(synodicMonth)
+}
+
+// moonAt places the moon in the upper sky. It drifts from right to left over
+// moonCross, rising and setting a little at either end.
+func moonAt(width, height, frame int, frameDelay time.Duration) (int, int) {
+	elapsed := time.Duration(frame) * frameDelay
+	t := math.Mod(float64(elapsed)/float64(moonCross)+0.1, 1)
+	x := float64(width) * (0.9 - 0.8*t)
+	arc := math.Sin(t * math.Pi)
+	y := float64(height/5) - arc*float64(height/5-moonRadius-1)
+	return int(math.Round(x)), int(math.Round(y))
+}
+
+// drawMoon draws the disc, masking its dark part with the sky color so it
+// hides the stars behind it, and a faint halo around it. The disc is twice
+// as wide as it is tall to look round in the terminal.
+func drawMoon(grid canvas.Grid, mx, my int, phase float64, sky []string) {
+	terminator := math.Cos(2 * math.Pi * phase)
+	halo := 1.7
+	reach := int(math.Ceil(halo * moonRadius))
+	for dy := -reach; dy <= reach; dy++ {
+		for dx := -reach * 2; dx <= reach*2; dx++ {
+			x, y := mx+dx, my+dy
+			if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[0]) {
+				continue
+			}
+			nx := float64(dx) / float64(moonRadius*2)
+			ny := float64(dy) / float64(moonRadius)
+			dist := math.Hypot(nx, ny)
+			if dist > 1 {
+				if dist < halo && (x*7+y*3)%4 == 0 {
+					grid.SetIfEmpty(x, y, '.', moonHaloColor)
+				}
+				continue
+			}
+			// The lit part runs from one limb to the terminator: the
+			// right limb while waxing, the left while waning.
+			w := math.Sqrt(1 - ny*ny)
+			lit := nx > w*terminator
+			if phase > 0.5 {
+				lit = nx < -w*terminator
+			}
+			if !lit {
+				grid.Set(x, y, ' ', sky[min(len(sky)-1, max(0, y/2))])
+				continue
+			}
+			glyph, color := '@', moonColor
+			if (dx*3+dy*5)%7 == 0 {
+				glyph, color = '%', moonMareColor
+			}
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
+
+// moonlight lifts the silhouettes on the moon's half of the screen by one
+// shade of gray.
+func moonlight(grid canvas.Grid, mx, top int) {
+	width := len(grid[0])
+	for y := top; y < len(grid); y++ {
+		for x := range grid[y] {
+			c := &grid[y][x]
+			if c.Glyph == ' ' || canvas.Abs(x-mx) > width/2 {
+				continue
+			}
+			var code int
+			if _, err := fmt.Sscanf(c.Color, "\x1b[38;5;%dm", &code); err != nil {
+				continue
+			}
+			if code >= 232 && code <= 240 {
+				c.Color = fmt.Sprintf("\x1b[38;5;%dm", code+1)
+			}
+		}
+	}
+}
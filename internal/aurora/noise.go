@@ -0,0 +1,19 @@
+package aurora
+
+import "math"
+
+// noise1 is 1D value noise in 0-1: hashed lattice values blended with a
+// smoothstep, so it is continuous and repeats nowhere useful.
+func noise1(x float64) float64 {
+	i := math.Floor(x)
+	f := x - i
+	a := latticeValue(int(i))
+	b := latticeValue(int(i) + 1)
+	return a + (b-a)*smoothstep(f)
+}
+
+func latticeValue(n int) float64 {
+	n = (n << 13) ^ n
+	v := (n*(n*n*15731+789221) + 1376312589) & 0x7fffffff
+	return float64(v) / 0x7fffffff
+}
@@ -0,0 +1,77 @@
+package aurora
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+// dipper is the Big Dipper, handle first, laid out in cells.
+var dipper = [][2]int{{0, 2}, {4, 0}, {7, 0}, {11, 1}, {12, 3}, {17, 3}, {16, 0}}
+
+// star is generated once per run; only its brightness changes from frame to
+// frame.
+type star struct {
+	x, y   int
+	base   float64
+	phase  float64
+	speed  float64
+	bright bool
+}
+
+// newStars scatters density*(sky cells)/15 stars over the upper half of the
+// screen and adds the Big Dipper near the top left corner.
+func newStars(width, height int, density float64, rng *rand.Rand) []star {
+	rows := height / 2
+	count := int(density * float64(width*rows) / 15)
+	stars := make([]star, 0, count+len(dipper))
+	for i := 0; i < count; i++ {
+		stars = append(stars, star{
+			x:     rng.Intn(width),
+			y:     rng.Intn(rows),
+			base:  0.2 + rng.Float64()*0.6,
+			phase: rng.Float64() * 2 * math.Pi,
+			speed: 0.02 + rng.Float64()*0.06,
+		})
+	}
+	if density > 0 {
+		ox, oy := width/8, 1
+		for _, p := range dipper {
+			stars = append(stars, star{
+				x:      ox + p[0],
+				y:      oy + p[1],
+				base:   0.95,
+				phase:  rng.Float64() * 2 * math.Pi,
+				speed:  0.03,
+				bright: true,
+			})
+		}
+	}
+	return stars
+}
+
+// drawStars twinkles every star on a slow sine of its own; faint stars drop
+// out entirely at the bottom of their cycle.
+func drawStars(grid canvas.Grid, stars []star, frame int, palette []string) {
+	for _, s := range stars {
+		amount := 0.3
+		if s.bright {
+			amount = 0.1
+		}
+		b := s.base + amount*math.Sin(float64(frame)*s.speed+s.phase)
+		var glyph rune
+		switch {
+		case b > 0.85:
+			glyph = '*'
+		case b > 0.55:
+			glyph = '+'
+		case b > 0.25:
+			glyph = '.'
+		default:
+			continue
+		}
+		color := palette[min(len(palette)-1, int((1-b)*float64(len(palette))))]
+		grid.Set(s.x, s.y, glyph, color)
+	}
+}
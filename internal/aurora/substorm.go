@@ -0,0 +1,102 @@
+package aurora
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	substormBuild = 30 * time.Second
+	substormRelax = 20 * time.Second
+)
+
+var (
+	// crownPalette is the pink and red fringe that appears above the green
+	// curtains when a substorm peaks.
+	crownPalette = []string{
+		"\x1b[38;5;175m",
+		"\x1b[38;5;169m",
+		"\x1b[38;5;168m",
+		"\x1b[38;5;161m",
+		"\x1b[38;5;160m",
+	}
+)
+
+// substorm drives the curtain intensity: long quiet spells broken by
+// outbursts that build to a peak over substormBuild and then relax. activity
+// raises the quiet level and makes outbursts more frequent and stronger.
+type substorm struct {
+	activity float64
+	build    int
+	relax    int
+	quiet    int
+	frame    int
+	peak     float64
+	rng      *rand.Rand
+}
+
+func newSubstorm(activity float64, frameDelay time.Duration, rng *rand.Rand) *substorm {
+	s := &substorm{
+		activity: activity,
+		rng:      rng,
+		build:    max(1, int(substormBuild/frameDelay)),
+		relax:    max(1, int(substormRelax/frameDelay)),
+	}
+	s.reset()
+	// Start part way through a quiet spell so the first outburst does not
+	// always take the full wait.
+	s.frame = rng.Intn(s.quiet + 1)
+	return s
+}
+
+// reset schedules the next outburst.
+func (s *substorm) reset() {
+	wait := (1.6 - s.activity) * float64(s.build+s.relax)
+	s.quiet = int(wait * (0.5 + s.rng.Float64()))
+	s.peak = math.Min(1, 0.6+0.3*s.activity+s.rng.Float64()*0.25)
+	s.frame = 0
+}
+
+func (s *substorm) floor() float64 {
+	return 0.1 + 0.4*s.activity
+}
+
+// advance steps the cycle by one frame and returns the intensity, 0-1.
+func (s *substorm) advance() float64 {
+	s.frame++
+	env := 0.0
+	switch t := s.frame - s.quiet; {
+	case t <= 0:
+	case t <= s.build:
+		env = smoothstep(float64(t) / float64(s.build))
+	case t <= s.build+s.relax:
+		env = 1 - smoothstep(float64(t-s.build)/float64(s.relax))
+	default:
+		s.reset()
+	}
+	floor := s.floor()
+	return floor + (math.Max(floor, s.peak)-floor)*env
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// drawRays shoots streaks upward from the curtain when it is bright enough,
+// tipped with the crown colors.
+func drawRays(grid canvas.Grid, x, y int, intensity float64, base string, crown []string, rng *rand.Rand) {
+	if intensity < 0.6 || rng.Float64() > (intensity-0.6)*0.5 {
+		return
+	}
+	length := 2 + rng.Intn(1+int(intensity*float64(len(grid))/5))
+	for i := 1; i <= length; i++ {
+		color := base
+		if i > length/2 {
+			color = crown[min(len(crown)-1, (i-length/2)*len(crown)/(length/2+1))]
+		}
+		grid.Set(x, y-i, '|', color)
+	}
+}
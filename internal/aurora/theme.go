@@ -0,0 +1,99 @@
+package aurora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// theme holds the palettes resolved for one color scheme. Every band gets its
+// own ramp, dark to bright, and the sky and stars lean slightly toward the
+// complement of the curtains so the scene reads as one.
+type theme struct {
+	bands [3][]string
+	crown []string
+	sky   []string
+	stars []string
+	// lake maps every color in the theme to its dimmer reflection.
+	lake map[string]string
+}
+
+var themeRamps = map[string][]int{
+	"red":    {52, 88, 124, 160, 196, 203},
+	"purple": {54, 91, 128, 134, 171, 213},
+}
+
+// IsTheme reports whether name is a built-in aurora color scheme.
+func IsTheme(name string) bool {
+	switch name {
+	case "green", "red", "purple", "rainbow":
+		return true
+	}
+	return false
+}
+
+// newTheme resolves the named scheme. custom, when set, replaces the curtain
+// colors with the given 256-color codes whatever the name.
+func newTheme(name string, custom []int) theme {
+	th := theme{crown: crownPalette, sky: skyPalette, stars: starPalette}
+	switch {
+	case len(custom) > 0:
+		th.bands = rotatedBands(colorRamp(custom))
+	case name == "red":
+		th.bands = rotatedBands(colorRamp(themeRamps["red"]))
+		th.crown = colorRamp([]int{161, 162, 163, 164, 165})
+		th.sky = colorRamp([]int{17, 17, 18, 23, 24})
+		th.stars = colorRamp([]int{231, 195, 159})
+	case name == "purple":
+		th.bands = rotatedBands(colorRamp(themeRamps["purple"]))
+		th.crown = colorRamp([]int{168, 204, 210, 217, 224})
+		th.sky = colorRamp([]int{16, 17, 17, 23, 23})
+		th.stars = colorRamp([]int{231, 230, 229})
+	case name == "rainbow":
+		th.bands = [3][]string{
+			colorRamp([]int{124, 160, 196, 202, 208, 214}),
+			colorRamp([]int{28, 34, 40, 46, 82, 118}),
+			colorRamp([]int{19, 20, 26, 27, 33, 39}),
+		}
+	default:
+		th.bands = rotatedBands(auroraPalette)
+	}
+	th.lake = lakeColorsFor(th)
+	return th
+}
+
+// rotatedBands gives each band the shared ramp shifted by its index, which is
+// how the bands used to pick from the single palette.
+func rotatedBands(ramp []string) [3][]string {
+	var bands [3][]string
+	for band := range bands {
+		bands[band] = make([]string, len(ramp))
+		for i := range ramp {
+			bands[band][i] = ramp[(i+band)%len(ramp)]
+		}
+	}
+	return bands
+}
+
+func colorRamp(codes []int) []string {
+	ramp := make([]string, len(codes))
+	for i, code := range codes {
+		ramp[i] = fmt.Sprintf("\x1b[38;5;%dm", code)
+	}
+	return ramp
+}
+
+// ParseColors reads a comma-separated list of 256-color codes.
+func ParseColors(spec string) ([]int, error) {
+	var codes []int
+	for _, part := range strings.Split(spec, ",") {
+		var code int
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d", &code); err != nil {
+			return nil, fmt.Errorf("bad color %q", part)
+		}
+		if code < 0 || code > 255 {
+			return nil, fmt.Errorf("color %d out of range 0-255", code)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
@@ -0,0 +1,217 @@
+package balls
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+	maxCount   = 40
+	// slowMotion is the share of normal speed while slow motion is on.
+	slowMotion = 0.2
+)
+
+var (
+	// ballColors is a bright color and the dimmer one its trail fades to.
+	ballColors = [][2]string{
+		{"\x1b[38;5;196m", "\x1b[38;5;88m"},
+		{"\x1b[38;5;214m", "\x1b[38;5;130m"},
+		{"\x1b[38;5;226m", "\x1b[38;5;100m"},
+		{"\x1b[38;5;46m", "\x1b[38;5;28m"},
+		{"\x1b[38;5;51m", "\x1b[38;5;30m"},
+		{"\x1b[38;5;33m", "\x1b[38;5;19m"},
+		{"\x1b[38;5;201m", "\x1b[38;5;90m"},
+	}
+	floorColor = "\x1b[38;5;240m"
+)
+
+// Config controls the bouncing balls.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Count is how many balls start in the box.
+	Count int
+	// Gravity is how much a ball speeds up downward each frame, in cells.
+	Gravity float64
+	// Restitution is the share of speed a bounce keeps, 0-1.
+	Restitution float64
+	// Trail is how many past positions each ball leaves behind.
+	Trail int
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:       100,
+		Height:      34,
+		FrameDelay:  30 * time.Millisecond,
+		Count:       8,
+		Gravity:     0.08,
+		Restitution: 0.85,
+		Trail:       6,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 30 * time.Millisecond
+	}
+	if c.Count <= 0 {
+		c.Count = 8
+	}
+	if c.Count > maxCount {
+		c.Count = maxCount
+	}
+	if c.Gravity < 0 {
+		c.Gravity = 0.08
+	}
+	if c.Restitution <= 0 || c.Restitution > 1 {
+		c.Restitution = 0.85
+	}
+	if c.Trail < 0 {
+		c.Trail = 6
+	}
+	return c
+}
+
+// Run launches the balls. 's' toggles slow motion, + and - add and remove
+// a ball, and space throws them all back up.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	w := newWorld(cfg, len(ballColors), rng)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	speed := 1.0
+	for limit.Next() {
+		w.update(speed)
+		grid.Clear()
+		drawWorld(grid, w)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case k := <-keys:
+				switch k {
+				case 's', 'S':
+					if speed == 1 {
+						speed = slowMotion
+					} else {
+						speed = 1
+					}
+				case '+', '=':
+					if len(w.balls) < maxCount {
+						w.add()
+					}
+				case '-', '_':
+					w.remove()
+				case ' ':
+					for _, b := range w.balls {
+						b.vx += (rng.Float64()*2 - 1) * 1.5
+						b.vy -= 2 + rng.Float64()*2
+					}
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+// drawWorld draws the trails first so the balls sit on top, the older
+// points of a trail lighter and dimmer. A ball fills every cell whose
+// center is inside it, with a rim of o around a solid middle.
+func drawWorld(grid canvas.Grid, w *world) {
+	trailGlyphs := []rune{'.', '.', ':', 'o'}
+	for _, b := range w.balls {
+		for i, p := range b.trail {
+			t := float64(i+1) / float64(len(b.trail)+1)
+			glyph := trailGlyphs[int(t*float64(len(trailGlyphs)-1))]
+			color := ballColors[b.color][1]
+			grid.Set(int(p.x), int(p.y/cellAspect), glyph, color)
+		}
+	}
+	for _, b := range w.balls {
+		color := ballColors[b.color][0]
+		cx, cy := int(b.x), int(b.y/cellAspect)
+		reach := int(math.Ceil(b.radius))
+		drawn := false
+		for y := cy - reach; y <= cy+reach; y++ {
+			for x := cx - reach; x <= cx+reach; x++ {
+				dx := float64(x) + 0.5 - b.x
+				dy := (float64(y)+0.5)*cellAspect - b.y
+				d := math.Hypot(dx, dy)
+				switch {
+				case d <= b.radius-0.8:
+					grid.Set(x, y, '@', color)
+				case d <= b.radius:
+					grid.Set(x, y, 'o', color)
+				default:
+					continue
+				}
+				drawn = true
+			}
+		}
+		if !drawn {
+			grid.Set(cx, cy, 'o', color)
+		}
+	}
+	for x := range grid[len(grid)-1] {
+		if grid[len(grid)-1][x].Glyph == ' ' {
+			grid.Set(x, len(grid)-1, '_', floorColor)
+		}
+	}
+}
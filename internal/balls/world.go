@@ -0,0 +1,163 @@
+package balls
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// maxMove is the furthest a ball may travel in one sub-step, well under
+	// the smallest radius, so fast balls cannot pass through each other or
+	// a wall between checks.
+	maxMove = 0.4
+	// restSpeed is the bounce speed below which a ball on the floor just
+	// stops, so it settles instead of buzzing.
+	restSpeed = 0.05
+)
+
+// ball is one ball, in cells with y stretched by cellAspect so it comes
+// out round on screen.
+type ball struct {
+	x, y   float64
+	vx, vy float64
+	radius float64
+	color  int
+	trail  []point
+}
+
+type point struct {
+	x, y float64
+}
+
+// mass goes with the ball's area.
+func (b *ball) mass() float64 {
+	return b.radius * b.radius
+}
+
+// world is the box the balls bounce around in.
+type world struct {
+	balls       []*ball
+	width       float64
+	height      float64
+	gravity     float64
+	restitution float64
+	trail       int
+	colors      int
+	rng         *rand.Rand
+}
+
+func newWorld(cfg Config, colors int, rng *rand.Rand) *world {
+	w := &world{
+		width:       float64(cfg.Width),
+		height:      float64(cfg.Height) * cellAspect,
+		gravity:     cfg.Gravity,
+		restitution: cfg.Restitution,
+		trail:       cfg.Trail,
+		colors:      colors,
+		rng:         rng,
+	}
+	for i := 0; i < cfg.Count; i++ {
+		w.add()
+	}
+	return w
+}
+
+// add drops a new ball in from somewhere along the top, thrown sideways.
+func (w *world) add() {
+	r := 1 + w.rng.Float64()*2
+	w.balls = append(w.balls, &ball{
+		x:      r + w.rng.Float64()*(w.width-2*r),
+		y:      r + w.rng.Float64()*w.height/3,
+		vx:     (w.rng.Float64()*2 - 1) * 1.2,
+		vy:     (w.rng.Float64()*2 - 1) * 0.5,
+		radius: r,
+		color:  w.rng.Intn(w.colors),
+	})
+}
+
+func (w *world) remove() {
+	if len(w.balls) > 0 {
+		w.balls = w.balls[:len(w.balls)-1]
+	}
+}
+
+// update advances the world by dt frames, split into sub-steps short
+// enough that no ball moves more than maxMove in one.
+func (w *world) update(dt float64) {
+	fastest := 0.0
+	for _, b := range w.balls {
+		fastest = math.Max(fastest, math.Hypot(b.vx, b.vy))
+		b.trail = append(b.trail, point{b.x, b.y})
+		if len(b.trail) > w.trail {
+			b.trail = b.trail[len(b.trail)-w.trail:]
+		}
+	}
+	steps := max(1, int(math.Ceil((fastest+w.gravity)*dt/maxMove)))
+	h := dt / float64(steps)
+	for i := 0; i < steps; i++ {
+		for _, b := range w.balls {
+			b.vy += w.gravity * h
+			b.x += b.vx * h
+			b.y += b.vy * h
+			w.bounceWalls(b)
+		}
+		for i, a := range w.balls {
+			for _, b := range w.balls[i+1:] {
+				w.collide(a, b)
+			}
+		}
+	}
+}
+
+// bounceWalls keeps b inside the box, reflecting and damping the speed
+// into whichever side it hit.
+func (w *world) bounceWalls(b *ball) {
+	if b.x < b.radius {
+		b.x = b.radius
+		b.vx = math.Abs(b.vx) * w.restitution
+	}
+	if b.x > w.width-b.radius {
+		b.x = w.width - b.radius
+		b.vx = -math.Abs(b.vx) * w.restitution
+	}
+	if b.y < b.radius {
+		b.y = b.radius
+		b.vy = math.Abs(b.vy) * w.restitution
+	}
+	if b.y > w.height-b.radius {
+		b.y = w.height - b.radius
+		b.vy = -math.Abs(b.vy) * w.restitution
+		if -b.vy < restSpeed {
+			b.vy = 0
+		}
+	}
+}
+
+// collide resolves an overlap between a and b: they are pushed apart along
+// the line between their centers in proportion to mass, and if they are
+// closing an impulse along that line sends them off again.
+func (w *world) collide(a, b *ball) {
+	dx, dy := b.x-a.x, b.y-a.y
+	dist := math.Hypot(dx, dy)
+	reach := a.radius + b.radius
+	if dist >= reach || dist == 0 {
+		return
+	}
+	nx, ny := dx/dist, dy/dist
+	ma, mb := a.mass(), b.mass()
+	overlap := reach - dist
+	a.x -= nx * overlap * mb / (ma + mb)
+	a.y -= ny * overlap * mb / (ma + mb)
+	b.x += nx * overlap * ma / (ma + mb)
+	b.y += ny * overlap * ma / (ma + mb)
+
+	closing := (b.vx-a.vx)*nx + (b.vy-a.vy)*ny
+	if closing >= 0 {
+		return
+	}
+	j := -(1 + w.restitution) * closing / (1/ma + 1/mb)
+	a.vx -= j * nx / ma
+	a.vy -= j * ny / ma
+	b.vx += j * nx / mb
+	b.vy += j * ny / mb
+}
@@ -0,0 +1,343 @@
+package banner
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/rain"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 12
+	// gapColumns is the blank run, in font pixels, between the end of the
+	// text and its next pass.
+	gapColumns = 8
+	// sparkleRate is how many sparkles leave the letters each frame.
+	sparkleRate = 3
+	// rainDensity keeps the background rain faint enough to read over.
+	rainDensity = 0.08
+)
+
+var (
+	rainbowColors = []string{
+		"\x1b[38;5;196m",
+		"\x1b[38;5;202m",
+		"\x1b[38;5;208m",
+		"\x1b[38;5;214m",
+		"\x1b[38;5;226m",
+		"\x1b[38;5;118m",
+		"\x1b[38;5;46m",
+		"\x1b[38;5;49m",
+		"\x1b[38;5;51m",
+		"\x1b[38;5;39m",
+		"\x1b[38;5;27m",
+		"\x1b[38;5;93m",
+		"\x1b[38;5;201m",
+	}
+	// plainColors shade the letters top to bottom when rainbow is off.
+	plainColors = []string{
+		"\x1b[38;5;231m",
+		"\x1b[38;5;230m",
+		"\x1b[38;5;229m",
+		"\x1b[38;5;228m",
+		"\x1b[38;5;221m",
+		"\x1b[38;5;220m",
+		"\x1b[38;5;214m",
+	}
+	shadowColor   = "\x1b[38;5;238m"
+	sparkleColors = []string{"\x1b[38;5;231m", "\x1b[38;5;229m", "\x1b[38;5;186m", "\x1b[38;5;143m"}
+	sparkleGlyphs = []rune{'*', '+', '\'', '.'}
+	rainColors    = []string{"\x1b[38;5;28m", "\x1b[38;5;22m", "\x1b[38;5;235m"}
+)
+
+// Config controls the banner.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Text is what scrolls by; it repeats once it has gone past.
+	Text string
+	// Font is block (two columns per pixel), thin (one) or shadow (block
+	// with a drop shadow).
+	Font string
+	// Speed is how many columns the text moves each frame.
+	Speed float64
+	// Rainbow cycles colors along the letters, Wave bobs them on a sine,
+	// Sparkle sheds sparks behind them and Matrix runs a faint rain behind
+	// everything.
+	Rainbow bool
+	Wave    bool
+	Sparkle bool
+	Matrix  bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Text:       "ANIM IN TERMINAL",
+		Font:       "block",
+		Speed:      1,
+		Rainbow:    true,
+		Wave:       true,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	c.Text = strings.Join(strings.Fields(c.Text), " ")
+	if c.Text == "" {
+		c.Text = "ANIM IN TERMINAL"
+	}
+	if !IsFont(c.Font) {
+		c.Font = "block"
+	}
+	if c.Speed <= 0 || math.IsNaN(c.Speed) {
+		c.Speed = 1
+	}
+	// Past a screen a frame the text is only a flicker; this also keeps the
+	// scroll offset from overflowing an int.
+	c.Speed = math.Min(c.Speed, float64(c.Width))
+	return c
+}
+
+// IsFont reports whether name is a banner font.
+func IsFont(name string) bool {
+	switch name {
+	case "block", "thin", "shadow":
+		return true
+	}
+	return false
+}
+
+// column is one pixel column of the rendered text and the letter it
+// belongs to.
+type column struct {
+	lit    [fontHeight]bool
+	letter int
+}
+
+// layout turns text into pixel columns with one blank column after each
+// letter and a gap after the whole text, so the strip can wrap around.
+func layout(text string) []column {
+	var strip []column
+	for i := 0; i < len(text); i++ {
+		g := glyphFor(text[i])
+		for x := 0; x < len(g[0])+1; x++ {
+			c := column{letter: i}
+			for y := range g {
+				c.lit[y] = x < len(g[y]) && g[y][x] == '#'
+			}
+			strip = append(strip, c)
+		}
+	}
+	for i := 0; i < gapColumns; i++ {
+		strip = append(strip, column{letter: len(text)})
+	}
+	return strip
+}
+
+type sparkle struct {
+	x, y   float64
+	vx, vy float64
+	life   int
+	max    int
+}
+
+// Run launches the banner.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	strip := layout(cfg.Text)
+	var drops *rain.Layer
+	if cfg.Matrix {
+		drops = rain.NewLayer(cfg.Width, cfg.Height, rainDensity, rng.Int63())
+	}
+	var sparks []sparkle
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	offset := 0.0
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		if drops != nil {
+			drawRain(grid, drops)
+			drops.Update()
+		}
+		sparks = updateSparks(sparks)
+		drawSparks(grid, sparks)
+		lit := drawText(grid, strip, cfg, offset, frame)
+		if cfg.Sparkle {
+			sparks = shed(sparks, lit, cfg.Speed, rng)
+		}
+		grid.Render(screen)
+		offset += cfg.Speed
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawText draws the strip scrolled left by offset columns, scaled up as
+// far as the screen allows while leaving room for the wave, and returns
+// where it lit cells.
+func drawText(grid canvas.Grid, strip []column, cfg Config, offset float64, frame int) [][2]int {
+	height := len(grid)
+	width := len(grid[0])
+	amp := 0.0
+	if cfg.Wave {
+		amp = math.Max(1, float64(height)/10)
+	}
+	scale := 1
+	for fontHeight*(scale+1)+int(2*amp)+2 <= height {
+		scale++
+	}
+	pixel := scale
+	if cfg.Font != "thin" {
+		pixel *= 2
+	}
+	top := (height - fontHeight*scale) / 2
+	length := len(strip) * pixel
+	start := int(math.Mod(offset, float64(length)))
+
+	var lit [][2]int
+	pass := func(dx, dy int, shadow bool) {
+		for x := 0; x < width; x++ {
+			sx := (start + x) % length
+			c := strip[sx/pixel]
+			shift := 0
+			if cfg.Wave {
+				phase := float64(frame)*0.12 + float64(sx)/float64(pixel)*0.3
+				shift = int(math.Round(amp * math.Sin(phase)))
+			}
+			for gy := 0; gy < fontHeight; gy++ {
+				if !c.lit[gy] {
+					continue
+				}
+				color := shadowColor
+				if !shadow {
+					if cfg.Rainbow {
+						color = rainbowColors[(c.letter+frame/4)%len(rainbowColors)]
+					} else {
+						color = plainColors[gy]
+					}
+				}
+				for sy := 0; sy < scale; sy++ {
+					y := top + gy*scale + sy + shift + dy
+					grid.Set(x+dx, y, '#', color)
+					if !shadow {
+						lit = append(lit, [2]int{x, y})
+					}
+				}
+			}
+		}
+	}
+	if cfg.Font == "shadow" {
+		pass(1, 1, true)
+	}
+	pass(0, 0, false)
+	return lit
+}
+
+// shed sends a few sparkles off from random lit cells. They hang about
+// where they start, drifting up, so the text pulls away from them.
+func shed(sparks []sparkle, lit [][2]int, speed float64, rng *rand.Rand) []sparkle {
+	if len(lit) == 0 {
+		return sparks
+	}
+	for i := 0; i < sparkleRate; i++ {
+		p := lit[rng.Intn(len(lit))]
+		life := 10 + rng.Intn(16)
+		sparks = append(sparks, sparkle{
+			x:    float64(p[0]) + 0.5,
+			y:    float64(p[1]) + 0.5,
+			vx:   (rng.Float64() - 0.3) * 0.3 * speed,
+			vy:   -0.05 - rng.Float64()*0.15,
+			life: life,
+			max:  life,
+		})
+	}
+	return sparks
+}
+
+func updateSparks(sparks []sparkle) []sparkle {
+	alive := sparks[:0]
+	for _, s := range sparks {
+		s.life--
+		if s.life <= 0 {
+			continue
+		}
+		s.x += s.vx
+		s.y += s.vy
+		alive = append(alive, s)
+	}
+	return alive
+}
+
+// drawSparks draws each sparkle dimmer and smaller as it burns out.
+func drawSparks(grid canvas.Grid, sparks []sparkle) {
+	for _, s := range sparks {
+		age := 1 - float64(s.life)/float64(s.max)
+		i := min(len(sparkleGlyphs)-1, int(age*float64(len(sparkleGlyphs))))
+		grid.Set(int(s.x), int(s.y), sparkleGlyphs[i], sparkleColors[i])
+	}
+}
+
+func drawRain(grid canvas.Grid, drops *rain.Layer) {
+	drops.Draw(func(x, y int, glyph rune, fade float64) {
+		color := rainColors[min(len(rainColors)-1, int(fade*float64(len(rainColors))))]
+		grid.Set(x, y, glyph, color)
+	})
+}
@@ -0,0 +1,243 @@
+// Package beat measures loudness and detects beats for the animations that
+// react to music. A Meter is read once per frame.
+package beat
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Meter reports the current loudness (0-1) and whether a beat has landed
+// since the last call.
+type Meter interface {
+	Sample() (level float64, beat bool)
+}
+
+// Spectrum is a Meter that also splits the loudness into bass and treble,
+// each 0-1.
+type Spectrum interface {
+	Meter
+	Bands() (bass, treble float64)
+}
+
+// Metronome is a synthetic Meter that beats at a fixed tempo, for when there
+// is no audio to listen to. The level jumps on each beat and decays until
+// the next.
+type Metronome struct {
+	period float64
+	phase  float64
+	step   float64
+}
+
+// NewMetronome beats bpm times a minute, sampled once every frameDelay.
+func NewMetronome(bpm float64, frameDelay time.Duration) *Metronome {
+	return &Metronome{
+		period: 60 / bpm,
+		step:   frameDelay.Seconds(),
+	}
+}
+
+func (m *Metronome) Sample() (float64, bool) {
+	m.phase += m.step
+	beat := false
+	if m.phase >= m.period {
+		m.phase = math.Mod(m.phase, m.period)
+		beat = true
+	}
+	return math.Exp(-4 * m.phase / m.period), beat
+}
+
+// Bands puts the beat in the bass and leaves a little for the treble to
+// flicker between beats.
+func (m *Metronome) Bands() (float64, float64) {
+	level := math.Exp(-4 * m.phase / m.period)
+	return level, 0.3 + 0.3*math.Sin(m.phase*20)
+}
+
+const (
+	// window is how many samples make up one energy reading (~23ms).
+	window = 1024
+	// history is how many readings the running average covers (~1s).
+	history = 43
+	// beatRatio is how far above the running average a reading must be to
+	// count as a beat.
+	beatRatio = 1.4
+	// refractory is the shortest gap between two beats.
+	refractory = 200 * time.Millisecond
+	// crossover is the one-pole low-pass coefficient that splits bass from
+	// treble, around 200Hz at 44.1kHz.
+	crossover = 0.028
+	// SampleRate is the rate OpenPCM takes its audio to be at.
+	SampleRate = 44100
+	// MaxWindow is the most samples Window hands back.
+	MaxWindow = 16384
+	// stallAfter is how long the audio may go quiet before Window stops
+	// handing it back.
+	stallAfter = 500 * time.Millisecond
+)
+
+// PCM is a Meter fed by raw signed 16-bit little-endian mono audio at
+// 44.1kHz, such as `arecord -f S16_LE -r 44100 -c 1 -t raw` writes into a
+// FIFO. A beat is an energy spike well above the last second's average.
+// It keeps the latest samples too, for Window.
+type PCM struct {
+	rate int
+
+	mu sync.Mutex
+	// samples is a ring of the last MaxWindow samples, next the slot the
+	// following one goes in and heard when the last came in.
+	samples  []float64
+	next     int
+	heard    time.Time
+	level    float64
+	beats    int
+	energies []float64
+	peak     float64
+	last     time.Time
+	bass     band
+	treble   band
+}
+
+// band is the loudness of one frequency band relative to its own decaying
+// peak.
+type band struct {
+	level float64
+	peak  float64
+}
+
+func (b *band) measure(energy float64) {
+	rms := math.Sqrt(energy)
+	b.peak = math.Max(rms, b.peak*0.999)
+	if b.peak > 0 {
+		b.level = rms / b.peak
+	}
+}
+
+// OpenPCM starts reading audio from path in the background. Opening a FIFO
+// waits for its writer, so that happens in the background too.
+func OpenPCM(path string) (*PCM, error) {
+	return OpenPCMRate(path, SampleRate)
+}
+
+// OpenPCMRate is OpenPCM for audio at rate samples a second. A path of
+// "stdin" reads standard input.
+func OpenPCMRate(path string, rate int) (*PCM, error) {
+	open := func() (io.ReadCloser, error) { return os.Open(path) }
+	if path == "stdin" {
+		open = func() (io.ReadCloser, error) { return os.Stdin, nil }
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	p := &PCM{rate: rate, samples: make([]float64, MaxWindow), peak: 1e-6}
+	go func() {
+		r, err := open()
+		if err != nil {
+			return
+		}
+		p.read(r)
+	}()
+	return p, nil
+}
+
+// read takes in samples until r ends. A file, or a decoder writing faster
+// than it plays, is held back to the sample rate so it plays in real time.
+func (p *PCM) read(r io.ReadCloser) {
+	defer r.Close()
+	buf := make([]int16, window)
+	low := 0.0
+	start, played := time.Now(), 0
+	for {
+		if err := binary.Read(r, binary.LittleEndian, buf); err != nil {
+			return
+		}
+		sum, bass, treble := 0.0, 0.0, 0.0
+		p.mu.Lock()
+		for _, s := range buf {
+			v := float64(s) / 32768
+			low += (v - low) * crossover
+			sum += v * v
+			bass += low * low
+			treble += (v - low) * (v - low)
+			p.samples[p.next] = v
+			p.next = (p.next + 1) % len(p.samples)
+		}
+		p.heard = time.Now()
+		p.bass.measure(bass / window)
+		p.treble.measure(treble / window)
+		p.mu.Unlock()
+		p.measure(sum / window)
+
+		played += len(buf)
+		ahead := time.Duration(played)*time.Second/time.Duration(p.rate) - time.Since(start)
+		switch {
+		case ahead > 0:
+			time.Sleep(ahead)
+		case ahead < -stallAfter:
+			// The source stalled; count from here rather than rush to
+			// catch up.
+			start, played = time.Now(), 0
+		}
+	}
+}
+
+func (p *PCM) measure(energy float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	avg := 0.0
+	for _, e := range p.energies {
+		avg += e
+	}
+	if len(p.energies) > 0 {
+		avg /= float64(len(p.energies))
+	}
+	if len(p.energies) == history && energy > avg*beatRatio && time.Since(p.last) > refractory {
+		p.beats++
+		p.last = time.Now()
+	}
+	p.energies = append(p.energies, energy)
+	if len(p.energies) > history {
+		p.energies = p.energies[1:]
+	}
+
+	// Loudness is relative to a slowly decaying peak so quiet tracks still
+	// move the needle.
+	rms := math.Sqrt(energy)
+	p.peak = math.Max(rms, p.peak*0.999)
+	p.level = rms / p.peak
+}
+
+func (p *PCM) Sample() (float64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	beat := p.beats > 0
+	p.beats = 0
+	return p.level, beat
+}
+
+func (p *PCM) Bands() (float64, float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.bass.level, p.treble.level
+}
+
+// Window fills dst, no longer than MaxWindow, with the latest len(dst)
+// samples, oldest first. It reports false, leaving dst alone, if no audio
+// has come in for half a second.
+func (p *PCM) Window(dst []float64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.heard.IsZero() || time.Since(p.heard) > stallAfter {
+		return false
+	}
+	n := len(p.samples)
+	from := p.next - len(dst) + n
+	for i := range dst {
+		dst[i] = p.samples[(from+i)%n]
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package boids
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+	// minCount and maxCount bound the population, and countStep is how many
+	// boids + and - add or remove.
+	minCount  = 10
+	maxCount  = 600
+	countStep = 20
+	// minRadius keeps the neighbour grid's buckets at least a cell wide, so
+	// there are never more of them than cells.
+	minRadius = 1.0
+)
+
+var (
+	flockColors = []string{
+		"\x1b[38;5;87m",
+		"\x1b[38;5;156m",
+		"\x1b[38;5;219m",
+		"\x1b[38;5;229m",
+	}
+	speedColors = []string{
+		"\x1b[38;5;24m",
+		"\x1b[38;5;31m",
+		"\x1b[38;5;38m",
+		"\x1b[38;5;45m",
+		"\x1b[38;5;159m",
+	}
+	predatorColor = "\x1b[38;5;196m"
+	hudColor      = "\x1b[38;5;240m"
+	// headings are the glyphs for the eight directions, clockwise from
+	// right with y pointing down the screen.
+	headings = []rune{'>', '\\', 'v', '/', '<', '\\', '^', '/'}
+)
+
+// Config controls the boids simulation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Count is how many boids there are to start with.
+	Count int
+	// Radius is how far a boid sees its neighbours, in columns.
+	Radius float64
+	// MaxSpeed is the top speed of a boid, in columns per frame.
+	MaxSpeed float64
+	// Predator lets two predators loose to chase the flock.
+	Predator bool
+	// Edges is what happens at the screen edge: wrap or bounce.
+	Edges string
+	// ColorBy picks what the colors show: flock or speed.
+	ColorBy string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Count:      150,
+		Radius:     6,
+		MaxSpeed:   1.2,
+		Edges:      "wrap",
+		ColorBy:    "flock",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	if c.Count <= 0 {
+		c.Count = 150
+	}
+	c.Count = max(minCount, min(maxCount, c.Count))
+	if c.Radius <= 0 {
+		c.Radius = 6
+	}
+	// The neighbour grid needs at least three buckets each way.
+	c.Radius = math.Min(c.Radius, math.Min(float64(c.Width), float64(c.Height)*cellAspect)/3)
+	c.Radius = math.Max(c.Radius, minRadius)
+	if c.MaxSpeed <= minSpeed {
+		c.MaxSpeed = 1.2
+	}
+	if !IsEdges(c.Edges) {
+		c.Edges = "wrap"
+	}
+	if !IsColorBy(c.ColorBy) {
+		c.ColorBy = "flock"
+	}
+	return c
+}
+
+// IsEdges reports whether name is a boids edge behaviour.
+func IsEdges(name string) bool {
+	return name == "wrap" || name == "bounce"
+}
+
+// IsColorBy reports whether name is something the boids can be colored by.
+func IsColorBy(name string) bool {
+	return name == "flock" || name == "speed"
+}
+
+// Run launches the flock. + and - grow and shrink the population.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	f := newFlock(cfg, rng)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		f.update()
+		grid.Clear()
+		drawFlock(grid, f, cfg.ColorBy)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case k := <-keys:
+				switch k {
+				case '+', '=':
+					f.resize(min(maxCount, len(f.boids)+countStep))
+				case '-', '_':
+					f.resize(max(minCount, len(f.boids)-countStep))
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+func drawFlock(grid canvas.Grid, f *flock, colorBy string) {
+	for _, b := range f.boids {
+		color := flockColors[b.flock%len(flockColors)]
+		if colorBy == "speed" {
+			t := math.Hypot(b.vx, b.vy) / f.maxSpeed
+			color = speedColors[min(len(speedColors)-1, int(t*float64(len(speedColors))))]
+		}
+		grid.Set(int(b.x), int(b.y/cellAspect), heading(b), color)
+	}
+	for _, p := range f.predators {
+		grid.Set(int(p.x), int(p.y/cellAspect), '@', predatorColor)
+	}
+	hud := fmt.Sprintf(" boids %d  +/- ", len(f.boids))
+	y := len(grid) - 1
+	grid.Text(0, y, hud, hudColor)
+}
+
+// heading picks the arrow for the way b is flying.
+func heading(b boid) rune {
+	angle := math.Atan2(b.vy, b.vx)
+	octant := int(math.Round(angle/(math.Pi/4))+8) % 8
+	return headings[octant]
+}
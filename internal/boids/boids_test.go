@@ -0,0 +1,23 @@
+package boids
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestNormalizeTinyRadius checks a radius far below a cell is raised to
+// one, so the neighbour grid has no more buckets than the screen has cells.
+func TestNormalizeTinyRadius(t *testing.T) {
+	for _, r := range []float64{1e-9, 0.0001, 0.5} {
+		cfg := DefaultConfig()
+		cfg.Radius = r
+		cfg = cfg.normalize()
+		if cfg.Radius != minRadius {
+			t.Errorf("radius %v normalizes to %v, want %v", r, cfg.Radius, minRadius)
+		}
+		f := newFlock(cfg, rand.New(rand.NewSource(1)))
+		if cells := float64(cfg.Width*cfg.Height) * cellAspect; float64(len(f.head)) > cells {
+			t.Errorf("radius %v makes %d buckets for %v cells", r, len(f.head), cells)
+		}
+	}
+}
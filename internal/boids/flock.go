@@ -0,0 +1,247 @@
+package boids
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// flocks is how many separate flocks the boids are split into; they
+	// keep apart but only steer with their own.
+	flocks = 4
+	// minSpeed keeps the boids from stalling in a crowd.
+	minSpeed = 0.3
+	// separation, alignment and cohesion weigh the three rules, and fear
+	// the urge to flee a predator.
+	separation = 0.3
+	alignment  = 0.08
+	cohesion   = 0.006
+	fear       = 0.25
+	// personalSpace is the share of the perception radius inside which
+	// boids push apart.
+	personalSpace = 0.4
+	// predatorSpeed is how fast a predator is against the boids' top speed.
+	predatorSpeed = 0.9
+)
+
+// boid is one agent, in cells with y stretched by cellAspect so distances
+// are the same both ways on screen.
+type boid struct {
+	x, y   float64
+	vx, vy float64
+	flock  int
+}
+
+// flock is every boid and predator, and the bucket grid used to find
+// neighbours without comparing every pair.
+type flock struct {
+	boids     []boid
+	predators []boid
+	width     float64
+	height    float64
+	radius    float64
+	maxSpeed  float64
+	wrap      bool
+	rng       *rand.Rand
+
+	// The grid splits the field into radius-sized buckets; head holds the
+	// first boid in each and next chains the rest, so only the 3x3 buckets
+	// around a boid need checking.
+	cols, rows int
+	head       []int
+	next       []int
+}
+
+func newFlock(cfg Config, rng *rand.Rand) *flock {
+	f := &flock{
+		width:    float64(cfg.Width),
+		height:   float64(cfg.Height) * cellAspect,
+		radius:   cfg.Radius,
+		maxSpeed: cfg.MaxSpeed,
+		wrap:     cfg.Edges == "wrap",
+		rng:      rng,
+	}
+	f.cols = max(1, int(f.width/f.radius))
+	f.rows = max(1, int(f.height/f.radius))
+	f.head = make([]int, f.cols*f.rows)
+	f.resize(cfg.Count)
+	if cfg.Predator {
+		f.predators = []boid{f.spawn(-1), f.spawn(-1)}
+	}
+	return f
+}
+
+func (f *flock) spawn(flock int) boid {
+	angle := f.rng.Float64() * 2 * math.Pi
+	speed := f.maxSpeed * (0.5 + 0.5*f.rng.Float64())
+	return boid{
+		x:     f.rng.Float64() * f.width,
+		y:     f.rng.Float64() * f.height,
+		vx:    math.Cos(angle) * speed,
+		vy:    math.Sin(angle) * speed,
+		flock: flock,
+	}
+}
+
+// resize adds or drops boids until there are n.
+func (f *flock) resize(n int) {
+	for len(f.boids) < n {
+		f.boids = append(f.boids, f.spawn(len(f.boids)%flocks))
+	}
+	f.boids = f.boids[:n]
+	f.next = make([]int, n)
+}
+
+// bucket is the grid bucket for x, y.
+func (f *flock) bucket(x, y float64) (int, int) {
+	col := min(f.cols-1, max(0, int(x/f.width*float64(f.cols))))
+	row := min(f.rows-1, max(0, int(y/f.height*float64(f.rows))))
+	return col, row
+}
+
+func (f *flock) index() {
+	for i := range f.head {
+		f.head[i] = -1
+	}
+	for i, b := range f.boids {
+		col, row := f.bucket(b.x, b.y)
+		at := row*f.cols + col
+		f.next[i] = f.head[at]
+		f.head[at] = i
+	}
+}
+
+// neighbours calls visit for every other boid within the perception
+// radius of boid i, with the offset to it.
+func (f *flock) neighbours(i int, visit func(j int, dx, dy, d2 float64)) {
+	b := f.boids[i]
+	col, row := f.bucket(b.x, b.y)
+	r2 := f.radius * f.radius
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			c, r := col+dc, row+dr
+			if f.wrap {
+				c = (c + f.cols) % f.cols
+				r = (r + f.rows) % f.rows
+			} else if c < 0 || c >= f.cols || r < 0 || r >= f.rows {
+				continue
+			}
+			for j := f.head[r*f.cols+c]; j >= 0; j = f.next[j] {
+				if j == i {
+					continue
+				}
+				dx, dy := f.offset(b, f.boids[j])
+				if d2 := dx*dx + dy*dy; d2 < r2 {
+					visit(j, dx, dy, d2)
+				}
+			}
+		}
+	}
+}
+
+// offset is the shortest step from a to b, across the edges when they wrap.
+func (f *flock) offset(a, b boid) (float64, float64) {
+	dx, dy := b.x-a.x, b.y-a.y
+	if f.wrap {
+		dx -= f.width * math.Round(dx/f.width)
+		dy -= f.height * math.Round(dy/f.height)
+	}
+	return dx, dy
+}
+
+// update applies the three rules to every boid, has them flee the
+// predators, lets the predators chase, and moves everything.
+func (f *flock) update() {
+	f.index()
+	steer := make([][2]float64, len(f.boids))
+	for i := range f.boids {
+		b := f.boids[i]
+		var sepX, sepY, velX, velY, posX, posY float64
+		mates := 0
+		close := f.radius * personalSpace
+		f.neighbours(i, func(j int, dx, dy, d2 float64) {
+			if d2 < close*close {
+				sepX -= dx / (d2 + 0.1)
+				sepY -= dy / (d2 + 0.1)
+			}
+			if f.boids[j].flock != b.flock {
+				return
+			}
+			velX += f.boids[j].vx
+			velY += f.boids[j].vy
+			posX += dx
+			posY += dy
+			mates++
+		})
+		ax, ay := sepX*separation, sepY*separation
+		if mates > 0 {
+			n := float64(mates)
+			ax += (velX/n - b.vx) * alignment
+			ay += (velY/n - b.vy) * alignment
+			ax += posX / n * cohesion
+			ay += posY / n * cohesion
+		}
+		for _, p := range f.predators {
+			dx, dy := f.offset(b, p)
+			if d2 := dx*dx + dy*dy; d2 < 4*f.radius*f.radius {
+				ax -= dx / math.Sqrt(d2+0.1) * fear
+				ay -= dy / math.Sqrt(d2+0.1) * fear
+			}
+		}
+		steer[i] = [2]float64{ax, ay}
+	}
+	for i := range f.boids {
+		b := &f.boids[i]
+		b.vx += steer[i][0]
+		b.vy += steer[i][1]
+		f.move(b, f.maxSpeed)
+	}
+	for i := range f.predators {
+		p := &f.predators[i]
+		f.chase(p)
+		f.move(p, f.maxSpeed*predatorSpeed)
+	}
+}
+
+// chase turns a predator toward the nearest boid.
+func (f *flock) chase(p *boid) {
+	best, bx, by := math.Inf(1), 0.0, 0.0
+	for _, b := range f.boids {
+		dx, dy := f.offset(*p, b)
+		if d2 := dx*dx + dy*dy; d2 < best {
+			best, bx, by = d2, dx, dy
+		}
+	}
+	if math.IsInf(best, 1) {
+		return
+	}
+	d := math.Sqrt(best) + 1e-6
+	p.vx += bx / d * 0.08
+	p.vy += by / d * 0.08
+}
+
+// move clamps b's speed between minSpeed and top, steps it and handles the
+// edges.
+func (f *flock) move(b *boid, top float64) {
+	speed := math.Hypot(b.vx, b.vy)
+	if speed > top {
+		b.vx, b.vy = b.vx/speed*top, b.vy/speed*top
+	} else if speed < minSpeed && speed > 0 {
+		b.vx, b.vy = b.vx/speed*minSpeed, b.vy/speed*minSpeed
+	}
+	b.x += b.vx
+	b.y += b.vy
+	if f.wrap {
+		b.x = math.Mod(b.x+f.width, f.width)
+		b.y = math.Mod(b.y+f.height, f.height)
+		return
+	}
+	if b.x < 0 || b.x >= f.width {
+		b.vx = -b.vx
+		b.x = math.Max(0, math.Min(f.width-0.01, b.x))
+	}
+	if b.y < 0 || b.y >= f.height {
+		b.vy = -b.vy
+		b.y = math.Max(0, math.Min(f.height-0.01, b.y))
+	}
+}
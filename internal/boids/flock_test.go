@@ -0,0 +1,25 @@
+package boids
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// BenchmarkUpdate times one step of the flock at a few sizes, on the
+// default screen. The spatial grid keeps each boid to the ones near it, so
+// the time grows with how crowded the screen gets rather than with every
+// pair of boids.
+func BenchmarkUpdate(b *testing.B) {
+	for _, n := range []int{75, 150, 300, 600} {
+		b.Run(fmt.Sprintf("boids=%d", n), func(b *testing.B) {
+			cfg := DefaultConfig()
+			cfg.Count = n
+			f := newFlock(cfg.normalize(), rand.New(rand.NewSource(1)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.update()
+			}
+		})
+	}
+}
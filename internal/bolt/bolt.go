@@ -0,0 +1,125 @@
+// Package bolt generates forked lightning: a jagged main channel built by
+// midpoint displacement with side branches splitting off it, so every mode
+// with a storm can strike with the same bolts.
+package bolt
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// roughness is how far a midpoint may be pushed sideways, as a share of
+	// the length of the piece it splits.
+	roughness = 0.3
+	// finest is the length in cells below which pieces stop splitting.
+	finest = 1.5
+	// aspect is how much taller a terminal cell is than it is wide.
+	aspect = 2.0
+)
+
+// Segment is one straight piece of a bolt in cells. Depth is 0 along the
+// main channel, 1 on a branch, 2 on a branch of a branch.
+type Segment struct {
+	X0, Y0, X1, Y1 float64
+	Depth          int
+}
+
+// Bolt is a whole strike: the main channel from the cloud down to where it
+// ends, and the branches that fork off it.
+type Bolt struct {
+	Segments []Segment
+}
+
+// New strikes from x0, y0 to x1, y1 with 2 to 4 side branches; branchiness,
+// 0 to 1, leans toward more of them and lets branches fork again.
+func New(r *rand.Rand, x0, y0, x1, y1, branchiness float64) Bolt {
+	branchiness = math.Max(0, math.Min(branchiness, 1))
+	var b Bolt
+	channel := b.path(r, x0, y0, x1, y1, 0)
+
+	branches := min(4, 2+int(branchiness*2+r.Float64()))
+	for i := 0; i < branches; i++ {
+		// Branches leave from the upper part of the channel and head on
+		// down, bent off to one side.
+		from := channel[r.Intn(max(1, len(channel)*3/4))]
+		b.fork(r, from, x1-x0, y1-y0, 0.25+r.Float64()*0.3, 1, branchiness)
+	}
+	return b
+}
+
+// fork sends a branch of depth off from point from, running share of the
+// length dx, dy at an angle off that direction.
+func (b *Bolt) fork(r *rand.Rand, from [2]float64, dx, dy, share float64, depth int, branchiness float64) {
+	angle := (0.35 + r.Float64()*0.5) * float64(1-2*r.Intn(2))
+	sin, cos := math.Sin(angle), math.Cos(angle)
+	dy *= aspect
+	ex := (dx*cos - dy*sin) * share
+	ey := (dx*sin + dy*cos) * share / aspect
+	if ey < 0 {
+		ey = -ey
+	}
+	points := b.path(r, from[0], from[1], from[0]+ex, from[1]+ey, depth)
+	if depth < 2 && r.Float64() < branchiness*0.5 {
+		b.fork(r, points[r.Intn(len(points))], ex, ey, 0.5, depth+1, branchiness)
+	}
+}
+
+// path adds a jagged line from x0, y0 to x1, y1 and returns the points
+// along it.
+func (b *Bolt) path(r *rand.Rand, x0, y0, x1, y1 float64, depth int) [][2]float64 {
+	points := displace(r, [2]float64{x0, y0}, [2]float64{x1, y1}, nil)
+	points = append(points, [2]float64{x1, y1})
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		b.Segments = append(b.Segments, Segment{X0: p[0], Y0: p[1], X1: q[0], Y1: q[1], Depth: depth})
+	}
+	return points
+}
+
+// displace appends the points from a up to but not including b, splitting
+// the line at a midpoint pushed off sideways and doing the same to each half
+// with half the push, until the pieces are a cell or so long.
+func displace(r *rand.Rand, a, b [2]float64, points [][2]float64) [][2]float64 {
+	dx, dy := b[0]-a[0], (b[1]-a[1])*aspect
+	length := math.Hypot(dx, dy)
+	if length < finest {
+		return append(points, a)
+	}
+	push := (r.Float64()*2 - 1) * roughness * length
+	mid := [2]float64{
+		(a[0]+b[0])/2 - dy/length*push,
+		(a[1]+b[1])/2 + dx/length*push/aspect,
+	}
+	points = displace(r, a, mid, points)
+	return displace(r, mid, b, points)
+}
+
+// Draw hands every cell of the bolt to set, with a glyph leaning the way
+// the bolt runs there and the depth of the piece it is on. Cells where
+// pieces meet may come more than once.
+func (b Bolt) Draw(set func(x, y int, glyph rune, depth int)) {
+	for _, s := range b.Segments {
+		dx, dy := s.X1-s.X0, s.Y1-s.Y0
+		glyph := slant(dx, dy)
+		steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy)) * 2))
+		for i := 0; i <= steps; i++ {
+			t := float64(i) / float64(max(1, steps))
+			set(int(math.Floor(s.X0+dx*t)), int(math.Floor(s.Y0+dy*t)), glyph, s.Depth)
+		}
+	}
+}
+
+// slant picks the glyph for a piece running dx, dy cells, y down.
+func slant(dx, dy float64) rune {
+	switch {
+	case math.Abs(dx) < math.Abs(dy)*aspect*0.4:
+		return '|'
+	case math.Abs(dy)*aspect < math.Abs(dx)*0.4:
+		return '-'
+	case (dx > 0) == (dy > 0):
+		return '\\'
+	default:
+		return '/'
+	}
+}
@@ -0,0 +1,147 @@
+// Package canvas is the grid of colored cells a mode draws each frame into,
+// with the drawing most modes need: points, lines, ellipses and text, all
+// clipped to the grid.
+package canvas
+
+import (
+	"math"
+
+	"animinterminal/internal/render"
+)
+
+// Cell is one character cell: a glyph in a color.
+type Cell struct {
+	Glyph rune
+	Color string
+}
+
+// Grid is a frame, rows top to bottom: grid[y][x] is the cell at x, y.
+type Grid [][]Cell
+
+// New returns a blank width by height grid.
+func New(width, height int) Grid {
+	g := make(Grid, height)
+	for y := range g {
+		g[y] = make([]Cell, width)
+	}
+	g.Clear()
+	return g
+}
+
+// Width is the number of cells across.
+func (g Grid) Width() int {
+	if len(g) == 0 {
+		return 0
+	}
+	return len(g[0])
+}
+
+// Height is the number of rows.
+func (g Grid) Height() int {
+	return len(g)
+}
+
+// Clear blanks every cell: a space with no color.
+func (g Grid) Clear() {
+	for y := range g {
+		row := g[y]
+		for x := range row {
+			row[x] = Cell{Glyph: ' '}
+		}
+	}
+}
+
+// In reports whether x, y is on the grid.
+func (g Grid) In(x, y int) bool {
+	return y >= 0 && y < len(g) && x >= 0 && x < len(g[y])
+}
+
+// Set draws glyph in color at x, y; points off the grid are ignored.
+func (g Grid) Set(x, y int, glyph rune, color string) {
+	if g.In(x, y) {
+		g[y][x] = Cell{Glyph: glyph, Color: color}
+	}
+}
+
+// SetIfEmpty is Set only over a blank cell, so what is drawn first stays on
+// top.
+func (g Grid) SetIfEmpty(x, y int, glyph rune, color string) {
+	if g.In(x, y) && g[y][x].Glyph == ' ' {
+		g[y][x] = Cell{Glyph: glyph, Color: color}
+	}
+}
+
+// Text writes text from x, y rightward, one glyph a cell.
+func (g Grid) Text(x, y int, text, color string) {
+	for _, r := range text {
+		g.Set(x, y, r, color)
+		x++
+	}
+}
+
+// Line draws a straight line of glyph from x0, y0 to x1, y1, ends included.
+func (g Grid) Line(x0, y0, x1, y1 int, glyph rune, color string) {
+	for _, p := range LinePoints(x0, y0, x1, y1) {
+		g.Set(p[0], p[1], glyph, color)
+	}
+}
+
+// Ellipse outlines the ellipse around cx, cy with radii rx and ry in glyph,
+// over blank cells only, so rings sit behind whatever is already drawn.
+// One quarter is worked out and mirrored into the others, so the ring comes
+// out symmetric whatever rounding does.
+func (g Grid) Ellipse(cx, cy int, rx, ry float64, glyph rune, color string) {
+	steps := max(int(rx*6), 24) / 4
+	for i := 0; i <= steps; i++ {
+		angle := float64(i) / float64(steps) * math.Pi / 2
+		dx, dy := int(math.Cos(angle)*rx), int(math.Sin(angle)*ry)
+		g.SetIfEmpty(cx+dx, cy+dy, glyph, color)
+		g.SetIfEmpty(cx-dx, cy+dy, glyph, color)
+		g.SetIfEmpty(cx+dx, cy-dy, glyph, color)
+		g.SetIfEmpty(cx-dx, cy-dy, glyph, color)
+	}
+}
+
+// Render sends the grid to screen as a frame.
+func (g Grid) Render(screen *render.Screen) {
+	if len(g) == 0 {
+		return
+	}
+	screen.Draw(g.Width(), g.Height(), func(x, y int) (string, string) {
+		return render.Rune(g[y][x].Glyph), g[y][x].Color
+	})
+}
+
+// LinePoints is every cell on the line from x0, y0 to x1, y1, in order, ends
+// included (Bresenham's line).
+func LinePoints(x0, y0, x1, y1 int) [][2]int {
+	points := make([][2]int, 0, max(Abs(x1-x0), Abs(y1-y0))+1)
+	dx := Abs(x1 - x0)
+	sx := -1
+	if x0 < x1 {
+		sx = 1
+	}
+	dy := -Abs(y1 - y0)
+	sy := -1
+	if y0 < y1 {
+		sy = 1
+	}
+	err := dx + dy
+
+	for {
+		points = append(points, [2]int{x0, y0})
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+	return points
+}
@@ -0,0 +1,186 @@
+package canvas
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinePoints(t *testing.T) {
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+		want           [][2]int
+	}{
+		{"point", 3, 4, 3, 4, [][2]int{{3, 4}}},
+		{"right", 0, 0, 3, 0, [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}}},
+		{"left", 3, 0, 0, 0, [][2]int{{3, 0}, {2, 0}, {1, 0}, {0, 0}}},
+		{"down", 1, 1, 1, 3, [][2]int{{1, 1}, {1, 2}, {1, 3}}},
+		{"diagonal", 0, 0, 3, 3, [][2]int{{0, 0}, {1, 1}, {2, 2}, {3, 3}}},
+		{"up-left", 2, 2, 0, 0, [][2]int{{2, 2}, {1, 1}, {0, 0}}},
+		{"shallow", 0, 0, 6, 2, [][2]int{{0, 0}, {1, 0}, {2, 1}, {3, 1}, {4, 1}, {5, 2}, {6, 2}}},
+		{"steep", 0, 0, 1, 3, [][2]int{{0, 0}, {0, 1}, {1, 2}, {1, 3}}},
+	}
+	for _, tc := range tests {
+		got := LinePoints(tc.x0, tc.y0, tc.x1, tc.y1)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: LinePoints(%d, %d, %d, %d) = %v, want %v", tc.name, tc.x0, tc.y0, tc.x1, tc.y1, got, tc.want)
+		}
+	}
+}
+
+// TestLinePointsConnected checks every line, whatever its slope, steps at
+// most one cell at a time and ends where it was asked to.
+func TestLinePointsConnected(t *testing.T) {
+	for x1 := -7; x1 <= 7; x1++ {
+		for y1 := -7; y1 <= 7; y1++ {
+			points := LinePoints(0, 0, x1, y1)
+			if end := points[len(points)-1]; end != [2]int{x1, y1} {
+				t.Fatalf("line to %d, %d ends at %v", x1, y1, end)
+			}
+			if want := max(Abs(x1), Abs(y1)) + 1; len(points) != want {
+				t.Errorf("line to %d, %d has %d points, want %d", x1, y1, len(points), want)
+			}
+			for i := 1; i < len(points); i++ {
+				if Abs(points[i][0]-points[i-1][0]) > 1 || Abs(points[i][1]-points[i-1][1]) > 1 {
+					t.Fatalf("line to %d, %d jumps from %v to %v", x1, y1, points[i-1], points[i])
+				}
+			}
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+		want           []string
+	}{
+		{"inside", 0, 0, 3, 3, []string{
+			"#...",
+			".#..",
+			"..#.",
+			"...#",
+		}},
+		{"clipped", -2, 1, 6, 1, []string{
+			"....",
+			"####",
+			"....",
+			"....",
+		}},
+		{"off the grid", -5, -5, -1, 8, []string{
+			"....",
+			"....",
+			"....",
+			"....",
+		}},
+		{"through a corner", 2, -1, 5, 2, []string{
+			"...#",
+			"....",
+			"....",
+			"....",
+		}},
+	}
+	for _, tc := range tests {
+		g := New(4, 4)
+		g.Line(tc.x0, tc.y0, tc.x1, tc.y1, '#', "c")
+		if got := picture(g); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got\n%v\nwant\n%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEllipse(t *testing.T) {
+	tests := []struct {
+		name   string
+		rx, ry float64
+	}{
+		{"circle", 6, 6},
+		{"wide", 10, 4},
+		{"tall", 3, 7},
+		{"small", 1, 1},
+	}
+	for _, tc := range tests {
+		const cx, cy = 12, 9
+		g := New(25, 19)
+		g.Ellipse(cx, cy, tc.rx, tc.ry, 'o', "")
+		for y := range g {
+			for x := range g[y] {
+				if g[y][x].Glyph != 'o' {
+					continue
+				}
+				dx, dy := x-cx, y-cy
+				if Abs(dx) > int(tc.rx) || Abs(dy) > int(tc.ry) {
+					t.Errorf("%s: %d, %d is outside the radii", tc.name, x, y)
+				}
+				for _, m := range [][2]int{{cx - dx, y}, {x, cy - dy}} {
+					if g[m[1]][m[0]].Glyph != 'o' {
+						t.Errorf("%s: %d, %d is drawn but its mirror %d, %d is not", tc.name, x, y, m[0], m[1])
+					}
+				}
+			}
+		}
+		for _, p := range [][2]int{{cx + int(tc.rx), cy}, {cx - int(tc.rx), cy}, {cx, cy + int(tc.ry)}, {cx, cy - int(tc.ry)}} {
+			if g[p[1]][p[0]].Glyph != 'o' {
+				t.Errorf("%s: extreme %d, %d is not drawn", tc.name, p[0], p[1])
+			}
+		}
+	}
+}
+
+// TestEllipseClipsAndKeepsCells draws a ring bigger than the grid over a
+// cell that is already set.
+func TestEllipseClipsAndKeepsCells(t *testing.T) {
+	g := New(5, 5)
+	g.Set(4, 2, '@', "")
+	g.Ellipse(2, 2, 2, 2, 'o', "")
+	g.Ellipse(0, 0, 40, 40, 'x', "")
+	if g[2][4].Glyph != '@' {
+		t.Errorf("ellipse drew over a set cell: %q", g[2][4].Glyph)
+	}
+	if g[0][2].Glyph != 'o' {
+		t.Errorf("top of the ring = %q, want 'o'", g[0][2].Glyph)
+	}
+}
+
+func TestClamp(t *testing.T) {
+	ints := []struct{ v, lo, hi, want int }{
+		{5, 0, 10, 5},
+		{-3, 0, 10, 0},
+		{12, 0, 10, 10},
+		{0, 0, 10, 0},
+		{10, 0, 10, 10},
+		{4, 4, 4, 4},
+	}
+	for _, tc := range ints {
+		if got := Clamp(tc.v, tc.lo, tc.hi); got != tc.want {
+			t.Errorf("Clamp(%d, %d, %d) = %d, want %d", tc.v, tc.lo, tc.hi, got, tc.want)
+		}
+	}
+	floats := []struct{ v, lo, hi, want float64 }{
+		{0.5, 0, 1, 0.5},
+		{-0.1, 0, 1, 0},
+		{1.5, 0, 1, 1},
+		{-2, -1, 1, -1},
+	}
+	for _, tc := range floats {
+		if got := Clamp(tc.v, tc.lo, tc.hi); got != tc.want {
+			t.Errorf("Clamp(%v, %v, %v) = %v, want %v", tc.v, tc.lo, tc.hi, got, tc.want)
+		}
+	}
+}
+
+// picture is the grid as rows of text, '.' for a blank cell.
+func picture(g Grid) []string {
+	rows := make([]string, len(g))
+	for y, row := range g {
+		line := make([]rune, len(row))
+		for x, c := range row {
+			line[x] = c.Glyph
+			if c.Glyph == ' ' {
+				line[x] = '.'
+			}
+		}
+		rows[y] = string(line)
+	}
+	return rows
+}
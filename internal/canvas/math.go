@@ -0,0 +1,22 @@
+package canvas
+
+import "cmp"
+
+// Abs is the absolute value of v.
+func Abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Clamp is v held between lo and hi.
+func Clamp[T cmp.Ordered](v, lo, hi T) T {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
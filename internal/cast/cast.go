@@ -0,0 +1,127 @@
+// Package cast writes what a mode prints as an asciicast v2 recording, the
+// format asciinema plays back.
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Writer is an io.Writer that turns each write into an output event of a
+// recording. Events are timed by frames rather than the clock: the render
+// package calls Frame before each one, and every frame comes delay after the
+// one before.
+type Writer struct {
+	mu     sync.Mutex
+	out    *bufio.Writer
+	echo   io.Writer
+	delay  time.Duration
+	frames int
+	// at is the time of the current frame.
+	at  time.Duration
+	err error
+	// The header waits for the first frame, which knows the size the mode
+	// really draws at; until then events wait in early, and width and
+	// height are only a guess.
+	started       bool
+	early         bytes.Buffer
+	width, height int
+	timestamp     int64
+}
+
+type header struct {
+	Version   int   `json:"version"`
+	Width     int   `json:"width"`
+	Height    int   `json:"height"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewWriter starts a recording on w. Its header gives the size of the
+// first frame, or width by height if none is drawn. echo, if not nil, is
+// also given everything written, to show it on the terminal as well.
+func NewWriter(w io.Writer, width, height int, delay time.Duration, echo io.Writer) *Writer {
+	return &Writer{
+		out:       bufio.NewWriter(w),
+		echo:      echo,
+		delay:     delay,
+		width:     width,
+		height:    height,
+		timestamp: time.Now().Unix(),
+	}
+}
+
+// start writes the header for a width by height recording, then whatever
+// was written before it.
+func (c *Writer) start(width, height int) {
+	c.started = true
+	line, _ := json.Marshal(header{Version: 2, Width: width, Height: height, Timestamp: c.timestamp})
+	c.out.Write(append(line, '\n'))
+	c.out.Write(c.early.Bytes())
+	c.early.Reset()
+}
+
+// Frame marks the start of a width by height frame, which moves the
+// recording's clock on by the delay; the first frame is at 0 and sets the
+// recording's size.
+func (c *Writer) Frame(width, height int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.started {
+		c.start(width, height)
+	}
+	if c.frames > 0 {
+		c.at += c.delay
+	}
+	c.frames++
+}
+
+// SetDelay changes the time between frames from the next frame on, for a
+// recording that goes on into a mode with another frame rate.
+func (c *Writer) SetDelay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delay = delay
+}
+
+// Write records p as printed at the time of the current frame.
+func (c *Writer) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.echo != nil {
+		c.echo.Write(p)
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	line, err := json.Marshal([]any{c.at.Seconds(), "o", string(p)})
+	if err == nil {
+		if c.started {
+			_, err = c.out.Write(append(line, '\n'))
+		} else {
+			c.early.Write(append(line, '\n'))
+		}
+	}
+	if err != nil {
+		c.err = err
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes out any events still buffered. Call it once the mode has
+// returned.
+func (c *Writer) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if !c.started {
+		c.start(c.width, c.height)
+	}
+	return c.out.Flush()
+}
@@ -0,0 +1,129 @@
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"animinterminal/internal/fire"
+)
+
+// escapes matches the control sequences in a frame, to count the cells
+// between them.
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// TestRecording records a few frames of a mode, reads the file back as
+// asciinema would and checks the header matches the frames, there is one
+// time per frame, and time only goes forward. The second size is below
+// the mode's minimum, which it draws at instead.
+func TestRecording(t *testing.T) {
+	const frames = 12
+	delay := 40 * time.Millisecond
+	for _, size := range [][2]int{{60, 20}, {10, 5}} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, size[0], size[1], delay, nil)
+		cfg := fire.DefaultConfig()
+		cfg.Width, cfg.Height = size[0], size[1]
+		cfg.FrameDelay = time.Millisecond
+		cfg.MaxFrames = frames
+		cfg.Seed = 1
+		cfg.Output = w
+		if err := fire.Run(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := bufio.NewScanner(&buf)
+		lines.Buffer(nil, 1<<20)
+		if !lines.Scan() {
+			t.Fatal("empty recording")
+		}
+		var h header
+		if err := json.Unmarshal(lines.Bytes(), &h); err != nil {
+			t.Fatalf("header %q: %v", lines.Text(), err)
+		}
+		if h.Version != 2 || h.Width < size[0] || h.Height < size[1] || h.Timestamp == 0 {
+			t.Errorf("%dx%d: header = %+v", size[0], size[1], h)
+		}
+
+		last := -1.0
+		times := 0
+		var first strings.Builder
+		for lines.Scan() {
+			var event []any
+			if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+				t.Fatalf("event %q: %v", lines.Text(), err)
+			}
+			at, ok := event[0].(float64)
+			if len(event) != 3 || !ok || event[1] != "o" {
+				t.Fatalf("event %q is not [time, \"o\", data]", lines.Text())
+			}
+			data, ok := event[2].(string)
+			if !ok {
+				t.Fatalf("event %q has no output", lines.Text())
+			}
+			if at < last {
+				t.Fatalf("time goes back from %v to %v", last, at)
+			}
+			if at > last {
+				times++
+			}
+			if at == 0 {
+				first.WriteString(data)
+			}
+			last = at
+		}
+		if err := lines.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if times != frames {
+			t.Errorf("%dx%d: events at %d times, want one per frame: %d", size[0], size[1], times, frames)
+		}
+		if want := (frames - 1) * delay; last != want.Seconds() {
+			t.Errorf("%dx%d: last event at %vs, want %vs", size[0], size[1], last, want.Seconds())
+		}
+
+		// The first frame is drawn whole and leaves the cursor under it.
+		frame := first.String()
+		if !strings.Contains(frame, fmt.Sprintf("\x1b[%d;1H", h.Height+1)) {
+			t.Errorf("%dx%d: first frame is not %d rows tall", size[0], size[1], h.Height)
+		}
+		row, _, _ := strings.Cut(frame[strings.LastIndex(frame, "\x1b[H")+3:], "\n")
+		if n := utf8.RuneCountInString(escapes.ReplaceAllString(row, "")); n != h.Width {
+			t.Errorf("%dx%d: first row is %d cells, header says %d", size[0], size[1], n, h.Width)
+		}
+	}
+}
+
+type failWriter struct{}
+
+var errFull = errors.New("disk full")
+
+func (failWriter) Write(p []byte) (int, error) { return 0, errFull }
+
+// TestWriteError checks that a recording that cannot be written says so
+// from Write and Flush rather than dropping frames quietly.
+func TestWriteError(t *testing.T) {
+	w := NewWriter(failWriter{}, 10, 10, time.Millisecond, nil)
+	w.Frame(10, 10)
+	// Enough to spill the buffer and reach the file.
+	big := bytes.Repeat([]byte("x"), 8192)
+	if _, err := w.Write(big); !errors.Is(err, errFull) {
+		t.Errorf("Write error = %v, want %v", err, errFull)
+	}
+	if _, err := w.Write([]byte("more")); !errors.Is(err, errFull) {
+		t.Errorf("Write after a failure = %v, want %v", err, errFull)
+	}
+	if err := w.Flush(); !errors.Is(err, errFull) {
+		t.Errorf("Flush error = %v, want %v", err, errFull)
+	}
+}
@@ -0,0 +1,293 @@
+package clock
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/plasma"
+	"animinterminal/internal/rain"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 12
+	// scrambleFrames is how long a changed digit flickers before it
+	// settles.
+	scrambleFrames = 8
+	// rainDensity keeps the background rain sparse enough to read over.
+	rainDensity = 0.1
+)
+
+var (
+	glowColors = []string{
+		"\x1b[38;5;195m",
+		"\x1b[38;5;159m",
+		"\x1b[38;5;123m",
+		"\x1b[38;5;87m",
+		"\x1b[38;5;51m",
+		"\x1b[38;5;45m",
+		"\x1b[38;5;39m",
+	}
+	scrambleColor  = "\x1b[38;5;231m"
+	scrambleGlyphs = []rune("01#%*+=")
+	dateColor      = "\x1b[38;5;73m"
+	rainColors     = []string{"\x1b[38;5;29m", "\x1b[38;5;22m", "\x1b[38;5;236m"}
+	plasmaGlyphs   = []rune("   ..,:")
+	plasmaColors   = []string{"\x1b[38;5;17m", "\x1b[38;5;18m", "\x1b[38;5;54m", "\x1b[38;5;55m"}
+)
+
+// Config controls the clock.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Hour12 shows a 12-hour clock with AM/PM under it.
+	Hour12 bool
+	// Seconds adds :SS after the minutes.
+	Seconds bool
+	// Date shows the weekday and date under the time.
+	Date bool
+	// Background is the faint effect behind the digits: none, rain or
+	// plasma.
+	Background string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Seconds:    true,
+		Date:       true,
+		Background: "rain",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	if !IsBackground(c.Background) {
+		c.Background = "rain"
+	}
+	return c
+}
+
+// IsBackground reports whether name is a clock background.
+func IsBackground(name string) bool {
+	switch name {
+	case "none", "rain", "plasma":
+		return true
+	}
+	return false
+}
+
+// digit is one character of the time on screen. When it changes it keeps
+// the old character for a few frames, flickering between the two.
+type digit struct {
+	from, to byte
+	changed  int
+}
+
+// Run launches the clock.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	var drops *rain.Layer
+	if cfg.Background == "rain" {
+		drops = rain.NewLayer(cfg.Width, cfg.Height, rainDensity, rng.Int63())
+	}
+	var digits []digit
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		now := time.Now()
+		digits = tick(digits, timeText(now, cfg), frame)
+
+		grid.Clear()
+		switch cfg.Background {
+		case "rain":
+			drawRain(grid, drops)
+			drops.Update()
+		case "plasma":
+			drawPlasma(grid, frame)
+		}
+		bottom := drawDigits(grid, digits, frame, rng)
+		if line := dateText(now, cfg); line != "" {
+			drawText(grid, line, bottom+2, dateColor)
+		}
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func timeText(now time.Time, cfg Config) string {
+	layout := "15:04"
+	if cfg.Hour12 {
+		layout = "03:04"
+	}
+	if cfg.Seconds {
+		layout += ":05"
+	}
+	return now.Format(layout)
+}
+
+func dateText(now time.Time, cfg Config) string {
+	var parts []string
+	if cfg.Date {
+		parts = append(parts, now.Format("Mon 2006-01-02"))
+	}
+	if cfg.Hour12 {
+		parts = append(parts, now.Format("PM"))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// tick moves digits on to text, starting the flicker on each character
+// that changed. A change of length (the seconds toggled) starts over.
+func tick(digits []digit, text string, frame int) []digit {
+	if len(digits) != len(text) {
+		digits = make([]digit, len(text))
+		for i := range digits {
+			digits[i] = digit{from: text[i], to: text[i], changed: -scrambleFrames}
+		}
+		return digits
+	}
+	for i := range digits {
+		if digits[i].to != text[i] {
+			digits[i] = digit{from: digits[i].to, to: text[i], changed: frame}
+		}
+	}
+	return digits
+}
+
+// drawDigits draws the time centered on the screen, as large as fits, and
+// returns the last row it used. Pixels are twice as wide as tall so they
+// come out square.
+func drawDigits(grid canvas.Grid, digits []digit, frame int, rng *rand.Rand) int {
+	height := len(grid)
+	width := len(grid[0])
+	pixels := -1
+	for _, d := range digits {
+		pixels += glyphWidth(d.to) + 1
+	}
+	scale := 1
+	for pixels*2*(scale+1) <= width-4 && fontHeight*(scale+1) <= height-6 {
+		scale++
+	}
+	left := (width - pixels*2*scale) / 2
+	top := (height - fontHeight*scale) / 2
+	// Keep the background off the digits so they stay readable.
+	for y := top - 1; y <= top+fontHeight*scale; y++ {
+		for x := left - 2; x < left+pixels*2*scale+2; x++ {
+			grid.Set(x, y, ' ', "")
+		}
+	}
+
+	px := 0
+	for _, d := range digits {
+		progress := float64(frame-d.changed) / scrambleFrames
+		for gx := 0; gx < glyphWidth(d.to); gx++ {
+			for gy := 0; gy < fontHeight; gy++ {
+				on, glyph, color := lit(d.to, gx, gy), '#', glowColors[gy*len(glowColors)/fontHeight]
+				if progress < 1 {
+					if rng.Float64() > progress {
+						on = lit(d.from, gx, gy)
+					}
+					glyph = scrambleGlyphs[rng.Intn(len(scrambleGlyphs))]
+					color = scrambleColor
+				}
+				if !on {
+					continue
+				}
+				for sy := 0; sy < scale; sy++ {
+					for sx := 0; sx < 2*scale; sx++ {
+						grid.Set(left+(px+gx)*2*scale+sx, top+gy*scale+sy, glyph, color)
+					}
+				}
+			}
+		}
+		px += glyphWidth(d.to) + 1
+	}
+	return top + fontHeight*scale - 1
+}
+
+func drawText(grid canvas.Grid, text string, y int, color string) {
+	x := (len(grid[0]) - len(text)) / 2
+	grid.Set(x-1, y, ' ', "")
+	grid.Set(x+len(text), y, ' ', "")
+	grid.Text(x, y, text, color)
+}
+
+func drawRain(grid canvas.Grid, drops *rain.Layer) {
+	drops.Draw(func(x, y int, glyph rune, fade float64) {
+		color := rainColors[min(len(rainColors)-1, int(fade*float64(len(rainColors))))]
+		grid.Set(x, y, glyph, color)
+	})
+}
+
+func drawPlasma(grid canvas.Grid, frame int) {
+	height := len(grid)
+	width := len(grid[0])
+	t := float64(frame) * 0.04
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := plasma.Field(float64(x)/float64(width), float64(y)/float64(height), t)
+			glyph := plasmaGlyphs[min(len(plasmaGlyphs)-1, int(v*float64(len(plasmaGlyphs))))]
+			if glyph == ' ' {
+				continue
+			}
+			grid.Set(x, y, glyph, plasmaColors[min(len(plasmaColors)-1, int(v*float64(len(plasmaColors))))])
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package clock
+
+// fontHeight is the number of rows in every glyph of font.
+const fontHeight = 7
+
+// font is a 5x7 pixel font for the digits and the colon; '#' is lit.
+var font = map[byte][fontHeight]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	':': {"   ", "   ", " # ", "   ", " # ", "   ", "   "},
+}
+
+// lit reports whether pixel x, y of ch is on.
+func lit(ch byte, x, y int) bool {
+	rows, ok := font[ch]
+	if !ok || y < 0 || y >= fontHeight || x < 0 || x >= len(rows[y]) {
+		return false
+	}
+	return rows[y][x] == '#'
+}
+
+// glyphWidth is how many pixels wide ch is.
+func glyphWidth(ch byte) int {
+	return len(font[ch][0])
+}
@@ -1,18 +1,23 @@
 package cloud
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/bolt"
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
 const (
-	minWidthCloud  = 60
-	minHeightCloud = 24
+	minWidthCloud     = 60
+	minHeightCloud    = 24
+	coverageThreshold = 0.35
 )
 
 var (
@@ -22,20 +27,6 @@ var (
 		"\x1b[38;5;45m",
 		"\x1b[38;5;39m",
 	}
-	highCloudColors = []string{
-		"\x1b[38;5;255m",
-		"\x1b[38;5;252m",
-	}
-	midCloudColors = []string{
-		"\x1b[38;5;250m",
-		"\x1b[38;5;248m",
-		"\x1b[38;5;246m",
-	}
-	lowCloudColors = []string{
-		"\x1b[38;5;245m",
-		"\x1b[38;5;243m",
-		"\x1b[38;5;239m",
-	}
 	lightningPalette = []string{
 		"\x1b[38;5;231m",
 		"\x1b[38;5;229m",
@@ -48,6 +39,52 @@ type Config struct {
 	Width      int
 	Height     int
 	FrameDelay time.Duration
+	// Weather is the preset: clear, fair, overcast or storm.
+	Weather string
+	// Cycle, when set, is how long the sky takes to morph into the next preset.
+	Cycle time.Duration
+	// Ground is the silhouette along the bottom: "hills", "city", "sea" or
+	// "" for open sky, where the rain evaporates on the way down.
+	Ground string
+	// ReducedMotion swaps the full-screen lightning flash for a glow around the bolt.
+	ReducedMotion bool
+	// Light puts a "sun" or "moon" behind the clouds. It sits at
+	// LightX/LightY (0-1 across and down the screen) when both are set,
+	// otherwise it crosses the sky once every LightCycle.
+	Light      string
+	LightX     float64
+	LightY     float64
+	LightCycle time.Duration
+	// Wind scales how fast the clouds drift; a negative value blows them the
+	// other way. Shear makes the high layer faster than the low one and above
+	// 1 turns the low layer around. Gusts adds passing bursts that speed the
+	// drift up and stretch the clouds out.
+	Wind  float64
+	Shear float64
+	Gusts bool
+	// Layers is how many cloud layers to stack (1-6). LayerSpecs, when given,
+	// fixes each layer's shape explicitly and overrides Layers.
+	Layers     int
+	LayerSpecs []LayerSpec
+	// Realtime tints the sky and clouds for the local time of day; Hour
+	// (0-24) pins the time instead and is ignored while negative.
+	Realtime bool
+	Hour     float64
+	// DayCycle, when set, runs the time of day round once every DayCycle
+	// from Hour, or noon, blending the sky through sunrise, day, sunset and
+	// night instead of stepping between them.
+	DayCycle time.Duration
+	// FlyoverInterval is the average time between birds or planes crossing.
+	FlyoverInterval time.Duration
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
 }
 
 // DefaultConfig returns a preset suited for most terminals.
@@ -56,10 +93,26 @@ func DefaultConfig() Config {
 		Width:      100,
 		Height:     34,
 		FrameDelay: 70 * time.Millisecond,
+		Weather:    "overcast",
+		LightX:     -1,
+		LightY:     -1,
+		LightCycle: 10 * time.Minute,
+		Wind:       1,
+		Shear:      0.3,
+		Hour:       -1,
+		Layers:     3,
+
+		FlyoverInterval: time.Minute,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidthCloud {
 		c.Width = minWidthCloud
 	}
@@ -69,14 +122,40 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 70 * time.Millisecond
 	}
+	if !IsWeather(c.Weather) {
+		c.Weather = "overcast"
+	}
+	if c.Layers <= 0 {
+		c.Layers = 3
+	}
+	if c.Layers > maxLayers {
+		c.Layers = maxLayers
+	}
+	c.LayerSpecs = normalizeSpecs(c.LayerSpecs)
+	if !IsGround(c.Ground) {
+		c.Ground = ""
+	}
+	if c.Wind == 0 {
+		c.Wind = 1
+	}
+	if c.Shear < 0 {
+		c.Shear = 0
+	}
+	if c.FlyoverInterval <= 0 {
+		c.FlyoverInterval = time.Minute
+	}
+	if c.LightCycle <= 0 {
+		c.LightCycle = 10 * time.Minute
+	}
+	if c.LightX > 1 {
+		c.LightX = 1
+	}
+	if c.LightY > 1 {
+		c.LightY = 1
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
-}
-
 type cloudLayer struct {
 	height    float64
 	thickness float64
@@ -84,132 +163,156 @@ type cloudLayer struct {
 	scale     float64
 	speed     float64
 	colorSet  []string
-	glyphs    []byte
+	glyphs    []rune
 	parallax  float64
+	shearRank float64
+	rank      float64
+	crowd     float64
+	spec      *LayerSpec
+	shade     int
+	offset    float64
+	stretch   float64
+	center    float64
 }
 
-type point struct {
-	x int
-	y int
-}
-
+// lightning is a bolt, the ground row in each column it stops at, and how
+// many more frames it stays lit.
 type lightning struct {
-	points []point
-	life   int
+	bolt   bolt.Bolt
+	ground []int
+	// drop is how many rows the main channel falls.
+	drop float64
+	life int
 }
 
 // Run starts the cloud animation.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
-
-	layers := []cloudLayer{
-		{
-			height:    0.22,
-			thickness: 0.18,
-			density:   0.75,
-			scale:     0.11,
-			speed:     0.022,
-			colorSet:  highCloudColors,
-			glyphs:    []byte{'@', '%'},
-			parallax:  0.7,
-		},
-		{
-			height:    0.38,
-			thickness: 0.22,
-			density:   0.62,
-			scale:     0.07,
-			speed:     0.015,
-			colorSet:  midCloudColors,
-			glyphs:    []byte{'#', '*'},
-			parallax:  0.9,
-		},
-		{
-			height:    0.55,
-			thickness: 0.28,
-			density:   0.48,
-			scale:     0.05,
-			speed:     0.01,
-			colorSet:  lowCloudColors,
-			glyphs:    []byte{'=', '-'},
-			parallax:  1.2,
-		},
-	}
-
-	var bolt lightning
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	// Height, density, speed and colors come from the weather each frame.
+	layers := newLayers(cfg)
+	var wind gust
+	var craft flyover
+	trail := newContrail(cfg.Width, cfg.Height, cfg.FrameDelay)
+	flyoverChance := float64(cfg.FrameDelay) / float64(cfg.FlyoverInterval)
+
+	cover := newCoverMap(len(layers), cfg.Width, cfg.Height)
+	land := newTerrain(cfg.Ground, cfg.Width, cfg.Height, rng)
+	var strike lightning
+	var rumble thunder
+	drops := make([]drop, 0, 256)
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
-	grid := newGrid(cfg.Width, cfg.Height)
+	grid := canvas.New(cfg.Width, cfg.Height)
+
+	for frame := 0; limit.Next(); frame++ {
+		w := weatherAt(cfg, frame)
+		w.apply(layers)
+		wind.update(cfg.Gusts, cfg.FrameDelay, rng)
+		driftLayers(layers, w.wind*cfg.Wind, cfg.Shear, wind)
+
+		tint := tintAt(cfg, w, frame, time.Now())
+		tint.apply(layers)
+		cover.fill(layers)
+		sun := lightAt(cfg, frame)
+
+		grid.Clear()
+		flash := rumble.shift()
+		if cfg.ReducedMotion {
+			flash = 0
+		}
+		drawSky(grid, tint.skyPalette(w, flash))
+		drawStars(grid, cover, tint.stars, frame)
+		drawDisc(grid, sun)
+		if craft.active {
+			craft.update(cfg.Width, trail)
+		} else if rng.Float64() < flyoverChance {
+			craft = newFlyover(cfg.Width, cfg.Height, layers, rng)
+		}
+		trail.decay(rng)
 
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
-		drawSky(grid)
-		for i := range layers {
-			drawLayer(grid, &layers[i], frame)
+		// Planes and their trails fly between the high and mid layers.
+		drawLayer(grid, &layers[0], cover.layers[0], sun)
+		trail.draw(grid)
+		if craft.active && craft.kind == flyoverPlane {
+			drawPlane(grid, craft, frame)
 		}
-		if !bolt.active() && rand.Float64() < 0.02 {
-			bolt = newLightning(cfg.Width, cfg.Height)
+		for i := 1; i < len(layers); i++ {
+			drawLayer(grid, &layers[i], cover.layers[i], sun)
 		}
-		if bolt.active() {
-			drawLightning(grid, &bolt)
-			bolt.life--
+		if craft.active && craft.kind == flyoverFlock {
+			drawFlock(grid, craft, frame)
 		}
-		render(grid)
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		row := grid[y]
-		for x := range row {
-			row[x] = cell{glyph: ' ', color: ""}
+		columns := cloudColumns(cover, land)
+		updateRain(&drops, columns, w.rain, w.wind*cfg.Wind*(1+gustDrift*wind.strength), cfg.Height, land, rng)
+		drawRain(grid, drops, land)
+		drawRays(grid, sun, frame)
+		rumble.drawRumble(grid, &layers[len(layers)-1], cover.layers[len(layers)-1], columns, rng)
+		rumble.advance()
+		if !strike.active() && rng.Float64() < w.lightning {
+			strike = newLightning(columns, cfg.Height, land, rng)
+			if strike.active() {
+				rumble.strike(strike, cfg.Height, cfg.FrameDelay)
+			}
+		}
+		// Nothing of the sky shows below the land.
+		land.draw(grid, frame, strike.active())
+		drawSplashes(grid, drops, land, frame)
+		if strike.active() {
+			if cfg.ReducedMotion {
+				drawGlow(grid, strike)
+			}
+			drawLightning(grid, strike)
+			strike.life--
+		}
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
-func drawSky(grid [][]cell) {
+func drawSky(grid canvas.Grid, palette []string) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height; y++ {
-		color := skyPalette[min(len(skyPalette)-1, y*len(skyPalette)/max(1, height))]
+		color := palette[min(len(palette)-1, y*len(palette)/max(1, height))]
 		for x := 0; x < width; x++ {
-			grid[y][x] = cell{glyph: '.', color: color}
+			grid[y][x] = canvas.Cell{Glyph: '.', Color: color}
 		}
 	}
 }
 
-func drawLayer(grid [][]cell, layer *cloudLayer, frame int) {
+// drawLayer paints one cloud layer from its row of the coverage map; thin
+// cloud close to the sun or moon picks up a silver lining.
+func drawLayer(grid canvas.Grid, layer *cloudLayer, cover [][]float64, sun light) {
 	height := len(grid)
 	width := len(grid[0])
 	if len(layer.glyphs) == 0 || len(layer.colorSet) == 0 {
 		return
 	}
 
-	basePhase := float64(frame) * layer.speed
 	for y := 0; y < height; y++ {
-		yNorm := float64(y) / float64(height-1)
-		distance := math.Abs(yNorm - layer.height)
-		falloff := math.Exp(-math.Pow(distance/layer.thickness, 2) * 2.5)
-		if falloff < 0.05 {
-			continue
-		}
 		for x := 0; x < width; x++ {
-			noise := cloudNoise(float64(x), float64(y), basePhase, layer)
-			coverage := falloff*(0.55+0.45*noise) - (1-layer.density)*0.4
-			if coverage < 0.35 {
+			coverage := cover[y][x]
+			if coverage < coverageThreshold {
 				continue
 			}
 			glyph := layer.glyphs[0]
@@ -217,12 +320,30 @@ func drawLayer(grid [][]cell, layer *cloudLayer, frame int) {
 				glyph = layer.glyphs[1]
 			}
 			color := layer.colorSet[(x+y)%len(layer.colorSet)]
-			setCell(grid, x, y, glyph, color)
+			if lining, ok := sun.lining(x, y, coverage); ok {
+				color = lining
+			}
+			grid.Set(x, y, glyph, color)
 		}
 	}
 }
 
+// falloff fades the layer out above and below its center row.
+func (l *cloudLayer) falloff(y, height int) float64 {
+	yNorm := float64(y) / float64(height-1)
+	distance := math.Abs(yNorm - l.height)
+	return math.Exp(-math.Pow(distance/l.thickness, 2) * 2.5)
+}
+
+// coverage is how solid the layer is at a cell; it is drawn from coverageThreshold up.
+func (l *cloudLayer) coverage(x, y int, falloff float64) float64 {
+	noise := cloudNoise(float64(x), float64(y), l.offset, l)
+	return falloff*(0.55+0.45*noise) - (1-l.density)*0.4
+}
+
 func cloudNoise(x, y float64, phase float64, layer *cloudLayer) float64 {
+	// Gusts pull the field out sideways from the middle of the screen.
+	x = layer.center + (x-layer.center)/layer.stretch
 	s := layer.scale
 	p := layer.parallax
 	v := math.Sin((x*s+p*phase)*0.9+phase*2.0) +
@@ -231,91 +352,57 @@ func cloudNoise(x, y float64, phase float64, layer *cloudLayer) float64 {
 	return math.Tanh(v)
 }
 
-func drawLightning(grid [][]cell, bolt *lightning) {
-	for i, pt := range bolt.points {
-		if pt.y < 0 || pt.y >= len(grid) || pt.x < 0 || pt.x >= len(grid[pt.y]) {
-			continue
-		}
-		color := lightningPalette[min(len(lightningPalette)-1, i%len(lightningPalette))]
-		setCell(grid, pt.x, pt.y, lightningGlyph(i), color)
-	}
-}
-
-func lightningGlyph(i int) byte {
-	switch i % 3 {
-	case 0:
-		return '|'
-	case 1:
-		return '/'
-	default:
-		return '\\'
-	}
+// drawLightning draws l with its branches in the dimmer yellows.
+func drawLightning(grid canvas.Grid, l lightning) {
+	l.draw(func(x, y int, glyph rune, depth int) {
+		grid.Set(x, y, glyph, lightningPalette[min(depth, len(lightningPalette)-1)])
+	})
 }
 
-func newLightning(width, height int) lightning {
-	points := make([]point, 0, height)
-	x := rand.Intn(width/2) + width/4
-	y := rand.Intn(height/6) + 1
-	length := height/2 + rand.Intn(height/3)
-	for i := 0; i < length && y < height-2; i++ {
-		points = append(points, point{x: x, y: y})
-		x += rand.Intn(3) - 1
-		if x < 1 {
-			x = 1
+// newLightning strikes from a random column under heavy cloud; with none
+// overhead the sky stays quiet. Over land the bolt runs all the way down and
+// stops on the ground line.
+func newLightning(columns []column, height int, land terrain, rng *rand.Rand) lightning {
+	width := len(columns)
+	heavy := make([]int, 0, width)
+	for x, c := range columns {
+		if c.base >= 0 && c.cover >= heavyCover && x > 0 && x < width-1 {
+			heavy = append(heavy, x)
 		}
-		if x >= width-1 {
-			x = width - 2
-		}
-		y += 1 + rand.Intn(2)
 	}
-	return lightning{points: points, life: 4 + rand.Intn(4)}
-}
-
-func (l lightning) active() bool {
-	return l.life > 0 && len(l.points) > 0
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
+	if len(heavy) == 0 {
+		return lightning{}
 	}
-
-	fmt.Print(sb.String())
-}
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
+	x0 := heavy[rng.Intn(len(heavy))]
+	y0 := max(1, columns[x0].base-rng.Intn(height/6+1))
+	x1 := canvas.Clamp(x0+int((rng.Float64()*2-1)*float64(width)*0.08), 1, width-2)
+	ground := land.top
+	y1 := y0 + height/2 + rng.Intn(height/3)
+	if land.solid() {
+		y1 = land.top[x1]
+	} else {
+		ground = make([]int, width)
+		for x := range ground {
+			ground[x] = height - 2
+		}
+		y1 = min(y1, height-2)
 	}
-	if x < 0 || x >= len(grid[y]) {
-		return
+	if y1 <= y0 {
+		return lightning{}
 	}
-	grid[y][x] = cell{glyph: glyph, color: color}
+	b := bolt.New(rng, float64(x0)+0.5, float64(y0), float64(x1)+0.5, float64(y1), 0.5)
+	return lightning{bolt: b, ground: ground, drop: float64(y1 - y0), life: 4 + rng.Intn(4)}
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// draw hands set every cell of the bolt above the ground.
+func (l lightning) draw(set func(x, y int, glyph rune, depth int)) {
+	l.bolt.Draw(func(x, y int, glyph rune, depth int) {
+		if x >= 0 && x < len(l.ground) && y < l.ground[x] {
+			set(x, y, glyph, depth)
+		}
+	})
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
+func (l lightning) active() bool {
+	return l.life > 0 && len(l.bolt.Segments) > 0
 }
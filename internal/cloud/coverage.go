@@ -0,0 +1,64 @@
+package cloud
+
+import "math"
+
+// coverMap holds every layer's coverage for the current frame so drawing,
+// rain, lightning and the stars all read the same numbers. Rows a layer
+// cannot reach are stored as 0.
+type coverMap struct {
+	layers [][][]float64
+	total  [][]float64
+}
+
+func newCoverMap(layers, width, height int) *coverMap {
+	m := &coverMap{
+		layers: make([][][]float64, layers),
+		total:  make([][]float64, height),
+	}
+	for i := range m.layers {
+		m.layers[i] = make([][]float64, height)
+		for y := range m.layers[i] {
+			m.layers[i][y] = make([]float64, width)
+		}
+	}
+	for y := range m.total {
+		m.total[y] = make([]float64, width)
+	}
+	return m
+}
+
+// fill samples all layers for this frame. total is the sum of the positive
+// coverage of every layer at a cell.
+func (m *coverMap) fill(layers []cloudLayer) {
+	height := len(m.total)
+	for y := range m.total {
+		for x := range m.total[y] {
+			m.total[y][x] = 0
+		}
+	}
+	for i := range layers {
+		for y := 0; y < height; y++ {
+			row := m.layers[i][y]
+			falloff := layers[i].falloff(y, height)
+			if falloff < 0.05 {
+				for x := range row {
+					row[x] = 0
+				}
+				continue
+			}
+			for x := range row {
+				row[x] = layers[i].coverage(x, y, falloff)
+				m.total[y][x] += math.Max(0, row[x])
+			}
+		}
+	}
+}
+
+// peak is the strongest single-layer coverage at a cell.
+func (m *coverMap) peak(x, y int) float64 {
+	best := 0.0
+	for i := range m.layers {
+		best = math.Max(best, m.layers[i][y][x])
+	}
+	return best
+}
@@ -0,0 +1,182 @@
+package cloud
+
+import (
+	"math"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/color"
+)
+
+var (
+	dawnSkyPalette = []string{
+		"\x1b[38;5;97m",
+		"\x1b[38;5;139m",
+		"\x1b[38;5;174m",
+		"\x1b[38;5;216m",
+	}
+	nightSkyPalette = []string{
+		"\x1b[38;5;17m",
+		"\x1b[38;5;18m",
+		"\x1b[38;5;60m",
+		"\x1b[38;5;237m",
+	}
+	// warmCloudColors light the high, mid and low layers at sunrise and sunset.
+	warmCloudColors = [][]string{
+		{"\x1b[38;5;224m", "\x1b[38;5;223m", "\x1b[38;5;217m"},
+		{"\x1b[38;5;216m", "\x1b[38;5;210m", "\x1b[38;5;174m"},
+		{"\x1b[38;5;173m", "\x1b[38;5;138m", "\x1b[38;5;95m"},
+	}
+	starColors = []string{
+		"\x1b[38;5;230m",
+		"\x1b[38;5;153m",
+		"\x1b[38;5;255m",
+	}
+)
+
+// tint is how the time of day colors the scene. A zero tint leaves the
+// weather's own colors alone.
+type tint struct {
+	sky   []string
+	warm  bool
+	dim   int
+	stars float64
+}
+
+var (
+	dawnTint  = tint{sky: dawnSkyPalette, warm: true}
+	nightTint = tint{sky: nightSkyPalette, dim: 12, stars: 1}
+	// dayKeys are the tints a day cycle passes through, by hour; between two
+	// it blends from one to the next. A zero tint is the weather's own sky.
+	dayKeys = []struct {
+		hour float64
+		tint tint
+	}{
+		{0, nightTint},
+		{4.5, nightTint},
+		{6, dawnTint},
+		{7.5, tint{}},
+		{16.5, tint{}},
+		{18, dawnTint},
+		{20, nightTint},
+		{24, nightTint},
+	}
+)
+
+// tintAt returns the tint for cfg under weather w at frame, reading the
+// clock only in realtime mode. A day cycle starts at Hour, or noon.
+func tintAt(cfg Config, w weather, frame int, now time.Time) tint {
+	if cfg.DayCycle > 0 {
+		start := 12.0
+		if cfg.Hour >= 0 {
+			start = cfg.Hour
+		}
+		elapsed := time.Duration(frame) * cfg.FrameDelay
+		return blendedTint(math.Mod(start+24*float64(elapsed)/float64(cfg.DayCycle), 24), w)
+	}
+	hour := cfg.Hour
+	if hour < 0 {
+		if !cfg.Realtime {
+			return tint{}
+		}
+		hour = float64(now.Hour()) + float64(now.Minute())/60
+	}
+	return tintFor(math.Mod(hour, 24))
+}
+
+func tintFor(hour float64) tint {
+	switch {
+	case hour >= 5 && hour < 7, hour >= 17 && hour < 19.5:
+		return tint{sky: dawnSkyPalette, warm: true}
+	case hour >= 7 && hour < 17:
+		return tint{}
+	case hour >= 19.5 && hour < 21, hour >= 4 && hour < 5:
+		// Twilight: the first stars come out.
+		return tint{sky: nightSkyPalette, dim: 6, stars: 0.4}
+	default:
+		return tint{sky: nightSkyPalette, dim: 12, stars: 1}
+	}
+}
+
+// blendedTint is the tint at hour on the way between two dayKeys, with the
+// sky colors mixed; w fills in the sky of daytime.
+func blendedTint(hour float64, w weather) tint {
+	i := 1
+	for i < len(dayKeys)-1 && dayKeys[i].hour <= hour {
+		i++
+	}
+	from, to := dayKeys[i-1], dayKeys[i]
+	f := (hour - from.hour) / (to.hour - from.hour)
+	a, b := from.tint, to.tint
+	if a.sky == nil && b.sky == nil {
+		return tint{}
+	}
+	sky := func(t tint) []string {
+		if t.sky == nil {
+			return w.skyPalette(0)
+		}
+		return t.sky
+	}
+	near := a
+	if f >= 0.5 {
+		near = b
+	}
+	return tint{
+		sky:   mixPalette(sky(a), sky(b), f),
+		warm:  near.warm,
+		dim:   int(math.Round(lerp(float64(a.dim), float64(b.dim), f))),
+		stars: lerp(a.stars, b.stars, f),
+	}
+}
+
+// mixPalette is each color of a blended f of the way toward the matching
+// color of b.
+func mixPalette(a, b []string, f float64) []string {
+	mixed := make([]string, len(a))
+	for i := range a {
+		from, _, _, _ := color.Parse(a[i])
+		to, _, _, _ := color.Parse(b[i*len(b)/len(a)])
+		mixed[i] = color.Mix(from, to, f).Render(color.TrueColor)
+	}
+	return mixed
+}
+
+func (t tint) skyPalette(w weather, flash int) []string {
+	if t.sky == nil || flash > 0 {
+		return w.skyPalette(flash)
+	}
+	return t.sky
+}
+
+func (t tint) apply(layers []cloudLayer) {
+	for i := range layers {
+		switch {
+		case t.warm:
+			idx := int(math.Round(layers[i].rank * float64(len(warmCloudColors)-1)))
+			layers[i].colorSet = warmCloudColors[idx]
+		case t.dim > 0:
+			layers[i].colorSet = shadeColors(layers[i].shade - t.dim)
+		}
+	}
+}
+
+// drawStars sprinkles stars where no layer has any real coverage.
+func drawStars(grid canvas.Grid, cover *coverMap, amount float64, frame int) {
+	if amount <= 0 {
+		return
+	}
+	limit := int(amount * 7)
+	for y := range grid {
+		for x := range grid[y] {
+			seed := (x*x*31 + y*y*17 + x*y*7919 + x*13) % 331
+			if seed >= limit || cover.total[y][x] > 0.2 {
+				continue
+			}
+			glyph := '+'
+			if (seed+frame/25)%7 == 0 {
+				glyph = '*'
+			}
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: starColors[seed%len(starColors)]}
+		}
+	}
+}
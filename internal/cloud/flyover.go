@@ -0,0 +1,180 @@
+package cloud
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const contrailLife = 10 * time.Second
+
+var (
+	birdColor     = "\x1b[38;5;236m"
+	planeColor    = "\x1b[38;5;253m"
+	beaconColor   = "\x1b[38;5;196m"
+	contrailColor = []string{
+		"\x1b[38;5;255m",
+		"\x1b[38;5;252m",
+		"\x1b[38;5;249m",
+		"\x1b[38;5;246m",
+	}
+)
+
+type flyoverKind int
+
+const (
+	flyoverFlock flyoverKind = iota
+	flyoverPlane
+)
+
+// flyover is one crossing: a flock below the low clouds or a plane between
+// the high and mid layers.
+type flyover struct {
+	active bool
+	kind   flyoverKind
+	x, y   float64
+	vx     float64
+	birds  int
+}
+
+func newFlyover(width, height int, layers []cloudLayer, rng *rand.Rand) flyover {
+	f := flyover{active: true, kind: flyoverFlock}
+	if rng.Intn(2) == 0 {
+		f.kind = flyoverPlane
+	}
+	switch f.kind {
+	case flyoverPlane:
+		// Between the top two layers, or just above a lone layer.
+		mid := layers[0].height - layers[0].thickness
+		if len(layers) > 1 {
+			mid = (layers[0].height + layers[1].height) / 2
+		}
+		f.y = math.Max(1, mid*float64(height-1))
+		f.vx = 0.35 + rng.Float64()*0.25
+	default:
+		low := layers[len(layers)-1]
+		f.y = math.Min(float64(height-4), (low.height+low.thickness)*float64(height-1))
+		f.vx = 0.25 + rng.Float64()*0.2
+		f.birds = 4 + rng.Intn(4)
+	}
+	f.x = -6
+	if rng.Intn(2) == 0 {
+		f.vx = -f.vx
+		f.x = float64(width + 6)
+	}
+	return f
+}
+
+func (f *flyover) update(width int, trail *contrail) {
+	f.x += f.vx
+	if f.kind == flyoverPlane {
+		tail := f.x - 2
+		if f.vx < 0 {
+			tail = f.x + 2
+		}
+		trail.mark(int(math.Round(tail)), int(math.Round(f.y)))
+	}
+	if (f.vx > 0 && f.x > float64(width+8)) || (f.vx < 0 && f.x < -8) {
+		f.active = false
+	}
+}
+
+func drawPlane(grid canvas.Grid, f flyover, frame int) {
+	x := int(math.Round(f.x))
+	y := int(math.Round(f.y))
+	body := "-=>"
+	if f.vx < 0 {
+		body = "<=-"
+	}
+	grid.Text(x-1, y, body, planeColor)
+	if (frame/8)%2 == 0 {
+		grid.Set(x, y-1, '.', beaconColor)
+	}
+}
+
+// drawFlock lays the birds out in a loose V behind the leader.
+func drawFlock(grid canvas.Grid, f flyover, frame int) {
+	dir := 1.0
+	if f.vx < 0 {
+		dir = -1
+	}
+	for i := 0; i < f.birds; i++ {
+		rank := (i + 1) / 2
+		side := 1
+		if i%2 == 1 {
+			side = -1
+		}
+		x := int(math.Round(f.x - dir*float64(rank*2)))
+		y := int(math.Round(f.y)) + side*((rank+1)/2)
+		glyph := 'v'
+		if (frame/5+i)%2 == 1 {
+			glyph = '-'
+		}
+		grid.Set(x, y, glyph, birdColor)
+	}
+}
+
+// contrail is an overlay that outlives the frame grid: each cell holds how
+// fresh the trail is there and fades to nothing over contrailLife.
+type contrail struct {
+	cells [][]float64
+	fade  float64
+}
+
+func newContrail(width, height int, frameDelay time.Duration) *contrail {
+	cells := make([][]float64, height)
+	for y := range cells {
+		cells[y] = make([]float64, width)
+	}
+	return &contrail{cells: cells, fade: float64(frameDelay) / float64(contrailLife)}
+}
+
+func (c *contrail) mark(x, y int) {
+	if y < 0 || y >= len(c.cells) || x < 0 || x >= len(c.cells[y]) {
+		return
+	}
+	c.cells[y][x] = 1
+}
+
+// decay ages the trail; as it thins it spreads a little above and below.
+func (c *contrail) decay(rng *rand.Rand) {
+	for y := range c.cells {
+		for x, v := range c.cells[y] {
+			if v <= 0 {
+				continue
+			}
+			v -= c.fade
+			if v < 0.6 && rng.Float64() < c.fade*2 {
+				spread := y + 1
+				if rng.Intn(2) == 0 {
+					spread = y - 1
+				}
+				if spread >= 0 && spread < len(c.cells) && c.cells[spread][x] < v*0.6 {
+					c.cells[spread][x] = v * 0.6
+				}
+			}
+			c.cells[y][x] = math.Max(0, v)
+		}
+	}
+}
+
+func (c *contrail) draw(grid canvas.Grid) {
+	for y := range c.cells {
+		for x, v := range c.cells[y] {
+			if v <= 0 {
+				continue
+			}
+			glyph := '='
+			switch {
+			case v < 0.3:
+				glyph = '~'
+			case v < 0.65:
+				glyph = '-'
+			}
+			color := contrailColor[min(len(contrailColor)-1, int((1-v)*float64(len(contrailColor))))]
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cloud
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	landColor      = "\x1b[38;5;234m"
+	landEdgeColor  = "\x1b[38;5;236m"
+	landFlashColor = "\x1b[38;5;245m"
+	seaColors      = []string{
+		"\x1b[38;5;236m",
+		"\x1b[38;5;238m",
+		"\x1b[38;5;240m",
+	}
+)
+
+// terrain is the silhouette along the bottom of the screen: top holds the
+// first land row of every column. The zero value has no land at all.
+type terrain struct {
+	kind string
+	top  []int
+}
+
+// IsGround reports whether name is a ground silhouette ("" means none).
+func IsGround(name string) bool {
+	switch name {
+	case "", "hills", "city", "sea":
+		return true
+	}
+	return false
+}
+
+func newTerrain(kind string, width, height int, rng *rand.Rand) terrain {
+	if kind == "" {
+		return terrain{}
+	}
+	band := max(3, height/5)
+	t := terrain{kind: kind, top: make([]int, width)}
+	switch kind {
+	case "hills":
+		phase := rng.Float64() * 10
+		for x := range t.top {
+			fx := float64(x)
+			rise := 0.55 + 0.25*math.Sin(fx*0.045+phase) +
+				0.15*math.Sin(fx*0.13+phase*2) +
+				0.05*math.Sin(fx*0.41)
+			t.top[x] = height - 1 - int(math.Round(rise*float64(band)))
+		}
+	case "city":
+		for x := 0; x < width; {
+			w := 3 + rng.Intn(6)
+			h := 2 + rng.Intn(band)
+			for i := 0; i < w && x+i < width; i++ {
+				t.top[x+i] = height - h
+			}
+			x += w
+		}
+	case "sea":
+		for x := range t.top {
+			t.top[x] = height - band/2
+		}
+	}
+	return t
+}
+
+func (t terrain) solid() bool {
+	return len(t.top) > 0
+}
+
+// draw paints the silhouette, lit in grey while lightning flashes.
+func (t terrain) draw(grid canvas.Grid, frame int, flash bool) {
+	if !t.solid() {
+		return
+	}
+	height := len(grid)
+	for x, top := range t.top {
+		for y := top; y < height; y++ {
+			glyph, color := t.glyph(x, y, frame)
+			if flash {
+				color = landFlashColor
+			}
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
+
+func (t terrain) glyph(x, y, frame int) (rune, string) {
+	top := t.top[x]
+	switch t.kind {
+	case "sea":
+		// A slow shimmer runs along the water.
+		if (x*x*7+y*31+frame/6)%13 == 0 {
+			return '-', seaColors[2]
+		}
+		if y == top {
+			return '~', seaColors[1]
+		}
+		return '~', seaColors[0]
+	case "city":
+		if y == top {
+			left := x > 0 && t.top[x-1] > top
+			right := x < len(t.top)-1 && t.top[x+1] > top
+			if left || right {
+				return '|', landEdgeColor
+			}
+			return '_', landEdgeColor
+		}
+		return '#', landColor
+	default:
+		if y == top {
+			switch {
+			case x > 0 && t.top[x-1] > top:
+				return '/', landEdgeColor
+			case x < len(t.top)-1 && t.top[x+1] > top:
+				return '\\', landEdgeColor
+			}
+			return '_', landEdgeColor
+		}
+		return '#', landColor
+	}
+}
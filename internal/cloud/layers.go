@@ -0,0 +1,117 @@
+package cloud
+
+import (
+	"math"
+	"sort"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	maxLayers = 6
+	// densityBudget caps the summed density*thickness of explicit layers so a
+	// stack of thick, dense layers cannot white out the screen.
+	densityBudget = 0.45
+)
+
+// LayerSpec pins one layer's shape instead of taking it from the weather.
+// Height and Thickness are fractions of the screen height; Density runs 0-1.
+type LayerSpec struct {
+	Height    float64
+	Thickness float64
+	Density   float64
+	Scale     float64
+	Speed     float64
+}
+
+// newLayers builds the stack from top to bottom. rank runs 0 for the highest
+// layer to 1 for the lowest and picks the glyphs, noise scale and parallax
+// that the fixed high/mid/low layers used to have.
+func newLayers(cfg Config) []cloudLayer {
+	specs := cfg.LayerSpecs
+	count := cfg.Layers
+	if len(specs) > 0 {
+		count = len(specs)
+	}
+	layers := make([]cloudLayer, count)
+	for i := range layers {
+		rank := 0.5
+		if count > 1 {
+			rank = float64(i) / float64(count-1)
+		}
+		l := cloudLayer{
+			rank:      rank,
+			scale:     lerp(0.11, 0.05, rank),
+			parallax:  lerp(0.7, 1.2, rank),
+			shearRank: lerp(1, -1, rank),
+			crowd:     math.Min(1, 3/float64(count)),
+			stretch:   1,
+			center:    float64(cfg.Width) / 2,
+		}
+		switch {
+		case rank < 1.0/3:
+			l.glyphs = []rune{'@', '%'}
+		case rank < 2.0/3:
+			l.glyphs = []rune{'#', '*'}
+		default:
+			l.glyphs = []rune{'=', '-'}
+		}
+		if len(specs) > 0 {
+			spec := specs[i]
+			l.spec = &spec
+			if spec.Scale > 0 {
+				l.scale = spec.Scale
+			}
+		}
+		layers[i] = l
+	}
+	return layers
+}
+
+// normalizeSpecs sorts explicit layers from the top down, clamps each one and
+// scales the densities back if the stack would cover too much of the sky.
+func normalizeSpecs(specs []LayerSpec) []LayerSpec {
+	if len(specs) == 0 {
+		return nil
+	}
+	if len(specs) > maxLayers {
+		specs = specs[:maxLayers]
+	}
+	out := make([]LayerSpec, len(specs))
+	copy(out, specs)
+	sort.Slice(out, func(i, j int) bool { return out[i].Height < out[j].Height })
+
+	load := 0.0
+	for i := range out {
+		s := &out[i]
+		s.Height = canvas.Clamp(s.Height, 0, 1)
+		s.Thickness = canvas.Clamp(s.Thickness, 0.03, 0.5)
+		s.Density = canvas.Clamp(s.Density, 0, 1)
+		if s.Speed <= 0 {
+			s.Speed = 0.015
+		}
+		load += s.Density * s.Thickness
+	}
+	if load > densityBudget {
+		for i := range out {
+			out[i].Density *= densityBudget / load
+		}
+	}
+	return out
+}
+
+// sample reads the preset at a rank between its high (0), mid (0.5) and low
+// (1) layers.
+func (w weather) sample(rank float64) layerParams {
+	pos := canvas.Clamp(rank, 0, 1) * float64(len(w.layers)-1)
+	i := min(int(pos), len(w.layers)-2)
+	t := pos - float64(i)
+	a, b := w.layers[i], w.layers[i+1]
+	return layerParams{
+		height:    lerp(a.height, b.height, t),
+		thickness: lerp(a.thickness, b.thickness, t),
+		density:   lerp(a.density, b.density, t),
+		speed:     lerp(a.speed, b.speed, t),
+		shade:     lerp(a.shade, b.shade, t),
+	}
+}
@@ -0,0 +1,144 @@
+package cloud
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+// heavyCover is the coverage a column needs before it can rain or spark lightning.
+const heavyCover = 0.5
+
+var (
+	rainPalette = []string{
+		"\x1b[38;5;153m",
+		"\x1b[38;5;110m",
+		"\x1b[38;5;67m",
+		"\x1b[38;5;60m",
+	}
+)
+
+// column describes the cloud deck above one screen column: base is the lowest
+// cloud row (-1 for clear sky) and cover the densest coverage near that base.
+type column struct {
+	base  int
+	cover float64
+}
+
+type drop struct {
+	x, y   float64
+	vx, vy float64
+	start  float64
+	end    float64
+}
+
+// cloudColumns scans each column from the land (or the bottom) up to find the
+// cloud base.
+func cloudColumns(cover *coverMap, land terrain) []column {
+	height := len(cover.total)
+	width := len(cover.total[0])
+	columns := make([]column, width)
+	for x := range columns {
+		columns[x] = column{base: -1}
+		bottom := height - 1
+		if land.solid() {
+			bottom = land.top[x] - 1
+		}
+		for y := bottom; y >= 0; y-- {
+			if cover.peak(x, y) < coverageThreshold {
+				continue
+			}
+			c := column{base: y}
+			for dy := 0; dy < 3 && y-dy >= 0; dy++ {
+				c.cover = math.Max(c.cover, cover.peak(x, y-dy))
+			}
+			columns[x] = c
+			break
+		}
+	}
+	return columns
+}
+
+// updateRain spawns drops under heavy cloud bases and lets them fall onto the
+// land. Without any land they evaporate partway down, like virga.
+func updateRain(drops *[]drop, columns []column, intensity, wind float64, height int, land terrain, rng *rand.Rand) {
+	// Rain leans with the wind but never further than a steep slant.
+	wind = math.Max(-2, math.Min(2, wind))
+	width := len(columns)
+	attempts := int(math.Round(intensity * float64(width) * 0.25))
+	floor := float64(height - 1)
+	for i := 0; i < attempts; i++ {
+		x := rng.Intn(width)
+		c := columns[x]
+		if c.base < 0 || c.cover < heavyCover {
+			continue
+		}
+		d := drop{
+			x:     float64(x),
+			y:     float64(c.base + 1),
+			vx:    -0.15 * wind,
+			vy:    0.7 + rng.Float64()*0.5,
+			start: float64(c.base + 1),
+			end:   floor,
+		}
+		if land.solid() {
+			d.end = float64(land.top[x] - 1)
+		} else {
+			d.end = math.Min(floor, d.y+3+rng.Float64()*float64(height)/3)
+		}
+		*drops = append(*drops, d)
+	}
+
+	items := *drops
+	dst := items[:0]
+	for i := range items {
+		items[i].x += items[i].vx
+		items[i].y += items[i].vy
+		if items[i].y > items[i].end || items[i].x < 0 || items[i].x >= float64(width) {
+			continue
+		}
+		dst = append(dst, items[i])
+	}
+	*drops = dst
+}
+
+func drawRain(grid canvas.Grid, drops []drop, land terrain) {
+	for _, d := range drops {
+		fade := 0.0
+		if !land.solid() {
+			fade = (d.y - d.start) / math.Max(1, d.end-d.start)
+		}
+		color := rainPalette[min(len(rainPalette)-1, int(fade*float64(len(rainPalette))))]
+		glyph := '|'
+		switch {
+		case fade > 0.75:
+			glyph = ':'
+		case d.vx < -0.1:
+			glyph = '/'
+		case d.vx > 0.1:
+			glyph = '\\'
+		}
+		x, y := int(math.Round(d.x)), int(math.Round(d.y))
+		// Drifting drops pass behind any cloud hanging lower than where they started.
+		if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[y]) || grid[y][x].Glyph != '.' {
+			continue
+		}
+		grid.Set(x, y, glyph, color)
+	}
+}
+
+// drawSplashes flicks up a splash where drops meet the land.
+func drawSplashes(grid canvas.Grid, drops []drop, land terrain, frame int) {
+	if !land.solid() {
+		return
+	}
+	for _, d := range drops {
+		if d.end-d.y < d.vy && (frame+int(d.x))%2 == 0 {
+			x := int(math.Round(d.x))
+			if x >= 0 && x < len(land.top) {
+				grid.Set(x, land.top[x]-1, ',', rainPalette[0])
+			}
+		}
+	}
+}
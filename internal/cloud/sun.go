@@ -0,0 +1,178 @@
+package cloud
+
+import (
+	"math"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	liningReach = 3.0
+	liningCover = 0.5
+)
+
+var (
+	sunCoreColor   = "\x1b[38;5;230m"
+	sunEdgeColor   = "\x1b[38;5;220m"
+	sunLiningColor = "\x1b[38;5;229m"
+	sunRayColor    = "\x1b[38;5;186m"
+
+	moonCoreColor   = "\x1b[38;5;255m"
+	moonEdgeColor   = "\x1b[38;5;250m"
+	moonLiningColor = "\x1b[38;5;195m"
+	moonRayColor    = "\x1b[38;5;103m"
+)
+
+// lightKind selects the body shining behind the clouds.
+type lightKind int
+
+const (
+	lightNone lightKind = iota
+	lightSun
+	lightMoon
+)
+
+// light is the disc for one frame, in cell coordinates.
+type light struct {
+	kind   lightKind
+	x, y   float64
+	radius float64
+}
+
+// lightAt places the disc: at the configured spot when LightX/LightY are set,
+// otherwise on an arc across the sky that takes LightCycle to complete.
+func lightAt(cfg Config, frame int) light {
+	l := light{radius: math.Max(1.5, float64(cfg.Height)/12)}
+	switch cfg.Light {
+	case "sun":
+		l.kind = lightSun
+	case "moon":
+		l.kind = lightMoon
+	default:
+		return l
+	}
+	if cfg.LightX >= 0 && cfg.LightY >= 0 {
+		l.x = cfg.LightX * float64(cfg.Width-1)
+		l.y = cfg.LightY * float64(cfg.Height-1)
+		return l
+	}
+	elapsed := time.Duration(frame) * cfg.FrameDelay
+	u := math.Mod(float64(elapsed)/float64(cfg.LightCycle), 1)
+	l.x = u * float64(cfg.Width-1)
+	l.y = float64(cfg.Height) * (0.5 - 0.38*math.Sin(math.Pi*u))
+	return l
+}
+
+// edgeDistance is how far a cell sits outside the disc; characters are about
+// twice as tall as they are wide, so x counts half.
+func (l light) edgeDistance(x, y int) float64 {
+	dx := (float64(x) - l.x) / 2
+	dy := float64(y) - l.y
+	return math.Hypot(dx, dy) - l.radius
+}
+
+// lining returns the silver-lining color for thin cloud near the disc edge.
+func (l light) lining(x, y int, coverage float64) (string, bool) {
+	if l.kind == lightNone || coverage >= liningCover {
+		return "", false
+	}
+	if l.edgeDistance(x, y) > liningReach {
+		return "", false
+	}
+	if l.kind == lightMoon {
+		return moonLiningColor, true
+	}
+	return sunLiningColor, true
+}
+
+func drawDisc(grid canvas.Grid, l light) {
+	if l.kind == lightNone {
+		return
+	}
+	core, edge := sunCoreColor, sunEdgeColor
+	if l.kind == lightMoon {
+		core, edge = moonCoreColor, moonEdgeColor
+	}
+	reach := int(math.Ceil(l.radius))
+	cx, cy := int(math.Round(l.x)), int(math.Round(l.y))
+	for y := cy - reach; y <= cy+reach; y++ {
+		for x := cx - reach*2; x <= cx+reach*2; x++ {
+			d := l.edgeDistance(x, y)
+			switch {
+			case d <= -0.8:
+				grid.Set(x, y, 'O', core)
+			case d <= 0:
+				grid.Set(x, y, 'o', edge)
+			}
+		}
+	}
+}
+
+// drawRays fans faint crepuscular rays down from the disc through the gaps.
+// They only show while the disc is partly hidden: a clear or fully covered
+// disc casts none.
+func drawRays(grid canvas.Grid, l light, frame int) {
+	if l.kind == lightNone {
+		return
+	}
+	height := len(grid)
+	width := len(grid[0])
+
+	total, covered := 0, 0
+	reach := int(math.Ceil(l.radius + 4))
+	cx, cy := int(math.Round(l.x)), int(math.Round(l.y))
+	for y := cy - reach; y <= cy+reach; y++ {
+		for x := cx - reach*2; x <= cx+reach*2; x++ {
+			if y < 0 || y >= height || x < 0 || x >= width {
+				continue
+			}
+			d := l.edgeDistance(x, y)
+			if d <= 0 || d > 4 {
+				continue
+			}
+			total++
+			if grid[y][x].Glyph != '.' {
+				covered++
+			}
+		}
+	}
+	if total == 0 {
+		return
+	}
+	ratio := float64(covered) / float64(total)
+	if ratio < 0.2 || ratio > 0.9 {
+		return
+	}
+
+	color := sunRayColor
+	if l.kind == lightMoon {
+		color = moonRayColor
+	}
+	for i := 0; i < 7; i++ {
+		angle := math.Pi * (0.2 + 0.1*float64(i))
+		// Rays drift in and out slowly so the fan never looks static.
+		if (i*5+frame/40)%3 == 0 {
+			continue
+		}
+		dx, dy := math.Cos(angle)*2, math.Sin(angle)
+		glyph := '|'
+		switch {
+		case dx > 0.6:
+			glyph = '\\'
+		case dx < -0.6:
+			glyph = '/'
+		}
+		for t := l.radius + 2; t < l.radius+float64(height)/2; t++ {
+			x := int(math.Round(l.x + dx*t))
+			y := int(math.Round(l.y + dy*t))
+			if y < 0 || y >= height || x < 0 || x >= width {
+				break
+			}
+			if int(t)%3 != 0 || grid[y][x].Glyph != '.' {
+				continue
+			}
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+}
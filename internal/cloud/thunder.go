@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const rumbleTime = time.Second
+
+var (
+	rumbleColor = "\x1b[38;5;252m"
+	glowColor   = "\x1b[38;5;189m"
+)
+
+// thunder follows a strike: a flash of one or two frames that brightens the
+// sky, then a rumble that shimmers the underside of the lowest layer.
+type thunder struct {
+	flash  int
+	boost  int
+	rumble int
+	length int
+}
+
+// strike starts the flash and rumble; a longer bolt flashes brighter and longer.
+func (t *thunder) strike(l lightning, height int, frameDelay time.Duration) {
+	strength := l.drop / float64(height)
+	t.boost = 1
+	t.flash = 1
+	if strength > 0.5 {
+		t.boost = 2
+		t.flash = 2
+	}
+	t.length = max(1, int(rumbleTime/frameDelay))
+	t.rumble = t.length
+}
+
+// shift is how many steps brighter the sky palette should be this frame.
+func (t *thunder) shift() int {
+	if t.flash <= 0 {
+		return 0
+	}
+	return t.boost
+}
+
+func (t *thunder) advance() {
+	if t.flash > 0 {
+		t.flash--
+	}
+	if t.rumble > 0 {
+		t.rumble--
+	}
+}
+
+// drawRumble shimmers the bottom rows of the lowest layer, fading as the
+// rumble dies away.
+func (t *thunder) drawRumble(grid canvas.Grid, layer *cloudLayer, cover [][]float64, columns []column, rng *rand.Rand) {
+	if t.rumble <= 0 || len(layer.glyphs) < 2 {
+		return
+	}
+	amount := float64(t.rumble) / float64(t.length)
+	for x, c := range columns {
+		for dy := 0; dy < 3 && c.base-dy >= 0; dy++ {
+			y := c.base - dy
+			if cover[y][x] < coverageThreshold || rng.Float64() > amount*0.4 {
+				continue
+			}
+			glyph := layer.glyphs[rng.Intn(len(layer.glyphs))]
+			grid.Set(x, y, glyph, rumbleColor)
+		}
+	}
+}
+
+// drawGlow lights up the cells around the bolt instead of the whole sky.
+func drawGlow(grid canvas.Grid, l lightning) {
+	l.draw(func(px, py int, _ rune, _ int) {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -3; dx <= 3; dx++ {
+				if math.Abs(float64(dx))/3+math.Abs(float64(dy)) > 1.2 {
+					continue
+				}
+				y, x := py+dy, px+dx
+				if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[y]) {
+					continue
+				}
+				grid[y][x].Color = glowColor
+			}
+		}
+	})
+}
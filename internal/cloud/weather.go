@@ -0,0 +1,187 @@
+package cloud
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	clearSkyPalette = []string{
+		"\x1b[38;5;117m",
+		"\x1b[38;5;81m",
+		"\x1b[38;5;45m",
+		"\x1b[38;5;39m",
+	}
+	overcastSkyPalette = []string{
+		"\x1b[38;5;103m",
+		"\x1b[38;5;67m",
+		"\x1b[38;5;60m",
+		"\x1b[38;5;59m",
+	}
+	stormSkyPalette = []string{
+		"\x1b[38;5;60m",
+		"\x1b[38;5;59m",
+		"\x1b[38;5;238m",
+		"\x1b[38;5;236m",
+	}
+	// skyPalettes runs from the brightest sky to the darkest.
+	skyPalettes = [][]string{clearSkyPalette, skyPalette, overcastSkyPalette, stormSkyPalette}
+)
+
+// layerParams are the parts of a cloudLayer a weather preset controls.
+// A negative density clears the layer away entirely.
+type layerParams struct {
+	height    float64
+	thickness float64
+	density   float64
+	speed     float64
+	shade     float64
+}
+
+// weather is a full preset: the high, mid and low layers plus the
+// wind, rain intensity, lightning chance per frame and sky darkness
+// (0 bright, 1 dark).
+type weather struct {
+	layers    [3]layerParams
+	wind      float64
+	rain      float64
+	lightning float64
+	dark      float64
+}
+
+var presets = map[string]weather{
+	"clear": {
+		layers: [3]layerParams{
+			{height: 0.15, thickness: 0.08, density: 0.45, speed: 0.018, shade: 255},
+			{height: 0.38, thickness: 0.16, density: -1, speed: 0.012, shade: 252},
+			{height: 0.55, thickness: 0.2, density: -1, speed: 0.008, shade: 250},
+		},
+		wind: 0.8,
+	},
+	"fair": {
+		layers: [3]layerParams{
+			{height: 0.2, thickness: 0.14, density: 0.65, speed: 0.02, shade: 255},
+			{height: 0.38, thickness: 0.18, density: 0.45, speed: 0.014, shade: 252},
+			{height: 0.55, thickness: 0.2, density: 0.1, speed: 0.009, shade: 249},
+		},
+		wind: 1,
+		dark: 0.2,
+	},
+	"overcast": {
+		layers: [3]layerParams{
+			{height: 0.22, thickness: 0.18, density: 0.75, speed: 0.022, shade: 255},
+			{height: 0.38, thickness: 0.22, density: 0.62, speed: 0.015, shade: 250},
+			{height: 0.55, thickness: 0.28, density: 0.48, speed: 0.01, shade: 245},
+		},
+		wind:      1,
+		rain:      0.2,
+		lightning: 0.02,
+		dark:      1.0 / 3,
+	},
+	"storm": {
+		layers: [3]layerParams{
+			{height: 0.2, thickness: 0.22, density: 0.9, speed: 0.03, shade: 246},
+			{height: 0.4, thickness: 0.26, density: 0.85, speed: 0.024, shade: 242},
+			{height: 0.62, thickness: 0.34, density: 0.9, speed: 0.018, shade: 238},
+		},
+		wind:      1.8,
+		rain:      1,
+		lightning: 0.08,
+		dark:      1,
+	},
+}
+
+// weatherCycle is the order -cloud-cycle walks through, looping back to the start.
+var weatherCycle = []string{"clear", "fair", "overcast", "storm", "overcast", "fair"}
+
+// IsWeather reports whether name is a known weather preset.
+func IsWeather(name string) bool {
+	_, ok := presets[name]
+	return ok
+}
+
+// weatherAt returns the weather for a frame. Without a cycle it is the fixed
+// preset; with one it eases from each preset in weatherCycle into the next.
+func weatherAt(cfg Config, frame int) weather {
+	if cfg.Cycle <= 0 {
+		return presets[cfg.Weather]
+	}
+	elapsed := time.Duration(frame) * cfg.FrameDelay
+	pos := float64(elapsed) / float64(cfg.Cycle)
+	start := 0
+	for i, name := range weatherCycle {
+		if name == cfg.Weather {
+			start = i
+			break
+		}
+	}
+	step := int(pos)
+	from := weatherCycle[(start+step)%len(weatherCycle)]
+	to := weatherCycle[(start+step+1)%len(weatherCycle)]
+	t := pos - float64(step)
+	return blendWeather(presets[from], presets[to], t*t*(3-2*t))
+}
+
+func blendWeather(a, b weather, t float64) weather {
+	out := weather{
+		wind:      lerp(a.wind, b.wind, t),
+		rain:      lerp(a.rain, b.rain, t),
+		lightning: lerp(a.lightning, b.lightning, t),
+		dark:      lerp(a.dark, b.dark, t),
+	}
+	for i := range out.layers {
+		la, lb := a.layers[i], b.layers[i]
+		out.layers[i] = layerParams{
+			height:    lerp(la.height, lb.height, t),
+			thickness: lerp(la.thickness, lb.thickness, t),
+			density:   lerp(la.density, lb.density, t),
+			speed:     lerp(la.speed, lb.speed, t),
+			shade:     lerp(la.shade, lb.shade, t),
+		}
+	}
+	return out
+}
+
+// apply copies the preset onto the layers, reading it at each layer's rank.
+// Layers pinned by a LayerSpec keep their own shape and take only the color.
+// In stacks deeper than three the layers thin out so the sky stays visible.
+func (w weather) apply(layers []cloudLayer) {
+	for i := range layers {
+		l := &layers[i]
+		p := w.sample(l.rank)
+		l.shade = int(math.Round(p.shade))
+		l.colorSet = shadeColors(l.shade)
+		if l.spec != nil {
+			l.height = l.spec.Height
+			l.thickness = l.spec.Thickness
+			l.density = l.spec.Density
+			l.speed = l.spec.Speed
+			continue
+		}
+		l.height = p.height
+		l.thickness = p.thickness * l.crowd
+		l.density = p.density - 0.25*(1-l.crowd)
+		l.speed = p.speed
+	}
+}
+
+// skyPalette picks the sky for the darkness; brighten moves that many steps
+// toward the clear sky, as a lightning flash does.
+func (w weather) skyPalette(brighten int) []string {
+	idx := int(math.Round(w.dark*float64(len(skyPalettes)-1))) - brighten
+	return skyPalettes[max(0, min(len(skyPalettes)-1, idx))]
+}
+
+// shadeColors builds three neighbouring greys starting at an xterm grayscale index.
+func shadeColors(shade int) []string {
+	colors := make([]string, 3)
+	for i := range colors {
+		colors[i] = fmt.Sprintf("\x1b[38;5;%dm", max(232, min(255, shade-i*2)))
+	}
+	return colors
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,53 @@
+package cloud
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	gustEvery   = 8 * time.Second
+	gustRamp    = 0.04
+	gustDrift   = 2.0
+	gustStretch = 0.8
+)
+
+// gust is a passing burst of wind. strength eases toward target, which is
+// raised for the length of the gust and dropped back to zero afterwards.
+type gust struct {
+	strength float64
+	target   float64
+	frames   int
+}
+
+func (g *gust) update(enabled bool, frameDelay time.Duration, rng *rand.Rand) {
+	if g.frames > 0 {
+		g.frames--
+		if g.frames == 0 {
+			g.target = 0
+		}
+	} else if enabled && rng.Float64() < float64(frameDelay)/float64(gustEvery) {
+		g.target = 0.5 + rng.Float64()*0.5
+		seconds := 2 + rng.Float64()*3
+		g.frames = int(seconds * float64(time.Second) / float64(frameDelay))
+	}
+	switch {
+	case g.strength < g.target:
+		g.strength = math.Min(g.target, g.strength+gustRamp)
+	case g.strength > g.target:
+		g.strength = math.Max(g.target, g.strength-gustRamp)
+	}
+}
+
+// driftLayers moves each layer's noise phase on by one frame. The phase only
+// ever accumulates, so a change of wind changes the speed, never the position.
+// Shear speeds the high layer up and slows the low one down, reversing it
+// once shear passes 1.
+func driftLayers(layers []cloudLayer, wind, shear float64, g gust) {
+	for i := range layers {
+		l := &layers[i]
+		l.offset += l.speed * wind * (1 + shear*l.shearRank) * (1 + gustDrift*g.strength)
+		l.stretch = 1 + gustStretch*g.strength
+	}
+}
@@ -0,0 +1,46 @@
+package cloud
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDriftContinuousAcrossWindChange turns the wind round mid-gust and
+// checks no layer jumps: each frame moves a layer's phase by no more than
+// one frame's drift at the wind blowing then, and the cloud over a cell
+// changes no more at the turn than on any other frame.
+func TestDriftContinuousAcrossWindChange(t *testing.T) {
+	cfg := DefaultConfig().normalize()
+	layers := newLayers(cfg)
+	for i := range layers {
+		layers[i].speed = 0.02
+	}
+	g := gust{strength: 0.5}
+
+	steadiest := 0.0
+	for frame := 0; frame < 60; frame++ {
+		wind := 1.0
+		if frame >= 30 {
+			wind = -2
+		}
+		before := make([]float64, len(layers))
+		noise := make([]float64, len(layers))
+		for i := range layers {
+			before[i] = layers[i].offset
+			noise[i] = cloudNoise(10, 5, layers[i].offset, &layers[i])
+		}
+		driftLayers(layers, wind, cfg.Shear, g)
+		for i, l := range layers {
+			limit := math.Abs(l.speed*wind*(1+cfg.Shear*l.shearRank)) * (1 + gustDrift*g.strength)
+			if step := math.Abs(l.offset - before[i]); step > limit+1e-12 {
+				t.Fatalf("frame %d: layer %d moved %g, more than a frame's drift of %g", frame, i, step, limit)
+			}
+			change := math.Abs(cloudNoise(10, 5, l.offset, &layers[i]) - noise[i])
+			if frame < 30 {
+				steadiest = math.Max(steadiest, change)
+			} else if frame == 30 && change > 3*steadiest+1e-9 {
+				t.Errorf("layer %d changed by %g at the turn, against at most %g before", i, change, steadiest)
+			}
+		}
+	}
+}
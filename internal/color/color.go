@@ -0,0 +1,310 @@
+// Package color turns RGB colors into terminal escapes for whatever the
+// terminal can show: 24-bit, the 256-color cube, the 16 ANSI colors, or
+// nothing at all.
+package color
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Profile is how many colors the terminal can show.
+type Profile int
+
+const (
+	Mono Profile = iota
+	ANSI16
+	ANSI256
+	TrueColor
+)
+
+// active is the profile escapes are written for, detected at startup unless
+// Use picks another.
+var active = Detect()
+
+// Active returns the profile in use.
+func Active() Profile {
+	return active
+}
+
+// Use makes p the profile in use. Call it before starting a mode.
+func Use(p Profile) {
+	active = p
+}
+
+// Detect guesses the profile from the environment: NO_COLOR turns color off,
+// COLORTERM announces 24-bit color, and otherwise TERM decides, with the
+// 256-color cube assumed for anything it does not know.
+func Detect() Profile {
+	if os.Getenv("NO_COLOR") != "" {
+		return Mono
+	}
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	if os.Getenv("WT_SESSION") != "" {
+		return TrueColor
+	}
+	switch t := os.Getenv("TERM"); {
+	case t == "dumb":
+		return Mono
+	case t == "linux" || t == "ansi" || strings.HasPrefix(t, "vt"):
+		return ANSI16
+	}
+	return ANSI256
+}
+
+// ParseProfile reads a -color value; auto is Detect.
+func ParseProfile(name string) (Profile, error) {
+	switch name {
+	case "auto":
+		return Detect(), nil
+	case "truecolor", "24bit":
+		return TrueColor, nil
+	case "256":
+		return ANSI256, nil
+	case "16":
+		return ANSI16, nil
+	case "mono", "none":
+		return Mono, nil
+	}
+	return 0, fmt.Errorf("unknown color %q (expected auto | truecolor | 256 | 16 | mono)", name)
+}
+
+// RGB is a color with 0-255 channels.
+type RGB struct {
+	R, G, B uint8
+}
+
+// Mix is a blended t (0-1) of the way toward b.
+func Mix(a, b RGB, t float64) RGB {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return RGB{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B)}
+}
+
+// Ramp evaluates a gradient through stops into steps colors, first stop to
+// last.
+func Ramp(stops []RGB, steps int) []RGB {
+	colors := make([]RGB, steps)
+	if len(stops) == 1 || steps == 1 {
+		for i := range colors {
+			colors[i] = stops[0]
+		}
+		return colors
+	}
+	for i := range colors {
+		pos := float64(i) / float64(steps-1) * float64(len(stops)-1)
+		j := min(int(pos), len(stops)-2)
+		colors[i] = Mix(stops[j], stops[j+1], pos-float64(j))
+	}
+	return colors
+}
+
+// Render is the foreground escape for c under p; under Mono it is empty.
+func (c RGB) Render(p Profile) string {
+	if p == Mono {
+		return ""
+	}
+	return "\x1b[" + c.sgr(p, 38) + "m"
+}
+
+// sgr is the SGR parameters setting c as the foreground (base 38) or
+// background (base 48) under p, empty under Mono.
+func (c RGB) sgr(p Profile, base int) string {
+	switch p {
+	case TrueColor:
+		return fmt.Sprintf("%d;2;%d;%d;%d", base, c.R, c.G, c.B)
+	case ANSI256:
+		return fmt.Sprintf("%d;5;%d", base, nearest(c, 256))
+	case ANSI16:
+		n := nearest(c, 16)
+		if n >= 8 {
+			return strconv.Itoa(base - 38 + 90 + n - 8)
+		}
+		return strconv.Itoa(base - 38 + 30 + n)
+	}
+	return ""
+}
+
+// Pair joins a foreground escape and a second foreground escape, shown as
+// the background, into one escape, for cells that show two colors such as
+// half blocks. Either may be empty.
+func Pair(fg, bg string) string {
+	params := func(escape string) string {
+		return strings.TrimSuffix(strings.TrimPrefix(escape, "\x1b["), "m")
+	}
+	switch {
+	case bg == "":
+		return fg
+	case fg == "":
+		return "\x1b[" + "4" + params(bg)[1:] + "m"
+	}
+	return "\x1b[" + params(fg) + ";4" + params(bg)[1:] + "m"
+}
+
+// Xterm is the usual RGB value of a 256-color code.
+func Xterm(n int) RGB {
+	switch {
+	case n >= 232:
+		v := uint8(8 + (n-232)*10)
+		return RGB{v, v, v}
+	case n >= 16:
+		n -= 16
+		return RGB{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	default:
+		return ansi[n]
+	}
+}
+
+var (
+	cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+	ansi       = [16]RGB{
+		{0, 0, 0}, {128, 0, 0}, {0, 128, 0}, {128, 128, 0},
+		{0, 0, 128}, {128, 0, 128}, {0, 128, 128}, {192, 192, 192},
+		{128, 128, 128}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{0, 0, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+)
+
+// nearest is the code closest to c among the 16 ANSI colors, or among the
+// cube and gray ramp of the 256-color set, whose values do not depend on
+// the terminal's theme.
+func nearest(c RGB, colors int) int {
+	first, last := 16, 255
+	if colors == 16 {
+		first, last = 0, 15
+	}
+	best, bestDist := first, -1
+	for n := first; n <= last; n++ {
+		if d := distance(c, Xterm(n)); bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+func distance(a, b RGB) int {
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	// Weighted toward green, which the eye is most sensitive to.
+	return 2*dr*dr + 4*dg*dg + 3*db*db
+}
+
+// Downgrade rewrites the colors in an SGR escape such as "\x1b[38;5;45m" for
+// p, leaving any it can already show alone. Under Mono the colors are
+// dropped, and an escape left with nothing to set becomes empty. Anything
+// that is not an SGR escape comes back unchanged.
+func Downgrade(escape string, p Profile) string {
+	params, ok := sgrParams(escape)
+	if !ok {
+		return escape
+	}
+	var out []string
+	for i := 0; i < len(params); i++ {
+		if params[i] != "38" && params[i] != "48" || i+1 >= len(params) {
+			out = append(out, params[i])
+			continue
+		}
+		base, _ := strconv.Atoi(params[i])
+		c, depth, size, ok := extended(params[i:])
+		if !ok {
+			return escape
+		}
+		if depth <= p {
+			out = append(out, params[i:i+size]...)
+		} else if p != Mono {
+			out = append(out, c.sgr(p, base))
+		}
+		i += size - 1
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(out, ";") + "m"
+}
+
+// Parse reads the foreground and background an SGR escape sets, in any of
+// the 16-color, 256-color or 24-bit forms. hasFg and hasBg say which it
+// sets; resets and anything unreadable set neither.
+func Parse(escape string) (fg, bg RGB, hasFg, hasBg bool) {
+	params, ok := sgrParams(escape)
+	if !ok {
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		n, err := strconv.Atoi(params[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 38 || n == 48:
+			c, _, size, ok := extended(params[i:])
+			if !ok {
+				return
+			}
+			if n == 38 {
+				fg, hasFg = c, true
+			} else {
+				bg, hasBg = c, true
+			}
+			i += size - 1
+		case n >= 30 && n <= 37:
+			fg, hasFg = ansi[n-30], true
+		case n >= 90 && n <= 97:
+			fg, hasFg = ansi[n-90+8], true
+		case n >= 40 && n <= 47:
+			bg, hasBg = ansi[n-40], true
+		case n >= 100 && n <= 107:
+			bg, hasBg = ansi[n-100+8], true
+		case n == 0:
+			hasFg, hasBg = false, false
+		case n == 39:
+			hasFg = false
+		case n == 49:
+			hasBg = false
+		}
+	}
+	return
+}
+
+// sgrParams splits an SGR escape into its parameters.
+func sgrParams(escape string) ([]string, bool) {
+	body, ok := strings.CutPrefix(escape, "\x1b[")
+	if !ok {
+		return nil, false
+	}
+	body, ok = strings.CutSuffix(body, "m")
+	if !ok {
+		return nil, false
+	}
+	return strings.Split(body, ";"), true
+}
+
+// extended reads the color of a 38 or 48 at the start of params, either
+// 5;n or 2;r;g;b, with the profile needed to show it and how many
+// parameters it took.
+func extended(params []string) (c RGB, depth Profile, size int, ok bool) {
+	switch {
+	case len(params) >= 3 && params[1] == "5":
+		n, err := strconv.Atoi(params[2])
+		if err != nil || n < 0 || n > 255 {
+			return RGB{}, 0, 0, false
+		}
+		return Xterm(n), ANSI256, 3, true
+	case len(params) >= 5 && params[1] == "2":
+		var v [3]uint8
+		for k := range v {
+			n, err := strconv.Atoi(params[2+k])
+			if err != nil || n < 0 || n > 255 {
+				return RGB{}, 0, 0, false
+			}
+			v[k] = uint8(n)
+		}
+		return RGB{v[0], v[1], v[2]}, TrueColor, 5, true
+	}
+	return RGB{}, 0, 0, false
+}
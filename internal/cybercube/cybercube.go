@@ -1,12 +1,16 @@
 package cybercube
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
+	"os"
 	"sort"
-	"strings"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/space"
 	"animinterminal/internal/term"
 )
 
@@ -14,9 +18,17 @@ const (
 	cameraDistance = 4.5
 	aspectRatio    = 0.55
 	maxFitAttempts = 10
+
+	// pulse is how far the cubes swell and shrink around their size, as a
+	// share of it; + and - change it by pulseStep up to maxPulse. spinStep
+	// is how much an arrow key changes a spin, in radians per frame.
+	pulse     = 0.15
+	pulseStep = 0.05
+	maxPulse  = 0.4
+	spinStep  = 0.004
 )
 
-var baseRotationSpeed = vec3{0.022, 0.017, 0.013}
+var baseRotationSpeed = vec3{X: 0.022, Y: 0.017, Z: 0.013}
 
 var (
 	edgePalette = []string{
@@ -57,6 +69,12 @@ type Config struct {
 	Height     int
 	FrameDelay time.Duration
 	Instances  []InstanceConfig
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
 }
 
 // InstanceConfig describes how each cube copy behaves/positions itself.
@@ -79,6 +97,9 @@ func DefaultConfig() Config {
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
 	if c.Width < 48 {
 		c.Width = 48
 	}
@@ -102,8 +123,8 @@ func (ic InstanceConfig) normalize() InstanceConfig {
 	if ic.Scale <= 0 {
 		ic.Scale = 1
 	}
-	ic.OffsetX = clampFloat(ic.OffsetX, -0.9, 0.9)
-	ic.OffsetY = clampFloat(ic.OffsetY, -0.9, 0.9)
+	ic.OffsetX = canvas.Clamp(ic.OffsetX, -0.9, 0.9)
+	ic.OffsetY = canvas.Clamp(ic.OffsetY, -0.9, 0.9)
 	if ic.RotationSpeed == (vec3{}) {
 		ic.RotationSpeed = baseRotationSpeed
 	}
@@ -128,22 +149,22 @@ func defaultInstances() []InstanceConfig {
 			Scale:         0.9,
 			OffsetX:       -0.55,
 			OffsetY:       -0.12,
-			RotationSpeed: vec3{0.019, 0.021, 0.015},
-			RotationPhase: vec3{0.4, 0.1, 0.8},
+			RotationSpeed: vec3{X: 0.019, Y: 0.021, Z: 0.015},
+			RotationPhase: vec3{X: 0.4, Y: 0.1, Z: 0.8},
 		},
 		{
 			Scale:         1.05,
 			OffsetX:       0,
 			OffsetY:       0.05,
 			RotationSpeed: baseRotationSpeed,
-			RotationPhase: vec3{0.15, 0.05, 0},
+			RotationPhase: vec3{X: 0.15, Y: 0.05, Z: 0},
 		},
 		{
 			Scale:         0.78,
 			OffsetX:       0.55,
 			OffsetY:       -0.05,
-			RotationSpeed: vec3{0.017, 0.02, 0.014},
-			RotationPhase: vec3{0.7, 0.35, 0.2},
+			RotationSpeed: vec3{X: 0.017, Y: 0.02, Z: 0.014},
+			RotationPhase: vec3{X: 0.7, Y: 0.35, Z: 0.2},
 		},
 	}
 }
@@ -156,89 +177,43 @@ func defaultSingleInstance() InstanceConfig {
 	}
 }
 
-type cell struct {
-	glyph byte
-	color string
-	depth float64
-}
-
+// gridBuffer is a canvas that also keeps how deep each cell's glyph is, so
+// nearer faces hide farther ones whatever order they are drawn in.
 type gridBuffer struct {
-	width  int
-	height int
-	cells  [][]cell
+	canvas.Grid
+	depth [][]float64
 }
 
 func newGrid(width, height int) *gridBuffer {
-	g := &gridBuffer{
-		width:  width,
-		height: height,
-		cells:  make([][]cell, height),
-	}
-	for y := range g.cells {
-		g.cells[y] = make([]cell, width)
+	g := &gridBuffer{Grid: canvas.New(width, height), depth: make([][]float64, height)}
+	for y := range g.depth {
+		g.depth[y] = make([]float64, width)
 	}
 	g.Clear()
 	return g
 }
 
 func (g *gridBuffer) Clear() {
-	for y := 0; y < g.height; y++ {
-		for x := 0; x < g.width; x++ {
-			g.cells[y][x].glyph = ' '
-			g.cells[y][x].color = ""
-			g.cells[y][x].depth = math.MaxFloat64
+	g.Grid.Clear()
+	for y := range g.depth {
+		for x := range g.depth[y] {
+			g.depth[y][x] = math.MaxFloat64
 		}
 	}
 }
 
-func (g *gridBuffer) Set(x, y int, glyph byte, color string, depth float64) {
-	if y < 0 || y >= g.height {
-		return
-	}
-	if x < 0 || x >= g.width {
-		return
-	}
-	current := g.cells[y][x]
-	if current.glyph != ' ' && depth >= current.depth {
-		return
-	}
-	g.cells[y][x] = cell{glyph: glyph, color: color, depth: depth}
-}
-
-func (g *gridBuffer) SetIfEmpty(x, y int, glyph byte, color string) {
-	if y < 0 || y >= g.height {
+func (g *gridBuffer) Set(x, y int, glyph rune, color string, depth float64) {
+	if !g.In(x, y) {
 		return
 	}
-	if x < 0 || x >= g.width {
+	if g.Grid[y][x].Glyph != ' ' && depth >= g.depth[y][x] {
 		return
 	}
-	if g.cells[y][x].glyph == ' ' {
-		g.cells[y][x] = cell{glyph: glyph, color: color, depth: math.MaxFloat64}
-	}
+	g.Grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+	g.depth[y][x] = depth
 }
 
-func (g *gridBuffer) Render() {
-	var sb strings.Builder
-	sb.Grow((g.width+10)*g.height + 8)
-	sb.WriteString(term.Home)
-
-	for _, row := range g.cells {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
-
-type vec3 struct {
-	x, y, z float64
-}
+type vec3 = space.Vec3
 
 type point2D struct {
 	x, y  int
@@ -247,19 +222,19 @@ type point2D struct {
 
 type faceDef struct {
 	indices [4]int
-	glyph   byte
+	glyph   rune
 }
 
 var (
 	cubeVertices = []vec3{
-		{-1, -1, -1},
-		{1, -1, -1},
-		{1, 1, -1},
-		{-1, 1, -1},
-		{-1, -1, 1},
-		{1, -1, 1},
-		{1, 1, 1},
-		{-1, 1, 1},
+		{X: -1, Y: -1, Z: -1},
+		{X: 1, Y: -1, Z: -1},
+		{X: 1, Y: 1, Z: -1},
+		{X: -1, Y: 1, Z: -1},
+		{X: -1, Y: -1, Z: 1},
+		{X: 1, Y: -1, Z: 1},
+		{X: 1, Y: 1, Z: 1},
+		{X: -1, Y: 1, Z: 1},
 	}
 	cubeEdges = [][2]int{
 		{0, 1}, {1, 2}, {2, 3}, {3, 0},
@@ -274,7 +249,7 @@ var (
 		{indices: [4]int{1, 2, 6, 5}, glyph: '='},
 		{indices: [4]int{0, 4, 7, 3}, glyph: '='},
 	}
-	viewVector = vec3{0, 0, 1}
+	viewVector = vec3{X: 0, Y: 0, Z: 1}
 )
 
 type cubeInstanceState struct {
@@ -282,49 +257,119 @@ type cubeInstanceState struct {
 	cfg    InstanceConfig
 }
 
-// Run starts the infinite cyber cube animation loop.
-func Run(cfg Config) {
-	cfg = cfg.normalize()
-
-	instances := make([]cubeInstanceState, len(cfg.Instances))
-	for i, instCfg := range cfg.Instances {
+func newInstances(configs []InstanceConfig) []cubeInstanceState {
+	instances := make([]cubeInstanceState, len(configs))
+	for i, instCfg := range configs {
 		instances[i] = cubeInstanceState{
 			angles: instCfg.RotationPhase,
 			cfg:    instCfg,
 		}
 	}
+	return instances
+}
+
+// controls is what the keys have changed: the cubes' spins, the pulse and
+// whether everything is paused.
+type controls struct {
+	instances []cubeInstanceState
+	pulse     float64
+	paused    bool
+}
+
+// handle applies a key press. The arrows speed up or slow down the spin of
+// every cube, up and down about X and left and right about Y; + and -
+// deepen or flatten the pulse; space pauses and resumes; r puts everything
+// back as configs had it.
+func (c *controls) handle(ev term.Event, configs []InstanceConfig) {
+	nudge := vec3{}
+	switch {
+	case ev.Arrow == term.Up:
+		nudge.X = spinStep
+	case ev.Arrow == term.Down:
+		nudge.X = -spinStep
+	case ev.Arrow == term.Right:
+		nudge.Y = spinStep
+	case ev.Arrow == term.Left:
+		nudge.Y = -spinStep
+	case ev.Key == '+' || ev.Key == '=':
+		c.pulse = math.Min(maxPulse, c.pulse+pulseStep)
+	case ev.Key == '-' || ev.Key == '_':
+		c.pulse = math.Max(0, c.pulse-pulseStep)
+	case ev.Key == ' ':
+		c.paused = !c.paused
+	case ev.Key == 'r' || ev.Key == 'R':
+		*c = controls{instances: newInstances(configs), pulse: pulse}
+	}
+	for i := range c.instances {
+		speed := &c.instances[i].cfg.RotationSpeed
+		*speed = space.Add(*speed, nudge)
+	}
+}
 
-	cleanup := term.Start(true)
+// Run starts the infinite cyber cube animation loop.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	ctl := &controls{instances: newInstances(cfg.Instances), pulse: pulse}
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
 
+	events := term.Events(term.NoMouse)
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
 	grid := newGrid(cfg.Width, cfg.Height)
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
-	for frame := 0; ; frame++ {
+	// t is the time in default frames, which only moves on while running,
+	// so a pause holds the backdrop and the pulse as well as the spin.
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, false)
+	for t := 0.0; limit.Next(); {
 		grid.Clear()
-		drawBackdrop(grid, frame)
-		drawCubes(grid, instances, frame)
+		drawBackdrop(grid, int(t))
+		drawCubes(grid, ctl.instances, t, ctl.pulse)
 
-		grid.Render()
+		grid.Render(screen)
 
-		updateInstanceRotations(instances)
+		dt := clock.Tick()
+		if !ctl.paused {
+			updateInstanceRotations(ctl.instances, dt)
+			t += dt
+		}
 
-		<-ticker.C
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				ctl.handle(ev, cfg.Instances)
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
 	}
+	return nil
 }
 
 func drawBackdrop(grid *gridBuffer, frame int) {
-	height := grid.height
-	width := grid.width
+	height := grid.Height()
+	width := grid.Width()
 	for y := 0; y < height; y++ {
 		if y%4 != 0 {
 			continue
 		}
 		color := backdropPalette[(y/4+frame/30)%len(backdropPalette)]
 		for x := 0; x < width; x += 2 {
-			glyph := byte('.')
+			glyph := '.'
 			if (x/2+y+frame/8)%5 == 0 {
 				glyph = ':'
 			}
@@ -333,18 +378,17 @@ func drawBackdrop(grid *gridBuffer, frame int) {
 	}
 }
 
-func drawCubes(grid *gridBuffer, instances []cubeInstanceState, frame int) {
+func drawCubes(grid *gridBuffer, instances []cubeInstanceState, t float64, pulse float64) {
 	if len(instances) == 0 {
 		return
 	}
-	width := grid.width
-	height := grid.height
+	width := grid.Width()
+	height := grid.Height()
 	baseScale := float64(min(width, height)) * 1.25
-	pulse := 0.85 + 0.15*math.Sin(float64(frame)*0.05)
-	scale := baseScale * pulse
+	scale := baseScale * (1 - pulse + pulse*math.Sin(t*0.05))
 
 	for _, inst := range instances {
-		drawCubeInstance(grid, inst, width, height, scale, frame)
+		drawCubeInstance(grid, inst, width, height, scale, int(t))
 	}
 }
 
@@ -356,7 +400,7 @@ func drawCubeInstance(grid *gridBuffer, inst cubeInstanceState, width, height in
 
 	rotated := make([]vec3, len(cubeVertices))
 	for i, v := range cubeVertices {
-		rotated[i] = rotate(v, inst.angles.x, inst.angles.y, inst.angles.z)
+		rotated[i] = space.Rotate(v, inst.angles.X, inst.angles.Y, inst.angles.Z)
 	}
 
 	projected, fittedScale := projectToFit(rotated, width, height, instanceScale, 2)
@@ -416,12 +460,12 @@ func shiftPoints(points []point2D, dx, dy int) {
 	}
 }
 
-func updateInstanceRotations(instances []cubeInstanceState) {
+// updateInstanceRotations turns every cube by its speed for dt default
+// frames.
+func updateInstanceRotations(instances []cubeInstanceState, dt float64) {
 	for i := range instances {
-		speed := instances[i].cfg.RotationSpeed
-		instances[i].angles.x += speed.x
-		instances[i].angles.y += speed.y
-		instances[i].angles.z += speed.z
+		speed := space.Scale(instances[i].cfg.RotationSpeed, dt)
+		instances[i].angles = space.Add(instances[i].angles, speed)
 	}
 }
 
@@ -474,7 +518,7 @@ func drawGhostFrame(grid *gridBuffer, projected []point2D, frame int) {
 		color := ghostPalette[(idx+frame/6)%len(ghostPalette)]
 		from := projected[edge[0]]
 		to := projected[edge[1]]
-		points := linePoints(from.x, from.y, to.x, to.y)
+		points := canvas.LinePoints(from.x, from.y, to.x, to.y)
 		for _, p := range points {
 			depth := (from.depth+to.depth)*0.5 + 1.5
 			grid.Set(p[0], p[1], '.', color, depth)
@@ -488,8 +532,8 @@ func drawFaces(grid *gridBuffer, rotated []vec3, projected []point2D, frame int)
 		b := rotated[face.indices[1]]
 		c := rotated[face.indices[2]]
 
-		normal := cross(subtract(b, a), subtract(c, a))
-		intensity := -dot(normalize(normal), viewVector)
+		normal := space.Cross(space.Subtract(b, a), space.Subtract(c, a))
+		intensity := -space.Dot(space.Normalize(normal), viewVector)
 		if intensity <= 0 {
 			continue
 		}
@@ -510,16 +554,16 @@ func shadeForFace(intensity float64, frame int) string {
 	if levels == 0 {
 		return ""
 	}
-	idx := int(clampFloat(intensity*float64(levels-1), 0, float64(levels-1)))
+	idx := int(canvas.Clamp(intensity*float64(levels-1), 0, float64(levels-1)))
 	offset := (frame / 24) % levels
 	return faceFillPalette[(idx+offset)%levels]
 }
 
-func fillTriangle(grid *gridBuffer, a, b, c point2D, glyph byte, color string) {
+func fillTriangle(grid *gridBuffer, a, b, c point2D, glyph rune, color string) {
 	minX := max(0, min(a.x, min(b.x, c.x)))
-	maxX := min(grid.width-1, max(a.x, max(b.x, c.x)))
+	maxX := min(grid.Width()-1, max(a.x, max(b.x, c.x)))
 	minY := max(0, min(a.y, min(b.y, c.y)))
-	maxY := min(grid.height-1, max(a.y, max(b.y, c.y)))
+	maxY := min(grid.Height()-1, max(a.y, max(b.y, c.y)))
 
 	area := edgeFunction(a, b, c)
 	if area == 0 {
@@ -567,41 +611,17 @@ func edgeColor(idx int, depth float64, frame int) string {
 	if len(edgePalette) == 0 {
 		return ""
 	}
-	closeness := clampInt(int((cameraDistance+1-depth)*3), 0, len(edgePalette)-1)
+	closeness := canvas.Clamp(int((cameraDistance+1-depth)*3), 0, len(edgePalette)-1)
 	offset := (frame / 8) % len(edgePalette)
 	return edgePalette[(idx+offset+closeness)%len(edgePalette)]
 }
 
-func rotate(v vec3, ax, ay, az float64) vec3 {
-	sinX, cosX := math.Sin(ax), math.Cos(ax)
-	sinY, cosY := math.Sin(ay), math.Cos(ay)
-	sinZ, cosZ := math.Sin(az), math.Cos(az)
-
-	y := v.y*cosX - v.z*sinX
-	z := v.y*sinX + v.z*cosX
-
-	x := v.x*cosY + z*sinY
-	z = -v.x*sinY + z*cosY
-
-	x2 := x*cosZ - y*sinZ
-	y2 := x*sinZ + y*cosZ
-
-	return vec3{x: x2, y: y2, z: z}
-}
-
 func project(v vec3, scale float64, width, height int) (int, int, float64) {
-	distance := v.z + cameraDistance
-	if distance == 0 {
-		distance = 0.001
-	}
-	scaleFactor := scale / distance
-	x := int(float64(width)/2 + v.x*scaleFactor)
-	y := int(float64(height)/2 - v.y*scaleFactor*aspectRatio)
-	return x, y, distance
+	return space.Project(v, cameraDistance, scale, aspectRatio, width, height)
 }
 
 func drawEdge(grid *gridBuffer, from, to point2D, color string) {
-	points := linePoints(from.x, from.y, to.x, to.y)
+	points := canvas.LinePoints(from.x, from.y, to.x, to.y)
 	if len(points) == 0 {
 		return
 	}
@@ -621,9 +641,9 @@ func drawEdge(grid *gridBuffer, from, to point2D, color string) {
 	}
 }
 
-func edgeGlyph(dx, dy int) byte {
-	adx := abs(dx)
-	ady := abs(dy)
+func edgeGlyph(dx, dy int) rune {
+	adx := canvas.Abs(dx)
+	ady := canvas.Abs(dy)
 	switch {
 	case adx > ady*2:
 		return '-'
@@ -636,86 +656,10 @@ func edgeGlyph(dx, dy int) byte {
 	}
 }
 
-func linePoints(x0, y0, x1, y1 int) [][2]int {
-	points := make([][2]int, 0, max(abs(x1-x0), abs(y1-y0))+1)
-	dx := abs(x1 - x0)
-	sx := -1
-	if x0 < x1 {
-		sx = 1
-	}
-	dy := -abs(y1 - y0)
-	sy := -1
-	if y0 < y1 {
-		sy = 1
-	}
-	err := dx + dy
-
-	for {
-		points = append(points, [2]int{x0, y0})
-		if x0 == x1 && y0 == y1 {
-			break
-		}
-		e2 := 2 * err
-		if e2 >= dy {
-			err += dy
-			x0 += sx
-		}
-		if e2 <= dx {
-			err += dx
-			y0 += sy
-		}
-	}
-	return points
-}
-
 func lerp(a, b, t float64) float64 {
 	return a + (b-a)*t
 }
 
-func clampFloat(v, minV, maxV float64) float64 {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
-}
-
-func clampInt(v, minV, maxV int) int {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
-}
-
-func subtract(a, b vec3) vec3 {
-	return vec3{x: a.x - b.x, y: a.y - b.y, z: a.z - b.z}
-}
-
-func cross(a, b vec3) vec3 {
-	return vec3{
-		x: a.y*b.z - a.z*b.y,
-		y: a.z*b.x - a.x*b.z,
-		z: a.x*b.y - a.y*b.x,
-	}
-}
-
-func dot(a, b vec3) float64 {
-	return a.x*b.x + a.y*b.y + a.z*b.z
-}
-
-func normalize(v vec3) vec3 {
-	mag := math.Sqrt(v.x*v.x + v.y*v.y + v.z*v.z)
-	if mag == 0 {
-		return vec3{}
-	}
-	return vec3{x: v.x / mag, y: v.y / mag, z: v.z / mag}
-}
-
 func glowForDepth(depth float64) string {
 	switch {
 	case depth < cameraDistance-1.2:
@@ -729,27 +673,6 @@ func glowForDepth(depth float64) string {
 	}
 }
 
-func abs(v int) int {
-	if v < 0 {
-		return -v
-	}
-	return v
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 func cloneInstances(src []InstanceConfig) []InstanceConfig {
 	out := make([]InstanceConfig, len(src))
 	copy(out, src)
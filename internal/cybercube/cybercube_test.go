@@ -0,0 +1,41 @@
+package cybercube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"animinterminal/internal/term"
+)
+
+// TestRunContextCancel embeds the cube: cancelling its context has to end
+// the run within a frame and leave the terminal as it was.
+func TestRunContextCancel(t *testing.T) {
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Output = &out
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- RunContext(ctx, cfg) }()
+
+	time.Sleep(5 * cfg.FrameDelay)
+	cancel()
+	cancelled := time.Now()
+	select {
+	case err := <-done:
+		if took := time.Since(cancelled); took > cfg.FrameDelay {
+			t.Errorf("returned %v after cancelling, more than a frame (%v)", took, cfg.FrameDelay)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("returned %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("did not return after cancelling")
+	}
+	if !strings.HasSuffix(out.String(), term.ShowCursor+term.Reset) {
+		t.Error("terminal not put back after cancelling")
+	}
+}
@@ -0,0 +1,93 @@
+package cybercube
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/space"
+)
+
+// GridInstances tiles the screen with as many cubes as fit a width by height
+// terminal comfortably, each sized to its own tile. Cells are about twice as
+// tall as they are wide, so a tile is twice as many columns as rows.
+func GridInstances(width, height int) []InstanceConfig {
+	rows := canvas.Clamp(height/16, 1, 4)
+	cols := canvas.Clamp(int(math.Round(float64(width)/(2*float64(height)/float64(rows)))), 1, 8)
+	// A cube's size goes by the shorter side of the whole screen; bring it
+	// down to the shorter side of a tile.
+	tile := math.Min(float64(height)/float64(rows), float64(width)/float64(cols)/2)
+	scale := 1.1 * tile / float64(max(1, min(width, height)))
+
+	instances := make([]InstanceConfig, 0, rows*cols)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			n := float64(len(instances))
+			instances = append(instances, InstanceConfig{
+				Scale:   scale,
+				OffsetX: 2 * ((float64(col)+0.5)/float64(cols) - 0.5),
+				OffsetY: 2 * ((float64(row)+0.5)/float64(rows) - 0.5),
+				// Each cube a little out of step with the last.
+				RotationSpeed: space.Scale(baseRotationSpeed, 1+0.08*math.Sin(n*1.7)),
+				RotationPhase: vec3{X: n * 0.7, Y: n * 0.45, Z: n * 0.3},
+			}.normalize())
+		}
+	}
+	return instances
+}
+
+// ParseInstances reads a layout of cubes separated by semicolons, each a
+// comma separated list of key=value fields:
+//
+//	scale=0.8,x=-0.5,y=0.1,speed=0.02;scale=1.2,x=0.5
+//
+// scale is the size relative to the screen (default 1), x and y the offset
+// from the center from -0.9 to 0.9, where out-of-range ones are held, and
+// speed the spin in radians per frame (default 0.022, the other axes in
+// proportion).
+func ParseInstances(spec string) ([]InstanceConfig, error) {
+	var instances []InstanceConfig
+	for i, cube := range strings.Split(spec, ";") {
+		if strings.TrimSpace(cube) == "" {
+			continue
+		}
+		ic := InstanceConfig{RotationSpeed: baseRotationSpeed}
+		for _, field := range strings.Split(cube, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("cube %d: %q is not key=value", i+1, field)
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("cube %d: %s %q is not a number", i+1, key, value)
+			}
+			switch key {
+			case "scale":
+				if v <= 0 {
+					return nil, fmt.Errorf("cube %d: scale %v must be above 0", i+1, v)
+				}
+				ic.Scale = v
+			case "x":
+				ic.OffsetX = v
+			case "y":
+				ic.OffsetY = v
+			case "speed":
+				ic.RotationSpeed = space.Scale(baseRotationSpeed, v/baseRotationSpeed.X)
+			default:
+				return nil, fmt.Errorf("cube %d: unknown key %q (expected scale | x | y | speed)", i+1, key)
+			}
+		}
+		instances = append(instances, ic.normalize())
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no cubes in %q", spec)
+	}
+	return instances, nil
+}
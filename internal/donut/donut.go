@@ -0,0 +1,170 @@
+package donut
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/space"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// viewDistance is how far the camera sits from the torus center, in
+	// units of its outer radius.
+	viewDistance = 4.0
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+)
+
+var (
+	// luminance is the classic ramp from barely lit to facing the light.
+	luminance = []rune(".,-~:;=!*#$@")
+	palettes  = map[string][]string{
+		"mono": {"\x1b[38;5;240m", "\x1b[38;5;244m", "\x1b[38;5;248m", "\x1b[38;5;252m", "\x1b[38;5;231m"},
+		"neon": {"\x1b[38;5;54m", "\x1b[38;5;92m", "\x1b[38;5;129m", "\x1b[38;5;171m", "\x1b[38;5;213m", "\x1b[38;5;225m"},
+		"fire": {"\x1b[38;5;52m", "\x1b[38;5;124m", "\x1b[38;5;202m", "\x1b[38;5;214m", "\x1b[38;5;226m", "\x1b[38;5;230m"},
+		"ice":  {"\x1b[38;5;17m", "\x1b[38;5;25m", "\x1b[38;5;33m", "\x1b[38;5;45m", "\x1b[38;5;123m", "\x1b[38;5;195m"},
+	}
+	light = space.Normalize(space.Vec3{X: 0, Y: 1, Z: -1})
+)
+
+// Config controls the donut animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Ratio is the major radius over the minor one: how wide the ring is
+	// compared to its tube.
+	Ratio float64
+	// SpinX and SpinZ are the rotation speeds about the two axes, in
+	// radians per frame.
+	SpinX float64
+	SpinZ float64
+	// Palette names the shading colors: mono, neon, fire or ice.
+	Palette string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Ratio:      2,
+		SpinX:      0.04,
+		SpinZ:      0.02,
+		Palette:    "neon",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Ratio <= 1 {
+		c.Ratio = 2
+	}
+	if !IsPalette(c.Palette) {
+		c.Palette = "neon"
+	}
+	return c
+}
+
+// IsPalette reports whether name is a donut shading palette.
+func IsPalette(name string) bool {
+	_, ok := palettes[name]
+	return ok
+}
+
+// Run launches the spinning donut.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	depth := space.NewDepthBuffer(cfg.Width, cfg.Height)
+	palette := palettes[cfg.Palette]
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		depth.Clear()
+		a := float64(frame) * cfg.SpinX
+		b := float64(frame) * cfg.SpinZ
+		drawTorus(grid, depth, cfg.Ratio, a, b, palette)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawTorus sweeps a circle of the tube's radius around the ring, turns the
+// whole torus by a about X and b about Z, and plots each point nearest
+// first with the glyph and color of how squarely it faces the light.
+func drawTorus(grid canvas.Grid, depth *space.DepthBuffer, ratio, a, b float64, palette []string) {
+	height := len(grid)
+	width := len(grid[0])
+	minor := 1 / (1 + ratio)
+	major := ratio / (1 + ratio)
+	// Fit the outer edge to most of the screen height; cells are twice as
+	// tall as wide, so x gets the extra stretch.
+	scale := float64(height) * viewDistance * 0.7
+	for theta := 0.0; theta < 2*math.Pi; theta += 0.07 {
+		cosT, sinT := math.Cos(theta), math.Sin(theta)
+		circle := space.Vec3{X: major + minor*cosT, Y: minor * sinT}
+		normal := space.Vec3{X: cosT, Y: sinT}
+		for phi := 0.0; phi < 2*math.Pi; phi += 0.02 {
+			p := space.Rotate(space.Rotate(circle, 0, phi, 0), a, 0, b)
+			n := space.Rotate(space.Rotate(normal, 0, phi, 0), a, 0, b)
+			z := p.Z + viewDistance
+			x := int(float64(width)/2 + scale*cellAspect*p.X/z/2)
+			y := int(float64(height)/2 - scale*p.Y/z/2)
+			lit := space.Dot(n, light)
+			if lit <= 0 || !depth.Closer(x, y, z) {
+				continue
+			}
+			glyph := luminance[min(len(luminance)-1, int(lit*float64(len(luminance))))]
+			color := palette[min(len(palette)-1, int(lit*float64(len(palette))))]
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+}
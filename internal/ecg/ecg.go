@@ -0,0 +1,434 @@
+package ecg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 60
+	minHeight = 26
+	// panelWidth is the room on the right for the numbers.
+	panelWidth = 26
+	// columnSeconds is how much time each column of trace covers.
+	columnSeconds = 0.04
+	// subSamples is how many times the waveform is read per column.
+	subSamples = 8
+	// eraseGap is how many columns are wiped ahead of the write head.
+	eraseGap = 4
+	// hrLimit is the heart rate the tachycardia alarm goes off above.
+	hrLimit = 120
+	// flatSeconds is how long without a beat before the pulse is lost.
+	flatSeconds = 3
+)
+
+var (
+	ecgColor    = "\x1b[38;5;46m"
+	plethColor  = "\x1b[38;5;51m"
+	bpColor     = "\x1b[38;5;211m"
+	headColor   = "\x1b[38;5;231m"
+	labelColor  = "\x1b[38;5;250m"
+	borderColor = "\x1b[38;5;240m"
+	alarmColor  = "\x1b[38;5;196m"
+	warnColor   = "\x1b[38;5;226m"
+	// levelGlyphs draw a flat stretch of trace in the top, middle or
+	// bottom third of a cell.
+	levelGlyphs = []rune{'\'', '-', '_'}
+)
+
+// Config controls the monitor.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// BPM is the patient's resting heart rate.
+	BPM float64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 30 * time.Millisecond,
+		BPM:        72,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 30 * time.Millisecond
+	}
+	if c.BPM <= 0 {
+		c.BPM = 72
+	}
+	c.BPM = math.Max(30, math.Min(c.BPM, 220))
+	return c
+}
+
+// vitals are the numbers on the panel. They are read off the patient once
+// a second, the blood pressure less often, as a real monitor would.
+type vitals struct {
+	hr, spo2 float64
+	sys, dia float64
+	// pulse is false once no beat has come for flatSeconds.
+	pulse bool
+}
+
+// monitor writes the traces column by column as time passes and keeps the
+// readings up to date.
+type monitor struct {
+	pt        *patient
+	ecg       *trace
+	pleth     *trace
+	head      int
+	owed      float64
+	clock     float64
+	lastBeat  float64
+	intervals []float64
+	vitals    vitals
+	readings  int
+	rng       *rand.Rand
+}
+
+func newMonitor(bpm float64, width int, rng *rand.Rand) *monitor {
+	return &monitor{
+		pt:     newPatient(bpm, rng),
+		ecg:    newTrace(width),
+		pleth:  newTrace(width),
+		vitals: vitals{hr: bpm, spo2: 98, sys: 118, dia: 76, pulse: true},
+		rng:    rng,
+	}
+}
+
+// advance runs the monitor on by dt seconds.
+func (m *monitor) advance(dt float64) {
+	next := math.Floor(m.clock) + 1
+	m.owed += dt / columnSeconds
+	for ; m.owed >= 1; m.owed-- {
+		m.writeColumn()
+	}
+	if m.clock >= next {
+		m.read()
+	}
+}
+
+// writeColumn samples the patient across one column's worth of time and
+// clears the gap ahead of it.
+func (m *monitor) writeColumn() {
+	x := m.head
+	ecgLo, ecgHi := math.Inf(1), math.Inf(-1)
+	plethLo, plethHi := math.Inf(1), math.Inf(-1)
+	var e, p float64
+	for i := 0; i < subSamples; i++ {
+		var beat bool
+		e, p, beat = m.pt.step(columnSeconds / subSamples)
+		m.clock += columnSeconds / subSamples
+		if beat {
+			m.intervals = append(m.intervals, m.clock-m.lastBeat)
+			if len(m.intervals) > 4 {
+				m.intervals = m.intervals[1:]
+			}
+			m.lastBeat = m.clock
+		}
+		ecgLo, ecgHi = math.Min(ecgLo, e), math.Max(ecgHi, e)
+		plethLo, plethHi = math.Min(plethLo, p), math.Max(plethHi, p)
+	}
+	m.ecg.lo[x], m.ecg.hi[x], m.ecg.end[x] = ecgLo, ecgHi, e
+	m.pleth.lo[x], m.pleth.hi[x], m.pleth.end[x] = plethLo, plethHi, p
+
+	width := len(m.ecg.end)
+	m.head = (m.head + 1) % width
+	for i := 0; i < eraseGap; i++ {
+		m.ecg.erase((m.head + i) % width)
+		m.pleth.erase((m.head + i) % width)
+	}
+}
+
+// read updates the numbers, drifting them a little so they look alive.
+func (m *monitor) read() {
+	v := &m.vitals
+	m.readings++
+	v.pulse = m.clock-m.lastBeat < flatSeconds
+	if !v.pulse {
+		v.hr = 0
+		return
+	}
+	if len(m.intervals) > 0 {
+		sum := 0.0
+		for _, rr := range m.intervals {
+			sum += rr
+		}
+		v.hr = 60 / (sum / float64(len(m.intervals)))
+	}
+
+	spo2, sys, dia := 98.0, 118.0, 76.0
+	if m.pt.rhythm == tachycardia {
+		spo2, sys, dia = 94, 146, 94
+	}
+	v.spo2 += (spo2-v.spo2)*0.2 + (m.rng.Float64()*2-1)*0.4
+	v.spo2 = math.Min(v.spo2, 100)
+	if m.readings%5 == 0 {
+		v.sys += (sys-v.sys)*0.4 + (m.rng.Float64()*2-1)*3
+		v.dia += (dia-v.dia)*0.4 + (m.rng.Float64()*2-1)*2
+	}
+}
+
+// Run launches the monitor. 'a' sets off a run of tachycardia and 'f'
+// flatlines the patient; pressing either again brings them back.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	m := newMonitor(cfg.BPM, cfg.Width-panelWidth-5, rng)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		m.advance(cfg.FrameDelay.Seconds())
+		drawMonitor(grid, m)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case k := <-keys:
+				switch k {
+				case 'a', 'A':
+					m.pt.rhythm = toggle(m.pt.rhythm, tachycardia)
+				case 'f', 'F':
+					m.pt.rhythm = toggle(m.pt.rhythm, asystole)
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+// toggle switches to r, or back to a normal rhythm if already in it.
+func toggle(current, r rhythm) rhythm {
+	if current == r {
+		return sinus
+	}
+	return r
+}
+
+// drawMonitor lays out the screen: a frame that turns red on alarm, the
+// header, the ECG over the pleth on the left, and the numbers on the right.
+func drawMonitor(grid canvas.Grid, m *monitor) {
+	grid.Clear()
+	height := len(grid)
+	width := len(grid[0])
+	v := m.vitals
+	flash := int(m.clock*3)%2 == 0
+	tachy := v.pulse && v.hr > hrLimit
+
+	frame := borderColor
+	switch {
+	case !v.pulse:
+		frame = alarmColor
+	case tachy && flash:
+		frame = alarmColor
+	case tachy:
+		frame = warnColor
+	}
+	drawBox(grid, 0, 0, width, height, frame)
+	panel := width - panelWidth - 1
+	for y := 1; y < height-1; y++ {
+		grid.Set(panel-1, y, '|', borderColor)
+	}
+
+	grid.Text(2, 1, "BED 04  ADULT", labelColor)
+	clock := time.Now().Format("15:04:05")
+	grid.Text(width-2-len(clock), 1, clock, labelColor)
+	traceWidth := len(m.ecg.end)
+	switch {
+	case !v.pulse:
+		grid.Text(2+(traceWidth-20)/2, 1, "*** ASYSTOLE ***", alarmColor)
+		// A flatline alarm is one unbroken tone.
+		for x := 2; x < 2+traceWidth; x++ {
+			grid.Set(x, 2, '=', alarmColor)
+		}
+	case tachy && flash:
+		grid.Text(2+(traceWidth-20)/2, 1, "!!! TACHYCARDIA !!!", alarmColor)
+	}
+
+	top := 3
+	rows := height - 1 - top
+	ecgRows := rows * 3 / 5
+	grid.Text(2, top, "II", ecgColor)
+	drawChannel(grid, m.ecg, top+1, ecgRows-1, 12, -0.45, 1.1, m.head, ecgColor)
+	grid.Text(2, top+ecgRows, "PLETH", plethColor)
+	drawChannel(grid, m.pleth, top+ecgRows+1, rows-ecgRows-2, 8, -0.1, 1.05, m.head, plethColor)
+
+	hrColor := ecgColor
+	if tachy && flash || !v.pulse {
+		hrColor = alarmColor
+	}
+	grid.Text(panel+1, top, "HR", ecgColor)
+	grid.Text(panel+4, top, "bpm", labelColor)
+	if v.pulse && m.clock-m.lastBeat < 0.2 {
+		grid.Text(panel+8, top, "<3", alarmColor)
+	}
+	grid.Text(panel+panelWidth-6, top, fmt.Sprintf(">%d", hrLimit), labelColor)
+	hr := "---"
+	if v.pulse {
+		hr = fmt.Sprintf("%3.0f", v.hr)
+	}
+	drawNumber(grid, hr, panel+1, top+1, 2, hrColor)
+
+	y := top + fontHeight + 2
+	grid.Text(panel+1, y, "SpO2", plethColor)
+	grid.Text(panel+6, y, "%", labelColor)
+	spo2 := " --"
+	if v.pulse {
+		spo2 = fmt.Sprintf("%3.0f", v.spo2)
+	}
+	drawNumber(grid, spo2, panel+1, y+1, 2, plethColor)
+
+	y += fontHeight + 2
+	grid.Text(panel+1, y, "NIBP", bpColor)
+	grid.Text(panel+6, y, "mmHg", labelColor)
+	bp, mean := "---/--", "(--)"
+	if v.pulse {
+		bp = fmt.Sprintf("%3.0f/%.0f", v.sys, v.dia)
+		mean = fmt.Sprintf("(%.0f)", (v.sys+2*v.dia)/3)
+	}
+	drawNumber(grid, bp, panel+1, y+1, 1, bpColor)
+	grid.Text(panel+1, y+fontHeight+1, mean, bpColor)
+}
+
+// drawChannel draws t centered in the rows from top, no taller than tallest
+// rows; a trace stretched much further gets too jagged to read.
+func drawChannel(grid canvas.Grid, t *trace, top, rows, tallest int, lo, hi float64, head int, color string) {
+	used := min(rows, tallest)
+	drawTrace(grid, t, 2, top+(rows-used)/2, used, lo, hi, head, color)
+}
+
+// drawTrace draws t into the rows from top, scaling lo to hi onto three
+// levels a cell so slopes come out smooth. Each column joins the one before
+// it, and the column under the write head is brightest.
+func drawTrace(grid canvas.Grid, t *trace, left, top, rows int, lo, hi float64, head int, color string) {
+	if rows < 1 {
+		return
+	}
+	levels := rows * 3
+	level := func(v float64) int {
+		l := int((hi - v) / (hi - lo) * float64(levels))
+		return max(0, min(levels-1, l))
+	}
+	// Keep the baseline in the middle of its row so it reads as a line.
+	shift := 1 - level(0)%3
+	newest := (head - 1 + len(t.end)) % len(t.end)
+	for x := range t.end {
+		if t.blank(x) {
+			continue
+		}
+		low, high := t.lo[x], t.hi[x]
+		slope := '|'
+		if x > 0 && !t.blank(x-1) {
+			low = math.Min(low, t.end[x-1])
+			high = math.Max(high, t.end[x-1])
+			slope = '\\'
+			if t.end[x] > t.end[x-1] {
+				slope = '/'
+			}
+		}
+		c := color
+		if x == newest {
+			c = headColor
+		}
+		upper := max(0, min(levels-1, level(high)+shift))
+		lower := max(0, min(levels-1, level(low)+shift))
+		if upper/3 == lower/3 && lower-upper <= 1 {
+			grid.Set(left+x, top+upper/3, levelGlyphs[(upper+lower)/2%3], c)
+			continue
+		}
+		// Gentle slopes lean; anything steeper than two rows a column is
+		// drawn upright.
+		if lower/3-upper/3 > 1 {
+			slope = '|'
+		}
+		for y := upper / 3; y <= lower/3; y++ {
+			grid.Set(left+x, top+y, slope, c)
+		}
+	}
+}
+
+// drawNumber writes text in the big font, each pixel scale cells wide.
+func drawNumber(grid canvas.Grid, text string, left, top, scale int, color string) {
+	for i := 0; i < len(text); i++ {
+		for gy := 0; gy < fontHeight; gy++ {
+			for gx := 0; gx < 3; gx++ {
+				if !lit(text[i], gx, gy) {
+					continue
+				}
+				for s := 0; s < scale; s++ {
+					grid.Set(left+(i*4+gx)*scale+s, top+gy, '#', color)
+				}
+			}
+		}
+	}
+}
+
+func drawBox(grid canvas.Grid, left, top, width, height int, color string) {
+	right, bottom := left+width-1, top+height-1
+	for x := left; x <= right; x++ {
+		grid.Set(x, top, '-', color)
+		grid.Set(x, bottom, '-', color)
+	}
+	for y := top; y <= bottom; y++ {
+		grid.Set(left, y, '|', color)
+		grid.Set(right, y, '|', color)
+	}
+	for _, p := range [][2]int{{left, top}, {right, top}, {left, bottom}, {right, bottom}} {
+		grid.Set(p[0], p[1], '+', color)
+	}
+}
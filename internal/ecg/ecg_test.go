@@ -0,0 +1,28 @@
+package ecg
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// TestSeed checks the patient follows the seed: the same seed beats the
+// same way twice, another seed differently. It runs the monitor rather than
+// the mode, whose screen also shows the time of day.
+func TestSeed(t *testing.T) {
+	run := func(seed int64) string {
+		m := newMonitor(72, 60, rand.New(rand.NewSource(seed)))
+		for i := 0; i < 400; i++ {
+			m.advance(0.03)
+		}
+		// Sprint rather than comparing floats, as blank columns are NaN.
+		return fmt.Sprint(m.ecg.end, m.pleth.end, m.vitals)
+	}
+	first := run(7)
+	if first != run(7) {
+		t.Error("two runs with seed 7 differ")
+	}
+	if first == run(8) {
+		t.Error("seeds 7 and 8 beat the same")
+	}
+}
@@ -0,0 +1,31 @@
+package ecg
+
+// fontHeight is the number of rows in every glyph of font.
+const fontHeight = 5
+
+// font is a 3x5 pixel font for the readouts; '#' is lit.
+var font = map[byte][fontHeight]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", " ##", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", " # ", " # "},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'-': {"   ", "   ", "###", "   ", "   "},
+	'/': {"  #", "  #", " # ", "#  ", "#  "},
+	' ': {"   ", "   ", "   ", "   ", "   "},
+}
+
+// lit reports whether pixel x, y of ch is on. Characters missing from the
+// font are blank.
+func lit(ch byte, x, y int) bool {
+	rows, ok := font[ch]
+	if !ok || y < 0 || y >= fontHeight || x < 0 || x >= len(rows[y]) {
+		return false
+	}
+	return rows[y][x] == '#'
+}
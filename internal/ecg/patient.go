@@ -0,0 +1,112 @@
+package ecg
+
+import (
+	"math"
+	"math/rand"
+)
+
+// rhythm is what the heart is doing.
+type rhythm int
+
+const (
+	sinus rhythm = iota
+	tachycardia
+	asystole
+)
+
+const (
+	// tachyRate is the heart rate the tachycardia alarm climbs to.
+	tachyRate = 165
+	// variability is how much each beat's length strays from the rate.
+	variability = 0.04
+	// ease is how quickly, per second, the rate follows a change of rhythm.
+	ease = 0.6
+)
+
+// bump is a bell curve of height amp and width w centered at c.
+func bump(p, c, w, amp float64) float64 {
+	d := (p - c) / w
+	return amp * math.Exp(-d*d)
+}
+
+// ecgWave is lead II through one beat, phase 0 to 1: the small P wave, the
+// sharp Q, R and S of the QRS complex, then the broad T wave.
+func ecgWave(p float64) float64 {
+	return bump(p, 0.16, 0.03, 0.12) +
+		bump(p, 0.31, 0.008, -0.12) +
+		bump(p, 0.33, 0.009, 1) +
+		bump(p, 0.355, 0.01, -0.28) +
+		bump(p, 0.58, 0.05, 0.3)
+}
+
+// plethWave is the pulse oximeter's trace through one beat: it lags the
+// R wave, rises steeply, and has the dicrotic notch on the way down.
+func plethWave(p float64) float64 {
+	p = math.Mod(p+0.6, 1)
+	return bump(p, 0.22, 0.09, 0.9) + bump(p, 0.45, 0.08, 0.35)
+}
+
+// patient is the heart under the monitor. It is stepped in small slices of
+// time and reports the two traces and when each beat lands.
+type patient struct {
+	rhythm rhythm
+	rate   float64
+	base   float64
+	beat   float64
+	phase  float64
+	rng    *rand.Rand
+}
+
+func newPatient(bpm float64, rng *rand.Rand) *patient {
+	return &patient{rate: bpm, base: bpm, beat: bpm, rng: rng}
+}
+
+// step moves the patient on by dt seconds and returns the ECG and pleth
+// levels, and whether a beat started.
+func (pt *patient) step(dt float64) (float64, float64, bool) {
+	target := pt.base
+	if pt.rhythm == tachycardia {
+		target = tachyRate
+	}
+	pt.rate += (target - pt.rate) * math.Min(1, ease*dt)
+
+	pt.phase += dt * pt.beat / 60
+	started := false
+	if pt.phase >= 1 {
+		pt.phase -= 1
+		pt.beat = pt.rate * (1 + (pt.rng.Float64()*2-1)*variability)
+		started = pt.rhythm != asystole
+	}
+	if pt.rhythm == asystole {
+		return (pt.rng.Float64() - 0.5) * 0.01, 0, false
+	}
+	return ecgWave(pt.phase), plethWave(pt.phase), started
+}
+
+// trace is one sweep of a monitor channel, a column at a time. Each column
+// keeps the lowest and highest level seen while it was written and the
+// level it ended on, so a spike narrower than a column still shows.
+type trace struct {
+	lo, hi, end []float64
+}
+
+func newTrace(width int) *trace {
+	t := &trace{
+		lo:  make([]float64, width),
+		hi:  make([]float64, width),
+		end: make([]float64, width),
+	}
+	for x := range t.end {
+		t.erase(x)
+	}
+	return t
+}
+
+func (t *trace) erase(x int) {
+	t.lo[x], t.hi[x], t.end[x] = math.NaN(), math.NaN(), math.NaN()
+}
+
+// blank reports whether column x has nothing on it.
+func (t *trace) blank(x int) bool {
+	return math.IsNaN(t.end[x])
+}
@@ -0,0 +1,245 @@
+package fire
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// maxHeat is the temperature of the feeding row; everything above it
+	// cools from there.
+	maxHeat = 36
+)
+
+var (
+	// heatPalette runs black, dark red, red, orange, yellow to white.
+	heatPalette = []string{
+		"\x1b[38;5;16m",
+		"\x1b[38;5;52m",
+		"\x1b[38;5;88m",
+		"\x1b[38;5;124m",
+		"\x1b[38;5;160m",
+		"\x1b[38;5;196m",
+		"\x1b[38;5;202m",
+		"\x1b[38;5;208m",
+		"\x1b[38;5;214m",
+		"\x1b[38;5;220m",
+		"\x1b[38;5;226m",
+		"\x1b[38;5;227m",
+		"\x1b[38;5;229m",
+		"\x1b[38;5;230m",
+		"\x1b[38;5;231m",
+	}
+	glyphPalette = []rune{' ', '.', ',', ':', ';', '+', '*', '%', '#', '@'}
+)
+
+// Config controls the fire animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Intensity is how high the flames reach, 0-1.
+	Intensity float64
+	// Wind bends the flames sideways, -1 (left) to 1 (right).
+	Wind float64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 45 * time.Millisecond,
+		Intensity:  0.7,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 45 * time.Millisecond
+	}
+	if c.Intensity <= 0 {
+		c.Intensity = 0.7
+	}
+	if c.Intensity > 1 {
+		c.Intensity = 1
+	}
+	if c.Wind < -1 {
+		c.Wind = -1
+	}
+	if c.Wind > 1 {
+		c.Wind = 1
+	}
+	return c
+}
+
+// flames is the heat buffer, one value per cell, row by row.
+type flames struct {
+	width, height int
+	heat          []int
+	// cooling is the average heat a cell loses on its way up one row.
+	cooling float64
+	wind    float64
+	lit     bool
+	rng     *rand.Rand
+}
+
+func newFlames(cfg Config, rng *rand.Rand) *flames {
+	reach := 0.3 + 0.6*cfg.Intensity
+	f := &flames{
+		width:   cfg.Width,
+		height:  cfg.Height,
+		heat:    make([]int, cfg.Width*cfg.Height),
+		cooling: maxHeat / (float64(cfg.Height) * reach),
+		wind:    cfg.Wind,
+		rng:     rng,
+	}
+	f.ignite()
+	return f
+}
+
+// ignite feeds the bottom row at full heat; douse stops feeding it so the
+// fire burns down.
+func (f *flames) ignite() {
+	f.lit = true
+	row := f.heat[(f.height-1)*f.width:]
+	for x := range row {
+		row[x] = maxHeat
+	}
+}
+
+func (f *flames) douse() {
+	f.lit = false
+	row := f.heat[(f.height-1)*f.width:]
+	for x := range row {
+		row[x] = 0
+	}
+}
+
+// spread is the classic propagation: every cell passes its heat to the row
+// above, a little cooler and jittered sideways, with the wind tipping the
+// jitter one way. While lit, the bottom row flickers just under full heat.
+func (f *flames) spread() {
+	w := f.width
+	if f.lit {
+		row := f.heat[(f.height-1)*w:]
+		for x := range row {
+			row[x] = maxHeat - f.rng.Intn(6)
+		}
+	}
+	for x := 0; x < w; x++ {
+		for y := 1; y < f.height; y++ {
+			from := y*w + x
+			heat := f.heat[from]
+			jitter := f.rng.Intn(3) - 1
+			if f.rng.Float64() < math.Abs(f.wind) {
+				jitter += int(math.Copysign(1, f.wind))
+			}
+			to := (y-1)*w + (x+jitter+w)%w
+			if heat == 0 {
+				f.heat[to] = 0
+				continue
+			}
+			cool := int(-math.Log(1-f.rng.Float64())*f.cooling + 0.5)
+			f.heat[to] = max(0, heat-cool)
+		}
+	}
+}
+
+// Run launches the fire animation. Space puts the fire out and lights it
+// again.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	fire := newFlames(cfg, rng)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		fire.spread()
+		drawFlames(grid, fire)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case k := <-keys:
+				if k != ' ' {
+					continue
+				}
+				if fire.lit {
+					fire.douse()
+				} else {
+					fire.ignite()
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+// drawFlames maps the heat onto the palette and glyph ramps.
+func drawFlames(grid canvas.Grid, f *flames) {
+	for y := range grid {
+		for x := range grid[y] {
+			heat := f.heat[y*f.width+x]
+			if heat == 0 {
+				grid[y][x] = canvas.Cell{Glyph: ' '}
+				continue
+			}
+			t := float64(heat) / maxHeat
+			glyph := glyphPalette[min(len(glyphPalette)-1, 1+int(t*float64(len(glyphPalette)-1)))]
+			color := heatPalette[min(len(heatPalette)-1, int(t*float64(len(heatPalette))))]
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+}
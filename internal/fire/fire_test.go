@@ -0,0 +1,32 @@
+package fire
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSeed checks the flames follow Config.Seed: the same seed burns the
+// same way twice, another seed differently.
+func TestSeed(t *testing.T) {
+	run := func(seed int64) []byte {
+		var out bytes.Buffer
+		cfg := DefaultConfig()
+		cfg.FrameDelay = time.Millisecond
+		cfg.MaxFrames = 30
+		cfg.Seed = seed
+		cfg.Output = &out
+		if err := RunContext(context.Background(), cfg); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+	first := run(7)
+	if !bytes.Equal(first, run(7)) {
+		t.Error("two runs with seed 7 differ")
+	}
+	if bytes.Equal(first, run(8)) {
+		t.Error("seeds 7 and 8 burn the same")
+	}
+}
@@ -0,0 +1,154 @@
+package fireworks
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 20
+	// maxShells caps the shells in the air; the finale lifts it.
+	maxShells    = 4
+	finaleShells = 12
+	finaleTime   = 5 * time.Second
+)
+
+// Config controls the fireworks animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Rate is the average number of launches per minute.
+	Rate float64
+	// Gravity scales how fast rockets slow and stars fall; 1 is normal.
+	Gravity float64
+	// Palette names the shell colors: mixed, warm, cool, gold or neon.
+	Palette string
+	// Shells limits the shell types to choose from: peony, ring, willow and
+	// crackle. Empty means all of them.
+	Shells []string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Rate:       30,
+		Gravity:    1,
+		Palette:    "mixed",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Rate <= 0 {
+		c.Rate = 30
+	}
+	if c.Gravity <= 0 {
+		c.Gravity = 1
+	}
+	if !IsPalette(c.Palette) {
+		c.Palette = "mixed"
+	}
+	shells := c.Shells[:0:0]
+	for _, s := range c.Shells {
+		if IsShell(s) {
+			shells = append(shells, s)
+		}
+	}
+	c.Shells = shells
+	return c
+}
+
+// Run launches the fireworks animation. 'f' sets off a five-second finale.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	sky := newShow(cfg, rng)
+	dt := cfg.FrameDelay.Seconds()
+	chance := cfg.Rate / 60 * dt
+	finaleFrames := int(finaleTime / cfg.FrameDelay)
+	finale := 0
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		shells, odds := maxShells, chance
+		if finale > 0 {
+			finale--
+			shells, odds = finaleShells, 0.5
+		}
+		// Keep at least one shell going so the sky is never empty for long.
+		if sky.inFlight() == 0 || (sky.inFlight() < shells && rng.Float64() < odds) {
+			sky.launch()
+		}
+		sky.update(dt)
+
+		grid.Clear()
+		sky.drawGround(grid, frame)
+		sky.draw(grid)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case k := <-keys:
+				if k == 'f' || k == 'F' {
+					finale = finaleFrames
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package fireworks
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+// cellAspect is how much taller a terminal cell is than it is wide; vertical
+// speeds are divided by it so bursts come out round.
+const cellAspect = 2.0
+
+var (
+	trailColor   = "\x1b[38;5;223m"
+	crackleColor = "\x1b[38;5;231m"
+	emberColors  = []string{
+		"\x1b[38;5;240m",
+		"\x1b[38;5;237m",
+	}
+	palettes = map[string][]string{
+		"mixed": {"\x1b[38;5;196m", "\x1b[38;5;46m", "\x1b[38;5;51m", "\x1b[38;5;201m", "\x1b[38;5;226m", "\x1b[38;5;208m", "\x1b[38;5;231m"},
+		"warm":  {"\x1b[38;5;196m", "\x1b[38;5;202m", "\x1b[38;5;208m", "\x1b[38;5;214m", "\x1b[38;5;220m"},
+		"cool":  {"\x1b[38;5;51m", "\x1b[38;5;45m", "\x1b[38;5;39m", "\x1b[38;5;141m", "\x1b[38;5;159m"},
+		"gold":  {"\x1b[38;5;220m", "\x1b[38;5;221m", "\x1b[38;5;222m", "\x1b[38;5;229m"},
+		"neon":  {"\x1b[38;5;201m", "\x1b[38;5;165m", "\x1b[38;5;51m", "\x1b[38;5;118m"},
+	}
+	shellKinds = []string{"peony", "ring", "willow", "crackle"}
+)
+
+// IsPalette reports whether name is a shell palette.
+func IsPalette(name string) bool {
+	_, ok := palettes[name]
+	return ok
+}
+
+// IsShell reports whether name is a shell type.
+func IsShell(name string) bool {
+	for _, k := range shellKinds {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// spark is one glowing point: a rocket's trail, a star from a burst or a
+// crackle pop. Willow stars leave embers behind them; crackle stars pop
+// into a few white sparks when they burn out.
+type spark struct {
+	x, y    float64
+	vx, vy  float64
+	life    float64
+	maxLife float64
+	drag    float64
+	color   string
+	willow  bool
+	crackle bool
+}
+
+// rocket climbs until it slows to its apex and then bursts into kind.
+type rocket struct {
+	x, y  float64
+	vy    float64
+	kind  string
+	color string
+}
+
+// burst remembers where a shell went off so the ground can reflect it.
+type burst struct {
+	x     float64
+	color string
+	age   float64
+}
+
+// show is everything in the sky, in cells and seconds.
+type show struct {
+	width, height int
+	gravity       float64
+	colors        []string
+	kinds         []string
+	rockets       []rocket
+	sparks        []spark
+	bursts        []burst
+	rng           *rand.Rand
+}
+
+func newShow(cfg Config, rng *rand.Rand) *show {
+	kinds := cfg.Shells
+	if len(kinds) == 0 {
+		kinds = shellKinds
+	}
+	return &show{
+		width:   cfg.Width,
+		height:  cfg.Height,
+		gravity: 9 * cfg.Gravity,
+		colors:  palettes[cfg.Palette],
+		kinds:   kinds,
+		rng:     rng,
+	}
+}
+
+// inFlight counts the shells still climbing or bursting.
+func (s *show) inFlight() int {
+	n := len(s.rockets)
+	for _, b := range s.bursts {
+		if b.age < 1.5 {
+			n++
+		}
+	}
+	return n
+}
+
+// launch sends a rocket up from the ground toward the upper third.
+func (s *show) launch() {
+	ground := float64(s.height - 3)
+	apex := float64(s.height) * (0.12 + 0.25*s.rng.Float64())
+	// Rising (ground-apex) rows takes sqrt(2gh) rows/s of vertical speed.
+	vy := -math.Sqrt(2 * s.gravity * (ground - apex))
+	s.rockets = append(s.rockets, rocket{
+		x:     float64(s.width) * (0.1 + 0.8*s.rng.Float64()),
+		y:     ground,
+		vy:    vy,
+		kind:  s.kinds[s.rng.Intn(len(s.kinds))],
+		color: s.colors[s.rng.Intn(len(s.colors))],
+	})
+}
+
+// update advances everything by dt seconds.
+func (s *show) update(dt float64) {
+	rockets := s.rockets[:0]
+	for _, r := range s.rockets {
+		r.vy += s.gravity * dt
+		r.y += r.vy * dt
+		s.sparks = append(s.sparks, spark{
+			x:       r.x + (s.rng.Float64()-0.5)*0.6,
+			y:       r.y + 0.5,
+			vx:      (s.rng.Float64() - 0.5) * 2,
+			vy:      2 + s.rng.Float64()*2,
+			life:    0.3,
+			maxLife: 0.3,
+			drag:    0.9,
+			color:   trailColor,
+		})
+		if r.vy >= -1 {
+			s.explode(r)
+			continue
+		}
+		rockets = append(rockets, r)
+	}
+	s.rockets = rockets
+
+	var born []spark
+	sparks := s.sparks[:0]
+	for _, p := range s.sparks {
+		// drag is per 40ms frame; scale it so the look does not depend on
+		// the frame rate.
+		drag := math.Pow(p.drag, dt*25)
+		p.vx *= drag
+		p.vy = p.vy*drag + s.gravity*dt*0.4
+		p.x += p.vx * dt
+		p.y += p.vy * dt / cellAspect
+		p.life -= dt
+		if p.y >= float64(s.height-3) {
+			continue
+		}
+		if p.willow && s.rng.Float64() < 0.5 {
+			born = append(born, spark{x: p.x, y: p.y, life: 0.8, maxLife: 0.8, drag: 1, color: p.color})
+		}
+		if p.life <= 0 {
+			if p.crackle {
+				for i := 0; i < 3; i++ {
+					born = append(born, spark{
+						x: p.x, y: p.y,
+						vx:   (s.rng.Float64() - 0.5) * 10,
+						vy:   (s.rng.Float64() - 0.5) * 10,
+						life: 0.15, maxLife: 0.15, drag: 0.8,
+						color: crackleColor,
+					})
+				}
+			}
+			continue
+		}
+		sparks = append(sparks, p)
+	}
+	s.sparks = append(sparks, born...)
+
+	bursts := s.bursts[:0]
+	for _, b := range s.bursts {
+		b.age += dt
+		if b.age < 3 {
+			bursts = append(bursts, b)
+		}
+	}
+	s.bursts = bursts
+}
+
+// explode turns a rocket into its shell's stars.
+func (s *show) explode(r rocket) {
+	s.bursts = append(s.bursts, burst{x: r.x, color: r.color})
+	count, speed, life, drag := 40, 14.0, 1.4, 0.96
+	switch r.kind {
+	case "ring":
+		count = 32
+	case "willow":
+		count, speed, life, drag = 30, 10, 3, 0.93
+	case "crackle":
+		life = 1.1
+	}
+	for i := 0; i < count; i++ {
+		angle := s.rng.Float64() * 2 * math.Pi
+		v := speed * (0.4 + 0.6*math.Sqrt(s.rng.Float64()))
+		if r.kind == "ring" {
+			angle = float64(i) / float64(count) * 2 * math.Pi
+			v = speed
+		}
+		l := life * (0.8 + 0.4*s.rng.Float64())
+		s.sparks = append(s.sparks, spark{
+			x:       r.x,
+			y:       r.y,
+			vx:      math.Cos(angle) * v,
+			vy:      math.Sin(angle) * v,
+			life:    l,
+			maxLife: l,
+			drag:    drag,
+			color:   r.color,
+			willow:  r.kind == "willow",
+			crackle: r.kind == "crackle",
+		})
+	}
+}
+
+// draw puts the sparks on the grid, fading from bold to dots to embers as
+// they burn out.
+func (s *show) draw(grid canvas.Grid) {
+	for _, r := range s.rockets {
+		grid.Set(int(r.x), int(r.y), '|', trailColor)
+	}
+	for _, p := range s.sparks {
+		left := p.life / p.maxLife
+		var glyph rune
+		color := p.color
+		switch {
+		case left > 0.7:
+			glyph = '*'
+		case left > 0.4:
+			glyph = '+'
+		case left > 0.15:
+			glyph = '.'
+		default:
+			glyph = '.'
+			color = emberColors[0]
+		}
+		if p.willow && left > 0.4 {
+			glyph = '\''
+		}
+		grid.Set(int(math.Round(p.x)), int(math.Round(p.y)), glyph, color)
+	}
+}
+
+// drawGround draws the ground line and, below it, the water reflecting each
+// recent burst as a shimmer that fades with age.
+func (s *show) drawGround(grid canvas.Grid, frame int) {
+	height := len(grid)
+	width := len(grid[0])
+	ground := height - 3
+	for x := 0; x < width; x++ {
+		grid.Set(x, ground, '_', emberColors[1])
+	}
+	for _, b := range s.bursts {
+		reach := int(8 * (1 - b.age/3))
+		for dx := -reach; dx <= reach; dx++ {
+			x := int(b.x) + dx
+			for y := ground + 1; y < height; y++ {
+				if (x+y+frame)%3 == 0 {
+					continue
+				}
+				glyph := '~'
+				if canvas.Abs(dx) > reach/2 {
+					glyph = '-'
+				}
+				grid.Set(x, y, glyph, b.color)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package galaxy
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// pitch is the tangent of the arms' pitch angle; smaller winds them
+	// tighter.
+	pitch = 0.35
+	// crowding is how close neighbouring orbits come to touching in the
+	// arms, 0-1; it sets how much brighter the arms are than the gaps.
+	crowding = 0.9
+	// bulgeShare is the part of the stars that make up the core.
+	bulgeShare = 0.15
+	// patternShare is how fast the arm pattern turns against the stars at
+	// the edge of the disc.
+	patternShare = 0.3
+	// youngShare is the part of the disc stars that are young and bright.
+	// They are born in the arms and burn out before they can drift out of
+	// them, so they ride along with the pattern and light the arms up.
+	youngShare = 0.35
+)
+
+// star is one star on its orbit. Disc stars run around slightly lobed
+// orbits, each turned a little further the wider it is; where neighbouring
+// orbits bunch up the stars crowd into arms, so the arms keep their shape
+// while the stars themselves stream through them at their own speeds, the
+// inner ones faster. This is the density wave picture of a spiral galaxy.
+// Young stars stay put in the middle of an arm instead.
+type star struct {
+	// size is the orbit's mean radius, 1 at the edge of the disc, and theta
+	// how far round it the star is.
+	size  float64
+	theta float64
+	omega float64
+	// dx, dy scatter the star off its orbit.
+	dx, dy float64
+	bulge  bool
+	young  bool
+}
+
+// disc is every star and the shape of the orbits they follow.
+type disc struct {
+	stars []star
+	arms  int
+	// ecc is how lobed the orbits are, and lane the phase along an orbit
+	// where it is most crowded by its neighbours, the middle of an arm.
+	ecc     float64
+	lane    float64
+	pattern float64
+	speed   float64
+}
+
+func newDisc(count, arms int, speed float64, rng *rand.Rand) *disc {
+	k := float64(arms)
+	d := &disc{
+		stars: make([]star, count),
+		arms:  arms,
+		ecc:   crowding / math.Sqrt(1+k*k/(pitch*pitch)),
+		speed: speed,
+	}
+	// At phase theta, neighbouring orbits are 1 - ecc*(cos kθ + k/pitch
+	// sin kθ) times their mean spacing apart; they crowd most where that
+	// is lowest.
+	d.lane = math.Atan2(-d.ecc*k/pitch, -d.ecc) + math.Pi
+	for i := range d.stars {
+		s := &d.stars[i]
+		if rng.Float64() < bulgeShare {
+			s.size = math.Abs(rng.NormFloat64()) * 0.08
+			s.bulge = true
+		} else {
+			// An exponential disc: the product of two uniform draws gives
+			// the r e^-r spread of stars per ring.
+			s.size = 0.05 + math.Min(1, -math.Log((1-rng.Float64())*(1-rng.Float64()))*0.25)
+		}
+		s.theta = rng.Float64() * 2 * math.Pi
+		s.omega = speed / (s.size + 0.15)
+		if !s.bulge && rng.Float64() < youngShare {
+			s.young = true
+			s.theta = (d.lane + rng.NormFloat64()*0.3 + float64(rng.Intn(arms))*2*math.Pi) / k
+			s.omega = 0
+		}
+		scatter := 0.01 + 0.025*s.size
+		s.dx = rng.NormFloat64() * scatter
+		s.dy = rng.NormFloat64() * scatter
+	}
+	return d
+}
+
+// turn moves every star on along its orbit and the arm pattern with them.
+func (d *disc) turn() {
+	for i := range d.stars {
+		s := &d.stars[i]
+		s.theta = math.Mod(s.theta+s.omega, 2*math.Pi)
+	}
+	d.pattern += d.speed * patternShare
+}
+
+// place is where s is now, in disc units, and whether it sits in the dust
+// lane along the inside edge of an arm.
+func (d *disc) place(s *star) (x, y float64, dusty bool) {
+	if s.bulge {
+		return s.size*math.Cos(s.theta) + s.dx, s.size*math.Sin(s.theta) + s.dy, false
+	}
+	k := float64(d.arms)
+	r := s.size * (1 - d.ecc*math.Cos(k*s.theta))
+	angle := s.theta + math.Log(s.size)/pitch + d.pattern
+	phase := math.Remainder(k*s.theta-d.lane, 2*math.Pi)
+	dusty = phase > -1.1 && phase < -0.5 && s.size > 0.15
+	return r*math.Cos(angle) + s.dx, r*math.Sin(angle) + s.dy, dusty
+}
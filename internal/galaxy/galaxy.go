@@ -0,0 +1,321 @@
+package galaxy
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	maxStars  = 50000
+	// tilt squashes the disc vertically as if seen from a little above.
+	tilt = 0.7
+	// novaChance is the chance each frame that a star goes supernova, and
+	// novaFrames how long it burns.
+	novaChance = 0.004
+	novaFrames = 40
+)
+
+var (
+	// radiusColors run from the yellow core out through white to the blue
+	// arms.
+	radiusColors = []string{
+		"\x1b[38;5;229m",
+		"\x1b[38;5;223m",
+		"\x1b[38;5;255m",
+		"\x1b[38;5;189m",
+		"\x1b[38;5;153m",
+		"\x1b[38;5;117m",
+		"\x1b[38;5;75m",
+		"\x1b[38;5;69m",
+		"\x1b[38;5;62m",
+	}
+	// youngColors are the blue-white of the arms' young stars, by how
+	// many there are in a cell.
+	youngColors = []string{"\x1b[38;5;68m", "\x1b[38;5;111m", "\x1b[38;5;153m", "\x1b[38;5;195m"}
+	dustColor   = "\x1b[38;5;94m"
+	novaColors  = []string{"\x1b[38;5;231m", "\x1b[38;5;230m", "\x1b[38;5;222m", "\x1b[38;5;209m", "\x1b[38;5;131m"}
+	countGlyphs = []rune(" .,:;+*#%@")
+)
+
+// Config controls the galaxy.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Arms is how many spiral arms the disc has.
+	Arms int
+	// Speed is how far, in radians, a star at the edge of the disc turns
+	// each frame; inner stars go round faster.
+	Speed float64
+	// Stars is how many stars make up the galaxy.
+	Stars int
+	// ASCII draws one character per cell, shaded by how many stars fall in
+	// it, instead of braille dots.
+	ASCII bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Arms:       2,
+		Speed:      0.008,
+		Stars:      8000,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Arms <= 0 {
+		c.Arms = 2
+	}
+	if c.Arms > 6 {
+		c.Arms = 6
+	}
+	if c.Speed == 0 {
+		c.Speed = 0.008
+	}
+	if c.Stars <= 0 {
+		c.Stars = 8000
+	}
+	if c.Stars > maxStars {
+		c.Stars = maxStars
+	}
+	return c
+}
+
+// exposure collects the stars of one frame. With braille each cell is a
+// 2x4 block of dots; otherwise each cell is one dot and counts its stars.
+type exposure struct {
+	width, height int
+	braille       bool
+	dots          []uint8
+	count         []int
+	// inner is the smallest orbit drawn into each cell, which picks its
+	// color; young and dust count the stars in it that are young or sit in
+	// a dust lane.
+	inner []float64
+	young []int
+	dust  []int
+}
+
+func newExposure(width, height int, braille bool) *exposure {
+	n := width * height
+	return &exposure{
+		width:   width,
+		height:  height,
+		braille: braille,
+		dots:    make([]uint8, n),
+		count:   make([]int, n),
+		inner:   make([]float64, n),
+		young:   make([]int, n),
+		dust:    make([]int, n),
+	}
+}
+
+func (c *exposure) clear() {
+	for i := range c.dots {
+		c.dots[i] = 0
+		c.count[i] = 0
+		c.inner[i] = math.MaxFloat64
+		c.young[i] = 0
+		c.dust[i] = 0
+	}
+}
+
+// plot marks the dot at x, y, counted in dots: two per cell across and
+// four down with braille, one per cell otherwise.
+func (c *exposure) plot(x, y int, s *star, dusty bool) {
+	cx, cy := x, y
+	if c.braille {
+		cx, cy = x/2, y/4
+	}
+	if x < 0 || y < 0 || cx >= c.width || cy >= c.height {
+		return
+	}
+	i := cy*c.width + cx
+	if c.braille {
+		c.dots[i] |= uint8(render.BrailleDot(x%2, y%4))
+	}
+	c.count[i]++
+	c.inner[i] = math.Min(c.inner[i], s.size)
+	if s.young {
+		c.young[i]++
+	}
+	if dusty {
+		c.dust[i]++
+	}
+}
+
+// resolution is the exposure size in dots and how many dots tall a dot is
+// against its width, so the disc comes out round.
+func (c *exposure) resolution() (w, h int, aspect float64) {
+	if c.braille {
+		return c.width * 2, c.height * 4, 1
+	}
+	return c.width, c.height, 2
+}
+
+type nova struct {
+	star int
+	age  int
+}
+
+// Run launches the galaxy.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	d := newDisc(cfg.Stars, cfg.Arms, cfg.Speed, rng)
+	c := newExposure(cfg.Width, cfg.Height, !cfg.ASCII && hasUnicode())
+	var novas []nova
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		d.turn()
+		if rng.Float64() < novaChance {
+			novas = append(novas, nova{star: rng.Intn(len(d.stars))})
+		}
+		drawGalaxy(grid, c, d, novas)
+		grid.Render(screen)
+		live := novas[:0]
+		for _, n := range novas {
+			if n.age++; n.age < novaFrames {
+				live = append(live, n)
+			}
+		}
+		novas = live
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawGalaxy plots every star onto the exposure, turns the exposure into
+// cells, and lays any supernovae over the top.
+func drawGalaxy(grid canvas.Grid, c *exposure, d *disc, novas []nova) {
+	w, h, aspect := c.resolution()
+	scale := math.Min(float64(w)/2, float64(h)*aspect/2/tilt) / 1.25
+	toDots := func(x, y float64) (int, int) {
+		return int(float64(w)/2 + x*scale), int(float64(h)/2 + y*scale*tilt/aspect)
+	}
+
+	c.clear()
+	for i := range d.stars {
+		s := &d.stars[i]
+		x, y, dusty := d.place(s)
+		px, py := toDots(x, y)
+		c.plot(px, py, s, dusty)
+	}
+
+	for y := range grid {
+		for x := range grid[y] {
+			i := y*c.width + x
+			n := c.count[i]
+			if n == 0 {
+				grid[y][x] = canvas.Cell{Glyph: ' '}
+				continue
+			}
+			var color string
+			switch {
+			case c.dust[i]*2 > n:
+				color = dustColor
+			case c.young[i]*2 >= n:
+				color = youngColors[min(len(youngColors)-1, c.young[i]-1)]
+			default:
+				color = radiusColors[min(len(radiusColors)-1, int(c.inner[i]*float64(len(radiusColors))))]
+			}
+			glyph := rune(0x2800 + int(c.dots[i]))
+			if !c.braille {
+				glyph = countGlyphs[min(len(countGlyphs)-1, 1+n/3)]
+			}
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+
+	for _, n := range novas {
+		x, y, _ := d.place(&d.stars[n.star])
+		px, py := toDots(x, y)
+		if c.braille {
+			px, py = px/2, py/4
+		}
+		stage := n.age * len(novaColors) / novaFrames
+		color := novaColors[stage]
+		switch {
+		case stage == 0:
+			grid.Set(px, py, '@', color)
+			for _, r := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				grid.Set(px+r[0], py+r[1], '+', color)
+			}
+		case stage < 3:
+			grid.Set(px, py, '*', color)
+		default:
+			grid.Set(px, py, '+', color)
+		}
+	}
+}
+
+// hasUnicode guesses from the locale whether braille will show.
+func hasUnicode() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToUpper(v)
+			return strings.Contains(v, "UTF-8") || strings.Contains(v, "UTF8")
+		}
+	}
+	return false
+}
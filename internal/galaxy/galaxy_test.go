@@ -0,0 +1,33 @@
+package galaxy
+
+import (
+	"math/rand"
+	"testing"
+
+	"animinterminal/internal/canvas"
+)
+
+// BenchmarkFrame times turning and drawing 10,000 stars on a 200 by 60
+// terminal, in braille and in plain characters; frames/s wants to stay
+// well above 20.
+func BenchmarkFrame(b *testing.B) {
+	for _, braille := range []bool{true, false} {
+		name := "ascii"
+		if braille {
+			name = "braille"
+		}
+		b.Run(name, func(b *testing.B) {
+			const width, height = 200, 60
+			grid := canvas.New(width, height)
+			cfg := DefaultConfig()
+			d := newDisc(10000, cfg.Arms, cfg.Speed, rand.New(rand.NewSource(1)))
+			c := newExposure(width, height, braille)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d.turn()
+				drawGalaxy(grid, c, d, nil)
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "frames/s")
+		})
+	}
+}
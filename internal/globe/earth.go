@@ -0,0 +1,58 @@
+package globe
+
+// earth is a coarse equirectangular land map: 72 columns of 5 degrees of
+// longitude from 180W, 36 rows of 5 degrees of latitude from the north pole.
+// '#' is land, '.' is sea.
+var earth = [...]string{
+	"........................................................................",
+	"...........................##...........................................",
+	".......................#########........................##..............",
+	"..................##....########...................############.........",
+	"...###################...######.......##################################",
+	"...####################..####........###################################",
+	".........###############..............#############################.....",
+	"..........###############..........#.###########################........",
+	"...........#############...........############################.........",
+	"...........###########............###..########################.........",
+	"............#########.............#......#####################.#........",
+	".............########.............######..###################.#.........",
+	"..............###..#..............##########################............",
+	"...............##................##############..###########............",
+	"................###..............##############....##..##...............",
+	".................................############......#...##...............",
+	"....................####..........############..........#...............",
+	"....................#####.............#######.............##............",
+	"....................#######...........######..............##..#.........",
+	"....................#########.........######....................#.......",
+	".....................########..........#####..................###.......",
+	"......................######..........######.#...............####.......",
+	"......................######...........####..#.............#######......",
+	"......................#####............###.................########.....",
+	"......................###..............###.................##.####......",
+	".....................###........................................#.....#.",
+	".....................##...............................................#.",
+	".....................##.................................................",
+	"......................#.................................................",
+	"........................................................................",
+	"........................................................................",
+	"........................................................................",
+	"########################################################################",
+	"########################################################################",
+	"########################################################################",
+	"########################################################################",
+}
+
+// landAt reports whether the map shows land at lat, lon in degrees.
+func landAt(lat, lon float64) bool {
+	row := int((90 - lat) / 180 * float64(len(earth)))
+	if row < 0 {
+		row = 0
+	}
+	if row >= len(earth) {
+		row = len(earth) - 1
+	}
+	width := len(earth[0])
+	col := int((lon + 180) / 360 * float64(width))
+	col = ((col % width) + width) % width
+	return earth[row][col] == '#'
+}
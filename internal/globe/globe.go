@@ -0,0 +1,202 @@
+package globe
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/space"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+	// polarLat is where land turns to ice.
+	polarLat = 62.0
+)
+
+var (
+	seaGlyphs  = []rune(".,-~")
+	seaColors  = []string{"\x1b[38;5;17m", "\x1b[38;5;19m", "\x1b[38;5;26m", "\x1b[38;5;32m", "\x1b[38;5;39m"}
+	landGlyphs = []rune(":;=+*#%@")
+	landColors = []string{"\x1b[38;5;22m", "\x1b[38;5;28m", "\x1b[38;5;34m", "\x1b[38;5;70m", "\x1b[38;5;112m"}
+	iceGlyphs  = []rune("-=*#@")
+	iceColors  = []string{"\x1b[38;5;244m", "\x1b[38;5;250m", "\x1b[38;5;254m", "\x1b[38;5;231m"}
+	haloColor  = "\x1b[38;5;24m"
+	nightColor = "\x1b[38;5;236m"
+	cityColor  = "\x1b[38;5;178m"
+
+	// frontLight lights the whole visible face from the upper left.
+	frontLight = space.Normalize(space.Vec3{X: -0.5, Y: 0.5, Z: -1})
+	// sideLight comes from the right so the terminator crosses the disk.
+	sideLight = space.Normalize(space.Vec3{X: 1, Y: 0.2, Z: -0.3})
+)
+
+// Config controls the globe animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Speed is how far the globe turns each frame, in radians.
+	Speed float64
+	// Tilt leans the axis toward the right, in degrees.
+	Tilt float64
+	// Terminator lights the globe from the side so half of it is in night,
+	// with city lights on the dark land.
+	Terminator bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Speed:      0.03,
+		Tilt:       23.4,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	c.Tilt = math.Max(-90, math.Min(90, c.Tilt))
+	return c
+}
+
+// Run launches the spinning globe. Each frame depends only on its number, so
+// the same config always draws the same sequence.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		drawGlobe(grid, cfg, float64(frame)*cfg.Speed)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawGlobe casts each cell onto the sphere: cells off the disk are skipped
+// (or get the thin halo), and the rest are turned back through the tilt and
+// spin to find the latitude and longitude under them.
+func drawGlobe(grid canvas.Grid, cfg Config, spin float64) {
+	height := len(grid)
+	width := len(grid[0])
+	radius := float64(height) * 0.46
+	tilt := cfg.Tilt * math.Pi / 180
+	light := frontLight
+	if cfg.Terminator {
+		light = sideLight
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := (float64(x) + 0.5 - float64(width)/2) / (radius * cellAspect)
+			sy := (float64(height)/2 - float64(y) - 0.5) / radius
+			d2 := sx*sx + sy*sy
+			if d2 > 1 {
+				if d2 < 1.12 {
+					grid[y][x] = canvas.Cell{Glyph: '.', Color: haloColor}
+				}
+				continue
+			}
+			// Only the near hemisphere is ever visible, so the far
+			// side needs no depth test.
+			n := space.Vec3{X: sx, Y: sy, Z: -math.Sqrt(1 - d2)}
+			p := space.Rotate(space.Rotate(n, 0, 0, tilt), 0, spin, 0)
+			lat := math.Asin(p.Y) * 180 / math.Pi
+			lon := math.Atan2(p.X, -p.Z) * 180 / math.Pi
+			lit := space.Dot(n, light)
+			if !cfg.Terminator {
+				lit = 0.3 + 0.7*math.Max(lit, 0)
+			}
+			grid[y][x] = surface(lat, lon, lit)
+		}
+	}
+}
+
+// surface picks the glyph for one spot on the globe lit by lit, from -1 (the
+// far side of the night) to 1 (facing the light).
+func surface(lat, lon, lit float64) canvas.Cell {
+	land := landAt(lat, lon)
+	if lit <= 0 {
+		if !land {
+			return canvas.Cell{Glyph: ' '}
+		}
+		if city(lat, lon) {
+			return canvas.Cell{Glyph: '.', Color: cityColor}
+		}
+		return canvas.Cell{Glyph: '.', Color: nightColor}
+	}
+	switch {
+	case land && math.Abs(lat) > polarLat:
+		return shade(iceGlyphs, iceColors, lit)
+	case land:
+		return shade(landGlyphs, landColors, lit)
+	default:
+		return shade(seaGlyphs, seaColors, lit)
+	}
+}
+
+func shade(glyphs []rune, colors []string, lit float64) canvas.Cell {
+	return canvas.Cell{
+		Glyph: glyphs[min(len(glyphs)-1, int(lit*float64(len(glyphs))))],
+		Color: colors[min(len(colors)-1, int(lit*float64(len(colors))))],
+	}
+}
+
+// city scatters lights over the night side. It hashes the map cell rather
+// than rolling dice so the lights stay put as the globe turns.
+func city(lat, lon float64) bool {
+	h := uint32(int(lat*2)*7919 + int(lon*2)*104729)
+	h ^= h >> 13
+	h *= 0x5bd1e995
+	h ^= h >> 15
+	return h%7 == 0
+}
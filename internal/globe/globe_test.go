@@ -0,0 +1,30 @@
+package globe
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/golden"
+)
+
+// TestGoldenSpin draws a few frames of the tilted globe with the day and
+// night terminator and checks them against testdata/spin.golden. The globe
+// has no randomness, so it needs no seed.
+func TestGoldenSpin(t *testing.T) {
+	// Colors otherwise follow the terminal the test runs in.
+	color.Use(color.ANSI256)
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 48, 24
+	cfg.FrameDelay = time.Millisecond
+	cfg.MaxFrames = 4
+	cfg.Terminator = true
+	cfg.Output = &out
+	if err := RunContext(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "spin.golden", out.Bytes())
+}
@@ -0,0 +1,48 @@
+// Package golden compares what a test drew against a file kept under the
+// test's testdata directory. Run the tests with -update to rewrite the
+// files from what is drawn now, after checking the change is wanted.
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the current output")
+
+// Check fails t unless got is what testdata/name holds, reporting the first
+// byte that differs. With -update it writes got there instead.
+func Check(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	at := 0
+	for at < len(got) && at < len(want) && got[at] == want[at] {
+		at++
+	}
+	t.Errorf("output differs from %s at byte %d of %d (want %d): got %q, want %q",
+		path, at, len(got), len(want), excerpt(got, at), excerpt(want, at))
+}
+
+// excerpt is the few bytes of b from at on.
+func excerpt(b []byte, at int) []byte {
+	return b[at:min(len(b), at+40)]
+}
@@ -0,0 +1,219 @@
+package helix
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// randomBases is how long the random sequence is before it repeats.
+	randomBases = 4096
+	// rungSpacing is how many rows apart the base pairs are.
+	rungSpacing = 2
+	// scrollSpeed is how many rows the sequence climbs each frame.
+	scrollSpeed = 0.12
+	// letterRoom is the shortest rung that has space for its two letters.
+	letterRoom = 8
+)
+
+var (
+	baseColors = map[byte]string{
+		'A': "\x1b[38;5;203m",
+		'T': "\x1b[38;5;221m",
+		'G': "\x1b[38;5;114m",
+		'C': "\x1b[38;5;75m",
+	}
+	nearColor = "\x1b[38;5;195m"
+	farColor  = "\x1b[38;5;60m"
+)
+
+// Config controls the double helix.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Radius is how far each strand swings out from the axis, in columns.
+	Radius float64
+	// Pitch is how many rows one full turn of the helix takes.
+	Pitch float64
+	// Spin is how far the helix turns about its axis each frame, in
+	// radians.
+	Spin float64
+	// Sequence is the bases to show, A, C, G and T, repeating; empty makes
+	// up a random one.
+	Sequence string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Radius:     14,
+		Pitch:      24,
+		Spin:       0.05,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	if c.Radius <= 0 {
+		c.Radius = 14
+	}
+	c.Radius = math.Min(c.Radius, float64(c.Width)/2-2)
+	if c.Pitch <= 0 {
+		c.Pitch = 24
+	}
+	return c
+}
+
+// Run launches the helix.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	seq := cfg.Sequence
+	if seq == "" {
+		seq = randomSequence(randomBases, rng)
+	}
+	grid := canvas.New(cfg.Width, cfg.Height)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		drawHelix(grid, cfg, seq, float64(frame)*scrollSpeed, float64(frame)*cfg.Spin)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawHelix draws each row back to front: the far strand, the base pair
+// rung between the strands, then the near strand over both. The letters sit
+// a cell in from the rung's ends so the strands do not cover them. Row y shows the
+// point scroll+y along the helix, so the sequence climbs as scroll grows.
+func drawHelix(grid canvas.Grid, cfg Config, seq string, scroll, spin float64) {
+	center := float64(len(grid[0])) / 2
+	// strand is where strand one (sign 1) or two (sign -1) crosses the
+	// middle of a row at pos along the helix.
+	strand := func(pos, sign float64) float64 {
+		return center + sign*math.Sin(2*math.Pi*pos/cfg.Pitch+spin)*cfg.Radius
+	}
+	for y := range grid {
+		pos := scroll + float64(y)
+		// Strand one is nearer the viewer while its depth is positive.
+		nearSign := 1.0
+		if math.Cos(2*math.Pi*pos/cfg.Pitch+spin) < 0 {
+			nearSign = -1
+		}
+		drawStrand(grid, y, strand(pos-0.5, -nearSign), strand(pos+0.5, -nearSign), farColor)
+
+		index := int(math.Floor(pos))
+		if index%rungSpacing == 0 {
+			drawRung(grid, y, strand(pos, 1), strand(pos, -1), seq[(index/rungSpacing)%len(seq)])
+		}
+
+		drawStrand(grid, y, strand(pos-0.5, nearSign), strand(pos+0.5, nearSign), nearColor)
+	}
+}
+
+// drawStrand draws the piece of a strand that crosses row y, entering the
+// top of the row at x0 and leaving the bottom at x1, so the strand stays
+// joined up where it swings across faster than a column a row.
+func drawStrand(grid canvas.Grid, y int, x0, x1 float64, color string) {
+	glyph := strandGlyph(x1 - x0)
+	from, to := int(math.Round(math.Min(x0, x1))), int(math.Round(math.Max(x0, x1)))
+	for x := from; x <= max(from, to-1); x++ {
+		grid.Set(x, y, glyph, color)
+	}
+}
+
+// drawRung joins the strands at x1 and x2 with the pair for base, each half
+// in its own base's color, and spells the two bases at the ends when the
+// rung is long enough to read.
+func drawRung(grid canvas.Grid, y int, x1, x2 float64, base byte) {
+	pair := complement(base)
+	from, to := int(x1), int(x2)
+	step := 1
+	if to < from {
+		step = -1
+	}
+	length := (to - from) * step
+	for i := 1; i < length; i++ {
+		b := base
+		if i > length/2 {
+			b = pair
+		}
+		grid.Set(from+i*step, y, '=', baseColors[b])
+	}
+	if length >= letterRoom {
+		grid.Set(from+2*step, y, rune(base), baseColors[base])
+		grid.Set(to-2*step, y, rune(pair), baseColors[pair])
+	}
+}
+
+// strandGlyph follows the strand's drift across the screen, slope columns
+// per row, so it reads as a curve.
+func strandGlyph(slope float64) rune {
+	switch {
+	case slope > 2.5 || slope < -2.5:
+		return '_'
+	case slope > 0.5:
+		return '\\'
+	case slope < -0.5:
+		return '/'
+	}
+	return '|'
+}
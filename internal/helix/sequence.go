@@ -0,0 +1,54 @@
+package helix
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// ReadSequence reads bases from plain text or FASTA: header lines starting
+// with '>' are skipped, U reads as T, and anything that is not a base is
+// ignored. It returns an empty sequence, not an error, when there are no
+// bases at all.
+func ReadSequence(r io.Reader) (string, error) {
+	var sb strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		for _, ch := range strings.ToUpper(line) {
+			switch ch {
+			case 'A', 'C', 'G', 'T':
+				sb.WriteRune(ch)
+			case 'U':
+				sb.WriteByte('T')
+			}
+		}
+	}
+	return sb.String(), scanner.Err()
+}
+
+// randomSequence makes n random bases.
+func randomSequence(n int, rng *rand.Rand) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+// complement is the base that pairs with b.
+func complement(b byte) byte {
+	switch b {
+	case 'A':
+		return 'T'
+	case 'T':
+		return 'A'
+	case 'C':
+		return 'G'
+	}
+	return 'C'
+}
@@ -0,0 +1,317 @@
+package lava
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/metaball"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 20
+	// cellAspect is how much taller a terminal cell is than it is wide;
+	// the wax is simulated with y stretched by it so blobs stay round.
+	cellAspect = 2.0
+	// capRows and baseRows are the metal cap above the glass and the stand
+	// below it.
+	capRows  = 2
+	baseRows = 4
+	// heatZone is the share of the vessel at each end where the wax heats
+	// (bottom) or cools (top).
+	heatZone = 0.18
+)
+
+var (
+	palettes = map[string][]string{
+		"classic": {"\x1b[38;5;52m", "\x1b[38;5;88m", "\x1b[38;5;160m", "\x1b[38;5;202m", "\x1b[38;5;208m", "\x1b[38;5;214m", "\x1b[38;5;221m"},
+		"blue":    {"\x1b[38;5;17m", "\x1b[38;5;19m", "\x1b[38;5;27m", "\x1b[38;5;33m", "\x1b[38;5;39m", "\x1b[38;5;81m", "\x1b[38;5;159m"},
+		"green":   {"\x1b[38;5;22m", "\x1b[38;5;28m", "\x1b[38;5;34m", "\x1b[38;5;40m", "\x1b[38;5;76m", "\x1b[38;5;118m", "\x1b[38;5;193m"},
+		"purple":  {"\x1b[38;5;53m", "\x1b[38;5;91m", "\x1b[38;5;127m", "\x1b[38;5;165m", "\x1b[38;5;171m", "\x1b[38;5;213m", "\x1b[38;5;225m"},
+	}
+	// waxGlyphs runs from the dim skin of a blob to its bright core.
+	waxGlyphs  = []rune("o%#@@")
+	glassColor = "\x1b[38;5;245m"
+	metalColor = "\x1b[38;5;240m"
+	shineColor = "\x1b[38;5;250m"
+)
+
+// Config controls the lava lamp.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Blobs is how many blobs of wax float in the lamp.
+	Blobs int
+	// Viscosity is how thick the liquid is, 0-1: thicker liquid slows the
+	// blobs down.
+	Viscosity float64
+	// Palette names the wax colors: classic, blue, green or purple.
+	Palette string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Blobs:      6,
+		Viscosity:  0.5,
+		Palette:    "classic",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	if c.Blobs <= 0 {
+		c.Blobs = 6
+	}
+	if c.Blobs > 12 {
+		c.Blobs = 12
+	}
+	c.Viscosity = math.Max(0, math.Min(1, c.Viscosity))
+	if !IsPalette(c.Palette) {
+		c.Palette = "classic"
+	}
+	return c
+}
+
+// IsPalette reports whether name is a lava palette.
+func IsPalette(name string) bool {
+	_, ok := palettes[name]
+	return ok
+}
+
+// lamp is the glass vessel and the wax inside it. The glass runs from row
+// top to row bottom and tapers from halfBottom columns either side of the
+// center at the bottom to halfTop at the top.
+type lamp struct {
+	center              float64
+	top, bottom         int
+	halfTop, halfBottom float64
+	blobs               []metaball.Ball
+	heat                []float64
+	// pool is the wax melted at the bottom that blobs rise from and sink
+	// back into; it is part of the field but never moves.
+	pool metaball.Ball
+	drag float64
+	rng  *rand.Rand
+}
+
+func newLamp(cfg Config, rng *rand.Rand) *lamp {
+	top := capRows
+	bottom := cfg.Height - baseRows - 1
+	halfBottom := math.Min(float64(cfg.Width)/2-3, float64(bottom-top)*0.75)
+	l := &lamp{
+		center:     float64(cfg.Width) / 2,
+		top:        top,
+		bottom:     bottom,
+		halfTop:    halfBottom * 0.55,
+		halfBottom: halfBottom,
+		blobs:      make([]metaball.Ball, cfg.Blobs),
+		heat:       make([]float64, cfg.Blobs),
+		drag:       0.985 - 0.06*cfg.Viscosity,
+		rng:        rng,
+	}
+	floor := float64(bottom) * cellAspect
+	l.pool = metaball.Ball{X: l.center, Y: floor + halfBottom*0.45, R: halfBottom * 0.6}
+	for i := range l.blobs {
+		r := halfBottom * (0.12 + 0.08*rng.Float64())
+		y := float64(top)*cellAspect + rng.Float64()*(floor-float64(top)*cellAspect)
+		l.blobs[i] = metaball.Ball{
+			X: l.center + (rng.Float64()*2-1)*l.halfWidth(y/cellAspect)*0.5,
+			Y: y,
+			R: r,
+		}
+		l.heat[i] = rng.Float64()
+	}
+	return l
+}
+
+// halfWidth is the inside half-width of the glass at row y.
+func (l *lamp) halfWidth(y float64) float64 {
+	t := (y - float64(l.top)) / float64(l.bottom-l.top)
+	t = math.Max(0, math.Min(1, t))
+	return l.halfTop + (l.halfBottom-l.halfTop)*t
+}
+
+// update heats the blobs near the bottom and cools them near the top; warm
+// wax is lighter than the liquid and rises, cool wax sinks. The liquid's
+// drag keeps it all slow.
+func (l *lamp) update() {
+	top := float64(l.top) * cellAspect
+	floor := float64(l.bottom) * cellAspect
+	span := floor - top
+	for i := range l.blobs {
+		b := &l.blobs[i]
+		depth := (b.Y - top) / span
+		switch {
+		case depth > 1-heatZone:
+			l.heat[i] = math.Min(1, l.heat[i]+0.012)
+		case depth < heatZone:
+			l.heat[i] = math.Max(0, l.heat[i]-0.012)
+		default:
+			l.heat[i] += (0.5 - l.heat[i]) * 0.001
+		}
+		b.VY -= (l.heat[i] - 0.5) * 0.03
+		b.VX += (l.rng.Float64() - 0.5) * 0.02
+		b.VX *= l.drag
+		b.VY *= l.drag
+		b.X += b.VX
+		b.Y += b.VY
+
+		if b.Y < top+b.R*0.6 {
+			b.Y = top + b.R*0.6
+			b.VY = 0
+		}
+		if b.Y > floor {
+			b.Y = floor
+			b.VY = 0
+		}
+		room := math.Max(0, l.halfWidth(b.Y/cellAspect)-b.R*0.8)
+		if math.Abs(b.X-l.center) > room {
+			b.X = l.center + math.Copysign(room, b.X-l.center)
+			b.VX = -b.VX * 0.5
+		}
+	}
+}
+
+// Run launches the lava lamp.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	l := newLamp(cfg, rng)
+	palette := palettes[cfg.Palette]
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		grid.Clear()
+		drawWax(grid, l, palette)
+		drawLamp(grid, l)
+		grid.Render(screen)
+		l.update()
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawWax quantizes the blob field inside the glass: a faint glow around the
+// wax, and the wax itself brighter toward the middle of each blob.
+func drawWax(grid canvas.Grid, l *lamp, palette []string) {
+	field := append(l.blobs[:len(l.blobs):len(l.blobs)], l.pool)
+	for y := l.top; y <= l.bottom; y++ {
+		half := l.halfWidth(float64(y))
+		for x := int(l.center - half + 1); x < int(l.center+half); x++ {
+			level := metaball.Level(metaball.Field(field, float64(x)+0.5, (float64(y)+0.5)*cellAspect))
+			if level < 0.5 {
+				if level > 0.22 {
+					grid.Set(x, y, '.', palette[0])
+				}
+				continue
+			}
+			t := (level - 0.5) * 2
+			glyph := waxGlyphs[min(len(waxGlyphs)-1, int(t*float64(len(waxGlyphs))))]
+			color := palette[min(len(palette)-1, 1+int(t*float64(len(palette)-1)))]
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
+
+// drawLamp draws the glass with a highlight down one side, the cap and the
+// stand.
+func drawLamp(grid canvas.Grid, l *lamp) {
+	for y := l.top; y <= l.bottom; y++ {
+		half := l.halfWidth(float64(y))
+		left := int(l.center - half)
+		right := int(l.center + half)
+		leftEdge, rightEdge := '|', '|'
+		if int(l.center-l.halfWidth(float64(y+1))) < left {
+			leftEdge, rightEdge = '/', '\\'
+		}
+		grid.Set(left, y, leftEdge, glassColor)
+		grid.Set(right, y, rightEdge, glassColor)
+		if y > l.top+1 && y < l.bottom-1 && y%3 != 0 {
+			grid.Set(left+2, y, ':', shineColor)
+		}
+	}
+
+	capHalf := int(l.halfTop)
+	for i := 0; i < capRows; i++ {
+		y := l.top - capRows + i
+		half := capHalf - (capRows - 1 - i)
+		drawSpan(grid, int(l.center), y, half, '/', '=', '\\')
+	}
+
+	baseHalf := int(l.halfBottom)
+	for i := 1; i <= baseRows; i++ {
+		y := l.bottom + i
+		half := baseHalf - i + 1
+		if i > baseRows/2 {
+			half = baseHalf - baseRows + i
+		}
+		left, right := '\\', '/'
+		if i > baseRows/2 {
+			left, right = '/', '\\'
+		}
+		drawSpan(grid, int(l.center), y, half, left, '=', right)
+	}
+}
+
+func drawSpan(grid canvas.Grid, center, y, half int, left, fill, right rune) {
+	grid.Set(center-half, y, left, metalColor)
+	grid.Set(center+half, y, right, metalColor)
+	for x := center - half + 1; x < center+half; x++ {
+		grid.Set(x, y, fill, metalColor)
+	}
+}
@@ -0,0 +1,298 @@
+package life
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// ghostLife is how many generations a dead cell lingers as a ghost.
+	ghostLife = 4
+	// window is how many generations back a repeat counts as stagnation,
+	// which catches still lifes and oscillators up to that period.
+	window = 60
+	// stallLimit reseeds a board whose population has barely moved for that
+	// many generations, like one left with only gliders on a torus.
+	stallLimit = 300
+)
+
+var (
+	// agePalette runs from newborn to old.
+	agePalette = []string{
+		"\x1b[38;5;231m",
+		"\x1b[38;5;159m",
+		"\x1b[38;5;87m",
+		"\x1b[38;5;51m",
+		"\x1b[38;5;45m",
+		"\x1b[38;5;39m",
+		"\x1b[38;5;33m",
+		"\x1b[38;5;27m",
+		"\x1b[38;5;26m",
+		"\x1b[38;5;25m",
+	}
+	ghostColor = "\x1b[38;5;238m"
+)
+
+// Config controls the Game of Life animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Rule is the rule in B/S notation or by name; see ParseRule.
+	Rule string
+	// Density is the share of live cells in a random soup, 0-1.
+	Density float64
+	// Wrap joins the edges into a torus; without it cells past the edge are
+	// dead.
+	Wrap bool
+	// Pattern is what to seed with; see IsPattern.
+	Pattern string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 80 * time.Millisecond,
+		Rule:       "B3/S23",
+		Density:    0.3,
+		Wrap:       true,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 80 * time.Millisecond
+	}
+	if _, err := ParseRule(c.Rule); err != nil {
+		c.Rule = "B3/S23"
+	}
+	if c.Density <= 0 || c.Density > 1 {
+		c.Density = 0.3
+	}
+	if !IsPattern(c.Pattern) {
+		c.Pattern = ""
+	}
+	return c
+}
+
+// board holds every cell's age in generations (0 is dead) and how long a
+// dead one has left as a ghost.
+type board struct {
+	width, height int
+	wrap          bool
+	rule          Rule
+	age           []int
+	ghost         []int
+	next          []int
+	rng           *rand.Rand
+}
+
+func newBoard(cfg Config, rng *rand.Rand) *board {
+	r, _ := ParseRule(cfg.Rule)
+	n := cfg.Width * cfg.Height
+	return &board{
+		width:  cfg.Width,
+		height: cfg.Height,
+		wrap:   cfg.Wrap,
+		rule:   r,
+		age:    make([]int, n),
+		ghost:  make([]int, n),
+		next:   make([]int, n),
+		rng:    rng,
+	}
+}
+
+func (b *board) set(x, y int) {
+	if x >= 0 && x < b.width && y >= 0 && y < b.height {
+		b.age[y*b.width+x] = 1
+	}
+}
+
+func (b *board) alive(x, y int) bool {
+	if b.wrap {
+		x = (x + b.width) % b.width
+		y = (y + b.height) % b.height
+	} else if x < 0 || x >= b.width || y < 0 || y >= b.height {
+		return false
+	}
+	return b.age[y*b.width+x] > 0
+}
+
+// step advances one generation and returns the population.
+func (b *board) step() int {
+	population := 0
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if (dx != 0 || dy != 0) && b.alive(x+dx, y+dy) {
+						n++
+					}
+				}
+			}
+			i := y*b.width + x
+			switch {
+			case b.age[i] > 0 && b.rule.survive[n]:
+				b.next[i] = b.age[i] + 1
+			case b.age[i] == 0 && b.rule.born[n]:
+				b.next[i] = 1
+			default:
+				b.next[i] = 0
+				if b.age[i] > 0 {
+					b.ghost[i] = ghostLife
+				} else if b.ghost[i] > 0 {
+					b.ghost[i]--
+				}
+			}
+			if b.next[i] > 0 {
+				population++
+				b.ghost[i] = 0
+			}
+		}
+	}
+	b.age, b.next = b.next, b.age
+	return population
+}
+
+// hash fingerprints which cells are alive.
+func (b *board) hash() uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, len(b.age)/8+1)
+	var bits byte
+	for i, a := range b.age {
+		if a > 0 {
+			bits |= 1 << (i % 8)
+		}
+		if i%8 == 7 {
+			buf = append(buf, bits)
+			bits = 0
+		}
+	}
+	h.Write(append(buf, bits))
+	return h.Sum64()
+}
+
+// stagnation watches for a board that has settled: a layout seen within
+// the window, or a population stuck in a narrow band for too long.
+type stagnation struct {
+	seen    []uint64
+	low     int
+	high    int
+	stalled int
+}
+
+func (s *stagnation) stuck(hash uint64, population int) bool {
+	for _, h := range s.seen {
+		if h == hash {
+			return true
+		}
+	}
+	s.seen = append(s.seen, hash)
+	if len(s.seen) > window {
+		s.seen = s.seen[1:]
+	}
+	if population < s.low || population > s.high {
+		s.low, s.high = population*9/10-2, population*11/10+2
+		s.stalled = 0
+	}
+	s.stalled++
+	return population == 0 || s.stalled > stallLimit
+}
+
+// Run launches the Game of Life animation.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	b := newBoard(cfg, rng)
+	b.seed(cfg.Pattern, cfg.Density)
+	var watch stagnation
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		drawBoard(grid, b)
+		grid.Render(screen)
+		population := b.step()
+		if watch.stuck(b.hash(), population) {
+			b.seed(cfg.Pattern, cfg.Density)
+			watch = stagnation{}
+		}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawBoard colors live cells by age and leaves dim ghosts where cells
+// just died.
+func drawBoard(grid canvas.Grid, b *board) {
+	for y := range grid {
+		for x := range grid[y] {
+			i := y*b.width + x
+			switch age := b.age[i]; {
+			case age > 0:
+				glyph := 'o'
+				if age <= 2 {
+					glyph = 'O'
+				}
+				grid[y][x] = canvas.Cell{Glyph: glyph, Color: agePalette[min(len(agePalette)-1, (age-1)/3)]}
+			case b.ghost[i] > 0:
+				grid[y][x] = canvas.Cell{Glyph: '.', Color: ghostColor}
+			default:
+				grid[y][x] = canvas.Cell{Glyph: ' '}
+			}
+		}
+	}
+}
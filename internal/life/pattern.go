@@ -0,0 +1,77 @@
+package life
+
+// patterns are the classic seeds, drawn with 'O' for a live cell.
+var patterns = map[string][]string{
+	"glider": {
+		".O.",
+		"..O",
+		"OOO",
+	},
+	"rpentomino": {
+		".OO",
+		"OO.",
+		".O.",
+	},
+	"acorn": {
+		".O.....",
+		"...O...",
+		"OO..OOO",
+	},
+	"gosper": {
+		"........................O...........",
+		"......................O.O...........",
+		"............OO......OO............OO",
+		"...........O...O....OO............OO",
+		"OO........O.....O...OO..............",
+		"OO........O...O.OO....O.O...........",
+		"..........O.....O.......O...........",
+		"...........O...O....................",
+		"............OO......................",
+	},
+}
+
+// IsPattern reports whether name is a seed pattern. "soup" is random cells
+// at the configured density and "" picks something at random each time.
+func IsPattern(name string) bool {
+	if name == "" || name == "soup" {
+		return true
+	}
+	_, ok := patterns[name]
+	return ok
+}
+
+// seed clears the board and plants name; "" picks soup or one of the
+// classic patterns.
+func (b *board) seed(name string, density float64) {
+	if name == "" {
+		choices := []string{"soup", "soup", "gosper", "rpentomino", "acorn"}
+		name = choices[b.rng.Intn(len(choices))]
+	}
+	for i := range b.age {
+		b.age[i] = 0
+		b.ghost[i] = 0
+	}
+	if name == "soup" {
+		for i := range b.age {
+			if b.rng.Float64() < density {
+				b.age[i] = 1
+			}
+		}
+		return
+	}
+	shape := patterns[name]
+	// The gun goes in the top-left so its gliders have room to travel; the
+	// rest start in the middle.
+	x0, y0 := 2, 2
+	if name != "gosper" {
+		x0 = (b.width - len(shape[0])) / 2
+		y0 = (b.height - len(shape)) / 2
+	}
+	for dy, row := range shape {
+		for dx := 0; dx < len(row); dx++ {
+			if row[dx] == 'O' {
+				b.set(x0+dx, y0+dy)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule says how many live neighbours bring a dead cell to life and keep a
+// live one alive.
+type Rule struct {
+	born    [9]bool
+	survive [9]bool
+}
+
+// namedRules are the well-known rules by name.
+var namedRules = map[string]string{
+	"life":     "B3/S23",
+	"highlife": "B36/S23",
+	"daynight": "B3678/S34678",
+	"seeds":    "B2/S",
+	"maze":     "B3/S12345",
+}
+
+// ParseRule reads a rule in B/S notation, such as B3/S23 or B36/S23, or one
+// of the names life, highlife, daynight, seeds or maze.
+func ParseRule(spec string) (Rule, error) {
+	spec = strings.ToUpper(strings.TrimSpace(spec))
+	if named, ok := namedRules[strings.ToLower(spec)]; ok {
+		spec = named
+	}
+	bs, ss, ok := strings.Cut(spec, "/")
+	if !ok || !strings.HasPrefix(bs, "B") || !strings.HasPrefix(ss, "S") {
+		return Rule{}, fmt.Errorf("expected B<digits>/S<digits>")
+	}
+	var r Rule
+	for _, part := range []struct {
+		digits string
+		set    *[9]bool
+	}{{bs[1:], &r.born}, {ss[1:], &r.survive}} {
+		for _, d := range part.digits {
+			if d < '0' || d > '8' {
+				return Rule{}, fmt.Errorf("bad neighbour count %q", d)
+			}
+			part.set[d-'0'] = true
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,233 @@
+package maze
+
+import "math/rand"
+
+// carve opens the wall between cells a and b; b is where the generator is
+// working now.
+type carve struct {
+	a, b int
+}
+
+// layout is a cols by rows grid of cells, numbered row by row.
+type layout struct {
+	cols, rows int
+}
+
+// neighbours lists the cells next to c.
+func (l layout) neighbours(c int) []int {
+	x, y := c%l.cols, c/l.cols
+	var out []int
+	if x > 0 {
+		out = append(out, c-1)
+	}
+	if x < l.cols-1 {
+		out = append(out, c+1)
+	}
+	if y > 0 {
+		out = append(out, c-l.cols)
+	}
+	if y < l.rows-1 {
+		out = append(out, c+l.cols)
+	}
+	return out
+}
+
+// IsGenerator reports whether name is a maze generator.
+func IsGenerator(name string) bool {
+	switch name {
+	case "backtracker", "prim", "kruskal":
+		return true
+	}
+	return false
+}
+
+// IsSolver reports whether name is a maze solver.
+func IsSolver(name string) bool {
+	return name == "bfs" || name == "astar"
+}
+
+// generate returns the passages of a perfect maze in the order the named
+// algorithm carves them.
+func generate(name string, l layout, rng *rand.Rand) []carve {
+	switch name {
+	case "prim":
+		return prim(l, rng)
+	case "kruskal":
+		return kruskal(l, rng)
+	}
+	return backtracker(l, rng)
+}
+
+// backtracker walks to a random unvisited neighbour, backing up along its
+// trail whenever it is boxed in. It makes long winding corridors.
+func backtracker(l layout, rng *rand.Rand) []carve {
+	n := l.cols * l.rows
+	seen := make([]bool, n)
+	seen[0] = true
+	stack := []int{0}
+	carves := make([]carve, 0, n-1)
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		var open []int
+		for _, nb := range l.neighbours(c) {
+			if !seen[nb] {
+				open = append(open, nb)
+			}
+		}
+		if len(open) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := open[rng.Intn(len(open))]
+		seen[next] = true
+		carves = append(carves, carve{c, next})
+		stack = append(stack, next)
+	}
+	return carves
+}
+
+// prim grows the maze from one cell, each time opening a random wall on its
+// border. It makes short branching dead ends.
+func prim(l layout, rng *rand.Rand) []carve {
+	n := l.cols * l.rows
+	in := make([]bool, n)
+	in[0] = true
+	var frontier []carve
+	for _, nb := range l.neighbours(0) {
+		frontier = append(frontier, carve{0, nb})
+	}
+	carves := make([]carve, 0, n-1)
+	for len(frontier) > 0 {
+		i := rng.Intn(len(frontier))
+		e := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		if in[e.b] {
+			continue
+		}
+		in[e.b] = true
+		carves = append(carves, e)
+		for _, nb := range l.neighbours(e.b) {
+			if !in[nb] {
+				frontier = append(frontier, carve{e.b, nb})
+			}
+		}
+	}
+	return carves
+}
+
+// kruskal opens walls in random order wherever they join two parts of the
+// maze not yet connected, so it grows everywhere at once.
+func kruskal(l layout, rng *rand.Rand) []carve {
+	n := l.cols * l.rows
+	var walls []carve
+	for c := 0; c < n; c++ {
+		if c%l.cols < l.cols-1 {
+			walls = append(walls, carve{c, c + 1})
+		}
+		if c/l.cols < l.rows-1 {
+			walls = append(walls, carve{c, c + l.cols})
+		}
+	}
+	rng.Shuffle(len(walls), func(i, j int) { walls[i], walls[j] = walls[j], walls[i] })
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(c int) int {
+		if parent[c] != c {
+			parent[c] = find(parent[c])
+		}
+		return parent[c]
+	}
+	carves := make([]carve, 0, n-1)
+	for _, w := range walls {
+		ra, rb := find(w.a), find(w.b)
+		if ra == rb {
+			continue
+		}
+		parent[ra] = rb
+		carves = append(carves, w)
+	}
+	return carves
+}
+
+// solution is a solver's work: the cells in the order it reached them with
+// their distance from the start, and the path it found to the goal.
+type solution struct {
+	order []int
+	dist  []int
+	path  []int
+}
+
+// solve searches from the top-left cell to the bottom-right one through the
+// carved passages, breadth first or A* by Manhattan distance.
+func solve(name string, l layout, carves []carve) solution {
+	n := l.cols * l.rows
+	links := make([][]int, n)
+	for _, c := range carves {
+		links[c.a] = append(links[c.a], c.b)
+		links[c.b] = append(links[c.b], c.a)
+	}
+	goal := n - 1
+	dist := make([]int, n)
+	from := make([]int, n)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[0] = 0
+	var order []int
+	open := []int{0}
+	closed := make([]bool, n)
+	for len(open) > 0 {
+		// A* takes the open cell with the best estimate; BFS the oldest.
+		i := 0
+		if name == "astar" {
+			best := -1
+			for j, c := range open {
+				if f := dist[c] + l.manhattan(c, goal); best < 0 || f < best {
+					best, i = f, j
+				}
+			}
+		}
+		c := open[i]
+		open = append(open[:i], open[i+1:]...)
+		if closed[c] {
+			continue
+		}
+		closed[c] = true
+		order = append(order, c)
+		if c == goal {
+			break
+		}
+		for _, nb := range links[c] {
+			if dist[nb] < 0 {
+				dist[nb] = dist[c] + 1
+				from[nb] = c
+				open = append(open, nb)
+			}
+		}
+	}
+	var path []int
+	if dist[goal] >= 0 {
+		for c := goal; c != 0; c = from[c] {
+			path = append(path, c)
+		}
+		path = append(path, 0)
+	}
+	return solution{order: order, dist: dist, path: path}
+}
+
+func (l layout) manhattan(a, b int) int {
+	dx := a%l.cols - b%l.cols
+	dy := a/l.cols - b/l.cols
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
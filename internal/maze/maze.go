@@ -0,0 +1,377 @@
+package maze
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// holdFrames is how long the solved maze stays up before it fades.
+	holdFrames = 60
+	// fadeFrames is how long the dissolve takes.
+	fadeFrames = 30
+)
+
+// Sides of a wall cell that join another wall, for picking its glyph.
+const (
+	right = 1 << iota
+	down
+	left
+	up
+)
+
+var (
+	wallColor = "\x1b[38;5;67m"
+	headColor = "\x1b[38;5;231m"
+	pathColor = "\x1b[38;5;226m"
+	// searchColors shade the cells the solver reaches by how far they are
+	// from the start.
+	searchColors = []string{
+		"\x1b[38;5;24m",
+		"\x1b[38;5;25m",
+		"\x1b[38;5;61m",
+		"\x1b[38;5;97m",
+		"\x1b[38;5;133m",
+		"\x1b[38;5;169m",
+		"\x1b[38;5;205m",
+	}
+	boxGlyphs = map[int]rune{
+		right:                    '─',
+		left:                     '─',
+		left | right:             '─',
+		up:                       '│',
+		down:                     '│',
+		up | down:                '│',
+		right | down:             '┌',
+		left | down:              '┐',
+		right | up:               '└',
+		left | up:                '┘',
+		left | right | down:      '┬',
+		left | right | up:        '┴',
+		up | down | right:        '├',
+		up | down | left:         '┤',
+		up | down | left | right: '┼',
+	}
+)
+
+// Config controls the maze animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// CellSize is how many columns wide each passage is.
+	CellSize int
+	// Generator builds the maze: backtracker, prim or kruskal.
+	Generator string
+	// Solver searches it: bfs or astar.
+	Solver string
+	// Speed is how many steps of building or solving show each frame; 0
+	// picks one that takes a few seconds for the screen's size.
+	Speed int
+	// Seed makes the run repeatable; 0 seeds from the clock.
+	Seed int64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 30 * time.Millisecond,
+		CellSize:   3,
+		Generator:  "backtracker",
+		Solver:     "bfs",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 30 * time.Millisecond
+	}
+	if c.CellSize <= 0 {
+		c.CellSize = 3
+	}
+	if c.CellSize > 8 {
+		c.CellSize = 8
+	}
+	if !IsGenerator(c.Generator) {
+		c.Generator = "backtracker"
+	}
+	if !IsSolver(c.Solver) {
+		c.Solver = "bfs"
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	return c
+}
+
+// board is one maze on screen and how far its animation has got.
+type board struct {
+	layout
+	size   int
+	carves []carve
+	sol    solution
+	// carved, reached and traced count how much of the building, the
+	// search and the path are showing; fade counts frames after the hold.
+	carved  int
+	reached int
+	traced  int
+	hold    int
+	fade    int
+}
+
+func newBoard(cfg Config, rng *rand.Rand) *board {
+	l := layout{cols: (cfg.Width - 1) / (cfg.CellSize + 1), rows: (cfg.Height - 1) / 2}
+	carves := generate(cfg.Generator, l, rng)
+	return &board{
+		layout: l,
+		size:   cfg.CellSize,
+		carves: carves,
+		sol:    solve(cfg.Solver, l, carves),
+	}
+}
+
+// advance moves the animation on by speed steps and reports when the board
+// has faded out.
+func (b *board) advance(speed int) bool {
+	switch {
+	case b.carved < len(b.carves):
+		b.carved = min(len(b.carves), b.carved+speed)
+	case b.reached < len(b.sol.order):
+		b.reached = min(len(b.sol.order), b.reached+speed)
+	case b.traced < len(b.sol.path):
+		b.traced = min(len(b.sol.path), b.traced+max(1, speed/2))
+	case b.hold < holdFrames:
+		b.hold++
+	default:
+		b.fade++
+	}
+	return b.fade >= fadeFrames
+}
+
+// Run launches the maze: build, solve, show the path, fade and start again.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	glyphs := boxGlyphs
+	fill, block := '░', '█'
+	if !hasUnicode() {
+		glyphs, fill, block = nil, '.', '#'
+	}
+	b := newBoard(cfg, rng)
+	speed := cfg.Speed
+	if speed <= 0 {
+		speed = max(1, b.cols*b.rows/120)
+	}
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		grid.Clear()
+		drawBoard(grid, b, glyphs, fill, block)
+		grid.Render(screen)
+		if b.advance(speed) {
+			b = newBoard(cfg, rng)
+		}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawBoard draws the walls left standing, the cells the solver has reached,
+// the path so far and, while building, the cell being carved. Walls are the
+// grid lines between cells: cell x, y has its inside at column x*(size+1)+1
+// and row y*2+1.
+func drawBoard(grid canvas.Grid, b *board, glyphs map[int]rune, fill, block rune) {
+	width := b.cols*(b.size+1) + 1
+	height := b.rows*2 + 1
+	ox := (len(grid[0]) - width) / 2
+	oy := (len(grid) - height) / 2
+
+	wall := make([][]bool, height)
+	for y := range wall {
+		wall[y] = make([]bool, width)
+		for x := range wall[y] {
+			wall[y][x] = y%2 == 0 || x%(b.size+1) == 0
+		}
+	}
+	// The way in is on the left of the first cell and the way out on the
+	// right of the last.
+	wall[1][0] = false
+	wall[height-2][width-1] = false
+	for _, c := range b.carves[:b.carved] {
+		for _, p := range b.between(c.a, c.b) {
+			wall[p[1]][p[0]] = false
+		}
+	}
+
+	put := func(x, y int, glyph rune, color string) {
+		if b.fade > 0 && int(uint32(x*73856093^y*19349663)%fadeFrames) < b.fade {
+			return
+		}
+		grid.Set(ox+x, oy+y, glyph, color)
+	}
+	for y := range wall {
+		for x := range wall[y] {
+			if wall[y][x] {
+				put(x, y, wallGlyph(wall, x, y, glyphs), wallColor)
+			}
+		}
+	}
+
+	longest := 1
+	for _, c := range b.sol.order {
+		longest = max(longest, b.sol.dist[c])
+	}
+	shade := func(c int) string {
+		return searchColors[b.sol.dist[c]*(len(searchColors)-1)/longest]
+	}
+	reached := make([]bool, b.cols*b.rows)
+	for _, c := range b.sol.order[:b.reached] {
+		reached[c] = true
+		for _, p := range b.inside(c) {
+			put(p[0], p[1], fill, shade(c))
+		}
+	}
+	for _, c := range b.carves[:b.carved] {
+		if reached[c.a] && reached[c.b] {
+			for _, p := range b.between(c.a, c.b) {
+				put(p[0], p[1], fill, shade(c.b))
+			}
+		}
+	}
+	for i, c := range b.sol.path[:b.traced] {
+		for _, p := range b.inside(c) {
+			put(p[0], p[1], block, pathColor)
+		}
+		if i > 0 {
+			for _, p := range b.between(b.sol.path[i-1], c) {
+				put(p[0], p[1], block, pathColor)
+			}
+		}
+	}
+	if b.carved > 0 && b.carved < len(b.carves) {
+		for _, p := range b.inside(b.carves[b.carved-1].b) {
+			put(p[0], p[1], block, headColor)
+		}
+	}
+}
+
+// inside is the screen cells of cell c's passage.
+func (b *board) inside(c int) [][2]int {
+	x0 := (c%b.cols)*(b.size+1) + 1
+	y := (c/b.cols)*2 + 1
+	points := make([][2]int, b.size)
+	for i := range points {
+		points[i] = [2]int{x0 + i, y}
+	}
+	return points
+}
+
+// between is the screen cells of the wall between neighbouring cells a and
+// b.
+func (b *board) between(a, c int) [][2]int {
+	if a > c {
+		a, c = c, a
+	}
+	x := a % b.cols
+	y := a / b.cols
+	if c == a+1 {
+		return [][2]int{{(x + 1) * (b.size + 1), y*2 + 1}}
+	}
+	points := make([][2]int, b.size)
+	for i := range points {
+		points[i] = [2]int{x*(b.size+1) + 1 + i, y*2 + 2}
+	}
+	return points
+}
+
+// wallGlyph joins a wall cell up with the walls around it; with no box
+// glyphs it falls back to - | and +.
+func wallGlyph(wall [][]bool, x, y int, glyphs map[int]rune) rune {
+	at := func(x, y int) bool {
+		return y >= 0 && y < len(wall) && x >= 0 && x < len(wall[y]) && wall[y][x]
+	}
+	sides := 0
+	if at(x+1, y) {
+		sides |= right
+	}
+	if at(x, y+1) {
+		sides |= down
+	}
+	if at(x-1, y) {
+		sides |= left
+	}
+	if at(x, y-1) {
+		sides |= up
+	}
+	if glyphs != nil {
+		if g, ok := glyphs[sides]; ok {
+			return g
+		}
+		return '·'
+	}
+	switch sides {
+	case left, right, left | right:
+		return '-'
+	case up, down, up | down:
+		return '|'
+	}
+	return '+'
+}
+
+// hasUnicode guesses from the locale whether box drawing will show.
+func hasUnicode() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToUpper(v)
+			return strings.Contains(v, "UTF-8") || strings.Contains(v, "UTF8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package maze
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/golden"
+)
+
+// TestGoldenSolve builds a small maze with Prim's algorithm and solves it
+// with A* at a fixed seed, and checks the frames against
+// testdata/solve.golden.
+func TestGoldenSolve(t *testing.T) {
+	// Colors otherwise follow the terminal the test runs in.
+	color.Use(color.ANSI256)
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 40, 16
+	cfg.FrameDelay = time.Millisecond
+	cfg.MaxFrames = 40
+	cfg.Generator, cfg.Solver = "prim", "astar"
+	cfg.Speed = 8
+	cfg.Seed = 1
+	cfg.Output = &out
+	if err := RunContext(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "solve.golden", out.Bytes())
+}
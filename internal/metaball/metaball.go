@@ -0,0 +1,33 @@
+// Package metaball is the blob field shared by the modes that melt round
+// blobs together: each ball adds an inverse-square falloff, and where the
+// sum passes 1 the blobs merge into one surface.
+package metaball
+
+// Ball is one blob. Position and velocity are in the caller's units, which
+// should be square on screen, and R is the radius of the ball on its own.
+type Ball struct {
+	X, Y   float64
+	VX, VY float64
+	R      float64
+}
+
+// Field is the summed field of balls at x, y; it is 1 on the surface of a
+// lone ball and above 1 inside.
+func Field(balls []Ball, x, y float64) float64 {
+	f := 0.0
+	for _, b := range balls {
+		dx, dy := x-b.X, y-b.Y
+		f += b.R * b.R / (dx*dx + dy*dy + 1e-6)
+	}
+	return f
+}
+
+// Level maps a field value onto 0..1 with a hard step at the surface: the
+// glow outside stays below 0.4 and the inside starts at 0.5 and brightens
+// toward the centers.
+func Level(f float64) float64 {
+	if f < 1 {
+		return 0.4 * f * f
+	}
+	return 0.5 + 0.5*(1-1/f)
+}
@@ -0,0 +1,254 @@
+package night
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/noise"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 30
+	minHeight = 12
+	// meteorDelay is the frame delay while a shooting star is in flight;
+	// the rest of the time the sky barely needs redrawing.
+	meteorDelay = 50 * time.Millisecond
+	trailLength = 8
+	// cloudDrift is how many columns a second the wisps move.
+	cloudDrift = 0.15
+	// cloudCover is the noise level above which there is cloud.
+	cloudCover = 0.72
+)
+
+var (
+	starGlyphs  = []rune{'.', '.', '+', '*'}
+	starColors  = []string{"\x1b[38;5;238m", "\x1b[38;5;242m", "\x1b[38;5;247m", "\x1b[38;5;252m", "\x1b[38;5;231m"}
+	moonColors  = []string{"\x1b[38;5;230m", "\x1b[38;5;187m"}
+	earthshine  = "\x1b[38;5;237m"
+	cloudColors = []string{"\x1b[38;5;236m", "\x1b[38;5;238m", "\x1b[38;5;60m"}
+	trailColors = []string{"\x1b[38;5;231m", "\x1b[38;5;253m", "\x1b[38;5;249m", "\x1b[38;5;244m", "\x1b[38;5;240m"}
+)
+
+// Config controls the night sky.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Density is the share of cells holding a star.
+	Density float64
+	// Phase is the moon's phase: 0 new, 0.5 full, 0.75 last quarter.
+	// Negative follows the real moon.
+	Phase float64
+	// Meteors is the average number of shooting stars a minute.
+	Meteors float64
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals. The sky changes
+// so slowly that a few frames a second is plenty.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 250 * time.Millisecond,
+		Density:    0.03,
+		Phase:      -1,
+		Meteors:    0.5,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 250 * time.Millisecond
+	}
+	if c.Density <= 0 {
+		c.Density = 0.03
+	}
+	if c.Density > 0.3 {
+		c.Density = 0.3
+	}
+	if c.Phase >= 1 {
+		c.Phase = math.Mod(c.Phase, 1)
+	}
+	if c.Meteors < 0 {
+		c.Meteors = 0.5
+	}
+	return c
+}
+
+// Run launches the night sky.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	stars := scatter(rng, cfg.Width, cfg.Height, cfg.Density)
+	clouds := noise.NewPerlin(cfg.Seed)
+	var shooting *meteor
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	delay := cfg.FrameDelay
+	ticker := time.NewTicker(delay)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for limit.Next() {
+		now := time.Now()
+		t := now.Sub(start).Seconds()
+		phase := cfg.Phase
+		if phase < 0 {
+			phase = moonPhase(now)
+		}
+
+		if shooting == nil && rng.Float64() < cfg.Meteors/60*delay.Seconds() {
+			shooting = launch(rng, cfg.Width, cfg.Height)
+		}
+		if shooting != nil && !shooting.update(delay.Seconds()) {
+			shooting = nil
+		}
+		// Run fast only while there is something quick to show.
+		want := cfg.FrameDelay
+		if shooting != nil {
+			want = min(want, meteorDelay)
+		}
+		if want != delay {
+			delay = want
+			ticker.Reset(delay)
+		}
+
+		grid.Clear()
+		drawStars(grid, stars, t)
+		drawMeteor(grid, shooting)
+		drawMoon(grid, phase)
+		drawClouds(grid, clouds, t)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func drawStars(grid canvas.Grid, stars []star, t float64) {
+	for _, s := range stars {
+		v := s.shine(t)
+		glyph := starGlyphs[min(len(starGlyphs)-1, int(v*float64(len(starGlyphs))))]
+		grid.Set(s.x, s.y, glyph, starColors[min(len(starColors)-1, int(v*float64(len(starColors))))])
+	}
+}
+
+// drawMeteor draws the trail from its oldest point up to the head, fading
+// toward the tail.
+func drawMeteor(grid canvas.Grid, m *meteor) {
+	if m == nil {
+		return
+	}
+	glyph := '\\'
+	if m.vx < 0 {
+		glyph = '/'
+	}
+	points := append(append([][2]float64(nil), m.trail...), [2]float64{m.x, m.y})
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		fade := float64(len(points)-1-i) / float64(trailLength)
+		color := trailColors[min(len(trailColors)-1, int(fade*float64(len(trailColors))))]
+		steps := int(math.Ceil(math.Hypot(b[0]-a[0], (b[1]-a[1])*2) * 2))
+		for s := 0; s <= steps; s++ {
+			f := float64(s) / float64(max(1, steps))
+			grid.Set(int(a[0]+(b[0]-a[0])*f), int(a[1]+(b[1]-a[1])*f), glyph, color)
+		}
+	}
+	grid.Set(int(m.x), int(m.y), '*', trailColors[0])
+}
+
+// drawMoon draws the moon up in the right of the sky, its lit side bright
+// and mottled and the rest just showing in earthshine.
+func drawMoon(grid canvas.Grid, phase float64) {
+	height := len(grid)
+	width := len(grid[0])
+	radius := max(2, height/8)
+	cx, cy := float64(width)*0.75, float64(height)*0.25
+	for y := int(cy) - radius; y <= int(cy)+radius; y++ {
+		for x := int(cx) - radius*2 - 1; x <= int(cx)+radius*2+1; x++ {
+			u := (float64(x) + 0.5 - cx) / float64(radius*2)
+			v := (float64(y) + 0.5 - cy) / float64(radius)
+			if u*u+v*v > 1 {
+				continue
+			}
+			if !lit(u, v, phase) {
+				grid.Set(x, y, ' ', "")
+				if u*u+v*v > 0.7 {
+					grid.Set(x, y, '.', earthshine)
+				}
+				continue
+			}
+			// A few darker seas, fixed in place on the face.
+			color := moonColors[0]
+			if math.Sin(u*5+1)*math.Cos(v*4-0.5) > 0.45 {
+				color = moonColors[1]
+			}
+			grid.Set(x, y, '#', color)
+		}
+	}
+}
+
+// drawClouds lays thin wisps over everything, drifting only a few columns
+// a minute. Stars behind them are hidden; the moon glows through.
+func drawClouds(grid canvas.Grid, field noise.Field, t float64) {
+	for y := range grid {
+		for x := range grid[y] {
+			v := field.At((float64(x)-t*cloudDrift)*0.045, float64(y)*0.3)
+			if v < cloudCover {
+				continue
+			}
+			glyph, color := '-', cloudColors[0]
+			if v > cloudCover+0.06 {
+				glyph, color = '~', cloudColors[1]
+			}
+			if grid[y][x].Glyph == '#' {
+				color = cloudColors[2]
+			}
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
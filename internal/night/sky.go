@@ -0,0 +1,105 @@
+package night
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// synodicMonth is the days from one new moon to the next.
+const synodicMonth = 29.530588853
+
+// knownNewMoon is a new moon to count phases from.
+var knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// moonPhase is how far through its month the moon is at t: 0 new, 0.25
+// first quarter, 0.5 full, 0.75 last quarter.
+func moonPhase(t time.Time) float64 {
+	days := t.Sub(knownNewMoon).Hours() / 24
+	phase := math.Mod(days/synodicMonth, 1)
+	if phase < 0 {
+		phase++
+	}
+	return phase
+}
+
+// lit reports whether the point u, v on the moon's disc, both -1 to 1 with
+// u to the east, is in sunlight at phase. The moon waxes from the right.
+func lit(u, v, phase float64) bool {
+	terminator := math.Cos(2*math.Pi*phase) * math.Sqrt(math.Max(0, 1-v*v))
+	if phase < 0.5 {
+		return u > terminator
+	}
+	return u < -terminator
+}
+
+// star is a fixed point of light that twinkles at its own slow pace.
+type star struct {
+	x, y   int
+	bright float64
+	rate   float64
+	offset float64
+}
+
+// scatter places stars over the sky, density per cell, with the odd bright
+// one among many faint ones.
+func scatter(r *rand.Rand, width, height int, density float64) []star {
+	count := int(float64(width*height) * density)
+	stars := make([]star, count)
+	for i := range stars {
+		bright := r.Float64()
+		stars[i] = star{
+			x:      r.Intn(width),
+			y:      r.Intn(height),
+			bright: bright * bright * bright,
+			rate:   0.2 + r.Float64()*0.6,
+			offset: r.Float64() * 2 * math.Pi,
+		}
+	}
+	return stars
+}
+
+// shine is how bright s is t seconds in, 0 to 1.
+func (s star) shine(t float64) float64 {
+	return s.bright * (0.7 + 0.3*math.Sin(t*s.rate+s.offset))
+}
+
+// meteor is a shooting star: a bright head running down the sky with a
+// short trail behind it.
+type meteor struct {
+	x, y   float64
+	vx, vy float64
+	life   float64
+	trail  [][2]float64
+}
+
+// launch starts a meteor somewhere in the upper sky, slanting down to one
+// side, to burn out in under a second.
+func launch(r *rand.Rand, width, height int) *meteor {
+	dir := 1.0
+	if r.Intn(2) == 0 {
+		dir = -1
+	}
+	speed := float64(width) * (0.25 + r.Float64()*0.2)
+	angle := 0.25 + r.Float64()*0.35
+	return &meteor{
+		x:    float64(width) * (0.15 + r.Float64()*0.7),
+		y:    float64(height) * r.Float64() * 0.35,
+		vx:   dir * speed * math.Cos(angle),
+		vy:   speed * math.Sin(angle) / 2,
+		life: 0.5 + r.Float64()*0.4,
+	}
+}
+
+// update moves the meteor on by dt seconds and reports whether it still
+// burns.
+func (m *meteor) update(dt float64) bool {
+	m.trail = append(m.trail, [2]float64{m.x, m.y})
+	if len(m.trail) > trailLength {
+		m.trail = m.trail[1:]
+	}
+	m.x += m.vx * dt
+	m.y += m.vy * dt
+	m.life -= dt
+	return m.life > 0
+}
@@ -0,0 +1,160 @@
+// Package noise provides seeded 2D coherent noise for the animations. Every
+// generator returns values in 0-1 from At.
+package noise
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Field is a 2D noise function.
+type Field interface {
+	At(x, y float64) float64
+}
+
+// permutation is the shuffled 0-255 table, doubled so lookups can index
+// past 255 without wrapping.
+func permutation(seed int64) [512]int {
+	r := rand.New(rand.NewSource(seed))
+	var perm [512]int
+	p := r.Perm(256)
+	for i := range perm {
+		perm[i] = p[i&255]
+	}
+	return perm
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// Value is value noise: random heights on the integer lattice, blended
+// smoothly. Soft and blobby.
+type Value struct {
+	perm [512]int
+}
+
+func NewValue(seed int64) *Value {
+	return &Value{perm: permutation(seed)}
+}
+
+func (v *Value) At(x, y float64) float64 {
+	xi, yi := math.Floor(x), math.Floor(y)
+	fx, fy := x-xi, y-yi
+	ix, iy := int(xi)&255, int(yi)&255
+	h := func(i, j int) float64 {
+		return float64(v.perm[v.perm[(ix+i)&255]+((iy+j)&255)]) / 255
+	}
+	u, w := fade(fx), fade(fy)
+	return lerp(lerp(h(0, 0), h(1, 0), u), lerp(h(0, 1), h(1, 1), u), w)
+}
+
+// Perlin is classic gradient noise: smooth ridges and valleys with no
+// lattice-aligned blobs.
+type Perlin struct {
+	perm [512]int
+}
+
+func NewPerlin(seed int64) *Perlin {
+	return &Perlin{perm: permutation(seed)}
+}
+
+var gradients = [8][2]float64{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{math.Sqrt2 / 2, math.Sqrt2 / 2}, {-math.Sqrt2 / 2, math.Sqrt2 / 2},
+	{math.Sqrt2 / 2, -math.Sqrt2 / 2}, {-math.Sqrt2 / 2, -math.Sqrt2 / 2},
+}
+
+func (p *Perlin) At(x, y float64) float64 {
+	xi, yi := math.Floor(x), math.Floor(y)
+	fx, fy := x-xi, y-yi
+	ix, iy := int(xi)&255, int(yi)&255
+	dot := func(i, j int) float64 {
+		g := gradients[p.perm[p.perm[ix+i]+iy+j]&7]
+		return g[0]*(fx-float64(i)) + g[1]*(fy-float64(j))
+	}
+	u, w := fade(fx), fade(fy)
+	n := lerp(lerp(dot(0, 0), dot(1, 0), u), lerp(dot(0, 1), dot(1, 1), u), w)
+	// Unit gradients keep 2D Perlin within ±sqrt(1/2).
+	return clamp01(n/math.Sqrt2 + 0.5)
+}
+
+// Simplex is gradient noise on a triangular grid: cheaper than Perlin and
+// without its axis-aligned streaks.
+type Simplex struct {
+	perm [512]int
+}
+
+func NewSimplex(seed int64) *Simplex {
+	return &Simplex{perm: permutation(seed)}
+}
+
+var (
+	skew   = (math.Sqrt(3) - 1) / 2
+	unskew = (3 - math.Sqrt(3)) / 6
+)
+
+func (s *Simplex) At(x, y float64) float64 {
+	k := (x + y) * skew
+	i, j := math.Floor(x+k), math.Floor(y+k)
+	t := (i + j) * unskew
+	x0, y0 := x-(i-t), y-(j-t)
+
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	x1, y1 := x0-float64(i1)+unskew, y0-float64(j1)+unskew
+	x2, y2 := x0-1+2*unskew, y0-1+2*unskew
+
+	ii, jj := int(i)&255, int(j)&255
+	corner := func(gi int, dx, dy float64) float64 {
+		d := 0.5 - dx*dx - dy*dy
+		if d < 0 {
+			return 0
+		}
+		g := gradients[gi&7]
+		d *= d
+		return d * d * (g[0]*dx + g[1]*dy)
+	}
+	n := corner(s.perm[ii+s.perm[jj]], x0, y0) +
+		corner(s.perm[ii+i1+s.perm[jj+j1]], x1, y1) +
+		corner(s.perm[ii+1+s.perm[jj+1]], x2, y2)
+	// 100 brings the sum to roughly ±1 with these unit gradients.
+	return clamp01(n*100/2 + 0.5)
+}
+
+// Worley is cellular noise: the distance to the nearest of one random point
+// per lattice cell, which reads as cells or cracked mud.
+type Worley struct {
+	perm [512]int
+}
+
+func NewWorley(seed int64) *Worley {
+	return &Worley{perm: permutation(seed)}
+}
+
+func (w *Worley) At(x, y float64) float64 {
+	xi, yi := math.Floor(x), math.Floor(y)
+	best := math.Inf(1)
+	for j := -1; j <= 1; j++ {
+		for i := -1; i <= 1; i++ {
+			cx, cy := int(xi)+i, int(yi)+j
+			h := w.perm[w.perm[cx&255]+(cy&255)]
+			px := float64(cx) + float64(h)/255
+			py := float64(cy) + float64(w.perm[h+1])/255
+			best = math.Min(best, math.Hypot(px-x, py-y))
+		}
+	}
+	// The nearest point is never more than sqrt(2) away; in practice it is
+	// rarely past 1.
+	return clamp01(best)
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,68 @@
+package noise
+
+import (
+	"math"
+	"testing"
+)
+
+var fields = []struct {
+	name string
+	f    Field
+}{
+	{"value", NewValue(1)},
+	{"perlin", NewPerlin(1)},
+	{"simplex", NewSimplex(1)},
+	{"worley", NewWorley(1)},
+}
+
+// TestRange samples each field across many lattice cells, negative ones
+// included, and checks every value is in 0-1 and that the field is not flat.
+func TestRange(t *testing.T) {
+	for _, tc := range fields {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for y := -40.0; y < 40; y += 0.37 {
+			for x := -40.0; x < 40; x += 0.37 {
+				v := tc.f.At(x, y)
+				if v < 0 || v > 1 || math.IsNaN(v) {
+					t.Fatalf("%s: At(%v, %v) = %v, want 0-1", tc.name, x, y, v)
+				}
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+		if hi-lo < 0.3 {
+			t.Errorf("%s: values only span %v-%v", tc.name, lo, hi)
+		}
+	}
+}
+
+// TestContinuity walks each field in small steps, across cell edges too,
+// and checks no step jumps.
+func TestContinuity(t *testing.T) {
+	const step = 1e-3
+	for _, tc := range fields {
+		for _, dir := range [][2]float64{{1, 0}, {0, 1}, {0.6, 0.8}} {
+			x, y := -3.1, 2.7
+			prev := tc.f.At(x, y)
+			for i := 0; i < 8000; i++ {
+				x += dir[0] * step
+				y += dir[1] * step
+				v := tc.f.At(x, y)
+				if d := math.Abs(v - prev); d > 0.02 {
+					t.Fatalf("%s: jumps by %v at (%v, %v)", tc.name, d, x, y)
+				}
+				prev = v
+			}
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	a, b, c := NewPerlin(7), NewPerlin(7), NewPerlin(8)
+	x, y := 3.3, 4.4
+	if a.At(x, y) != b.At(x, y) {
+		t.Error("the same seed gives different noise")
+	}
+	if a.At(x, y) == c.At(x, y) {
+		t.Error("a different seed gives the same noise")
+	}
+}
@@ -0,0 +1,157 @@
+package ocean
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+const rippleFrames = 10
+
+var (
+	gullColor   = "\x1b[38;5;255m"
+	rippleColor = "\x1b[38;5;153m"
+)
+
+type birdStage int
+
+const (
+	birdGliding birdStage = iota
+	birdDiving
+)
+
+type bird struct {
+	x, y  float64
+	vx    float64
+	vy    float64
+	baseY float64
+	amp   float64
+	t     float64
+	flap  int
+	stage birdStage
+}
+
+type ripple struct {
+	x, y int
+	age  int
+}
+
+// flock keeps the gulls together with the splashes and ripples left by their dives.
+type flock struct {
+	birds    []bird
+	splashes []bubble
+	ripples  []ripple
+	rng      *rand.Rand
+}
+
+func newFlock(cfg Config, rng *rand.Rand) *flock {
+	f := &flock{birds: make([]bird, cfg.Birds), rng: rng}
+	for i := range f.birds {
+		f.birds[i] = newBird(cfg.Width, cfg.Height/3, true, rng)
+	}
+	return f
+}
+
+// newBird places a gull just past a screen edge, or anywhere in the sky when visible is set.
+func newBird(width, sky int, visible bool, rng *rand.Rand) bird {
+	b := bird{
+		vx:    0.15 + rng.Float64()*0.2,
+		baseY: 1 + rng.Float64()*float64(max(1, sky-4)),
+		amp:   0.5 + rng.Float64()*1.2,
+		t:     rng.Float64() * 2 * math.Pi,
+		flap:  rng.Intn(12),
+	}
+	b.x = -1
+	if rng.Intn(2) == 0 {
+		b.vx = -b.vx
+		b.x = float64(width)
+	}
+	if visible {
+		b.x = rng.Float64() * float64(width)
+	}
+	b.y = b.baseY
+	return b
+}
+
+// update moves the gulls; sky is the current waterline row.
+func (f *flock) update(width, sky int) {
+	for i := range f.birds {
+		b := &f.birds[i]
+		switch b.stage {
+		case birdGliding:
+			b.t += 0.06
+			b.x += b.vx
+			b.y = canvas.Clamp(b.baseY+math.Sin(b.t)*b.amp, 0, float64(sky-2))
+			if b.x < -2 || b.x > float64(width+1) {
+				*b = newBird(width, sky, false, f.rng)
+				continue
+			}
+			if b.x > 4 && b.x < float64(width-4) && f.rng.Float64() < 0.002 {
+				b.stage = birdDiving
+				b.vx *= 0.6
+				b.vy = 0.15
+			}
+		case birdDiving:
+			b.x += b.vx
+			b.y += b.vy
+			b.vy += 0.03
+			if b.y >= float64(sky) {
+				emitSplash(&f.splashes, b.x, float64(sky), f.rng)
+				f.ripples = append(f.ripples, ripple{x: int(math.Round(b.x)), y: sky + 1})
+				*b = newBird(width, sky, false, f.rng)
+			}
+		}
+	}
+
+	updateSplashes(&f.splashes, sky)
+
+	dst := f.ripples[:0]
+	for _, r := range f.ripples {
+		r.age++
+		if r.age < rippleFrames {
+			dst = append(dst, r)
+		}
+	}
+	f.ripples = dst
+}
+
+func (f *flock) draw(grid canvas.Grid, frame int, phase float64) {
+	height := len(grid)
+	width := len(grid[0])
+	cx, cy, sun, lit := celestialPosition(phase, width, height)
+	reach := 0
+	if sun {
+		reach = 1
+	}
+
+	for _, b := range f.birds {
+		x := int(math.Round(b.x))
+		y := int(math.Round(b.y))
+		// Let the gull slip behind the sun or moon rather than paint over it.
+		if lit && y == cy && canvas.Abs(x-cx) <= reach {
+			continue
+		}
+		glyph := 'v'
+		switch {
+		case b.stage == birdDiving && b.vx > 0:
+			glyph = '\\'
+		case b.stage == birdDiving:
+			glyph = '/'
+		case ((frame+b.flap)/6)%2 == 1:
+			glyph = '~'
+		}
+		grid.Set(x, y, glyph, gullColor)
+	}
+
+	for _, r := range f.ripples {
+		radius := 1 + r.age/3
+		if r.age == 0 {
+			grid.Set(r.x, r.y, 'o', rippleColor)
+			continue
+		}
+		grid.Set(r.x-radius, r.y, '(', rippleColor)
+		grid.Set(r.x+radius, r.y, ')', rippleColor)
+	}
+	drawSplashes(grid, f.splashes)
+}
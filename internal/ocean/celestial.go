@@ -0,0 +1,163 @@
+package ocean
+
+import (
+	"math"
+
+	"animinterminal/internal/canvas"
+)
+
+const twilightWidth = 0.06
+
+var (
+	daySkyPalette = []string{
+		"\x1b[38;5;27m",
+		"\x1b[38;5;33m",
+		"\x1b[38;5;39m",
+		"\x1b[38;5;75m",
+		"\x1b[38;5;111m",
+		"\x1b[38;5;117m",
+	}
+	duskSkyPalette = []string{
+		"\x1b[38;5;132m",
+		"\x1b[38;5;168m",
+		"\x1b[38;5;203m",
+		"\x1b[38;5;209m",
+		"\x1b[38;5;215m",
+		"\x1b[38;5;216m",
+	}
+	dayHorizonPalette = []string{
+		"\x1b[38;5;117m",
+		"\x1b[38;5;153m",
+		"\x1b[38;5;189m",
+		"\x1b[38;5;195m",
+	}
+	duskHorizonPalette = []string{
+		"\x1b[38;5;166m",
+		"\x1b[38;5;202m",
+		"\x1b[38;5;208m",
+		"\x1b[38;5;214m",
+	}
+	sunPalette = []string{
+		"\x1b[38;5;226m",
+		"\x1b[38;5;228m",
+		"\x1b[38;5;230m",
+	}
+	moonPalette = []string{
+		"\x1b[38;5;252m",
+		"\x1b[38;5;254m",
+		"\x1b[38;5;231m",
+	}
+)
+
+func wrapPhase(p float64) float64 {
+	p = math.Mod(p, 1)
+	if p < 0 {
+		p++
+	}
+	return p
+}
+
+func isTwilight(phase float64) bool {
+	return math.Abs(phase-0.25) < twilightWidth || math.Abs(phase-0.75) < twilightWidth
+}
+
+func isDay(phase float64) bool {
+	return phase > 0.25 && phase < 0.75
+}
+
+// skyPaletteFor picks the sky colors for a row; rowNorm runs 0 at the top to 1 at the horizon.
+func skyPaletteFor(phase, rowNorm float64) []string {
+	switch {
+	case isTwilight(phase) && rowNorm > 0.45:
+		return duskSkyPalette
+	case isDay(phase):
+		return daySkyPalette
+	case isDeepNight(phase):
+		return nightSkyPalette
+	default:
+		return skyPalette
+	}
+}
+
+func horizonPaletteFor(phase float64) []string {
+	switch {
+	case isTwilight(phase):
+		return duskHorizonPalette
+	case isDay(phase):
+		return dayHorizonPalette
+	default:
+		return horizonPalette
+	}
+}
+
+// celestialPosition returns where the sun (by day) or moon (by night) sits.
+// ok is false while the body is below the horizon.
+func celestialPosition(phase float64, width, height int) (x, y int, sun bool, ok bool) {
+	sun = isDay(phase)
+	u := (phase - 0.25) / 0.5
+	if !sun {
+		u = wrapPhase(phase+0.25) / 0.5
+	}
+	if u < 0 || u > 1 {
+		return 0, 0, sun, false
+	}
+	limit := height / 3
+	x = int(math.Round(u * float64(width-1)))
+	y = limit - 1 - int(math.Round(math.Sin(math.Pi*u)*float64(limit-2)))
+	return x, y, sun, true
+}
+
+func drawCelestial(grid canvas.Grid, phase float64) {
+	height := len(grid)
+	width := len(grid[0])
+	x, y, sun, ok := celestialPosition(phase, width, height)
+	if !ok {
+		return
+	}
+	if sun {
+		grid.Set(x-1, y, '(', sunPalette[0])
+		grid.Set(x, y, 'O', sunPalette[2])
+		grid.Set(x+1, y, ')', sunPalette[0])
+		return
+	}
+	moon := 'C'
+	if isDeepNight(phase) {
+		moon = ')'
+	}
+	grid.Set(x, y, moon, moonPalette[2])
+}
+
+// drawLightPath paints the shimmering reflection of the sun or moon on the water.
+func drawLightPath(grid canvas.Grid, phase float64, sea seaState) {
+	height := len(grid)
+	width := len(grid[0])
+	cx, _, sun, ok := celestialPosition(phase, width, height)
+	if !ok {
+		return
+	}
+	palette := moonPalette
+	if sun {
+		palette = sunPalette
+	}
+	base := sea.waterline(height)
+	for y := base; y < height; y++ {
+		py := float64(y-base) / float64(height-base)
+		spread := 1 + int(py*4)
+		for dx := -spread; dx <= spread; dx++ {
+			x := cx + dx
+			if x < 0 || x >= width {
+				continue
+			}
+			value := sea.value(float64(x)/float64(width), py)
+			if value < 0.45+0.1*math.Abs(float64(dx))/float64(spread) {
+				continue
+			}
+			glyph := '-'
+			if value > 0.7 {
+				glyph = '='
+			}
+			idx := min(len(palette)-1, int((1-py)*float64(len(palette))))
+			grid.Set(x, y, glyph, palette[idx])
+		}
+	}
+}
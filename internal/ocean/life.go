@@ -0,0 +1,341 @@
+package ocean
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	fishPalette = []string{
+		"\x1b[38;5;214m",
+		"\x1b[38;5;220m",
+		"\x1b[38;5;209m",
+	}
+	dolphinColor = "\x1b[38;5;247m"
+	whaleColor   = "\x1b[38;5;61m"
+
+	whaleRight = []string{
+		"   .------._ ",
+		">=(________o)",
+	}
+	whaleLeft = []string{
+		" _.------.   ",
+		"(o________)=<",
+	}
+)
+
+const (
+	whaleSpoutFrames = 60
+	dolphinSpan      = 14.0
+	dolphinLeap      = 4.0
+)
+
+type fish struct {
+	x, y   float64
+	vx, vy float64
+}
+
+type school struct {
+	fish  []fish
+	speed float64
+	color string
+}
+
+type dolphin struct {
+	active bool
+	startX float64
+	dir    float64
+	t      float64
+}
+
+type whaleStage int
+
+const (
+	whaleRising whaleStage = iota
+	whaleSpouting
+	whaleDiving
+)
+
+type whale struct {
+	active bool
+	x, y   float64
+	dir    float64
+	stage  whaleStage
+	timer  int
+}
+
+// marineLife owns every creature that lives below (or briefly above) the surface.
+type marineLife struct {
+	density  float64
+	schools  []school
+	dolphin  dolphin
+	whale    whale
+	splashes []bubble
+	rng      *rand.Rand
+}
+
+func newMarineLife(cfg Config, rng *rand.Rand) *marineLife {
+	m := &marineLife{density: cfg.Life, rng: rng}
+	count := max(1, int(math.Round(cfg.Life*4)))
+	m.schools = make([]school, count)
+	for i := range m.schools {
+		m.schools[i] = newSchool(cfg.Width, cfg.Height, cfg.Height/3, true, rng)
+	}
+	return m
+}
+
+func newSchool(width, height, base int, visible bool, rng *rand.Rand) school {
+	dir := 1.0
+	if rng.Intn(2) == 0 {
+		dir = -1
+	}
+	s := school{
+		speed: dir * (0.12 + rng.Float64()*0.18),
+		color: fishPalette[rng.Intn(len(fishPalette))],
+	}
+	cx := -6.0
+	if dir < 0 {
+		cx = float64(width) + 6
+	}
+	if visible {
+		cx = rng.Float64() * float64(width)
+	}
+	cy := float64(base+4) + rng.Float64()*float64(max(1, height-base-8))
+	n := 4 + rng.Intn(5)
+	s.fish = make([]fish, n)
+	for i := range s.fish {
+		s.fish[i] = fish{
+			x:  cx + rng.Float64()*6 - 3,
+			y:  cy + rng.Float64()*3 - 1.5,
+			vx: s.speed,
+		}
+	}
+	return s
+}
+
+// update moves every creature; base is the current waterline row.
+func (m *marineLife) update(width, height, base int) {
+	for i := range m.schools {
+		if !updateSchool(&m.schools[i], width, height, base, m.rng) {
+			m.schools[i] = newSchool(width, height, base, false, m.rng)
+		}
+	}
+
+	if m.dolphin.active {
+		m.updateDolphin(base)
+	} else if m.rng.Float64() < m.density*0.004 {
+		m.dolphin = dolphin{
+			active: true,
+			startX: float64(width/6) + m.rng.Float64()*float64(width*2/3),
+			dir:    []float64{-1, 1}[m.rng.Intn(2)],
+		}
+		emitSplash(&m.splashes, m.dolphin.startX, float64(base), m.rng)
+	}
+
+	if m.whale.active {
+		m.updateWhale(base, height)
+	} else if m.rng.Float64() < m.density*0.0005 {
+		dir := []float64{-1, 1}[m.rng.Intn(2)]
+		m.whale = whale{
+			active: true,
+			x:      float64(width/4) + m.rng.Float64()*float64(width/2),
+			y:      float64(height - 2),
+			dir:    dir,
+		}
+	}
+
+	updateSplashes(&m.splashes, base)
+}
+
+// updateSchool moves a school forward and reports whether it is still on screen.
+func updateSchool(s *school, width, height, base int, rng *rand.Rand) bool {
+	if len(s.fish) == 0 {
+		return false
+	}
+	var cx, cy float64
+	for _, f := range s.fish {
+		cx += f.x
+		cy += f.y
+	}
+	cx /= float64(len(s.fish))
+	cy /= float64(len(s.fish))
+
+	top := float64(base + 2)
+	bottom := float64(height - 2)
+	for i := range s.fish {
+		f := &s.fish[i]
+		f.vx += (cx-f.x)*0.01 + (rng.Float64()-0.5)*0.03
+		f.vy += (cy-f.y)*0.012 + (rng.Float64()-0.5)*0.03
+		f.vx = f.vx*0.9 + s.speed*0.1
+		f.vy *= 0.9
+		f.x += f.vx
+		f.y = canvas.Clamp(f.y+f.vy, top, bottom)
+	}
+
+	if s.speed > 0 {
+		return cx < float64(width)+8
+	}
+	return cx > -8
+}
+
+func (m *marineLife) updateDolphin(base int) {
+	d := &m.dolphin
+	d.t += 0.03
+	if d.t >= 1 {
+		d.active = false
+		emitSplash(&m.splashes, d.startX+d.dir*dolphinSpan, float64(base), m.rng)
+	}
+}
+
+func (m *marineLife) updateWhale(base, height int) {
+	w := &m.whale
+	w.x += w.dir * 0.05
+	switch w.stage {
+	case whaleRising:
+		w.y -= 0.08
+		if w.y <= float64(base) {
+			w.y = float64(base)
+			w.stage = whaleSpouting
+			w.timer = whaleSpoutFrames
+		}
+	case whaleSpouting:
+		w.timer--
+		if w.timer <= 0 {
+			w.stage = whaleDiving
+		}
+	case whaleDiving:
+		w.y += 0.1
+		if w.y > float64(height) {
+			w.active = false
+		}
+	}
+}
+
+// emitSplash throws a handful of foam droplets up from the surface.
+func emitSplash(splashes *[]bubble, x, y float64, rng *rand.Rand) {
+	count := 4 + rng.Intn(3)
+	for i := 0; i < count; i++ {
+		*splashes = append(*splashes, bubble{
+			x:     x + rng.Float64()*2 - 1,
+			y:     y,
+			vx:    rng.Float64()*0.6 - 0.3,
+			vy:    -0.4 - rng.Float64()*0.5,
+			life:  10 + rng.Intn(8),
+			color: foamPalette[rng.Intn(len(foamPalette))],
+		})
+	}
+}
+
+// updateSplashes lets droplets fall back until they rejoin the water.
+func updateSplashes(splashes *[]bubble, base int) {
+	items := *splashes
+	dst := items[:0]
+	surface := float64(base + 1)
+	for i := range items {
+		items[i].x += items[i].vx
+		items[i].y += items[i].vy
+		items[i].vy += 0.08
+		items[i].life--
+		if items[i].life <= 0 || items[i].y > surface {
+			continue
+		}
+		dst = append(dst, items[i])
+	}
+	*splashes = dst
+}
+
+func drawSplashes(grid canvas.Grid, splashes []bubble) {
+	for _, sp := range splashes {
+		grid.Set(int(math.Round(sp.x)), int(math.Round(sp.y)), '\'', sp.color)
+	}
+}
+
+func (m *marineLife) draw(grid canvas.Grid, frame, base int) {
+	for _, s := range m.schools {
+		for _, f := range s.fish {
+			glyph := '>'
+			if f.vx < 0 {
+				glyph = '<'
+			}
+			setUnderwater(grid, int(math.Round(f.x)), int(math.Round(f.y)), glyph, s.color)
+		}
+	}
+	if m.whale.active {
+		drawWhale(grid, m.whale, frame)
+	}
+	if m.dolphin.active {
+		drawDolphin(grid, m.dolphin, base)
+	}
+	drawSplashes(grid, m.splashes)
+}
+
+func drawDolphin(grid canvas.Grid, d dolphin, base int) {
+	surface := float64(base + 1)
+	head := '>'
+	if d.dir < 0 {
+		head = '<'
+	}
+	body := []rune{head, '=', '~'}
+	for k, glyph := range body {
+		t := d.t - float64(k)*0.05
+		if t < 0 {
+			continue
+		}
+		x := int(math.Round(d.startX + d.dir*t*dolphinSpan))
+		y := int(math.Round(surface - math.Sin(math.Pi*t)*dolphinLeap))
+		if float64(y) < surface {
+			grid.Set(x, y, glyph, dolphinColor)
+		} else {
+			setUnderwater(grid, x, y, glyph, dolphinColor)
+		}
+	}
+}
+
+func drawWhale(grid canvas.Grid, w whale, frame int) {
+	sprite := whaleRight
+	blowhole := 8
+	if w.dir < 0 {
+		sprite = whaleLeft
+		blowhole = 4
+	}
+	x := int(math.Round(w.x)) - len(sprite[0])/2
+	top := int(math.Round(w.y))
+	surfaced := w.stage == whaleSpouting
+	for row, line := range sprite {
+		for col := 0; col < len(line); col++ {
+			if line[col] == ' ' {
+				continue
+			}
+			if surfaced && row == 0 {
+				grid.Set(x+col, top+row, rune(line[col]), whaleColor)
+			} else {
+				setUnderwater(grid, x+col, top+row, rune(line[col]), whaleColor)
+			}
+		}
+	}
+	if !surfaced {
+		return
+	}
+	spout := 1 + (frame/4)%3
+	for i := 1; i <= spout; i++ {
+		color := foamPalette[min(len(foamPalette)-1, i-1)]
+		grid.Set(x+blowhole, top-i, '^', color)
+	}
+}
+
+// setUnderwater draws beneath the wave crests: only troughs let a creature show through.
+func setUnderwater(grid canvas.Grid, x, y int, glyph rune, color string) {
+	if y < 0 || y >= len(grid) {
+		return
+	}
+	if x < 0 || x >= len(grid[y]) {
+		return
+	}
+	switch grid[y][x].Glyph {
+	case '=', '~':
+		return
+	}
+	grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+}
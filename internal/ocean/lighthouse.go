@@ -0,0 +1,103 @@
+package ocean
+
+import (
+	"math"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	rockColor    = "\x1b[38;5;238m"
+	towerColor   = "\x1b[38;5;252m"
+	stripeColor  = "\x1b[38;5;160m"
+	lampColor    = "\x1b[38;5;229m"
+	lampDimColor = "\x1b[38;5;136m"
+	beamColor    = "\x1b[38;5;230m"
+	beamSeaColor = "\x1b[38;5;195m"
+)
+
+// drawLighthouse renders the tower on its rock and the beam for the given sweep angle.
+// side is -1 for the left edge and 1 for the right edge; the rock stays put
+// while waterline follows the tide.
+func drawLighthouse(grid canvas.Grid, side int, angle float64, waterline int) {
+	height := len(grid)
+	width := len(grid[0])
+	base := height / 3
+	towerHeight := canvas.Clamp(base-2, 3, 7)
+	cx := 4
+	if side > 0 {
+		cx = width - 5
+	}
+	lampY := base - towerHeight
+
+	drawBeam(grid, cx, lampY, side, angle, waterline)
+
+	grid.Set(cx-1, lampY-1, '_', towerColor)
+	grid.Set(cx, lampY-1, '^', towerColor)
+	grid.Set(cx+1, lampY-1, '_', towerColor)
+
+	lamp := lampDimColor
+	if math.Sin(angle) > 0.8 {
+		lamp = lampColor
+	}
+	grid.Set(cx-1, lampY, '|', towerColor)
+	grid.Set(cx, lampY, '*', lamp)
+	grid.Set(cx+1, lampY, '|', towerColor)
+
+	for y := lampY + 1; y < base-1; y++ {
+		fill, color := ' ', towerColor
+		if (y-lampY)%2 == 0 {
+			fill, color = '=', stripeColor
+		}
+		grid.Set(cx-1, y, '|', towerColor)
+		grid.Set(cx, y, fill, color)
+		grid.Set(cx+1, y, '|', towerColor)
+	}
+
+	printRock(grid, cx-2, base-1, "/###\\")
+	printRock(grid, cx-3, base, "#######")
+	printRock(grid, cx-5, base+1, "~#########~")
+}
+
+func printRock(grid canvas.Grid, x, y int, text string) {
+	grid.Text(x, y, text, rockColor)
+}
+
+// drawBeam sweeps a wedge of light away from the tower. The beam only shows
+// while it points across the screen; the far half of each rotation faces away.
+func drawBeam(grid canvas.Grid, lx, ly int, side int, angle float64, base int) {
+	reach := math.Cos(angle)
+	if reach <= 0.05 {
+		return
+	}
+	height := len(grid)
+	width := len(grid[0])
+	dir := 1
+	if side > 0 {
+		dir = -1
+	}
+	length := int(reach * float64(width) * 0.9)
+	slope := float64(base-ly) / (float64(width) * 0.9)
+	for dist := 2; dist < length; dist++ {
+		x := lx + dir*dist
+		if x < 0 || x >= width {
+			break
+		}
+		center := float64(ly) + float64(dist)*slope
+		spread := 0.4 + float64(dist)*0.035
+		for y := int(center - spread); y <= int(center+spread); y++ {
+			if y < 0 || y >= height {
+				continue
+			}
+			if y >= base {
+				grid[y][x].Color = beamSeaColor
+				continue
+			}
+			glyph := '-'
+			if math.Abs(float64(y)-center) > spread*0.6 {
+				glyph = '.'
+			}
+			grid.SetIfEmpty(x, y, glyph, beamColor)
+		}
+	}
+}
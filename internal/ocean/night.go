@@ -0,0 +1,76 @@
+package ocean
+
+import "animinterminal/internal/canvas"
+
+// nightPhase is where the night preset parks the clock: the moon is up and low.
+const nightPhase = 0.9
+
+var (
+	nightSkyPalette = []string{
+		"\x1b[38;5;17m",
+		"\x1b[38;5;54m",
+		"\x1b[38;5;55m",
+		"\x1b[38;5;56m",
+		"\x1b[38;5;18m",
+		"\x1b[38;5;54m",
+	}
+	nightWavePalette = []string{
+		"\x1b[38;5;23m",
+		"\x1b[38;5;23m",
+		"\x1b[38;5;29m",
+		"\x1b[38;5;30m",
+		"\x1b[38;5;36m",
+	}
+	glowPalette = []string{
+		"\x1b[38;5;29m",
+		"\x1b[38;5;36m",
+		"\x1b[38;5;43m",
+		"\x1b[38;5;50m",
+		"\x1b[38;5;87m",
+		"\x1b[38;5;159m",
+	}
+	nightStarPalette = []string{
+		"\x1b[38;5;189m",
+		"\x1b[38;5;231m",
+	}
+)
+
+func isDeepNight(phase float64) bool {
+	return phase < 0.15 || phase > 0.85
+}
+
+func wavePaletteFor(phase float64) []string {
+	if isDeepNight(phase) {
+		return nightWavePalette
+	}
+	return wavePalette
+}
+
+// glowForWave brightens plankton as a crest rolls over them.
+func glowForWave(value float64) (rune, string) {
+	idx := min(len(glowPalette)-1, int(canvas.Clamp(value, 0, 0.999)*float64(len(glowPalette))))
+	if value > 0.72 {
+		return '*', glowPalette[len(glowPalette)-1]
+	}
+	if value > 0.55 {
+		return 'o', glowPalette[idx]
+	}
+	return '.', glowPalette[idx]
+}
+
+func drawNightStars(grid canvas.Grid, frame int, limit int) {
+	width := len(grid[0])
+	for y := 0; y < limit-1; y++ {
+		for x := 0; x < width; x++ {
+			seed := x*73 + y*151
+			if seed%47 != 0 {
+				continue
+			}
+			glyph := '.'
+			if (seed/47+frame/20)%9 == 0 {
+				glyph = '+'
+			}
+			grid.SetIfEmpty(x, y, glyph, nightStarPalette[(seed+frame/30)%len(nightStarPalette)])
+		}
+	}
+}
@@ -1,15 +1,21 @@
 package ocean
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
+// maxBubbles is as many bubbles as clicks can bring the sea to.
+const maxBubbles = 400
+
 var (
 	skyPalette = []string{
 		"\x1b[38;5;18m",
@@ -49,18 +55,86 @@ type Config struct {
 	Width      int
 	Height     int
 	FrameDelay time.Duration
+	// ShipDirection picks where ships sail: 1 to the right, -1 to the left, 0 at random.
+	ShipDirection int
+	ShipSpeed     float64
+	ShipInterval  time.Duration
+	// Life scales how many fish schools, dolphins and whales appear.
+	Life float64
+	// Cycle is the length of a full day; Phase is the time of day (0 midnight,
+	// 0.25 sunrise, 0.5 noon, 0.75 sunset) and stays fixed when LockPhase is set.
+	Cycle     time.Duration
+	Phase     float64
+	LockPhase bool
+	// Storm whips up the sea; it ramps in over about a minute.
+	Storm bool
+	// Night locks the scene to a moonlit night with glowing plankton.
+	Night bool
+	// Amplitude scales wave height (0.3–1.8), Choppiness weights the short
+	// high-frequency ripples (0–1) and SwellDirection steers the swell from
+	// -1 (travelling left) through 0 (standing) to 1 (travelling right).
+	Amplitude      float64
+	Choppiness     float64
+	SwellDirection float64
+	// LighthouseSide places a lighthouse on the left (-1) or right (1) edge; 0 disables it.
+	LighthouseSide int
+	BeamPeriod     time.Duration
+	// Birds is the number of gulls gliding over the water.
+	Birds int
+	// Underwater moves the camera below the surface.
+	Underwater bool
+	// TidePeriod is how long the waterline takes to rise and fall back, by up
+	// to TideRange rows; SetPeriod is the spacing of the bigger wave sets.
+	TidePeriod time.Duration
+	TideRange  int
+	SetPeriod  time.Duration
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+	// Mouse has a click send up a burst of bubbles from the pointer, or
+	// from just under the waterline when it is over the sky, on terminals
+	// that report the mouse.
+	Mouse bool
 }
 
 // DefaultConfig returns a preset that fits most terminals.
 func DefaultConfig() Config {
 	return Config{
-		Width:      100,
-		Height:     34,
-		FrameDelay: 35 * time.Millisecond,
+		Width:        100,
+		Height:       34,
+		FrameDelay:   35 * time.Millisecond,
+		ShipSpeed:    0.12,
+		ShipInterval: 45 * time.Second,
+		Life:         0.5,
+		Cycle:        5 * time.Minute,
+		Phase:        0.22,
+
+		Amplitude:      1,
+		Choppiness:     0.5,
+		SwellDirection: -1,
+
+		BeamPeriod: 6 * time.Second,
+		Birds:      3,
+
+		TidePeriod: 2 * time.Minute,
+		TideRange:  2,
+		SetPeriod:  20 * time.Second,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < 60 {
 		c.Width = 60
 	}
@@ -70,14 +144,55 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 40 * time.Millisecond
 	}
+	if c.ShipSpeed <= 0 {
+		c.ShipSpeed = 0.12
+	}
+	if c.ShipInterval <= 0 {
+		c.ShipInterval = 45 * time.Second
+	}
+	if c.Life <= 0 {
+		c.Life = 0.5
+	}
+	if c.Cycle <= 0 {
+		c.Cycle = 5 * time.Minute
+	}
+	if c.Amplitude <= 0 {
+		c.Amplitude = 1
+	}
+	c.Amplitude = canvas.Clamp(c.Amplitude, 0.3, 1.8)
+	c.Choppiness = canvas.Clamp(c.Choppiness, 0, 1)
+	c.SwellDirection = canvas.Clamp(c.SwellDirection, -1, 1)
+	if c.BeamPeriod <= 0 {
+		c.BeamPeriod = 6 * time.Second
+	}
+	if c.LighthouseSide > 0 {
+		c.LighthouseSide = 1
+	} else if c.LighthouseSide < 0 {
+		c.LighthouseSide = -1
+	}
+	if c.TidePeriod <= 0 {
+		c.TidePeriod = 2 * time.Minute
+	}
+	if c.SetPeriod <= 0 {
+		c.SetPeriod = 20 * time.Second
+	}
+	c.TideRange = canvas.Clamp(c.TideRange, 0, c.Height/8)
+	if c.Birds < 0 {
+		c.Birds = 0
+	}
+	if c.Night {
+		c.Phase = nightPhase
+		c.LockPhase = true
+	}
+	c.Phase = wrapPhase(c.Phase)
+	if c.ShipDirection > 0 {
+		c.ShipDirection = 1
+	} else if c.ShipDirection < 0 {
+		c.ShipDirection = -1
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
-}
-
 type bubble struct {
 	x, y  float64
 	vx    float64
@@ -87,132 +202,261 @@ type bubble struct {
 }
 
 // Run starts the ocean currents animation.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	grid := newGrid(cfg.Width, cfg.Height)
+	grid := canvas.New(cfg.Width, cfg.Height)
 	bubbles := make([]bubble, 0, 128)
 	plankton := make([]bubble, 0, 128)
+	ships := make([]ship, 0, 4)
+	shipChance := float64(cfg.FrameDelay) / float64(cfg.ShipInterval)
+	life := newMarineLife(cfg, rng)
+	birds := newFlock(cfg, rng)
+	phase := cfg.Phase
+	phaseStep := float64(cfg.FrameDelay) / float64(cfg.Cycle)
+	weather := newStorm(cfg, rng)
+	sea := newSeaState(cfg)
+	beamStep := 2 * math.Pi * float64(cfg.FrameDelay) / float64(cfg.BeamPeriod)
+	deep := newDeepScene(cfg, rng)
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
+	mouse := term.NoMouse
+	if cfg.Mouse {
+		mouse = term.MouseClicks
+	}
+	events := term.Events(mouse)
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
-		drawSky(grid, frame)
-		drawHorizonGlow(grid, frame)
-		drawWaveLayers(grid, frame)
-		drawFoam(grid, frame)
-		updatePlankton(&plankton, cfg.Width, cfg.Height)
-		drawPlankton(grid, plankton)
-		updateBubbles(&bubbles, cfg.Width, cfg.Height)
-		drawBubbles(grid, bubbles)
-		render(grid)
-
-		<-ticker.C
+	// clicks are where the pointer was clicked since the last frame. wait
+	// collects them until the next tick, and reports false once ctx is done.
+	var clicks [][2]int
+	wait := func() bool {
+		for {
+			select {
+			case ev := <-events:
+				// Clicks off the edge of a frame smaller than the
+				// terminal are ignored.
+				if ev.Click && ev.X < cfg.Width && ev.Y < cfg.Height {
+					clicks = append(clicks, [2]int{ev.X, ev.Y})
+				}
+			case <-ctx.Done():
+				return false
+			case <-ticker.C:
+				return true
+			}
+		}
 	}
-}
 
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
+	for frame := 0; limit.Next(); frame++ {
+		weather.update(cfg.Width, cfg.Height, sea)
+		sea = sea.advance(weather.intensity)
+
+		if cfg.Underwater {
+			for _, c := range clicks {
+				burst(&deep.bubbles, c[0], max(c[1], cfg.Height/4+1), rng)
+			}
+			clicks = clicks[:0]
+			grid.Clear()
+			deep.update(cfg.Width, cfg.Height)
+			deep.draw(grid, frame, sea)
+			grid.Render(screen)
+			if !wait() {
+				return context.Cause(ctx)
+			}
+			continue
+		}
+
+		night := isDeepNight(phase)
+		base := sea.waterline(cfg.Height)
+
+		grid.Clear()
+		drawSky(grid, frame, base, phase, weather.sky())
+		if night {
+			drawNightStars(grid, frame, base)
+		}
+		drawHorizonGlow(grid, frame, base, phase, weather.intensity)
+		drawCelestial(grid, phase)
+		weather.drawRain(grid, frame, base)
+		drawWaveLayers(grid, frame, sea, wavePaletteFor(phase))
+		drawLightPath(grid, phase, sea)
+		if cfg.LighthouseSide != 0 {
+			drawLighthouse(grid, cfg.LighthouseSide, float64(frame)*beamStep, base)
+		}
+		drawFoam(grid, frame, sea)
+		life.update(cfg.Width, cfg.Height, base)
+		life.draw(grid, frame, base)
+		updateShips(&ships, cfg, shipChance, rng)
+		drawShips(grid, ships, frame, sea)
+		birds.update(cfg.Width, base)
+		birds.draw(grid, frame, phase)
+		weather.drawSpray(grid)
+		updatePlankton(&plankton, cfg.Width, cfg.Height, sea, night, rng)
+		drawPlankton(grid, plankton, sea, night)
+		for _, c := range clicks {
+			burst(&bubbles, c[0], max(c[1], base+1), rng)
+		}
+		clicks = clicks[:0]
+		updateBubbles(&bubbles, cfg.Width, cfg.Height, base, rng)
+		drawBubbles(grid, bubbles)
+		grid.Render(screen)
 
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		for x := range grid[y] {
-			grid[y][x] = cell{glyph: ' ', color: ""}
+		if !cfg.LockPhase {
+			phase = wrapPhase(phase + phaseStep)
+		}
+		if !wait() {
+			return context.Cause(ctx)
 		}
 	}
+	return nil
 }
 
-func drawSky(grid [][]cell, frame int) {
-	height := len(grid)
+func drawSky(grid canvas.Grid, frame int, limit int, phase float64, storm stormSky) {
 	width := len(grid[0])
-	limit := height / 3
 	for y := 0; y < limit; y++ {
-		idx := (y/2 + frame/18) % len(skyPalette)
-		color := skyPalette[idx]
+		palette := storm.palette(skyPaletteFor(phase, float64(y)/float64(limit)))
+		idx := (y/2 + frame/18) % len(palette)
+		color := palette[idx]
 		for x := 0; x < width; x++ {
-			grid[y][x] = cell{glyph: ' ', color: color}
+			grid[y][x] = canvas.Cell{Glyph: ' ', Color: color}
 		}
 	}
-	drawClouds(grid, frame)
+	drawClouds(grid, frame, limit, storm.palette(skyPaletteFor(phase, 0)))
 }
 
-func drawClouds(grid [][]cell, frame int) {
-	height := len(grid)
+func drawClouds(grid canvas.Grid, frame int, limit int, palette []string) {
 	width := len(grid[0])
-	limit := height / 3
 	for i := 0; i < width/6; i++ {
 		x := (i*9 + frame/2) % width
 		y := limit/2 + int(math.Sin(float64(x)/10+float64(frame)*0.01)*3)
 		if y < 1 || y >= limit {
 			continue
 		}
-		color := skyPalette[(i+frame/12)%len(skyPalette)]
-		setIfEmpty(grid, x, y, '~', color)
-		setIfEmpty(grid, (x+1)%width, y, '~', color)
+		color := palette[(i+frame/12)%len(palette)]
+		grid.SetIfEmpty(x, y, '~', color)
+		grid.SetIfEmpty((x+1)%width, y, '~', color)
 	}
 }
 
-func drawHorizonGlow(grid [][]cell, frame int) {
+func drawHorizonGlow(grid canvas.Grid, frame int, line int, phase float64, storm float64) {
 	height := len(grid)
 	width := len(grid[0])
-	line := height / 3
+	palette := horizonPaletteFor(phase)
+	if storm > 0.5 {
+		palette = stormHorizonPalette
+	}
 	for y := line; y < line+3 && y < height; y++ {
-		color := horizonPalette[(y+frame/10)%len(horizonPalette)]
+		color := palette[(y+frame/10)%len(palette)]
 		for x := 0; x < width; x++ {
-			setIfEmpty(grid, x, y, ' ', color)
+			grid.SetIfEmpty(x, y, ' ', color)
 		}
 	}
 }
 
-func drawWaveLayers(grid [][]cell, frame int) {
+func drawWaveLayers(grid canvas.Grid, frame int, sea seaState, palette []string) {
 	height := len(grid)
 	width := len(grid[0])
-	base := height / 3
-	layerConfigs := []struct {
-		scale float64
-		speed float64
-		amp   float64
-	}{
-		{scale: 1.0, speed: 1.0, amp: 1},
-		{scale: 1.5, speed: 0.7, amp: 0.8},
-		{scale: 2.3, speed: 0.4, amp: 0.6},
-	}
+	base := sea.waterline(height)
+	whitecap := 0.8 - 0.22*sea.storm
 	for y := base; y < height; y++ {
 		py := float64(y-base) / float64(height-base)
-		color := wavePalette[(int(py*float64(len(wavePalette)))+frame/15)%len(wavePalette)]
+		color := palette[(int(py*float64(len(palette)))+frame/15)%len(palette)]
 		for x := 0; x < width; x++ {
 			fx := float64(x) / float64(width)
-			value := 0.0
-			for _, cfg := range layerConfigs {
-				value += cfg.amp * waveValue(fx*cfg.scale, py*cfg.scale, frame, cfg.speed)
+			value := sea.value(fx, py)
+			if sea.storm > 0.1 && value > whitecap {
+				grid[y][x] = canvas.Cell{Glyph: '*', Color: foamPalette[(x+y)%len(foamPalette)]}
+				continue
 			}
-			value = value / float64(len(layerConfigs))
-			glyph := waveGlyph(value)
-			grid[y][x] = cell{glyph: glyph, color: color}
+			grid[y][x] = canvas.Cell{Glyph: waveGlyph(value), Color: color}
 		}
 	}
 }
 
-func waveValue(fx, fy float64, frame int, speed float64) float64 {
-	t := float64(frame) * 0.035 * speed
-	value := math.Sin((fx*8+fy*6)*math.Pi+t) +
-		0.7*math.Sin((fx*3-fy*5)*math.Pi+t*0.7) +
-		0.5*math.Sin((fx+fy)*12*math.Pi+t*1.4)
+var waveLayers = []struct {
+	scale float64
+	speed float64
+	amp   float64
+}{
+	{scale: 1.0, speed: 1.0, amp: 1},
+	{scale: 1.5, speed: 0.7, amp: 0.8},
+	{scale: 2.3, speed: 0.4, amp: 0.6},
+}
+
+// seaState carries the wave clock and swell so everything riding the water agrees.
+type seaState struct {
+	clock float64
+	storm float64
+	field waveField
+	tide  tideCycle
+}
+
+// waveField holds the coefficients derived from the wave settings in Config.
+type waveField struct {
+	amplitude float64
+	chop      float64
+	swell     float64
+}
+
+func newSeaState(cfg Config) seaState {
+	return seaState{
+		field: waveField{
+			amplitude: cfg.Amplitude,
+			chop:      cfg.Choppiness,
+			swell:     cfg.SwellDirection,
+		},
+		tide: newTideCycle(cfg),
+	}
+}
+
+// advance moves the wave clock on by one frame; storms make the sea run faster.
+func (s seaState) advance(storm float64) seaState {
+	s.storm = storm
+	s.clock += 1 + storm*1.2
+	s.tide = s.tide.advance()
+	return s
+}
+
+// value blends every wave layer at a normalized sea position.
+func (s seaState) value(fx, py float64) float64 {
+	value := 0.0
+	for _, layer := range waveLayers {
+		value += layer.amp * waveValue(fx*layer.scale, py*layer.scale, s.clock, layer.speed, s.field)
+	}
+	value /= float64(len(waveLayers))
+	amp := s.field.amplitude * (1 + s.storm*0.7) * s.tide.gain()
+	return canvas.Clamp(0.4+(value-0.4)*amp, 0, 1)
+}
+
+// waterline is the first sea row; the tide moves it a few rows either way.
+func (s seaState) waterline(height int) int {
+	return s.tide.waterline(height)
+}
+
+// waveValue samples one layer; the swell term drifts against the clock so a
+// negative direction travels left and a positive one travels right.
+func waveValue(fx, fy float64, clock float64, speed float64, field waveField) float64 {
+	t := clock * 0.035 * speed
+	drift := -field.swell * t
+	value := math.Sin((fx*8+fy*6)*math.Pi+drift) +
+		0.7*math.Sin((fx*3-fy*5)*math.Pi+drift*0.7) +
+		field.chop*math.Sin((fx+fy)*12*math.Pi+t*1.4)
 	return (value + 3) / 6
 }
 
-func waveGlyph(v float64) byte {
+func waveGlyph(v float64) rune {
 	switch {
 	case v < 0.2:
 		return '`'
@@ -227,129 +471,126 @@ func waveGlyph(v float64) byte {
 	}
 }
 
-func drawFoam(grid [][]cell, frame int) {
+// drawFoam lines the near water with foam; it thickens as a set rolls in.
+func drawFoam(grid canvas.Grid, frame int, sea seaState) {
 	height := len(grid)
 	width := len(grid[0])
 	base := height - 5
+	spacing := 7 - int(math.Round(sea.tide.set*4))
 	for x := 0; x < width; x++ {
-		if (x+frame)%7 == 0 {
+		if (x+frame)%spacing == 0 {
 			color := foamPalette[(x/4+frame/10)%len(foamPalette)]
-			for dy := 0; dy < 2 && base-dy >= height/3; dy++ {
-				setIfEmpty(grid, x, base-dy, '*', color)
+			for dy := 0; dy < 2 && base-dy >= sea.waterline(height); dy++ {
+				grid.SetIfEmpty(x, base-dy, '*', color)
 			}
 		}
 	}
 }
 
-func drawBubbles(grid [][]cell, bubbles []bubble) {
+func drawBubbles(grid canvas.Grid, bubbles []bubble) {
 	for _, b := range bubbles {
 		x := int(math.Round(b.x))
 		y := int(math.Round(b.y))
 		if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[0]) {
 			continue
 		}
-		setCell(grid, x, y, 'o', b.color)
+		grid.Set(x, y, 'o', b.color)
 	}
 }
 
-func updateBubbles(bubbles *[]bubble, width, height int) {
-	if rand.Intn(3) == 0 {
+func updateBubbles(bubbles *[]bubble, width, height, base int, rng *rand.Rand) {
+	if rng.Intn(3) == 0 {
 		*bubbles = append(*bubbles, bubble{
-			x:     rand.Float64() * float64(width),
+			x:     rng.Float64() * float64(width),
 			y:     float64(height - 1),
-			vx:    rand.Float64()*0.2 - 0.1,
-			vy:    -0.3 - rand.Float64()*0.4,
-			life:  40 + rand.Intn(40),
-			color: foamPalette[rand.Intn(len(foamPalette))],
+			vx:    rng.Float64()*0.2 - 0.1,
+			vy:    -0.3 - rng.Float64()*0.4,
+			life:  40 + rng.Intn(40),
+			color: foamPalette[rng.Intn(len(foamPalette))],
 		})
 	}
-	items := *bubbles
-	dst := items[:0]
-	for i := range items {
-		items[i].x += items[i].vx
-		items[i].y += items[i].vy
-		items[i].life--
-		if items[i].y < float64(height/3) || items[i].life <= 0 {
-			continue
-		}
-		dst = append(dst, items[i])
+	advanceParticles(bubbles, float64(base))
+}
+
+// burst sends up a cluster of bubbles from x, y, up to maxBubbles in all.
+func burst(bubbles *[]bubble, x, y int, rng *rand.Rand) {
+	count := min(8+rng.Intn(6), maxBubbles-len(*bubbles))
+	for i := 0; i < count; i++ {
+		*bubbles = append(*bubbles, bubble{
+			x:     float64(x) + rng.Float64()*2 - 1,
+			y:     float64(y) + rng.Float64(),
+			vx:    rng.Float64()*0.3 - 0.15,
+			vy:    -0.2 - rng.Float64()*0.5,
+			life:  30 + rng.Intn(40),
+			color: foamPalette[rng.Intn(len(foamPalette))],
+		})
 	}
-	*bubbles = dst
 }
 
-func drawPlankton(grid [][]cell, plankton []bubble) {
+func drawPlankton(grid canvas.Grid, plankton []bubble, sea seaState, night bool) {
+	height := len(grid)
+	width := len(grid[0])
+	base := sea.waterline(height)
 	for _, p := range plankton {
 		x := int(math.Round(p.x))
 		y := int(math.Round(p.y))
-		if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[0]) {
+		if y < 0 || y >= height || x < 0 || x >= width {
+			continue
+		}
+		if !night {
+			grid.Set(x, y, '.', p.color)
 			continue
 		}
-		setCell(grid, x, y, '.', p.color)
+		py := float64(y-base) / float64(height-base)
+		glyph, color := glowForWave(sea.value(float64(x)/float64(width), py))
+		grid.Set(x, y, glyph, color)
 	}
 }
 
-func updatePlankton(plankton *[]bubble, width, height int) {
-	if rand.Intn(4) == 0 {
-		*plankton = append(*plankton, bubble{
-			x:     rand.Float64() * float64(width),
-			y:     float64(height/2 + rand.Intn(height/2)),
-			vx:    rand.Float64()*0.3 - 0.15,
-			vy:    -rand.Float64() * 0.1,
-			life:  80 + rand.Intn(80),
-			color: planktonPalette[rand.Intn(len(planktonPalette))],
-		})
+func updatePlankton(plankton *[]bubble, width, height int, sea seaState, night bool, rng *rand.Rand) {
+	spawns := 0
+	if rng.Intn(4) == 0 {
+		spawns = 1
+	}
+	if night {
+		spawns = 3
 	}
-	items := *plankton
+	base := sea.waterline(height)
+	for i := 0; i < spawns; i++ {
+		p := bubble{
+			x:     rng.Float64() * float64(width),
+			y:     float64(height/2 + rng.Intn(height/2)),
+			vx:    rng.Float64()*0.3 - 0.15,
+			vy:    -rng.Float64() * 0.1,
+			life:  80 + rng.Intn(80),
+			color: planktonPalette[rng.Intn(len(planktonPalette))],
+		}
+		if night {
+			// Favor the crests: sample near the surface and keep high spots.
+			p.y = float64(base + 1 + rng.Intn(max(1, height-base-1)))
+			py := (p.y - float64(base)) / float64(height-base)
+			if sea.value(p.x/float64(width), py) < 0.45 && rng.Intn(3) != 0 {
+				continue
+			}
+		}
+		*plankton = append(*plankton, p)
+	}
+	advanceParticles(plankton, float64(base))
+}
+
+// advanceParticles moves every particle one step and drops those that have
+// expired or risen above top.
+func advanceParticles(particles *[]bubble, top float64) {
+	items := *particles
 	dst := items[:0]
 	for i := range items {
 		items[i].x += items[i].vx
 		items[i].y += items[i].vy
 		items[i].life--
-		if items[i].y < float64(height/3) || items[i].life <= 0 {
+		if items[i].y < top || items[i].life <= 0 {
 			continue
 		}
 		dst = append(dst, items[i])
 	}
-	*plankton = dst
-}
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-	fmt.Print(sb.String())
+	*particles = dst
 }
@@ -0,0 +1,32 @@
+package ocean
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/golden"
+)
+
+// TestGoldenWaves draws a few frames of a choppy swell running left at a
+// fixed seed and checks them against testdata/waves.golden.
+func TestGoldenWaves(t *testing.T) {
+	// Colors otherwise follow the terminal the test runs in.
+	color.Use(color.ANSI256)
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Width, cfg.Height = 48, 20
+	cfg.FrameDelay = time.Millisecond
+	cfg.MaxFrames = 4
+	cfg.Seed = 1
+	cfg.Amplitude = 1.4
+	cfg.Choppiness = 0.8
+	cfg.SwellDirection = -1
+	cfg.Output = &out
+	if err := RunContext(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "waves.golden", out.Bytes())
+}
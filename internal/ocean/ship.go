@@ -0,0 +1,136 @@
+package ocean
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	shipWidth  = 5
+	wakeLength = 7
+)
+
+var (
+	hullColor = "\x1b[38;5;236m"
+	mastColor = "\x1b[38;5;240m"
+	sailColor = "\x1b[38;5;253m"
+
+	// Sprites are listed top row first; the hull sits on the last row.
+	shipRight = []string{
+		"  |\\ ",
+		"  |_\\",
+		"\\___/",
+	}
+	shipLeft = []string{
+		" /|  ",
+		"/_|  ",
+		"\\___/",
+	}
+)
+
+type ship struct {
+	x   float64
+	dir int
+	vx  float64
+}
+
+func updateShips(ships *[]ship, cfg Config, spawnChance float64, rng *rand.Rand) {
+	if rng.Float64() < spawnChance {
+		*ships = append(*ships, newShip(cfg, rng))
+	}
+	items := *ships
+	dst := items[:0]
+	for i := range items {
+		items[i].x += items[i].vx
+		if items[i].dir > 0 && items[i].x > float64(cfg.Width+wakeLength) {
+			continue
+		}
+		if items[i].dir < 0 && items[i].x < -float64(shipWidth+wakeLength) {
+			continue
+		}
+		dst = append(dst, items[i])
+	}
+	*ships = dst
+}
+
+func newShip(cfg Config, rng *rand.Rand) ship {
+	dir := cfg.ShipDirection
+	if dir == 0 {
+		dir = 1
+		if rng.Intn(2) == 0 {
+			dir = -1
+		}
+	}
+	speed := cfg.ShipSpeed * (0.8 + rng.Float64()*0.4)
+	s := ship{dir: dir, vx: speed * float64(dir)}
+	if dir > 0 {
+		s.x = -shipWidth
+	} else {
+		s.x = float64(cfg.Width)
+	}
+	return s
+}
+
+func drawShips(grid canvas.Grid, ships []ship, frame int, sea seaState) {
+	for _, s := range ships {
+		drawShip(grid, s, frame, sea)
+	}
+}
+
+func drawShip(grid canvas.Grid, s ship, frame int, sea seaState) {
+	height := len(grid)
+	width := len(grid[0])
+	base := sea.waterline(height)
+	x := int(math.Round(s.x))
+
+	center := canvas.Clamp(float64(x+shipWidth/2)/float64(width), 0, 1)
+	bob := int(math.Round((sea.value(center, 0) - 0.5) * 2.5))
+	hullY := base + 1 + bob
+
+	drawWake(grid, s, x, hullY, frame)
+
+	sprite := shipRight
+	if s.dir < 0 {
+		sprite = shipLeft
+	}
+	top := hullY - len(sprite) + 1
+	for row, line := range sprite {
+		for col := 0; col < len(line); col++ {
+			glyph := rune(line[col])
+			if glyph == ' ' {
+				continue
+			}
+			color := sailColor
+			switch {
+			case row == len(sprite)-1:
+				color = hullColor
+			case glyph == '|':
+				color = mastColor
+			}
+			grid.Set(x+col, top+row, glyph, color)
+		}
+	}
+}
+
+func drawWake(grid canvas.Grid, s ship, x, y int, frame int) {
+	for i := 1; i <= wakeLength; i++ {
+		col := x - i
+		if s.dir < 0 {
+			col = x + shipWidth - 1 + i
+		}
+		if (i+frame/3)%3 == 0 {
+			continue
+		}
+		glyph := '='
+		switch {
+		case i > wakeLength*2/3:
+			glyph = '.'
+		case i > wakeLength/3:
+			glyph = '-'
+		}
+		color := foamPalette[min(len(foamPalette)-1, (wakeLength-i)*len(foamPalette)/wakeLength)]
+		grid.Set(col, y, glyph, color)
+	}
+}
@@ -0,0 +1,193 @@
+package ocean
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/bolt"
+	"animinterminal/internal/canvas"
+)
+
+const stormRamp = time.Minute
+
+var (
+	stormSkyPalette = []string{
+		"\x1b[38;5;235m",
+		"\x1b[38;5;236m",
+		"\x1b[38;5;237m",
+		"\x1b[38;5;238m",
+		"\x1b[38;5;239m",
+		"\x1b[38;5;240m",
+	}
+	stormHorizonPalette = []string{
+		"\x1b[38;5;237m",
+		"\x1b[38;5;238m",
+		"\x1b[38;5;239m",
+		"\x1b[38;5;60m",
+	}
+	flashPalette = []string{
+		"\x1b[38;5;255m",
+		"\x1b[38;5;231m",
+	}
+	lightningPalette = []string{
+		"\x1b[38;5;231m",
+		"\x1b[38;5;229m",
+		"\x1b[38;5;227m",
+	}
+	rainColor = "\x1b[38;5;244m"
+)
+
+// lightning is a bolt and how many more frames it stays lit.
+type lightning struct {
+	bolt bolt.Bolt
+	life int
+}
+
+func (l lightning) active() bool {
+	return l.life > 0
+}
+
+// storm tracks how rough the weather is and owns the spray and lightning it produces.
+type storm struct {
+	intensity float64
+	target    float64
+	rate      float64
+	spray     []bubble
+	bolt      lightning
+	rng       *rand.Rand
+}
+
+// stormSky tells drawSky how to tint the sky this frame.
+type stormSky struct {
+	intensity float64
+	flash     bool
+}
+
+func newStorm(cfg Config, rng *rand.Rand) *storm {
+	s := &storm{rate: float64(cfg.FrameDelay) / float64(stormRamp), rng: rng}
+	if cfg.Storm {
+		s.target = 1
+	}
+	return s
+}
+
+func (s *storm) update(width, height int, sea seaState) {
+	switch {
+	case s.intensity < s.target:
+		s.intensity = math.Min(s.target, s.intensity+s.rate)
+	case s.intensity > s.target:
+		s.intensity = math.Max(s.target, s.intensity-s.rate)
+	}
+
+	if s.bolt.active() {
+		s.bolt.life--
+	} else if s.intensity > 0.6 && s.rng.Float64() < 0.012*s.intensity {
+		s.bolt = newLightning(width, sea.waterline(height), s.rng)
+	}
+
+	s.updateSpray(width, height, sea)
+}
+
+func (s *storm) updateSpray(width, height int, sea seaState) {
+	base := sea.waterline(height)
+	attempts := int(s.intensity * 6)
+	for i := 0; i < attempts; i++ {
+		x := s.rng.Intn(width)
+		y := base + s.rng.Intn(3)
+		py := float64(y-base) / float64(height-base)
+		if sea.value(float64(x)/float64(width), py) < 0.7 {
+			continue
+		}
+		s.spray = append(s.spray, bubble{
+			x:     float64(x),
+			y:     float64(y),
+			vx:    0.8 + s.rng.Float64()*0.8,
+			vy:    -0.15 - s.rng.Float64()*0.2,
+			life:  6 + s.rng.Intn(6),
+			color: foamPalette[s.rng.Intn(len(foamPalette))],
+		})
+	}
+	items := s.spray
+	dst := items[:0]
+	for i := range items {
+		items[i].x += items[i].vx
+		items[i].y += items[i].vy
+		items[i].vy += 0.05
+		items[i].life--
+		if items[i].life <= 0 || items[i].x >= float64(width) {
+			continue
+		}
+		dst = append(dst, items[i])
+	}
+	s.spray = dst
+}
+
+func (s *storm) sky() stormSky {
+	return stormSky{
+		intensity: s.intensity,
+		flash:     s.bolt.active() && s.bolt.life > 3,
+	}
+}
+
+func (t stormSky) palette(base []string) []string {
+	switch {
+	case t.flash:
+		return flashPalette
+	case t.intensity > 0.5:
+		return stormSkyPalette
+	default:
+		return base
+	}
+}
+
+// drawRain layers sparse wind-blown streaks over the sky, plus any active bolt.
+func (s *storm) drawRain(grid canvas.Grid, frame int, limit int) {
+	if s.intensity <= 0 {
+		return
+	}
+	width := len(grid[0])
+	density := 0.01 + 0.04*s.intensity
+	for y := 0; y < limit; y++ {
+		for x := 0; x < width; x++ {
+			if s.rng.Float64() > density {
+				continue
+			}
+			glyph := '/'
+			if (x+frame)%3 == 0 {
+				glyph = '\''
+			}
+			grid.Set(x, y, glyph, rainColor)
+		}
+	}
+	if s.bolt.active() {
+		drawLightning(grid, s.bolt, limit)
+	}
+}
+
+func (s *storm) drawSpray(grid canvas.Grid) {
+	for _, sp := range s.spray {
+		glyph := '-'
+		if sp.vy < 0 {
+			glyph = '\''
+		}
+		grid.Set(int(math.Round(sp.x)), int(math.Round(sp.y)), glyph, sp.color)
+	}
+}
+
+// newLightning forks a bolt from the top of the sky down to the horizon.
+func newLightning(width, horizon int, rng *rand.Rand) lightning {
+	x0 := float64(width/4) + rng.Float64()*float64(width/2)
+	x1 := x0 + (rng.Float64()*2-1)*float64(width)*0.1
+	b := bolt.New(rng, x0, 0, x1, float64(horizon), 0.4)
+	return lightning{bolt: b, life: 4 + rng.Intn(4)}
+}
+
+// drawLightning draws l down to the horizon, dimmer along the branches.
+func drawLightning(grid canvas.Grid, l lightning, horizon int) {
+	l.bolt.Draw(func(x, y int, glyph rune, depth int) {
+		if y <= horizon {
+			grid.Set(x, y, glyph, lightningPalette[min(depth, len(lightningPalette)-1)])
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package ocean
+
+import (
+	"math"
+
+	"animinterminal/internal/canvas"
+)
+
+// setSwing is how far a wave set pushes the amplitude above or below normal.
+const setSwing = 0.3
+
+// tideCycle slowly raises and lowers the waterline and rolls bigger sets of
+// waves through on a shorter period.
+type tideCycle struct {
+	frames   float64
+	tideStep float64
+	setStep  float64
+	rows     float64
+	level    float64
+	set      float64
+}
+
+func newTideCycle(cfg Config) tideCycle {
+	t := tideCycle{
+		tideStep: float64(cfg.FrameDelay) / float64(cfg.TidePeriod),
+		setStep:  float64(cfg.FrameDelay) / float64(cfg.SetPeriod),
+		rows:     float64(cfg.TideRange),
+	}
+	return t.advance()
+}
+
+// advance moves the cycle on one frame. level is in rows above the mean
+// waterline; set runs 0 (lull) to 1 (the biggest waves of a set).
+func (t tideCycle) advance() tideCycle {
+	t.frames++
+	t.level = math.Sin(2*math.Pi*t.frames*t.tideStep) * t.rows
+	swell := 0.5 + 0.5*math.Sin(2*math.Pi*t.frames*t.setStep)
+	t.set = swell * swell
+	return t
+}
+
+// gain scales the wave amplitude for the current point in the set.
+func (t tideCycle) gain() float64 {
+	return 1 + setSwing*(t.set*2-1)
+}
+
+// waterline returns the first sea row for a screen of the given height.
+func (t tideCycle) waterline(height int) int {
+	base := height/3 - int(math.Round(t.level))
+	return canvas.Clamp(base, height/4, height/2)
+}
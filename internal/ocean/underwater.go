@@ -0,0 +1,256 @@
+package ocean
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	// underSurfacePalette lights the underside of the waves, brightest at the top.
+	underSurfacePalette = []string{
+		"\x1b[38;5;195m",
+		"\x1b[38;5;159m",
+		"\x1b[38;5;123m",
+		"\x1b[38;5;80m",
+		"\x1b[38;5;37m",
+	}
+	shaftPalette = []string{
+		"\x1b[38;5;230m",
+		"\x1b[38;5;194m",
+		"\x1b[38;5;152m",
+		"\x1b[38;5;109m",
+		"\x1b[38;5;66m",
+	}
+	// deepPalette darkens toward the sea floor.
+	deepPalette = []string{
+		"\x1b[38;5;31m",
+		"\x1b[38;5;24m",
+		"\x1b[38;5;23m",
+		"\x1b[38;5;17m",
+		"\x1b[38;5;16m",
+	}
+	silhouetteColor = "\x1b[38;5;237m"
+
+	silhouetteRight = []string{"><>", "><=>", ">=={>"}
+	silhouetteLeft  = []string{"<><", "<=><", "<}==<"}
+)
+
+// shaft is a column of sunlight slanting down from the surface.
+type shaft struct {
+	x     float64
+	slant float64
+	sway  float64
+	depth float64
+}
+
+type silhouette struct {
+	x, y   float64
+	vx     float64
+	sprite int
+}
+
+// deepScene is the underwater camera: the surface is seen from below and
+// bubbles rise all the way up to it.
+type deepScene struct {
+	shafts   []shaft
+	vents    []float64
+	bubbles  []bubble
+	plankton []bubble
+	fish     []silhouette
+	rng      *rand.Rand
+}
+
+func newDeepScene(cfg Config, rng *rand.Rand) *deepScene {
+	d := &deepScene{rng: rng}
+	for x := rng.Float64() * 8; x < float64(cfg.Width); x += 10 + rng.Float64()*10 {
+		d.shafts = append(d.shafts, shaft{
+			x:     x,
+			slant: 0.25 + rng.Float64()*0.35,
+			sway:  rng.Float64() * 2 * math.Pi,
+			depth: 0.5 + rng.Float64()*0.35,
+		})
+	}
+	vents := 2 + cfg.Width/40
+	for i := 0; i < vents; i++ {
+		d.vents = append(d.vents, float64(cfg.Width)*(float64(i)+0.3+rng.Float64()*0.4)/float64(vents))
+	}
+	count := max(2, int(math.Round(cfg.Life*6)))
+	for i := 0; i < count; i++ {
+		d.fish = append(d.fish, newSilhouette(cfg.Width, cfg.Height, true, rng))
+	}
+	return d
+}
+
+func newSilhouette(width, height int, visible bool, rng *rand.Rand) silhouette {
+	top := height/4 + 2
+	f := silhouette{
+		y:      float64(top + rng.Intn(max(1, height-top-2))),
+		vx:     0.08 + rng.Float64()*0.2,
+		sprite: rng.Intn(len(silhouetteRight)),
+	}
+	f.x = -float64(len(silhouetteRight[f.sprite]))
+	if rng.Intn(2) == 0 {
+		f.vx = -f.vx
+		f.x = float64(width)
+	}
+	if visible {
+		f.x = rng.Float64() * float64(width)
+	}
+	return f
+}
+
+func (d *deepScene) update(width, height int) {
+	surface := float64(height / 4)
+
+	for _, vx := range d.vents {
+		if d.rng.Intn(4) != 0 {
+			continue
+		}
+		d.bubbles = append(d.bubbles, bubble{
+			x:     vx + d.rng.Float64()*2 - 1,
+			y:     float64(height - 1),
+			vx:    d.rng.Float64()*0.1 - 0.05,
+			vy:    -0.25 - d.rng.Float64()*0.3,
+			life:  height * 6,
+			color: foamPalette[d.rng.Intn(len(foamPalette))],
+		})
+	}
+	for i := range d.bubbles {
+		// Rising bubbles wobble from side to side.
+		d.bubbles[i].vx = math.Sin(d.bubbles[i].y*0.6) * 0.15
+	}
+	advanceParticles(&d.bubbles, surface)
+
+	if d.rng.Intn(3) == 0 {
+		d.plankton = append(d.plankton, bubble{
+			x:     d.rng.Float64() * float64(width),
+			y:     surface + 1 + d.rng.Float64()*(float64(height)-surface-1),
+			vx:    d.rng.Float64()*0.16 - 0.08,
+			vy:    d.rng.Float64()*0.04 - 0.02,
+			life:  120 + d.rng.Intn(120),
+			color: planktonPalette[d.rng.Intn(len(planktonPalette))],
+		})
+	}
+	advanceParticles(&d.plankton, surface)
+
+	for i := range d.fish {
+		f := &d.fish[i]
+		f.x += f.vx
+		span := float64(len(silhouetteRight[f.sprite]))
+		if (f.vx > 0 && f.x > float64(width)) || (f.vx < 0 && f.x < -span) {
+			*f = newSilhouette(width, height, false, d.rng)
+		}
+	}
+}
+
+func (d *deepScene) draw(grid canvas.Grid, frame int, sea seaState) {
+	drawUnderSurface(grid, frame, sea)
+	drawDeepWater(grid, sea)
+	drawShafts(grid, d.shafts, sea)
+	for _, b := range d.bubbles {
+		glyph := 'o'
+		if b.y > float64(len(grid))*0.75 {
+			glyph = '.'
+		}
+		grid.Set(int(math.Round(b.x)), int(math.Round(b.y)), glyph, b.color)
+	}
+	for _, p := range d.plankton {
+		grid.SetIfEmpty(int(math.Round(p.x)), int(math.Round(p.y)), '.', p.color)
+	}
+	for _, f := range d.fish {
+		sprite := silhouetteRight[f.sprite]
+		if f.vx < 0 {
+			sprite = silhouetteLeft[f.sprite]
+		}
+		x := int(math.Round(f.x))
+		y := int(math.Round(f.y))
+		grid.Text(x, y, sprite, silhouetteColor)
+	}
+}
+
+// drawUnderSurface fills the top quarter with the waves seen from below: the
+// band is mirrored so the surface sits on the top row and thins out downward.
+func drawUnderSurface(grid canvas.Grid, frame int, sea seaState) {
+	height := len(grid)
+	width := len(grid[0])
+	rows := height / 4
+	for y := 0; y < rows; y++ {
+		depth := float64(y) / float64(rows)
+		py := 1 - depth
+		color := underSurfacePalette[min(len(underSurfacePalette)-1, int(depth*float64(len(underSurfacePalette))))]
+		for x := 0; x < width; x++ {
+			value := sea.value(float64(x)/float64(width), py)
+			if value < 0.2+depth*0.45 {
+				continue
+			}
+			grid[y][x] = canvas.Cell{Glyph: underWaveGlyph(value), Color: color}
+		}
+	}
+	// A faint caustic line just under the surface.
+	for x := (frame / 3) % 5; x < width; x += 5 {
+		grid.SetIfEmpty(x, rows, '`', underSurfacePalette[len(underSurfacePalette)-1])
+	}
+}
+
+// underWaveGlyph mirrors waveGlyph: from below, crests hang down as troughs.
+func underWaveGlyph(v float64) rune {
+	switch {
+	case v < 0.4:
+		return '.'
+	case v < 0.6:
+		return '-'
+	case v < 0.8:
+		return '='
+	default:
+		return 'v'
+	}
+}
+
+// drawDeepWater scatters a sparse texture that darkens toward the bottom.
+func drawDeepWater(grid canvas.Grid, sea seaState) {
+	height := len(grid)
+	width := len(grid[0])
+	top := height/4 + 1
+	for y := top; y < height; y++ {
+		py := float64(y-top) / float64(height-top)
+		color := deepPalette[min(len(deepPalette)-1, int(py*float64(len(deepPalette))))]
+		for x := 0; x < width; x++ {
+			grid[y][x] = canvas.Cell{Glyph: ' ', Color: color}
+			if sea.value(float64(x)/float64(width), py) > 0.78 {
+				grid[y][x].Glyph = '.'
+			}
+		}
+	}
+}
+
+// drawShafts draws each light shaft as a slanted column that sways with the
+// sea clock and fades out with depth.
+func drawShafts(grid canvas.Grid, shafts []shaft, sea seaState) {
+	height := len(grid)
+	top := height / 4
+	span := float64(height - top)
+	for _, s := range shafts {
+		offset := math.Sin(sea.clock*0.01+s.sway) * 2
+		slant := s.slant + math.Sin(sea.clock*0.006+s.sway)*0.1
+		glyph := '\\'
+		if slant < 0.15 {
+			glyph = '|'
+		}
+		bottom := top + int(span*s.depth)
+		for y := top; y < bottom; y++ {
+			fade := float64(y-top) / float64(bottom-top)
+			color := shaftPalette[min(len(shaftPalette)-1, int(fade*float64(len(shaftPalette))))]
+			x := int(math.Round(s.x + offset + slant*float64(y-top)))
+			g := glyph
+			if fade > 0.7 {
+				g = '.'
+			}
+			grid.SetIfEmpty(x, y, g, color)
+			if fade < 0.4 {
+				grid.SetIfEmpty(x+1, y, g, color)
+			}
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package orbit
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -15,6 +19,8 @@ const (
 	minHeight        = 24
 	minParticles     = 48
 	coreRadiusFactor = 0.12
+	// maxHUDLines is how many lines of text the HUD shows in the top left.
+	maxHUDLines = 3
 )
 
 var (
@@ -67,6 +73,25 @@ type Config struct {
 	Height        int
 	FrameDelay    time.Duration
 	ParticleCount int
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+	// HUDLines replaces the particle count and frame number in the top left
+	// with up to three lines of your own, cut to fit the width. HUDProvider,
+	// if set, is asked for them every frame instead, so they can change. An
+	// empty but non-nil list leaves the corner blank.
+	HUDLines    []string
+	HUDProvider func(frame int) []string
+	// Progress, if set, fills the bar along the bottom to its value each
+	// frame, from 0 (empty) to 1 (full), in place of the slow sweep. It is
+	// called from the animation loop, so it should return quickly.
+	Progress func() float64
 }
 
 // DefaultConfig returns a preset suited for typical terminals.
@@ -80,6 +105,12 @@ func DefaultConfig() Config {
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidth {
 		c.Width = minWidth
 	}
@@ -95,11 +126,6 @@ func (c Config) normalize() Config {
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
-}
-
 type particle struct {
 	radius     float64
 	angle      float64
@@ -116,66 +142,70 @@ type ring struct {
 }
 
 // Run starts the particle orbit HUD animation loop.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	// A run given a seed goes a frame at a time, so it repeats exactly.
+	seeded := cfg.Seed != 0
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	grid := newGrid(cfg.Width, cfg.Height)
-	particles := makeParticles(cfg)
+	grid := canvas.New(cfg.Width, cfg.Height)
+	particles := makeParticles(cfg, rng)
 	rings := makeRings(cfg)
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
+	// t is the time in default frames.
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, seeded)
+	for t := 0.0; limit.Next(); {
+		frame := int(t)
+		grid.Clear()
 		drawBackground(grid, frame)
 		drawRings(grid, rings, frame)
 		drawCore(grid, frame)
 		drawSensors(grid, frame)
 		drawParticles(grid, particles, frame)
-		drawHUD(grid, particles, frame)
-		render(grid)
-
-		updateParticles(particles)
-		updateRings(rings)
-
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		row := grid[y]
-		for x := range row {
-			row[x] = cell{glyph: ' ', color: ""}
+		drawHUD(grid, cfg, particles, frame)
+		grid.Render(screen)
+
+		dt := clock.Tick()
+		updateParticles(particles, dt, rng)
+		updateRings(rings, dt)
+		t += dt
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
-func makeParticles(cfg Config) []particle {
+func makeParticles(cfg Config, rng *rand.Rand) []particle {
 	result := make([]particle, cfg.ParticleCount)
 	for i := range result {
-		layer := rand.Intn(3)
+		layer := rng.Intn(3)
 		result[i] = particle{
-			radius:     0.35 + rand.Float64()*0.45 + float64(layer)*0.18,
-			angle:      rand.Float64() * math.Pi * 2,
-			angularVel: 0.006 + rand.Float64()*0.018 + float64(layer)*0.004,
+			radius:     0.35 + rng.Float64()*0.45 + float64(layer)*0.18,
+			angle:      rng.Float64() * math.Pi * 2,
+			angularVel: 0.006 + rng.Float64()*0.018 + float64(layer)*0.004,
 			layer:      layer,
 			trail:      make([][2]int, 0, 6),
 		}
-		if rand.Intn(2) == 0 {
+		if rng.Intn(2) == 0 {
 			result[i].angularVel *= -1
 		}
 	}
@@ -190,18 +220,18 @@ func makeRings(cfg Config) []ring {
 	}
 }
 
-func drawBackground(grid [][]cell, frame int) {
+func drawBackground(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height; y += 2 {
 		color := backgroundPalette[(y/2+frame/16)%len(backgroundPalette)]
 		for x := (y + frame) % 6; x < width; x += 6 {
-			setIfEmpty(grid, x, y, '.', color)
+			grid.SetIfEmpty(x, y, '.', color)
 		}
 	}
 }
 
-func drawRings(grid [][]cell, rings []ring, frame int) {
+func drawRings(grid canvas.Grid, rings []ring, frame int) {
 	width := len(grid[0])
 	height := len(grid)
 	centerX := width / 2
@@ -214,7 +244,7 @@ func drawRings(grid [][]cell, rings []ring, frame int) {
 	}
 }
 
-func drawRing(grid [][]cell, cx, cy int, radius, thickness float64, phase float64, color string) {
+func drawRing(grid canvas.Grid, cx, cy int, radius, thickness float64, phase float64, color string) {
 	steps := int(radius * 8)
 	if steps < 32 {
 		steps = 32
@@ -223,27 +253,14 @@ func drawRing(grid [][]cell, cx, cy int, radius, thickness float64, phase float6
 		angle := float64(i)/float64(steps)*math.Pi*2 + phase
 		x := cx + int(math.Cos(angle)*radius)
 		y := cy + int(math.Sin(angle)*radius*0.6)
-		setIfEmpty(grid, x, y, '-', color)
+		grid.SetIfEmpty(x, y, '-', color)
 		if thickness > 1 {
-			setIfEmpty(grid, x, y+1, '-', color)
+			grid.SetIfEmpty(x, y+1, '-', color)
 		}
 	}
 }
 
-func drawEllipse(grid [][]cell, cx, cy int, rx, ry float64, color string) {
-	steps := int(rx * 6)
-	if steps < 24 {
-		steps = 24
-	}
-	for i := 0; i < steps; i++ {
-		angle := float64(i) / float64(steps) * math.Pi * 2
-		x := cx + int(math.Cos(angle)*rx)
-		y := cy + int(math.Sin(angle)*ry)
-		setIfEmpty(grid, x, y, '.', color)
-	}
-}
-
-func drawCore(grid [][]cell, frame int) {
+func drawCore(grid canvas.Grid, frame int) {
 	width := len(grid[0])
 	height := len(grid)
 	centerX := width / 2
@@ -259,23 +276,23 @@ func drawCore(grid [][]cell, frame int) {
 				continue
 			}
 			intensity := 1 - dist/radius
-			color := corePalette[int(clampFloat(intensity*float64(len(corePalette)), 0, float64(len(corePalette)-1)))]
-			setCell(grid, centerX+x, centerY+y, '*', color)
+			color := corePalette[int(canvas.Clamp(intensity*float64(len(corePalette)), 0, float64(len(corePalette)-1)))]
+			grid.Set(centerX+x, centerY+y, '*', color)
 		}
 	}
-	setCell(grid, centerX, centerY, '#', "\x1b[38;5;231m")
+	grid.Set(centerX, centerY, '#', "\x1b[38;5;231m")
 	drawCoreHalo(grid, centerX, centerY, radius, frame)
 }
 
-func drawCoreHalo(grid [][]cell, cx, cy int, baseRadius float64, frame int) {
+func drawCoreHalo(grid canvas.Grid, cx, cy int, baseRadius float64, frame int) {
 	for i := 0; i < len(haloPalette); i++ {
 		r := baseRadius*1.1 + float64(i)*1.6
 		color := haloPalette[(i+frame/14)%len(haloPalette)]
-		drawEllipse(grid, cx, cy, r, r*0.62, color)
+		grid.Ellipse(cx, cy, r, r*0.62, '.', color)
 	}
 }
 
-func drawParticles(grid [][]cell, particles []particle, frame int) {
+func drawParticles(grid canvas.Grid, particles []particle, frame int) {
 	width := len(grid[0])
 	height := len(grid)
 	centerX := width / 2
@@ -292,11 +309,11 @@ func drawParticles(grid [][]cell, particles []particle, frame int) {
 
 		color := particlePalette[p.layer%len(particlePalette)]
 		glyph := particleGlyph(frame, i)
-		setCell(grid, x, y, glyph, color)
+		grid.Set(x, y, glyph, color)
 	}
 }
 
-func drawSensors(grid [][]cell, frame int) {
+func drawSensors(grid canvas.Grid, frame int) {
 	width := len(grid[0])
 	height := len(grid)
 	cx := width / 2
@@ -310,18 +327,18 @@ func drawSensors(grid [][]cell, frame int) {
 	}
 }
 
-func drawSensorSweep(grid [][]cell, cx, cy int, angle float64, radius float64, color string) {
+func drawSensorSweep(grid canvas.Grid, cx, cy int, angle float64, radius float64, color string) {
 	for r := radius * 0.6; r < radius; r += 3 {
 		x := cx + int(math.Cos(angle)*r)
 		y := cy + int(math.Sin(angle)*r*0.6)
-		setIfEmpty(grid, x, y, '/', color)
+		grid.SetIfEmpty(x, y, '/', color)
 	}
-	points := linePoints(cx, cy, cx+int(math.Cos(angle)*radius), cy+int(math.Sin(angle)*radius*0.6))
+	points := canvas.LinePoints(cx, cy, cx+int(math.Cos(angle)*radius), cy+int(math.Sin(angle)*radius*0.6))
 	for idx, pt := range points {
 		if idx%3 != 0 {
 			continue
 		}
-		setIfEmpty(grid, pt[0], pt[1], '.', color)
+		grid.SetIfEmpty(pt[0], pt[1], '.', color)
 	}
 }
 
@@ -332,19 +349,19 @@ func addTrailPoint(p *particle, x, y int) {
 	}
 }
 
-func drawParticleTrail(grid [][]cell, p *particle) {
+func drawParticleTrail(grid canvas.Grid, p *particle) {
 	for i := 0; i < len(p.trail)-1; i++ {
 		from := p.trail[i]
 		to := p.trail[i+1]
-		points := linePoints(from[0], from[1], to[0], to[1])
+		points := canvas.LinePoints(from[0], from[1], to[0], to[1])
 		color := trailPalette[min(i, len(trailPalette)-1)]
 		for _, pt := range points {
-			setIfEmpty(grid, pt[0], pt[1], '.', color)
+			grid.SetIfEmpty(pt[0], pt[1], '.', color)
 		}
 	}
 }
 
-func particleGlyph(frame, index int) byte {
+func particleGlyph(frame, index int) rune {
 	switch (frame + index) % 3 {
 	case 0:
 		return 'o'
@@ -355,162 +372,79 @@ func particleGlyph(frame, index int) byte {
 	}
 }
 
-func drawHUD(grid [][]cell, particles []particle, frame int) {
+func drawHUD(grid canvas.Grid, cfg Config, particles []particle, frame int) {
 	width := len(grid[0])
 	height := len(grid)
 	centerY := height - 3
 	color := uiPalette[frame/20%len(uiPalette)]
 
 	barWidth := width / 3
-	fill := int(float64(barWidth) * (0.5 + 0.5*math.Sin(float64(frame)*0.03)))
+	level := 0.5 + 0.5*math.Sin(float64(frame)*0.03)
+	if cfg.Progress != nil {
+		level = cfg.Progress()
+		if math.IsNaN(level) {
+			level = 0
+		}
+		level = canvas.Clamp(level, 0, 1)
+	}
+	fill := int(float64(barWidth) * level)
 	x0 := (width - barWidth) / 2
 	for x := 0; x < barWidth; x++ {
 		glyph := '-'
 		if x < fill {
 			glyph = '='
 		}
-		setCell(grid, x0+x, centerY, byte(glyph), color)
+		grid.Set(x0+x, centerY, glyph, color)
 	}
 
-	text := fmt.Sprintf("particles:%03d  rings:%d  frame:%06d", len(particles), 3, frame)
-	printText(grid, 2, 1, text, uiPalette[(frame/12+1)%len(uiPalette)])
+	lines := cfg.HUDLines
+	if cfg.HUDProvider != nil {
+		lines = cfg.HUDProvider(frame)
+	}
+	if lines == nil {
+		lines = []string{fmt.Sprintf("particles:%03d  rings:%d  frame:%06d", len(particles), 3, frame)}
+	}
+	for i, line := range lines {
+		if i >= maxHUDLines {
+			break
+		}
+		grid.Text(2, 1+i, hudLine(line, width-4), uiPalette[(frame/12+1+i)%len(uiPalette)])
+	}
 }
 
-func printText(grid [][]cell, x, y int, text string, color string) {
-	for i := 0; i < len(text); i++ {
-		setCell(grid, x+i, y, text[i], color)
+// hudLine cuts line to at most width runes, with anything that would move
+// the cursor, like a tab or newline, shown as a space.
+func hudLine(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) > width {
+		runes = runes[:max(0, width)]
+	}
+	for i, r := range runes {
+		if r < ' ' || r == 0x7f {
+			runes[i] = ' '
+		}
 	}
+	return string(runes)
 }
 
-func updateParticles(particles []particle) {
+// updateParticles moves the particles along their orbits by dt default
+// frames, wandering a little in and out as they go.
+func updateParticles(particles []particle, dt float64, rng *rand.Rand) {
 	for i := range particles {
 		p := &particles[i]
-		p.angle += p.angularVel
+		p.angle += p.angularVel * dt
 		if p.angle > math.Pi*2 {
 			p.angle -= math.Pi * 2
 		} else if p.angle < 0 {
 			p.angle += math.Pi * 2
 		}
-		noise := (rand.Float64() - 0.5) * 0.002
-		p.radius = clampFloat(p.radius+noise, 0.25, 0.95)
+		noise := (rng.Float64() - 0.5) * 0.002 * dt
+		p.radius = canvas.Clamp(p.radius+noise, 0.25, 0.95)
 	}
 }
 
-func updateRings(rings []ring) {
+func updateRings(rings []ring, dt float64) {
 	for i := range rings {
-		rings[i].phase += rings[i].speed
-	}
-}
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	if height == 0 {
-		return
-	}
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			if c.glyph == 0 {
-				sb.WriteByte(' ')
-			} else {
-				sb.WriteByte(c.glyph)
-			}
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
-
-func linePoints(x0, y0, x1, y1 int) [][2]int {
-	points := make([][2]int, 0, max(abs(x1-x0), abs(y1-y0))+1)
-	dx := abs(x1 - x0)
-	sx := -1
-	if x0 < x1 {
-		sx = 1
-	}
-	dy := -abs(y1 - y0)
-	sy := -1
-	if y0 < y1 {
-		sy = 1
-	}
-	err := dx + dy
-
-	for {
-		points = append(points, [2]int{x0, y0})
-		if x0 == x1 && y0 == y1 {
-			break
-		}
-		e2 := 2 * err
-		if e2 >= dy {
-			err += dy
-			x0 += sx
-		}
-		if e2 <= dx {
-			err += dx
-			y0 += sy
-		}
-	}
-	return points
-}
-
-func abs(v int) int {
-	if v < 0 {
-		return -v
-	}
-	return v
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func clampFloat(v, minV, maxV float64) float64 {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
+		rings[i].phase += rings[i].speed * dt
 	}
-	return v
 }
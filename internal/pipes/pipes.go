@@ -0,0 +1,301 @@
+package pipes
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// coverage is the share of the screen the pipes fill before it fades.
+	coverage = 0.6
+	// fadeFrames is how long a dissolve or wipe takes.
+	fadeFrames = 40
+)
+
+// Directions a pipe can head in, also used as bits for the sides a segment
+// connects.
+const (
+	right = 1 << iota
+	down
+	left
+	up
+)
+
+var (
+	pipeColors = []string{
+		"\x1b[38;5;196m",
+		"\x1b[38;5;46m",
+		"\x1b[38;5;33m",
+		"\x1b[38;5;226m",
+		"\x1b[38;5;201m",
+		"\x1b[38;5;51m",
+		"\x1b[38;5;208m",
+		"\x1b[38;5;231m",
+	}
+	// boxGlyphs and asciiGlyphs draw a segment by the sides it connects.
+	boxGlyphs = map[int]rune{
+		left | right: '━',
+		up | down:    '┃',
+		right | down: '┏',
+		left | down:  '┓',
+		right | up:   '┗',
+		left | up:    '┛',
+	}
+	asciiGlyphs = map[int]rune{
+		left | right: '-',
+		up | down:    '|',
+		right | down: '+',
+		left | down:  '+',
+		right | up:   '+',
+		left | up:    '+',
+	}
+)
+
+// Config controls the pipes animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Pipes is how many pipes grow at once.
+	Pipes int
+	// Turn is the chance, 0-1, that a pipe turns at each step.
+	Turn float64
+	// Fade is how the full screen clears: dissolve, wipe or clear.
+	Fade string
+	// ASCII draws with + - | even where box drawing is available.
+	ASCII bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 30 * time.Millisecond,
+		Pipes:      4,
+		Turn:       0.15,
+		Fade:       "dissolve",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 30 * time.Millisecond
+	}
+	if c.Pipes <= 0 {
+		c.Pipes = 4
+	}
+	if c.Turn < 0 || c.Turn > 1 {
+		c.Turn = 0.15
+	}
+	if !IsFade(c.Fade) {
+		c.Fade = "dissolve"
+	}
+	return c
+}
+
+// IsFade reports whether name is a fade style.
+func IsFade(name string) bool {
+	switch name {
+	case "dissolve", "wipe", "clear":
+		return true
+	}
+	return false
+}
+
+type pipe struct {
+	x, y  int
+	dir   int
+	color string
+}
+
+// Run launches the pipes animation.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	glyphs := asciiGlyphs
+	if !cfg.ASCII && hasUnicode() {
+		glyphs = boxGlyphs
+	}
+	pipes := make([]pipe, cfg.Pipes)
+	for i := range pipes {
+		pipes[i] = spawn(cfg.Width, cfg.Height, rng)
+	}
+	filled := 0
+	fading := 0
+	order := rng.Perm(cfg.Width * cfg.Height)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		if fading > 0 {
+			fade(grid, cfg.Fade, fadeFrames-fading, order)
+			fading--
+			if fading == 0 {
+				grid.Clear()
+				filled = 0
+				for i := range pipes {
+					pipes[i] = spawn(cfg.Width, cfg.Height, rng)
+				}
+			}
+		} else {
+			for i := range pipes {
+				filled += grow(grid, &pipes[i], cfg.Turn, glyphs, rng)
+			}
+			if float64(filled) > coverage*float64(cfg.Width*cfg.Height) {
+				fading = fadeFrames
+				if cfg.Fade == "clear" {
+					fading = 1
+				}
+			}
+		}
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// spawn starts a pipe at a random edge, heading into the screen, in a
+// random color.
+func spawn(width, height int, rng *rand.Rand) pipe {
+	p := pipe{color: pipeColors[rng.Intn(len(pipeColors))]}
+	switch rng.Intn(4) {
+	case 0:
+		p.x, p.y, p.dir = 0, rng.Intn(height), right
+	case 1:
+		p.x, p.y, p.dir = width-1, rng.Intn(height), left
+	case 2:
+		p.x, p.y, p.dir = rng.Intn(width), 0, down
+	default:
+		p.x, p.y, p.dir = rng.Intn(width), height-1, up
+	}
+	return p
+}
+
+// opposite is the side a pipe heading in dir came in through.
+func opposite(dir int) int {
+	switch dir {
+	case right:
+		return left
+	case left:
+		return right
+	case down:
+		return up
+	}
+	return down
+}
+
+// grow lays one segment: the pipe may turn, the cell it is in gets the
+// glyph joining where it came from to where it goes, and it moves on. A
+// pipe that runs off the screen starts again from an edge. It returns 1 if
+// the segment covered an empty cell.
+func grow(grid canvas.Grid, p *pipe, turn float64, glyphs map[int]rune, rng *rand.Rand) int {
+	from := opposite(p.dir)
+	if rng.Float64() < turn {
+		if p.dir == left || p.dir == right {
+			p.dir = []int{up, down}[rng.Intn(2)]
+		} else {
+			p.dir = []int{left, right}[rng.Intn(2)]
+		}
+	}
+	added := 0
+	if grid[p.y][p.x].Glyph == ' ' {
+		added = 1
+	}
+	grid[p.y][p.x] = canvas.Cell{Glyph: glyphs[from|p.dir], Color: p.color}
+	switch p.dir {
+	case right:
+		p.x++
+	case left:
+		p.x--
+	case down:
+		p.y++
+	case up:
+		p.y--
+	}
+	if p.x < 0 || p.x >= len(grid[0]) || p.y < 0 || p.y >= len(grid) {
+		*p = spawn(len(grid[0]), len(grid), rng)
+	}
+	return added
+}
+
+// fade clears the part of the screen due at step of fadeFrames: random
+// cells for a dissolve, columns from the left for a wipe.
+func fade(grid canvas.Grid, style string, step int, order []int) {
+	width := len(grid[0])
+	switch style {
+	case "dissolve":
+		per := (len(order) + fadeFrames - 1) / fadeFrames
+		for _, i := range order[min(len(order), step*per):min(len(order), (step+1)*per)] {
+			grid[i/width][i%width] = canvas.Cell{Glyph: ' '}
+		}
+	case "wipe":
+		per := (width + fadeFrames - 1) / fadeFrames
+		for x := step * per; x < min(width, (step+1)*per); x++ {
+			for y := range grid {
+				grid[y][x] = canvas.Cell{Glyph: ' '}
+			}
+		}
+	}
+}
+
+// hasUnicode guesses from the locale whether box drawing will show.
+func hasUnicode() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToUpper(v)
+			return strings.Contains(v, "UTF-8") || strings.Contains(v, "UTF8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package plasma
+
+import (
+	"math"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/term"
+)
+
+const (
+	// maxPinned is how many attractors 'b' can drop before the oldest goes.
+	maxPinned = 5
+	// thrust is the push of one arrow key press, drag what is left of the
+	// speed after a frame.
+	thrust = 0.006
+	drag   = 0.9
+	// follow is how much of the way to the pointer the mover closes per frame.
+	follow = 0.25
+)
+
+// attractor is a blob that lifts the plasma around it and sends ripples
+// out. phase offsets its ripples so dropped ones do not pulse in step.
+type attractor struct {
+	x, y  float64
+	phase float64
+}
+
+// attractors is the mover, steered by the arrow keys or the pointer, and
+// the ones pinned with 'b'. The mover stays hidden until the first input.
+// They keep their own clock so the ripples do not speed up with the plasma.
+type attractors struct {
+	mover         attractor
+	vx, vy        float64
+	tx, ty        float64
+	pointer       bool
+	active        bool
+	pinned        []attractor
+	clock         float64
+	width, height int
+	aspect        float64
+	live          []attractor
+}
+
+func newAttractors(width, height int) *attractors {
+	return &attractors{
+		mover:  attractor{x: 0.5, y: 0.5},
+		width:  width,
+		height: height,
+		aspect: float64(height) * cellAspect / float64(width),
+	}
+}
+
+// handle steers the mover with the arrow keys, moves it under the pointer,
+// or pins a copy where it is on 'b'.
+func (a *attractors) handle(ev term.Event) {
+	switch {
+	case ev.Mouse:
+		a.tx = (float64(ev.X) + 0.5) / float64(a.width)
+		a.ty = (float64(ev.Y) + 0.5) / float64(a.height)
+		a.pointer = true
+	case ev.Arrow == term.Up:
+		a.vy -= thrust / a.aspect
+	case ev.Arrow == term.Down:
+		a.vy += thrust / a.aspect
+	case ev.Arrow == term.Left:
+		a.vx -= thrust
+	case ev.Arrow == term.Right:
+		a.vx += thrust
+	case ev.Key == 'b' || ev.Key == 'B':
+		if !a.active {
+			return
+		}
+		if len(a.pinned) == maxPinned {
+			a.pinned = a.pinned[1:]
+		}
+		a.pinned = append(a.pinned, attractor{x: a.mover.x, y: a.mover.y, phase: a.clock})
+		return
+	default:
+		return
+	}
+	if ev.Arrow != 0 {
+		a.pointer = false
+	}
+	a.active = true
+}
+
+// advance moves the mover and collects the attractors to draw this frame,
+// folded into the kaleidoscope when symmetry is on so each one sits in
+// every wedge.
+func (a *attractors) advance(symmetry int, spin, dt float64) {
+	a.clock += 0.15 * dt
+	m := &a.mover
+	if a.pointer {
+		closed := math.Min(1, follow*dt)
+		m.x += (a.tx - m.x) * closed
+		m.y += (a.ty - m.y) * closed
+	} else {
+		m.x += a.vx * dt
+		m.y += a.vy * dt
+		a.vx *= math.Pow(drag, dt)
+		a.vy *= math.Pow(drag, dt)
+		if m.x < 0 || m.x > 1 {
+			m.x = canvas.Clamp(m.x, 0, 1)
+			a.vx = -a.vx
+		}
+		if m.y < 0 || m.y > 1 {
+			m.y = canvas.Clamp(m.y, 0, 1)
+			a.vy = -a.vy
+		}
+	}
+
+	a.live = a.live[:0]
+	if a.active {
+		a.live = append(a.live, *m)
+	}
+	a.live = append(a.live, a.pinned...)
+	if symmetry > 0 {
+		for i, s := range a.live {
+			a.live[i].x, a.live[i].y = fold(s.x, s.y, a.width, a.height, symmetry, spin)
+		}
+	}
+}
+
+// pull is the attractors' share of the plasma value at fx, fy: a soft
+// bump under each one and rings that run outward and fade with distance.
+func (a *attractors) pull(fx, fy float64) float64 {
+	if a == nil {
+		return 0
+	}
+	v := 0.0
+	for _, s := range a.live {
+		d := math.Hypot(fx-s.x, (fy-s.y)*a.aspect)
+		v += 1.2*math.Exp(-d*d*300) + 0.4*math.Sin(d*70-(a.clock-s.phase))*math.Exp(-d*8)
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package plasma
+
+// trail keeps each cell's value from the previous frame so new values can
+// be blended into it. A nil trail, or a blur of 0, leaves values as they are.
+type trail struct {
+	blur   float64
+	values [][]float64
+	primed bool
+}
+
+func newTrail(blur float64) *trail {
+	if blur == 0 {
+		return nil
+	}
+	return &trail{blur: blur}
+}
+
+// blend eases the cell at x, y toward v by 1-blur and returns the result.
+func (t *trail) blend(x, y int, v float64) float64 {
+	if t == nil {
+		return v
+	}
+	if t.primed {
+		v = t.values[y][x]*t.blur + v*(1-t.blur)
+	}
+	t.values[y][x] = v
+	return v
+}
+
+// fit rebuilds the buffer when the grid size changes; the first frame after
+// that is taken as is.
+func (t *trail) fit(width, height int) {
+	if t == nil {
+		return
+	}
+	if len(t.values) == height && len(t.values[0]) == width {
+		t.primed = true
+		return
+	}
+	t.values = make([][]float64, height)
+	for y := range t.values {
+		t.values[y] = make([]float64, width)
+	}
+	t.primed = false
+}
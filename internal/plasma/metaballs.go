@@ -0,0 +1,63 @@
+package plasma
+
+import (
+	"math"
+	"math/rand"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/metaball"
+)
+
+// metaballs bounce around the screen; their summed inverse-square field is
+// what the glyph and palette ramps quantize instead of the sines. Balls are
+// in cells with y stretched by cellAspect so the blobs are round on screen.
+type metaballs struct {
+	balls         []metaball.Ball
+	width, height float64
+}
+
+func newMetaballs(cfg Config, rng *rand.Rand) *metaballs {
+	if cfg.Metaballs == 0 {
+		return nil
+	}
+	m := &metaballs{
+		balls:  make([]metaball.Ball, cfg.Metaballs),
+		width:  float64(cfg.Width),
+		height: float64(cfg.Height) * cellAspect,
+	}
+	radius := cfg.MetaballRadius
+	for i := range m.balls {
+		angle := rng.Float64() * 2 * math.Pi
+		speed := cfg.MetaballSpeed * (0.3 + 0.4*rng.Float64())
+		m.balls[i] = metaball.Ball{
+			X:  radius + rng.Float64()*(m.width-2*radius),
+			Y:  radius + rng.Float64()*(m.height-2*radius),
+			VX: math.Cos(angle) * speed,
+			VY: math.Sin(angle) * speed,
+			R:  radius,
+		}
+	}
+	return m
+}
+
+// update moves every ball, bouncing it elastically off the edges.
+func (m *metaballs) update(dt float64) {
+	for i := range m.balls {
+		b := &m.balls[i]
+		b.X += b.VX * dt
+		b.Y += b.VY * dt
+		if b.X < 0 || b.X > m.width {
+			b.X = canvas.Clamp(b.X, 0, m.width)
+			b.VX = -b.VX
+		}
+		if b.Y < 0 || b.Y > m.height {
+			b.Y = canvas.Clamp(b.Y, 0, m.height)
+			b.VY = -b.VY
+		}
+	}
+}
+
+// value is the field at fx, fy on the 0..1 scale of metaball.Level.
+func (m *metaballs) value(fx, fy float64) float64 {
+	return metaball.Level(metaball.Field(m.balls, fx*m.width, fy*m.height))
+}
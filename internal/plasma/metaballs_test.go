@@ -0,0 +1,34 @@
+package plasma
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// BenchmarkMetaballs times a frame of the metaball field over a 200 by 60
+// grid, one sample a cell, for a few ball counts: the cost grows with
+// cells times balls.
+func BenchmarkMetaballs(b *testing.B) {
+	for _, n := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("balls=%d", n), func(b *testing.B) {
+			cfg := DefaultConfig()
+			cfg.Width, cfg.Height = 200, 60
+			cfg.Metaballs = n
+			cfg = cfg.normalize()
+			m := newMetaballs(cfg, rand.New(rand.NewSource(1)))
+			sum := 0.0
+			for i := 0; i < b.N; i++ {
+				m.update(1)
+				for y := 0; y < cfg.Height; y++ {
+					for x := 0; x < cfg.Width; x++ {
+						sum += m.value(float64(x)/float64(cfg.Width), float64(y)/float64(cfg.Height))
+					}
+				}
+			}
+			if sum < 0 {
+				b.Fatal("negative field")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package plasma
+
+import (
+	"math"
+
+	"animinterminal/internal/beat"
+)
+
+const (
+	// shockSpeed is how far a beat's shockwave travels per frame, in screen
+	// widths, and shockLife how many frames it lasts.
+	shockSpeed = 0.02
+	shockLife  = 60
+)
+
+// pulse is the music's hold on the field: bass swells the whole field,
+// treble brings up the fine ripples, and every beat sends a shockwave out
+// from the center. A nil pulse leaves the plasma alone.
+type pulse struct {
+	meter  beat.Meter
+	bass   float64
+	treble float64
+	shocks []float64
+	aspect float64
+}
+
+// newPulse listens to the audio input if there is one, falls back to the
+// synthetic beat, and returns nil when neither is set.
+func newPulse(cfg Config) *pulse {
+	var meter beat.Meter
+	if cfg.AudioInput != "" {
+		if pcm, err := beat.OpenPCM(cfg.AudioInput); err == nil {
+			meter = pcm
+		}
+	}
+	if meter == nil && cfg.Pulse > 0 {
+		meter = beat.NewMetronome(cfg.Pulse, cfg.FrameDelay)
+	}
+	if meter == nil {
+		return nil
+	}
+	return &pulse{
+		meter:  meter,
+		aspect: float64(cfg.Height) * cellAspect / float64(cfg.Width),
+	}
+}
+
+// listen reads the meter once a frame, easing the bands so the field
+// breathes instead of jittering, ages the shockwaves by dt default frames
+// and starts a new one on a beat.
+func (p *pulse) listen(dt float64) {
+	if p == nil {
+		return
+	}
+	level, hit := p.meter.Sample()
+	bass, treble := level, level
+	if s, ok := p.meter.(beat.Spectrum); ok {
+		bass, treble = s.Bands()
+	}
+	p.bass += (bass - p.bass) * 0.3
+	p.treble += (treble - p.treble) * 0.3
+
+	alive := p.shocks[:0]
+	for _, age := range p.shocks {
+		if age+dt < shockLife {
+			alive = append(alive, age+dt)
+		}
+	}
+	p.shocks = alive
+	if hit {
+		p.shocks = append(p.shocks, 0)
+	}
+}
+
+// gain scales the sines with the bass.
+func (p *pulse) gain() float64 {
+	if p == nil {
+		return 1
+	}
+	return 1 + 0.8*p.bass
+}
+
+// fine is the weight of the high-frequency ring term, raised by the treble.
+func (p *pulse) fine() float64 {
+	if p == nil {
+		return 0.5
+	}
+	return 0.5 + p.treble
+}
+
+// shock sums the rings of every live shockwave at fx, fy. Each is a thin
+// band around its radius that fades with age, so overlapping waves simply
+// add up.
+func (p *pulse) shock(fx, fy float64) float64 {
+	if p == nil {
+		return 0
+	}
+	d := math.Hypot(fx-0.5, (fy-0.5)*p.aspect)
+	v := 0.0
+	for _, age := range p.shocks {
+		off := (d - age*shockSpeed) / 0.03
+		v += math.Exp(-off*off) * (1 - age/shockLife)
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package plasma
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"animinterminal/internal/color"
+)
+
+const (
+	// gradientSteps is how many colors a custom gradient is evaluated into.
+	gradientSteps = 24
+	// fineSteps is how many shades each palette entry is split into in
+	// 24-bit color, so the ramp blends instead of banding.
+	fineSteps = 4
+	// hysteresis is how far past a palette boundary a cell's value must go
+	// before the cell changes color, so neighbours sitting on the boundary
+	// do not flicker as the palette scrolls.
+	hysteresis = 0.2
+)
+
+// IsCycle reports whether name is a palette cycle direction.
+func IsCycle(name string) bool {
+	switch name {
+	case "forward", "reverse", "pingpong":
+		return true
+	}
+	return false
+}
+
+// ParseGradient reads comma-separated 256-color codes, at least two, as the
+// stops of a custom gradient.
+func ParseGradient(spec string) ([]int, error) {
+	var codes []int
+	for _, part := range strings.Split(spec, ",") {
+		var code int
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d", &code); err != nil {
+			return nil, fmt.Errorf("bad color %q", part)
+		}
+		if code < 0 || code > 255 {
+			return nil, fmt.Errorf("color %d out of range 0-255", code)
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) < 2 {
+		return nil, fmt.Errorf("need at least two stops")
+	}
+	return codes, nil
+}
+
+// cycleOffset is how far the palette has scrolled by frame: steadily one way
+// or the other, or back and forth across the whole palette.
+func cycleOffset(t float64, scroll float64, mode string, n int) float64 {
+	s := t * scroll
+	switch mode {
+	case "reverse":
+		return -s
+	case "pingpong":
+		span := float64(n)
+		s = math.Mod(s, 2*span)
+		if s > span {
+			s = 2*span - s
+		}
+		return s
+	}
+	return s
+}
+
+// palette is the color ramp, dark to bright, plus, per cell, the entry it
+// showed last frame. In 24-bit color each of its steps is split into fine
+// shades.
+type palette struct {
+	colors []string
+	rgb    []color.RGB
+	index  map[string]int
+	steps  int
+	fine   int
+	held   [][]int
+}
+
+// newPalette uses the built-in ramp, or the gradient through stops when
+// there are any.
+func newPalette(stops []int, width, height int) *palette {
+	ramp, steps := colorRamp, len(colorRamp)
+	if len(stops) > 0 {
+		ramp, steps = make([]color.RGB, len(stops)), gradientSteps
+		for i, code := range stops {
+			ramp[i] = color.Xterm(code)
+		}
+	}
+	p := &palette{steps: steps, fine: 1, held: make([][]int, height)}
+	profile := color.Active()
+	if profile == color.TrueColor {
+		p.fine = fineSteps
+	}
+	p.rgb = color.Ramp(ramp, steps*p.fine)
+	p.colors = make([]string, len(p.rgb))
+	p.index = make(map[string]int, len(p.colors))
+	for i, c := range p.rgb {
+		p.colors[i] = c.Render(profile)
+		p.index[p.colors[i]] = i
+	}
+	for y := range p.held {
+		p.held[y] = make([]int, width)
+		for x := range p.held[y] {
+			p.held[y][x] = -1
+		}
+	}
+	return p
+}
+
+// pick is the color for v at the cell x, y, wrapping around the palette and
+// holding on to the cell's previous entry until v is clearly past it.
+func (p *palette) pick(x, y int, v float64) string {
+	n := float64(len(p.colors))
+	v = math.Mod(v*float64(p.fine), n)
+	if v < 0 {
+		v += n
+	}
+	idx := int(v) % len(p.colors)
+	if held := p.held[y][x]; held >= 0 && held != idx {
+		off := math.Mod(v-float64(held)+n, n)
+		if off < 1+hysteresis || off > n-hysteresis {
+			idx = held
+		}
+	}
+	p.held[y][x] = idx
+	return p.colors[idx]
+}
+
+// brighten lifts code by amount (0-1): in 24-bit color a palette entry is
+// blended toward white, and otherwise it moves that far up the ramp,
+// stopping at the brightest. Any other color is left alone.
+func (p *palette) brighten(code string, amount float64) string {
+	i, ok := p.index[code]
+	if !ok {
+		return code
+	}
+	if p.fine > 1 {
+		return color.Mix(p.rgb[i], color.RGB{R: 255, G: 255, B: 255}, amount*0.7).Render(color.TrueColor)
+	}
+	return p.colors[min(len(p.colors)-1, i+int(math.Round(amount*float64(len(p.colors)))))]
+}
@@ -1,12 +1,18 @@
 package plasma
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
+	"os"
 	"strings"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/color"
+	"animinterminal/internal/noise"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -18,21 +24,25 @@ const (
 )
 
 var (
-	colorPalette = []string{
-		"\x1b[38;5;17m",
-		"\x1b[38;5;18m",
-		"\x1b[38;5;19m",
-		"\x1b[38;5;20m",
-		"\x1b[38;5;27m",
-		"\x1b[38;5;33m",
-		"\x1b[38;5;39m",
-		"\x1b[38;5;51m",
-		"\x1b[38;5;87m",
-		"\x1b[38;5;123m",
-		"\x1b[38;5;159m",
-		"\x1b[38;5;195m",
-	}
-	glyphPalette = []byte{' ', '.', ',', ':', '-', '=', '*', '#', '%', '@'}
+	// colorRamp is the built-in blues, dark to bright.
+	colorRamp = []color.RGB{
+		{R: 0, G: 0, B: 95},
+		{R: 0, G: 0, B: 135},
+		{R: 0, G: 0, B: 175},
+		{R: 0, G: 0, B: 215},
+		{R: 0, G: 95, B: 255},
+		{R: 0, G: 135, B: 255},
+		{R: 0, G: 175, B: 255},
+		{R: 0, G: 255, B: 255},
+		{R: 95, G: 255, B: 255},
+		{R: 135, G: 255, B: 255},
+		{R: 175, G: 255, B: 255},
+		{R: 215, G: 255, B: 255},
+	}
+	glyphPalette = []rune{' ', '.', ',', ':', '-', '=', '*', '#', '%', '@'}
+	// ditherOrder is the order the dots of a braille cell light in as the
+	// value rises, spread out so a half-lit cell looks even.
+	ditherOrder = [4][2]int{{0, 4}, {6, 2}, {1, 5}, {7, 3}}
 )
 
 // Config controls the plasma animation behaviour.
@@ -41,19 +51,74 @@ type Config struct {
 	Height        int
 	FrameDelay    time.Duration
 	PaletteScroll float64
+	// PaletteCycle is the scroll direction: forward, reverse, or pingpong to
+	// sweep back and forth across the palette.
+	PaletteCycle string
+	// Gradient, when set, holds 256-color stops blended into a smooth ramp
+	// that replaces the built-in blues.
+	Gradient []int
+	// NoiseType picks the noise mixed into the sines: hash (the original
+	// grain), value, perlin, simplex or worley.
+	NoiseType string
+	// Symmetry folds the plasma into that many mirrored wedges around the
+	// center; 0 turns it off. SymmetrySpin turns the wedges, in turns per
+	// minute.
+	Symmetry     int
+	SymmetrySpin float64
+	// Metaballs replaces the sines and noise with that many bouncing blobs
+	// that merge as they meet; 0 keeps the plasma. MetaballRadius is a
+	// lone ball's radius in cells and MetaballSpeed its speed in cells per
+	// frame, on average.
+	Metaballs      int
+	MetaballRadius float64
+	MetaballSpeed  float64
+	// AudioInput is a raw PCM file or FIFO to react to: bass swells the
+	// field, treble sharpens it and beats send shockwaves from the center.
+	// Pulse is a synthetic beat in beats per minute for when there is none.
+	AudioInput string
+	Pulse      float64
+	// Blur blends each cell's value with the last frame's, 0-0.9, for
+	// smoother motion at low frame rates; 0 turns it off.
+	Blur float64
+	// Mouse turns on mouse reporting so the attractor follows the pointer.
+	// The arrow keys steer it either way, and 'b' drops a fixed one.
+	Mouse bool
+	// Charset is how the field is drawn: ascii glyphs by value; blocks,
+	// two colored half blocks a cell; or braille, eight dots a cell
+	// dithered by value.
+	Charset string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
 }
 
 // DefaultConfig returns sane defaults for typical terminals.
 func DefaultConfig() Config {
 	return Config{
-		Width:         100,
-		Height:        34,
-		FrameDelay:    35 * time.Millisecond,
-		PaletteScroll: 0.08,
+		Width:          100,
+		Height:         34,
+		FrameDelay:     35 * time.Millisecond,
+		PaletteScroll:  0.08,
+		PaletteCycle:   "forward",
+		NoiseType:      "hash",
+		MetaballRadius: 5,
+		MetaballSpeed:  0.6,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidth {
 		c.Width = minWidth
 	}
@@ -66,32 +131,137 @@ func (c Config) normalize() Config {
 	if c.PaletteScroll <= 0 {
 		c.PaletteScroll = 0.05
 	}
+	if !IsCycle(c.PaletteCycle) {
+		c.PaletteCycle = "forward"
+	}
+	c.Blur = canvas.Clamp(c.Blur, 0, 0.9)
+	if c.Symmetry < 0 {
+		c.Symmetry = 0
+	}
+	if c.Metaballs < 0 {
+		c.Metaballs = 0
+	}
+	if c.MetaballRadius <= 0 {
+		c.MetaballRadius = 5
+	}
+	if c.MetaballSpeed < 0 {
+		c.MetaballSpeed = 0.6
+	}
+	if !IsNoise(c.NoiseType) {
+		c.NoiseType = "hash"
+	}
+	// Half blocks show the field only by color, so without any they fall
+	// back to glyphs.
+	if !render.IsCharset(c.Charset) || c.Charset == "blocks" && color.Active() == color.Mono {
+		c.Charset = "ascii"
+	}
 	return c
 }
 
 type cell struct {
-	glyph byte
+	glyph rune
 	color string
+	// below is the lower half's color under a half block, shown as the
+	// background.
+	below string
+}
+
+// colors is the cell's escape, with below as the background if it has one.
+func (c cell) colors() string {
+	return color.Pair(c.color, c.below)
+}
+
+// samples is how many values across and down each cell is drawn from.
+func samples(charset string) (cols, rows int) {
+	switch charset {
+	case "blocks":
+		return 1, 2
+	case "braille":
+		return 2, 4
+	}
+	return 1, 1
 }
 
 // Run launches the plasma grid animation.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	// A run given a seed goes a frame at a time, so it repeats exactly.
+	seeded := cfg.Seed != 0
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
 	grid := newGrid(cfg.Width, cfg.Height)
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	mouse := term.NoMouse
+	if cfg.Mouse {
+		mouse = term.MouseMotion
+	}
+	events := term.Events(mouse)
+	cols, rows := samples(cfg.Charset)
+	sc := &scene{
+		pal:   newPalette(cfg.Gradient, cfg.Width*cols, cfg.Height*rows),
+		field: newNoise(cfg.NoiseType, rng.Int63()),
+		blobs: newMetaballs(cfg, rng),
+		spots: newAttractors(cfg.Width, cfg.Height),
+		music: newPulse(cfg),
+		trail: newTrail(cfg.Blur),
+	}
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		drawPlasma(grid, frame, cfg)
-		render(grid)
-		<-ticker.C
+	// 's' saves the frame and freezes it until the next key press. t is
+	// the time in default frames, which stands still while frozen.
+	frozen := false
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, seeded)
+	for t := 0.0; limit.Next(); {
+		dt := clock.Tick()
+		if !frozen {
+			if sc.blobs != nil {
+				sc.blobs.update(dt)
+			}
+			sc.music.listen(dt)
+			drawPlasma(grid, t, dt, cfg, sc)
+			paint(screen, grid)
+			t += dt
+		}
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				switch {
+				case frozen:
+					frozen = ev.Mouse
+				case ev.Key == 's' || ev.Key == 'S':
+					frozen = true
+					msg := "saved "
+					name, err := saveFrame(grid, time.Now())
+					if err != nil {
+						msg, name = "save failed: ", err.Error()
+					}
+					drawNote(grid, msg+name)
+					paint(screen, grid)
+				default:
+					sc.spots.handle(ev)
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
 	}
+	return nil
 }
 
 func newGrid(width, height int) [][]cell {
@@ -102,59 +272,155 @@ func newGrid(width, height int) [][]cell {
 	return grid
 }
 
-func drawPlasma(grid [][]cell, frame int, cfg Config) {
+// scene is everything that shapes a frame besides the config: the palette,
+// the field (noise or metaballs) and what plays on it.
+type scene struct {
+	pal   *palette
+	field noiseField
+	blobs *metaballs
+	spots *attractors
+	music *pulse
+	trail *trail
+}
+
+// drawPlasma draws the plasma t default frames in, moving the attractors on
+// by dt.
+func drawPlasma(grid [][]cell, t, dt float64, cfg Config, sc *scene) {
 	height := len(grid)
 	width := len(grid[0])
-	t := float64(frame) * 0.03
-	scroll := float64(frame) * cfg.PaletteScroll
+	frame := int(t)
+	phase := t * 0.03
+	scroll := cycleOffset(t, cfg.PaletteScroll, cfg.PaletteCycle, sc.pal.steps)
+	elapsed := time.Duration(t * float64(DefaultConfig().FrameDelay))
+	spin := elapsed.Minutes() * cfg.SymmetrySpin * 2 * math.Pi
+	sc.spots.advance(cfg.Symmetry, spin, dt)
+	cols, rows := samples(cfg.Charset)
+	sc.trail.fit(width*cols, height*rows)
+
+	// sample is the field's value at sx, sy, counted in samples.
+	sample := func(sx, sy int) float64 {
+		fx := float64(sx) / float64(width*cols)
+		fy := float64(sy) / float64(height*rows)
+		px, py := fx, fy
+		if cfg.Symmetry > 0 {
+			px, py = fold(fx, fy, width, height, cfg.Symmetry, spin)
+		}
+		var value float64
+		if sc.blobs != nil {
+			value = sc.blobs.value(px, py) + (sc.spots.pull(px, py)+sc.music.shock(px, py))/2
+		} else {
+			value = plasmaValue(px, py, phase, sc.field, sc.spots, sc.music)
+		}
+		return sc.trail.blend(sx, sy, value)
+	}
 
 	for y := 0; y < height; y++ {
-		fy := float64(y) / float64(height)
 		for x := 0; x < width; x++ {
-			fx := float64(x) / float64(width)
-			value := plasmaValue(fx, fy, t)
-			color := paletteForValue(value + scroll)
-			glyph := glyphForValue(value)
-			grid[y][x] = cell{glyph: glyph, color: color}
+			switch cfg.Charset {
+			case "blocks":
+				top, bottom := sample(x, 2*y), sample(x, 2*y+1)
+				grid[y][x] = cell{
+					glyph: '▀',
+					color: sc.pal.pick(x, 2*y, top+scroll),
+					below: sc.pal.pick(x, 2*y+1, bottom+scroll),
+				}
+			case "braille":
+				var dots rune
+				var sum float64
+				for dy := 0; dy < 4; dy++ {
+					for dx := 0; dx < 2; dx++ {
+						value := sample(2*x+dx, 4*y+dy)
+						sum += value
+						if value > (float64(ditherOrder[dy][dx])+0.5)/8 {
+							dots |= render.BrailleDot(dx, dy)
+						}
+					}
+				}
+				grid[y][x] = cell{glyph: 0x2800 + dots, color: sc.pal.pick(2*x, 4*y, sum/8+scroll)}
+			default:
+				value := sample(x, y)
+				grid[y][x] = cell{glyph: glyphForValue(value), color: sc.pal.pick(x, y, value+scroll)}
+			}
 		}
 	}
 
-	drawScanline(grid, frame)
-	drawGlow(grid, frame)
+	// The scanline would break the symmetry; the glow is round already.
+	if cfg.Symmetry == 0 {
+		drawScanline(grid, frame)
+	}
+	drawGlow(grid, frame, sc.pal)
 }
 
-func plasmaValue(fx, fy, t float64) float64 {
+func plasmaValue(fx, fy, t float64, field noiseField, spots *attractors, music *pulse) float64 {
 	v := math.Sin((fx*10)+t) +
 		math.Sin((fy*12)-t*0.7) +
 		math.Sin((fx+fy)*8+t*0.3) +
-		0.5*math.Sin(math.Hypot(fx-0.5, fy-0.5)*15-t*1.5)
+		music.fine()*math.Sin(math.Hypot(fx-0.5, fy-0.5)*15-t*1.5)
+	v *= music.gain()
 
-	noise := simpleNoise(fx, fy, t)
-	return (v/3.5 + noise*0.25 + 1) / 2 // normalize 0..1
+	noise := field.at(fx, fy, t)
+	return (v/3.5*(1-field.damp) + (noise-field.center)*field.gain + spots.pull(fx, fy) + music.shock(fx, fy) + 1) / 2 // normalize 0..1
 }
 
-func simpleNoise(x, y, t float64) float64 {
-	n := math.Sin((x*13+y*17+t)*12.9898) * 43758.5453
-	return math.Mod(math.Abs(n), 1)
+// Field is the bare sine plasma at fx, fy (0-1 across the screen) and time
+// t, 0-1, for modes that want a faint plasma behind their own picture.
+func Field(fx, fy, t float64) float64 {
+	return canvas.Clamp(plasmaValue(fx, fy, t, noiseField{at: simpleNoise}, nil, nil), 0, 1)
+}
+
+// noiseField is the noise mixed into the sines: damp turns the sines down to
+// make room, and the noise is added around center, scaled by gain. The
+// original grain is only ever added, so it has no center.
+type noiseField struct {
+	at     func(x, y, t float64) float64
+	damp   float64
+	gain   float64
+	center float64
+}
+
+// IsNoise reports whether name is a plasma noise type.
+func IsNoise(name string) bool {
+	switch name {
+	case "hash", "value", "perlin", "simplex", "worley":
+		return true
+	}
+	return false
 }
 
-func paletteForValue(v float64) string {
-	if len(colorPalette) == 0 {
-		return ""
+func newNoise(kind string, seed int64) noiseField {
+	var f noise.Field
+	switch kind {
+	case "value":
+		f = noise.NewValue(seed)
+	case "perlin":
+		f = noise.NewPerlin(seed)
+	case "simplex":
+		f = noise.NewSimplex(seed)
+	case "worley":
+		f = noise.NewWorley(seed)
+	default:
+		return noiseField{at: simpleNoise, gain: 0.25}
 	}
-	v = math.Mod(v, float64(len(colorPalette)))
-	if v < 0 {
-		v += float64(len(colorPalette))
+	return noiseField{
+		at: func(x, y, t float64) float64 {
+			return f.At(x*8+t*0.4, y*6-t*0.25)
+		},
+		damp:   0.55,
+		gain:   1.1,
+		center: 0.5,
 	}
-	idx := int(v) % len(colorPalette)
-	return colorPalette[idx]
 }
 
-func glyphForValue(v float64) byte {
+func simpleNoise(x, y, t float64) float64 {
+	n := math.Sin((x*13+y*17+t)*12.9898) * 43758.5453
+	return math.Mod(math.Abs(n), 1)
+}
+
+func glyphForValue(v float64) rune {
 	if len(glyphPalette) == 0 {
 		return '#'
 	}
-	idx := int(clampFloat(v*float64(len(glyphPalette)), 0, float64(len(glyphPalette)-1)))
+	idx := int(canvas.Clamp(v*float64(len(glyphPalette)), 0, float64(len(glyphPalette)-1)))
 	return glyphPalette[idx]
 }
 
@@ -172,7 +438,7 @@ func drawScanline(grid [][]cell, frame int) {
 	}
 }
 
-func drawGlow(grid [][]cell, frame int) {
+func drawGlow(grid [][]cell, frame int, pal *palette) {
 	height := len(grid)
 	width := len(grid[0])
 	centerX := float64(width) / 2
@@ -186,47 +452,53 @@ func drawGlow(grid [][]cell, frame int) {
 			if falloff < 0.1 {
 				continue
 			}
-			boost := pulse * falloff
-			color := paletteForValue(boost * float64(len(colorPalette)))
-			grid[y][x].color = color
+			grid[y][x].color = pal.brighten(grid[y][x].color, pulse*falloff)
+			if grid[y][x].below != "" {
+				grid[y][x].below = pal.brighten(grid[y][x].below, pulse*falloff)
+			}
 		}
 	}
 }
 
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	if height == 0 {
+func paint(screen *render.Screen, grid [][]cell) {
+	if len(grid) == 0 {
 		return
 	}
+	screen.Draw(len(grid[0]), len(grid), cellAt(grid))
+}
+
+// cellAt hands out the grid's cells as Screen.Draw asks for them.
+func cellAt(grid [][]cell) func(x, y int) (string, string) {
+	return func(x, y int) (string, string) {
+		return render.Rune(grid[y][x].glyph), grid[y][x].colors()
+	}
+}
+
+// frameText is the grid as colored text, one line per row.
+func frameText(grid [][]cell) string {
+	var sb strings.Builder
+	height := len(grid)
 	width := len(grid[0])
 	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
 
 	for _, row := range grid {
+		background := false
 		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
+			if background && c.below == "" {
+				sb.WriteString(term.Reset)
+			}
+			background = c.below != ""
+			if escape := c.colors(); escape != "" {
+				sb.WriteString(escape)
 			}
 			g := c.glyph
 			if g == 0 {
 				g = ' '
 			}
-			sb.WriteByte(g)
+			sb.WriteRune(g)
 		}
 		sb.WriteString(term.Reset)
 		sb.WriteByte('\n')
 	}
-
-	fmt.Print(sb.String())
-}
-
-func clampFloat(v, minV, maxV float64) float64 {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
+	return sb.String()
 }
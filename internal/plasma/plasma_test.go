@@ -0,0 +1,106 @@
+package plasma
+
+import (
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"animinterminal/internal/color"
+)
+
+// TestGlowBrightens lays one dim palette entry over the whole grid and
+// checks the center glow only ever moves cells up the ramp: the center
+// region ends up brighter than it started, nothing ends up darker, and the
+// corners are left alone.
+func TestGlowBrightens(t *testing.T) {
+	color.Use(color.ANSI256)
+	const width, height = 60, 24
+	pal := newPalette(nil, width, height)
+	dim := len(pal.colors) / 4
+	grid := make([][]cell, height)
+	for y := range grid {
+		grid[y] = make([]cell, width)
+		for x := range grid[y] {
+			grid[y][x] = cell{glyph: '#', color: pal.colors[dim]}
+		}
+	}
+
+	// Frame 39 is the pulse at its height.
+	drawGlow(grid, 39, pal)
+
+	brightness := func(x, y int) int {
+		i, ok := pal.index[grid[y][x].color]
+		if !ok {
+			t.Fatalf("cell %d,%d has %q, not a palette color", x, y, grid[y][x].color)
+		}
+		return i
+	}
+	center := 0
+	for y := height/2 - 2; y < height/2+2; y++ {
+		for x := width/2 - 4; x < width/2+4; x++ {
+			center += brightness(x, y)
+		}
+	}
+	if mean := float64(center) / 32; mean < float64(dim)+float64(len(pal.colors))/4 {
+		t.Errorf("center brightness %.1f, want well above %d", mean, dim)
+	}
+	for y := range grid {
+		for x := range grid[y] {
+			if b := brightness(x, y); b < dim {
+				t.Fatalf("cell %d,%d darkened from %d to %d", x, y, dim, b)
+			}
+		}
+	}
+	if b := brightness(0, 0); b != dim {
+		t.Errorf("corner changed from %d to %d", dim, b)
+	}
+}
+
+// TestSaveFrame saves a small frame in a scratch directory and checks both
+// the text and the PNG are there, named for the time, and the PNG is the
+// one reported.
+func TestSaveFrame(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(dir)
+
+	grid := newGrid(8, 3)
+	for y := range grid {
+		for x := range grid[y] {
+			grid[y][x] = cell{glyph: '#', color: "\x1b[38;5;201m"}
+		}
+	}
+	name, err := saveFrame(grid, time.Date(2024, 1, 1, 12, 3, 1, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "plasma-20240101-120301.png" {
+		t.Errorf("saved as %q", name)
+	}
+	text, err := os.ReadFile("plasma-20240101-120301.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(text), "#") != 8*3 {
+		t.Errorf("text frame is %q", text)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() <= 8 || b.Dy() <= 3 {
+		t.Errorf("image is only %v", b)
+	}
+}
@@ -0,0 +1,43 @@
+package plasma
+
+import (
+	"os"
+	"time"
+
+	"animinterminal/internal/render"
+)
+
+var noteColor = "\x1b[38;5;231m"
+
+// saveFrame writes the frame to timestamped files in the working
+// directory, as ANSI text and as a PNG, and returns the PNG's name.
+func saveFrame(grid [][]cell, now time.Time) (string, error) {
+	stem := now.Format("plasma-20060102-150405")
+	if err := os.WriteFile(stem+".txt", []byte(frameText(grid)), 0o644); err != nil {
+		return "", err
+	}
+	f, err := os.Create(stem + ".png")
+	if err != nil {
+		return "", err
+	}
+	err = render.WritePNG(f, len(grid[0]), len(grid), cellAt(grid))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	return stem + ".png", nil
+}
+
+// drawNote puts msg in the bottom right corner.
+func drawNote(grid [][]cell, msg string) {
+	label := " " + msg + " "
+	y := len(grid) - 2
+	x := len(grid[0]) - len(label) - 2
+	for i := 0; i < len(label); i++ {
+		if x+i >= 0 && x+i < len(grid[y]) {
+			grid[y][x+i] = cell{glyph: rune(label[i]), color: noteColor}
+		}
+	}
+}
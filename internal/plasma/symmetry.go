@@ -0,0 +1,27 @@
+package plasma
+
+import "math"
+
+// cellAspect is how much taller a terminal cell is than it is wide.
+const cellAspect = 2.0
+
+// fold maps a point into the first wedge of an n-way kaleidoscope around the
+// screen center, mirroring every other wedge so the seams match. spin turns
+// the wedges. It works in cell units scaled by cellAspect so the symmetry is
+// round on screen.
+func fold(fx, fy float64, width, height, n int, spin float64) (float64, float64) {
+	dx := (fx - 0.5) * float64(width)
+	dy := (fy - 0.5) * float64(height) * cellAspect
+	r := math.Hypot(dx, dy)
+	wedge := 2 * math.Pi / float64(n)
+	angle := math.Atan2(dy, dx) - spin
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	a := math.Mod(angle, wedge)
+	if int(angle/wedge)%2 == 1 {
+		a = wedge - a
+	}
+	return r*math.Cos(a)/float64(width) + 0.5, r*math.Sin(a)/(float64(height)*cellAspect) + 0.5
+}
@@ -0,0 +1,86 @@
+package radar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Contact is something on the scope: its bearing in degrees clockwise from
+// north, its range as a share of the scope's radius, and an optional label.
+type Contact struct {
+	Bearing float64
+	Range   float64
+	Label   string
+}
+
+// ReadContacts reads one contact per line as "bearing range [label]", the
+// fields split by spaces or commas. Blank lines and lines starting with '#'
+// are skipped; contacts out past the edge of the scope are dropped.
+func ReadContacts(r io.Reader) ([]Contact, error) {
+	var contacts []Contact
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: want bearing and range, got %q", n, line)
+		}
+		bearing, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || math.IsNaN(bearing) || math.IsInf(bearing, 0) {
+			return nil, fmt.Errorf("line %d: bad bearing %q", n, fields[0])
+		}
+		rng, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || math.IsNaN(rng) {
+			return nil, fmt.Errorf("line %d: bad range %q", n, fields[1])
+		}
+		if rng < 0 || rng > 1 {
+			continue
+		}
+		contacts = append(contacts, Contact{
+			Bearing: math.Mod(math.Mod(bearing, 360)+360, 360),
+			Range:   rng,
+			Label:   strings.Join(fields[2:], " "),
+		})
+	}
+	return contacts, scanner.Err()
+}
+
+// target is a random contact cruising across the scope, in scope units
+// with north up.
+type target struct {
+	x, y   float64
+	vx, vy float64
+	label  string
+}
+
+// spawn puts a target somewhere inside the scope heading any which way.
+func spawn(n int, rng *rand.Rand) target {
+	r := 0.2 + 0.75*math.Sqrt(rng.Float64())
+	a := rng.Float64() * 2 * math.Pi
+	heading := rng.Float64() * 2 * math.Pi
+	speed := 0.0005 + rng.Float64()*0.0015
+	return target{
+		x:     r * math.Sin(a),
+		y:     r * math.Cos(a),
+		vx:    speed * math.Sin(heading),
+		vy:    speed * math.Cos(heading),
+		label: fmt.Sprintf("TK%02d", n),
+	}
+}
+
+func (t *target) contact() Contact {
+	bearing := math.Atan2(t.x, t.y) * 180 / math.Pi
+	return Contact{
+		Bearing: math.Mod(bearing+360, 360),
+		Range:   math.Hypot(t.x, t.y),
+		Label:   t.label,
+	}
+}
@@ -0,0 +1,332 @@
+package radar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// cellAspect is how much taller a terminal cell is than it is wide.
+	cellAspect = 2.0
+	// decay is how many seconds the phosphor takes to fade to a third
+	// behind the sweep.
+	decay       = 1.0
+	maxContacts = 40
+)
+
+var (
+	// phosphor runs from the fresh glow under the sweep down to the dark
+	// green of an old trace.
+	phosphor   = []string{"\x1b[38;5;157m", "\x1b[38;5;120m", "\x1b[38;5;82m", "\x1b[38;5;40m", "\x1b[38;5;34m", "\x1b[38;5;28m", "\x1b[38;5;22m"}
+	wakeGlyphs = []rune{'#', '+', ':', ':', '.'}
+	gridColor  = "\x1b[38;5;22m"
+	labelColor = "\x1b[38;5;71m"
+	hudColor   = "\x1b[38;5;65m"
+)
+
+// Config controls the radar scope.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// RPM is how many turns the sweep makes a minute.
+	RPM float64
+	// Contacts is how many random targets cruise about the scope.
+	Contacts int
+	// Feed, when set, is a file of contacts in the form ReadContacts takes.
+	// It is read again on every turn of the sweep, so it can be kept up to
+	// date from outside, and replaces the random targets.
+	Feed string
+	// Labels writes each contact's name beside it.
+	Labels bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		RPM:        12,
+		Contacts:   6,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.RPM <= 0 {
+		c.RPM = 12
+	}
+	if c.RPM > 120 {
+		c.RPM = 120
+	}
+	if c.Contacts < 0 {
+		c.Contacts = 6
+	}
+	if c.Contacts > maxContacts {
+		c.Contacts = maxContacts
+	}
+	return c
+}
+
+// scope is the round screen: its size and, for every cell, the bearing it
+// lies on and how long since the sweep last passed over it.
+type scope struct {
+	cx, cy  float64
+	radius  float64
+	bearing []float64
+	age     []float64
+	width   int
+}
+
+func newScope(width, height int) *scope {
+	s := &scope{
+		cx:      float64(width) / 2,
+		cy:      float64(height) / 2,
+		radius:  math.Min(float64(width)/2-6, (float64(height)/2-2.5)*cellAspect),
+		bearing: make([]float64, width*height),
+		age:     make([]float64, width*height),
+		width:   width,
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			dx, dy := s.offset(x, y)
+			s.age[i] = math.Inf(1)
+			if math.Hypot(dx, dy) > s.radius {
+				s.bearing[i] = math.NaN()
+				continue
+			}
+			s.bearing[i] = math.Atan2(dx, -dy)
+		}
+	}
+	return s
+}
+
+// offset is cell x, y from the center, in columns.
+func (s *scope) offset(x, y int) (float64, float64) {
+	return float64(x) + 0.5 - s.cx, (float64(y) + 0.5 - s.cy) * cellAspect
+}
+
+// at is the cell for a bearing in radians and a range as a share of the
+// radius.
+func (s *scope) at(bearing, rng float64) (int, int) {
+	r := rng * s.radius
+	return int(math.Floor(s.cx + r*math.Sin(bearing))), int(math.Floor(s.cy - r*math.Cos(bearing)/cellAspect))
+}
+
+// crossed reports whether bearing lies in the arc the sweep turned through
+// from from by turn radians.
+func crossed(bearing, from, turn float64) bool {
+	d := math.Mod(bearing-from+4*math.Pi, 2*math.Pi)
+	return d < turn
+}
+
+// blip is a contact as the scope shows it: where it was when the sweep last
+// crossed it, and how long ago that was.
+type blip struct {
+	shown Contact
+	age   float64
+}
+
+// Run launches the radar.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	sc := newScope(cfg.Width, cfg.Height)
+	targets := make([]target, cfg.Contacts)
+	for i := range targets {
+		targets[i] = spawn(i+1, rng)
+	}
+	var fed []Contact
+	if cfg.Feed != "" {
+		fed = readFeed(cfg.Feed, nil)
+	}
+	var blips []blip
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	dt := cfg.FrameDelay.Seconds()
+	turn := cfg.RPM / 60 * 2 * math.Pi * dt
+	sweep := 0.0
+	for limit.Next() {
+		var contacts []Contact
+		if cfg.Feed != "" {
+			contacts = fed
+		} else {
+			for i := range targets {
+				t := &targets[i]
+				t.x += t.vx
+				t.y += t.vy
+				if math.Hypot(t.x, t.y) > 1 {
+					*t = spawn(i+1, rng)
+				}
+				contacts = append(contacts, t.contact())
+			}
+		}
+		if len(blips) != len(contacts) {
+			blips = make([]blip, len(contacts))
+			for i := range blips {
+				blips[i].age = math.Inf(1)
+			}
+		}
+
+		for i := range sc.age {
+			if crossed(sc.bearing[i], sweep, turn) {
+				sc.age[i] = 0
+			} else {
+				sc.age[i] += dt
+			}
+		}
+		for i, c := range contacts {
+			if crossed(c.Bearing*math.Pi/180, sweep, turn) {
+				blips[i] = blip{shown: c}
+			} else {
+				blips[i].age += dt
+			}
+		}
+		sweep += turn
+		if sweep >= 2*math.Pi {
+			sweep -= 2 * math.Pi
+			if cfg.Feed != "" {
+				fed = readFeed(cfg.Feed, fed)
+			}
+		}
+
+		drawScope(grid, sc, blips, sweep, cfg)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// readFeed reads the contacts in path, keeping last if it cannot.
+func readFeed(path string, last []Contact) []Contact {
+	f, err := os.Open(path)
+	if err != nil {
+		return last
+	}
+	defer f.Close()
+	contacts, err := ReadContacts(f)
+	if err != nil {
+		return last
+	}
+	return contacts
+}
+
+// drawScope draws the phosphor wake, the rings and bearing marks over it,
+// the sweep line, and the contacts fading since the sweep last lit them.
+func drawScope(grid canvas.Grid, sc *scope, blips []blip, sweep float64, cfg Config) {
+	grid.Clear()
+	for y := range grid {
+		for x := range grid[y] {
+			i := y*sc.width + x
+			if math.IsNaN(sc.bearing[i]) {
+				continue
+			}
+			level := len(wakeGlyphs)
+			if glow := math.Exp(-sc.age[i] / decay); glow > 0.25 {
+				level = int((1 - glow) / 0.75 * float64(len(wakeGlyphs)))
+				grid.Set(x, y, wakeGlyphs[level], phosphor[min(len(phosphor)-1, level+1)])
+			}
+			// The rings show through all but the brightest of the wake.
+			dx, dy := sc.offset(x, y)
+			d := math.Hypot(dx, dy)
+			for _, ring := range []float64{1, 2.0 / 3, 1.0 / 3} {
+				if math.Abs(d-ring*sc.radius) < 0.5 && level >= 2 {
+					grid.Set(x, y, '.', gridColor)
+				}
+			}
+		}
+	}
+	for deg := 0; deg < 360; deg += 30 {
+		a := float64(deg) * math.Pi / 180
+		x, y := sc.at(a, 1+1.5/sc.radius)
+		grid.Set(x, y, '+', gridColor)
+		if deg%90 == 0 {
+			label := fmt.Sprintf("%03d", deg)
+			lx, ly := sc.at(a, 1+4/sc.radius)
+			grid.Text(lx-1, ly, label, hudColor)
+		}
+	}
+	for r := 0.0; r <= sc.radius; r += 0.5 {
+		x, y := sc.at(sweep, r/sc.radius)
+		grid.Set(x, y, '*', phosphor[0])
+	}
+	for _, b := range blips {
+		if math.IsInf(b.age, 1) {
+			continue
+		}
+		fade := b.age / (60 / cfg.RPM)
+		if fade >= 1 {
+			continue
+		}
+		x, y := sc.at(b.shown.Bearing*math.Pi/180, b.shown.Range)
+		glyph := '@'
+		switch {
+		case fade > 0.6:
+			glyph = '.'
+		case fade > 0.25:
+			glyph = 'o'
+		}
+		color := phosphor[min(len(phosphor)-1, int(fade*float64(len(phosphor))))]
+		grid.Set(x, y, glyph, color)
+		if cfg.Labels && b.shown.Label != "" && fade < 0.6 {
+			grid.Text(x+2, y, b.shown.Label, labelColor)
+		}
+	}
+	grid.Text(1, len(grid)-1, fmt.Sprintf("BRG %03.0f  RPM %.0f  CONTACTS %d", sweep*180/math.Pi, cfg.RPM, len(blips)), hudColor)
+}
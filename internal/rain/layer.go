@@ -0,0 +1,47 @@
+package rain
+
+import "math/rand"
+
+// Layer is the falling streams on their own, without the mist, splashes or
+// lightning, so other modes can run a rain behind their own picture.
+type Layer struct {
+	streams []stream
+	cfg     Config
+	frame   int
+	rng     *rand.Rand
+}
+
+// NewLayer builds streams for a width by height screen; density is streams
+// per column, as in Config. The same seed makes the same streams.
+func NewLayer(width, height int, density float64, seed int64) *Layer {
+	cfg := Config{Width: width, Height: height, Density: density, Seed: seed}.normalize()
+	l := &Layer{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+	l.streams = makeStreams(l.rng, cfg)
+	return l
+}
+
+// Update moves every stream down one frame.
+func (l *Layer) Update() {
+	updateStreams(l.rng, l.streams, l.cfg, 1)
+	l.frame++
+}
+
+// Draw hands every visible stream cell to set, with fade running from 0 at
+// the head of its stream toward 1 at the tip of its tail.
+func (l *Layer) Draw(set func(x, y int, glyph rune, fade float64)) {
+	for _, s := range l.streams {
+		head := int(s.head)
+		x := streamColumn(s, l.frame, l.cfg.Width)
+		glyphs := s.charset
+		if len(glyphs) == 0 {
+			glyphs = glyphPool
+		}
+		for i := 0; i < s.length; i++ {
+			y := head - i
+			if y < 0 || y >= l.cfg.Height {
+				continue
+			}
+			set(x, y, glyphs[(l.frame+y+i)%len(glyphs)], float64(i)/float64(s.length))
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package rain
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/bolt"
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -17,10 +21,46 @@ const (
 )
 
 var (
-	streamPalettes = [][]string{
-		{"\x1b[38;5;159m", "\x1b[38;5;81m", "\x1b[38;5;42m", "\x1b[38;5;35m"},
-		{"\x1b[38;5;120m", "\x1b[38;5;47m", "\x1b[38;5;40m", "\x1b[38;5;34m"},
-		{"\x1b[38;5;123m", "\x1b[38;5;75m", "\x1b[38;5;43m", "\x1b[38;5;29m"},
+	// themes are the stream colors, each a few palettes that run from the
+	// bright top of a tail to its dim end; every stream picks one. Heads
+	// glow in glowPalette whatever the theme.
+	themes = map[string][][]string{
+		"green": {
+			{"\x1b[38;5;159m", "\x1b[38;5;81m", "\x1b[38;5;42m", "\x1b[38;5;35m"},
+			{"\x1b[38;5;120m", "\x1b[38;5;47m", "\x1b[38;5;40m", "\x1b[38;5;34m"},
+			{"\x1b[38;5;123m", "\x1b[38;5;75m", "\x1b[38;5;43m", "\x1b[38;5;29m"},
+		},
+		"cyan": {
+			{"\x1b[38;5;159m", "\x1b[38;5;117m", "\x1b[38;5;45m", "\x1b[38;5;31m"},
+			{"\x1b[38;5;195m", "\x1b[38;5;87m", "\x1b[38;5;44m", "\x1b[38;5;30m"},
+			{"\x1b[38;5;123m", "\x1b[38;5;81m", "\x1b[38;5;38m", "\x1b[38;5;24m"},
+		},
+		"amber": {
+			{"\x1b[38;5;229m", "\x1b[38;5;221m", "\x1b[38;5;214m", "\x1b[38;5;172m"},
+			{"\x1b[38;5;230m", "\x1b[38;5;220m", "\x1b[38;5;208m", "\x1b[38;5;166m"},
+			{"\x1b[38;5;223m", "\x1b[38;5;215m", "\x1b[38;5;178m", "\x1b[38;5;136m"},
+		},
+		"mono": {
+			{"\x1b[38;5;255m", "\x1b[38;5;250m", "\x1b[38;5;245m", "\x1b[38;5;240m"},
+			{"\x1b[38;5;254m", "\x1b[38;5;248m", "\x1b[38;5;243m", "\x1b[38;5;238m"},
+			{"\x1b[38;5;253m", "\x1b[38;5;247m", "\x1b[38;5;242m", "\x1b[38;5;237m"},
+		},
+	}
+	// charsets are the glyphs streams are made of, each a few sets that
+	// every stream picks one of.
+	charsets = map[string][][]rune{
+		"ascii": {
+			{'|', '/', '\\', ':'},
+			{'1', '=', '-', ':'},
+			{'[', ']', '0', '|'},
+		},
+		// Half-width katakana, as in the film, with a few digits.
+		"katakana": {
+			[]rune("ｦｱｳｴｵｶｷｹｺｻｼｽｾｿﾀﾂﾃﾅﾆﾇﾈﾊﾋﾎﾏﾐﾑﾒﾓﾔﾕﾗﾘﾜ012345789"),
+		},
+		"binary": {
+			{'0', '1'},
+		},
 	}
 	glowPalette = []string{
 		"\x1b[38;5;195m",
@@ -40,7 +80,7 @@ var (
 		"\x1b[38;5;36m",
 		"\x1b[38;5;44m",
 	}
-	glyphPool = []byte{'0', '1', '|', '/', '\\', '[', ']'}
+	glyphPool = []rune{'0', '1', '|', '/', '\\', '[', ']'}
 )
 
 // Config controls the rain animation.
@@ -49,6 +89,23 @@ type Config struct {
 	Height     int
 	FrameDelay time.Duration
 	Density    float64
+	// Charset is what the streams are made of: ascii, katakana, binary, or
+	// mixed for each stream to pick one.
+	Charset string
+	// Theme colors the streams: green, cyan, amber or mono.
+	Theme string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+	// Mouse has a click bring lightning down where the pointer is and
+	// splash the rain up from there, on terminals that report the mouse.
+	Mouse bool
 }
 
 // DefaultConfig returns a preset tuned for most terminals.
@@ -58,10 +115,18 @@ func DefaultConfig() Config {
 		Height:     34,
 		FrameDelay: 55 * time.Millisecond,
 		Density:    0.18,
+		Charset:    "ascii",
+		Theme:      "green",
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidth {
 		c.Width = minWidth
 	}
@@ -74,12 +139,25 @@ func (c Config) normalize() Config {
 	if c.Density <= 0 {
 		c.Density = 0.15
 	}
+	if !IsCharset(c.Charset) {
+		c.Charset = "ascii"
+	}
+	if !IsTheme(c.Theme) {
+		c.Theme = "green"
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
+// IsCharset reports whether name is a stream charset.
+func IsCharset(name string) bool {
+	_, ok := charsets[name]
+	return ok || name == "mixed"
+}
+
+// IsTheme reports whether name is a stream color theme.
+func IsTheme(name string) bool {
+	_, ok := themes[name]
+	return ok
 }
 
 type stream struct {
@@ -91,76 +169,103 @@ type stream struct {
 	layer      int
 	swayPhase  float64
 	thickness  int
-	charset    []byte
+	charset    []rune
 }
 
 type splash struct {
 	x, y   float64
 	vx, vy float64
-	life   int
+	life   float64
 	color  string
 }
 
+// lightning is a bolt and how many more frames it stays lit.
 type lightning struct {
-	points [][2]int
-	decay  int
+	bolt  bolt.Bolt
+	decay int
 }
 
 // Run launches the rain animation loop.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	// A run given a seed goes a frame at a time, so it repeats exactly.
+	seeded := cfg.Seed != 0
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
-	streams := makeStreams(cfg)
+	streams := makeStreams(rng, cfg)
+	palettes := themes[cfg.Theme]
 	splashes := make([]splash, 0, 128)
-	var bolt lightning
+	var flash lightning
+	mouse := term.NoMouse
+	if cfg.Mouse {
+		mouse = term.MouseClicks
+	}
+	events := term.Events(mouse)
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
-	grid := newGrid(cfg.Width, cfg.Height)
-
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
+	grid := canvas.New(cfg.Width, cfg.Height)
+
+	// t is the time in default frames. Splashes and lightning come once
+	// each time it reaches another whole frame, however often that is drawn.
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, seeded)
+	last := -1
+	for t := 0.0; limit.Next(); {
+		frame := int(t)
+		fresh := frame != last
+		last = frame
+		grid.Clear()
 		drawBackground(grid, frame)
 		drawMist(grid, frame)
 		drawDrizzle(grid, frame)
-		drawStreams(grid, streams, frame, &splashes)
+		drawStreams(rng, grid, streams, palettes, frame, fresh, &splashes)
 		drawSplashes(grid, splashes)
 		drawReflections(grid, frame)
-		if bolt.decay > 0 {
-			drawLightning(grid, bolt)
-			bolt.decay--
-		} else if rand.Intn(90) == 0 {
-			bolt = newLightning(cfg.Width, cfg.Height/2)
+		if flash.decay > 0 {
+			drawLightning(grid, flash)
+			if fresh {
+				flash.decay--
+			}
+		} else if fresh && rng.Intn(90) == 0 {
+			flash = newLightning(rng, cfg.Width, cfg.Height/2)
 		}
-		render(grid)
-		updateSplashes(&splashes, cfg.Width, cfg.Height)
-		updateStreams(streams, cfg.Width, cfg.Height)
-
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		row := grid[y]
-		for x := range row {
-			row[x] = cell{glyph: ' ', color: ""}
+		grid.Render(screen)
+		dt := clock.Tick()
+		updateSplashes(&splashes, cfg.Width, cfg.Height, dt)
+		updateStreams(rng, streams, cfg, dt)
+		t += dt
+
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				// Clicks off the edge of a frame smaller than the
+				// terminal are ignored.
+				if ev.Click && ev.X < cfg.Width && ev.Y < cfg.Height {
+					flash = newStrike(rng, ev.X, ev.Y)
+					emitSplash(rng, &splashes, ev.X, min(ev.Y, cfg.Height-2))
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
 		}
 	}
+	return nil
 }
 
-func drawMist(grid [][]cell, frame int) {
+func drawMist(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height; y++ {
@@ -169,40 +274,42 @@ func drawMist(grid [][]cell, frame int) {
 		}
 		color := mistPalette[(y/2+frame/10)%len(mistPalette)]
 		for x := (y + frame) % 6; x < width; x += 6 {
-			setIfEmpty(grid, x, y, '.', color)
+			grid.SetIfEmpty(x, y, '.', color)
 		}
 	}
 }
 
-func drawBackground(grid [][]cell, frame int) {
+func drawBackground(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height/3; y++ {
 		color := horizonPalette[(y+frame/12)%len(horizonPalette)]
 		for x := 0; x < width; x += 4 {
-			setIfEmpty(grid, x+(y%3), y, '.', color)
+			grid.SetIfEmpty(x+(y%3), y, '.', color)
 		}
 	}
 }
 
-func drawDrizzle(grid [][]cell, frame int) {
+func drawDrizzle(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for x := 0; x < width; x += 5 {
 		for y := height / 3; y < height; y += 7 {
 			if (x+y+frame)%9 == 0 {
-				ch := []byte{'`', '.', '\''}[(x/3+y+frame)%3]
-				setIfEmpty(grid, x+(frame%3), y, ch, "\x1b[38;5;240m")
+				ch := []rune{'`', '.', '\''}[(x/3+y+frame)%3]
+				grid.SetIfEmpty(x+(frame%3), y, ch, "\x1b[38;5;240m")
 			}
 		}
 	}
 }
 
-func drawStreams(grid [][]cell, streams []stream, frame int, splashes *[]splash) {
+// drawStreams draws every stream, with heads that reach the ground
+// splashing if splash is set.
+func drawStreams(r *rand.Rand, grid canvas.Grid, streams []stream, palettes [][]string, frame int, splash bool, splashes *[]splash) {
 	height := len(grid)
 	width := len(grid[0])
 	for _, s := range streams {
-		palette := streamPalettes[s.paletteIdx%len(streamPalettes)]
+		palette := palettes[s.paletteIdx%len(palettes)]
 		head := int(s.head)
 		column := streamColumn(s, frame, width)
 		for i := 0; i < s.length; i++ {
@@ -226,10 +333,10 @@ func drawStreams(grid [][]cell, streams []stream, frame int, splashes *[]splash)
 				if col < 0 || col >= width {
 					continue
 				}
-				setCell(grid, col, y, glyph, color)
+				grid.Set(col, y, glyph, color)
 			}
-			if i == 0 && y >= height-2 {
-				emitSplash(splashes, column, height)
+			if splash && i == 0 && y >= height-2 {
+				emitSplash(r, splashes, column, height-2)
 			}
 		}
 	}
@@ -248,8 +355,9 @@ func streamColumn(s stream, frame int, width int) int {
 	return col
 }
 
-func emitSplash(splashes *[]splash, x int, height int) {
-	count := 2 + rand.Intn(3)
+// emitSplash throws a few drops up from x, y.
+func emitSplash(r *rand.Rand, splashes *[]splash, x, y int) {
+	count := 2 + r.Intn(3)
 	remaining := maxSplashes - len(*splashes)
 	if remaining <= 0 {
 		return
@@ -257,20 +365,20 @@ func emitSplash(splashes *[]splash, x int, height int) {
 	if count > remaining {
 		count = remaining
 	}
-	baseY := float64(height - 2)
+	baseY := float64(y)
 	for i := 0; i < count; i++ {
 		*splashes = append(*splashes, splash{
-			x:     float64(x) + rand.Float64()*0.6 - 0.3,
+			x:     float64(x) + r.Float64()*0.6 - 0.3,
 			y:     baseY,
-			vx:    rand.Float64()*0.8 - 0.4,
-			vy:    -0.6 - rand.Float64()*0.7,
-			life:  10 + rand.Intn(10),
-			color: glowPalette[rand.Intn(len(glowPalette))],
+			vx:    r.Float64()*0.8 - 0.4,
+			vy:    -0.6 - r.Float64()*0.7,
+			life:  float64(10 + r.Intn(10)),
+			color: glowPalette[r.Intn(len(glowPalette))],
 		})
 	}
 }
 
-func drawSplashes(grid [][]cell, splashes []splash) {
+func drawSplashes(grid canvas.Grid, splashes []splash) {
 	for _, sp := range splashes {
 		x := int(math.Round(sp.x))
 		y := int(math.Round(sp.y))
@@ -280,11 +388,11 @@ func drawSplashes(grid [][]cell, splashes []splash) {
 		if x < 0 || x >= len(grid[y]) {
 			continue
 		}
-		setCell(grid, x, y, '\'', sp.color)
+		grid.Set(x, y, '\'', sp.color)
 	}
 }
 
-func drawReflections(grid [][]cell, frame int) {
+func drawReflections(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	base := height - 4
@@ -294,22 +402,24 @@ func drawReflections(grid [][]cell, frame int) {
 	for x := 0; x < width; x++ {
 		if (x+frame)%5 == 0 {
 			color := reflectionPalette[(x/3+frame/7)%len(reflectionPalette)]
-			setIfEmpty(grid, x, base, '_', color)
+			grid.SetIfEmpty(x, base, '_', color)
 			if base+1 < height {
-				setIfEmpty(grid, x, base+1, '.', color)
+				grid.SetIfEmpty(x, base+1, '.', color)
 			}
 		}
 	}
 }
 
-func updateSplashes(splashes *[]splash, width, height int) {
+// updateSplashes moves the splashes on by dt default frames and drops the
+// ones that have landed, left the screen or burnt out.
+func updateSplashes(splashes *[]splash, width, height int, dt float64) {
 	items := *splashes
 	dst := items[:0]
 	for i := range items {
-		items[i].x += items[i].vx
-		items[i].y += items[i].vy
-		items[i].vy += 0.08
-		items[i].life--
+		items[i].x += items[i].vx * dt
+		items[i].y += items[i].vy * dt
+		items[i].vy += 0.08 * dt
+		items[i].life -= dt
 		if items[i].x < 0 || items[i].x >= float64(width) {
 			continue
 		}
@@ -324,183 +434,77 @@ func updateSplashes(splashes *[]splash, width, height int) {
 	*splashes = dst
 }
 
-func updateStreams(streams []stream, width, height int) {
+// updateStreams moves the streams down by dt default frames, starting any
+// that have run off the bottom again above the top.
+func updateStreams(r *rand.Rand, streams []stream, cfg Config, dt float64) {
 	for i := range streams {
-		streams[i].head += streams[i].speed
-		if int(streams[i].head)-streams[i].length > height {
-			resetStream(&streams[i], width, height, false)
+		streams[i].head += streams[i].speed * dt
+		if int(streams[i].head)-streams[i].length > cfg.Height {
+			resetStream(r, &streams[i], cfg, false)
 		}
 	}
 }
 
-func newLightning(width, height int) lightning {
-	points := make([][2]int, 0, height)
-	x := rand.Intn(width)
-	y := rand.Intn(height / 3)
-	for y < height && len(points) < height*2 {
-		points = append(points, [2]int{x, y})
-		x += rand.Intn(3) - 1
-		if x < 1 {
-			x = 1
-		}
-		if x >= width-1 {
-			x = width - 2
-		}
-		y += 1 + rand.Intn(2)
-	}
-	return lightning{points: points, decay: 5}
+// newLightning forks a bolt down from somewhere in the top third of height
+// to its bottom.
+func newLightning(r *rand.Rand, width, height int) lightning {
+	x0 := r.Float64() * float64(width)
+	y0 := r.Float64() * float64(height/3)
+	x1 := x0 + (r.Float64()*2-1)*float64(width)*0.1
+	return lightning{bolt: bolt.New(r, x0, y0, x1, float64(height), 0.5), decay: 5}
 }
 
-func drawLightning(grid [][]cell, bolt lightning) {
-	for i := 0; i < len(bolt.points)-1; i++ {
-		from := bolt.points[i]
-		to := bolt.points[i+1]
-		color := glowPalette[i%len(glowPalette)]
-		for _, p := range linePoints(from[0], from[1], to[0], to[1]) {
-			setCell(grid, p[0], p[1], '|', color)
-		}
-	}
+// newStrike is a bolt from the top of the screen that forks its way down
+// to x, y.
+func newStrike(r *rand.Rand, x, y int) lightning {
+	x0 := float64(x+r.Intn(9)-4) + 0.5
+	return lightning{bolt: bolt.New(r, x0, 0, float64(x)+0.5, float64(y)+0.5, 0.5), decay: 5}
 }
 
-func makeStreams(cfg Config) []stream {
+// drawLightning draws l with its branches in the dimmer glow.
+func drawLightning(grid canvas.Grid, l lightning) {
+	l.bolt.Draw(func(x, y int, glyph rune, depth int) {
+		grid.Set(x, y, glyph, glowPalette[min(depth, len(glowPalette)-1)])
+	})
+}
+
+func makeStreams(r *rand.Rand, cfg Config) []stream {
 	count := int(float64(cfg.Width) * cfg.Density)
 	if count < 4 {
 		count = 4
 	}
 	streams := make([]stream, count)
 	for i := range streams {
-		resetStream(&streams[i], cfg.Width, cfg.Height, true)
+		resetStream(r, &streams[i], cfg, true)
 	}
 	return streams
 }
 
-func resetStream(s *stream, width, height int, visible bool) {
-	s.baseX = rand.Intn(width)
-	s.length = clampInt(6+rand.Intn(height/2), 6, height)
-	s.layer = rand.Intn(3)
+func resetStream(r *rand.Rand, s *stream, cfg Config, visible bool) {
+	width, height := cfg.Width, cfg.Height
+	s.baseX = r.Intn(width)
+	s.length = canvas.Clamp(6+r.Intn(height/2), 6, height)
+	s.layer = r.Intn(3)
 	baseSpeed := 0.35 + float64(s.layer)*0.25
-	s.speed = baseSpeed + rand.Float64()*0.6
-	s.paletteIdx = rand.Intn(len(streamPalettes))
-	s.swayPhase = rand.Float64() * math.Pi * 2
-	s.thickness = 1 + rand.Intn(1+s.layer)
-	s.charset = pickCharset()
+	s.speed = baseSpeed + r.Float64()*0.6
+	s.paletteIdx = r.Intn(len(themes[cfg.Theme]))
+	s.swayPhase = r.Float64() * math.Pi * 2
+	s.thickness = 1 + r.Intn(1+s.layer)
+	s.charset = pickCharset(r, cfg.Charset)
 	if visible {
-		s.head = rand.Float64() * float64(height)
+		s.head = r.Float64() * float64(height)
 	} else {
-		s.head = -float64(rand.Intn(height))
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func clampInt(v, lo, hi int) int {
-	if v < lo {
-		return lo
-	}
-	if v > hi {
-		return hi
-	}
-	return v
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func pickCharset() []byte {
-	charsets := [][]byte{
-		{'|', '/', '\\', ':'},
-		{'1', '=', '-', ':'},
-		{'[', ']', '0', '|'},
-	}
-	return charsets[rand.Intn(len(charsets))]
-}
-
-func linePoints(x0, y0, x1, y1 int) [][2]int {
-	points := make([][2]int, 0, max(abs(x1-x0), abs(y1-y0))+1)
-	dx := abs(x1 - x0)
-	sx := -1
-	if x0 < x1 {
-		sx = 1
-	}
-	dy := -abs(y1 - y0)
-	sy := -1
-	if y0 < y1 {
-		sy = 1
-	}
-	err := dx + dy
-	for {
-		points = append(points, [2]int{x0, y0})
-		if x0 == x1 && y0 == y1 {
-			break
-		}
-		e2 := 2 * err
-		if e2 >= dy {
-			err += dy
-			x0 += sx
-		}
-		if e2 <= dx {
-			err += dx
-			y0 += sy
-		}
+		s.head = -float64(r.Intn(height))
 	}
-	return points
 }
 
-func abs(v int) int {
-	if v < 0 {
-		return -v
+// pickCharset is one of the sets of the charset name; mixed picks the
+// charset at random first.
+func pickCharset(r *rand.Rand, name string) []rune {
+	if name == "mixed" {
+		names := []string{"ascii", "katakana", "binary"}
+		name = names[r.Intn(len(names))]
 	}
-	return v
+	sets := charsets[name]
+	return sets[r.Intn(len(sets))]
 }
@@ -0,0 +1,98 @@
+package render
+
+// IsCharset reports whether name is a way of drawing a picture: ascii, one
+// character per cell; blocks, half blocks for two dots to a cell; or
+// braille, eight dots to a cell.
+func IsCharset(name string) bool {
+	switch name {
+	case "ascii", "blocks", "braille":
+		return true
+	}
+	return false
+}
+
+// brailleBits maps a dot's column and row inside its cell to its bit in
+// the braille pattern.
+var brailleBits = [2][4]uint8{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// BrailleDot is the bit for the dot at column x (0-1) and row y (0-3) of a
+// braille cell; a cell's glyph is U+2800 plus the bits of its lit dots.
+func BrailleDot(x, y int) rune {
+	return rune(brailleBits[x][y])
+}
+
+// Dots is a picture in dots finer than the cells: braille packs 2x4 dots
+// into a cell and half blocks 1x2. Each cell has one color, the first one
+// drawn into it.
+type Dots struct {
+	width, height int
+	cols, rows    int
+	bits          []uint8
+	colors        []string
+}
+
+// NewDots returns a blank width by height cell picture in the charset,
+// braille or blocks.
+func NewDots(width, height int, charset string) *Dots {
+	d := &Dots{
+		width:  width,
+		height: height,
+		cols:   1,
+		rows:   2,
+		bits:   make([]uint8, width*height),
+		colors: make([]string, width*height),
+	}
+	if charset == "braille" {
+		d.cols, d.rows = 2, 4
+	}
+	return d
+}
+
+// Size is the picture's size in dots.
+func (d *Dots) Size() (width, height int) {
+	return d.width * d.cols, d.height * d.rows
+}
+
+// Dot is the dot at x, y measured in cells.
+func (d *Dots) Dot(x, y float64) (int, int) {
+	return int(x * float64(d.cols)), int(y * float64(d.rows))
+}
+
+// Clear puts out every dot.
+func (d *Dots) Clear() {
+	for i := range d.bits {
+		d.bits[i] = 0
+		d.colors[i] = ""
+	}
+}
+
+// Set lights the dot at x, y, coloring its cell if it has no color yet.
+// Dots off the picture are ignored.
+func (d *Dots) Set(x, y int, color string) {
+	if x < 0 || y < 0 || x >= d.width*d.cols || y >= d.height*d.rows {
+		return
+	}
+	i := y/d.rows*d.width + x/d.cols
+	if d.cols == 2 {
+		d.bits[i] |= brailleBits[x%2][y%4]
+	} else {
+		d.bits[i] |= 1 << (y % 2)
+	}
+	if d.colors[i] == "" {
+		d.colors[i] = color
+	}
+}
+
+// At is the glyph and color of the cell at x, y, and whether any of its dots
+// are lit.
+func (d *Dots) At(x, y int) (glyph rune, color string, ok bool) {
+	i := y*d.width + x
+	bits := d.bits[i]
+	if bits == 0 {
+		return ' ', "", false
+	}
+	if d.cols == 2 {
+		return rune(0x2800 + int(bits)), d.colors[i], true
+	}
+	return []rune{' ', '▀', '▄', '█'}[bits], d.colors[i], true
+}
@@ -0,0 +1,179 @@
+package render
+
+const (
+	// cellWidth and cellHeight are the pixels a cell takes in a recording,
+	// about the shape of a terminal cell.
+	cellWidth  = 6
+	cellHeight = 12
+	// fontTop is the pixel row a font glyph starts on in its cell.
+	fontTop = 3
+)
+
+// font is a 5x7 pixel font for the printable ASCII glyphs; '#' is lit.
+// Lower case is drawn as upper case.
+var font = map[rune][7]string{
+	'A':  {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B':  {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C':  {" ### ", "#   #", "#    ", "#    ", "#    ", "#   #", " ### "},
+	'D':  {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E':  {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+	'F':  {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'G':  {" ### ", "#   #", "#    ", "# ###", "#   #", "#   #", " ####"},
+	'H':  {"#   #", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'I':  {" ### ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'J':  {"  ###", "   # ", "   # ", "   # ", "   # ", "#  # ", " ##  "},
+	'K':  {"#   #", "#  # ", "# #  ", "##   ", "# #  ", "#  # ", "#   #"},
+	'L':  {"#    ", "#    ", "#    ", "#    ", "#    ", "#    ", "#####"},
+	'M':  {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+	'N':  {"#   #", "#   #", "##  #", "# # #", "#  ##", "#   #", "#   #"},
+	'O':  {" ### ", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'P':  {"#### ", "#   #", "#   #", "#### ", "#    ", "#    ", "#    "},
+	'Q':  {" ### ", "#   #", "#   #", "#   #", "# # #", "#  # ", " ## #"},
+	'R':  {"#### ", "#   #", "#   #", "#### ", "# #  ", "#  # ", "#   #"},
+	'S':  {" ####", "#    ", "#    ", " ### ", "    #", "    #", "#### "},
+	'T':  {"#####", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U':  {"#   #", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'V':  {"#   #", "#   #", "#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W':  {"#   #", "#   #", "#   #", "# # #", "# # #", "# # #", " # # "},
+	'X':  {"#   #", "#   #", " # # ", "  #  ", " # # ", "#   #", "#   #"},
+	'Y':  {"#   #", "#   #", " # # ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'Z':  {"#####", "    #", "   # ", "  #  ", " #   ", "#    ", "#####"},
+	'0':  {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1':  {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2':  {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3':  {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4':  {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5':  {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6':  {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7':  {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8':  {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9':  {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'!':  {"  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "     ", "  #  "},
+	'?':  {" ### ", "#   #", "    #", "   # ", "  #  ", "     ", "  #  "},
+	'.':  {"     ", "     ", "     ", "     ", "     ", "     ", "  #  "},
+	',':  {"     ", "     ", "     ", "     ", "     ", "  #  ", " #   "},
+	':':  {"     ", "     ", "  #  ", "     ", "  #  ", "     ", "     "},
+	';':  {"     ", "     ", "  #  ", "     ", "  #  ", "  #  ", " #   "},
+	'\'': {"  #  ", "  #  ", " #   ", "     ", "     ", "     ", "     "},
+	'"':  {" # # ", " # # ", "     ", "     ", "     ", "     ", "     "},
+	'`':  {" #   ", "  #  ", "     ", "     ", "     ", "     ", "     "},
+	'(':  {"   # ", "  #  ", " #   ", " #   ", " #   ", "  #  ", "   # "},
+	')':  {" #   ", "  #  ", "   # ", "   # ", "   # ", "  #  ", " #   "},
+	'[':  {" ### ", " #   ", " #   ", " #   ", " #   ", " #   ", " ### "},
+	']':  {" ### ", "   # ", "   # ", "   # ", "   # ", "   # ", " ### "},
+	'{':  {"   ##", "  #  ", "  #  ", "##   ", "  #  ", "  #  ", "   ##"},
+	'}':  {"##   ", "  #  ", "  #  ", "   ##", "  #  ", "  #  ", "##   "},
+	'<':  {"   # ", "  #  ", " #   ", "#    ", " #   ", "  #  ", "   # "},
+	'>':  {" #   ", "  #  ", "   # ", "    #", "   # ", "  #  ", " #   "},
+	'-':  {"     ", "     ", "     ", "#####", "     ", "     ", "     "},
+	'+':  {"     ", "  #  ", "  #  ", "#####", "  #  ", "  #  ", "     "},
+	'=':  {"     ", "     ", "#####", "     ", "#####", "     ", "     "},
+	'_':  {"     ", "     ", "     ", "     ", "     ", "     ", "#####"},
+	'~':  {"     ", "     ", " #   ", "# # #", "   # ", "     ", "     "},
+	'^':  {"  #  ", " # # ", "#   #", "     ", "     ", "     ", "     "},
+	'*':  {"     ", "# # #", " ### ", "#####", " ### ", "# # #", "     "},
+	'/':  {"    #", "    #", "   # ", "  #  ", " #   ", "#    ", "#    "},
+	'\\': {"#    ", "#    ", " #   ", "  #  ", "   # ", "    #", "    #"},
+	'|':  {"  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'#':  {" # # ", " # # ", "#####", " # # ", "#####", " # # ", " # # "},
+	'&':  {" ##  ", "#  # ", "# #  ", " #   ", "# # #", "#  # ", " ## #"},
+	'@':  {" ### ", "#   #", "# ###", "# # #", "# ###", "#    ", " ####"},
+	'$':  {"  #  ", " ####", "# #  ", " ### ", "  # #", "#### ", "  #  "},
+	'%':  {"##   ", "##  #", "   # ", "  #  ", " #   ", "#  ##", "   ##"},
+}
+
+// boxArms is which way each box-drawing glyph's lines run from the middle of
+// the cell: left, right, up, down. heavyBox are the thick ones.
+var (
+	boxArms = map[rune][4]bool{
+		'─': {true, true, false, false}, '│': {false, false, true, true},
+		'┌': {false, true, false, true}, '┐': {true, false, false, true},
+		'└': {false, true, true, false}, '┘': {true, false, true, false},
+		'┬': {true, true, false, true}, '┴': {true, true, true, false},
+		'├': {false, true, true, true}, '┤': {true, false, true, true},
+		'┼': {true, true, true, true},
+		'━': {true, true, false, false}, '┃': {false, false, true, true},
+		'┏': {false, true, false, true}, '┓': {true, false, false, true},
+		'┗': {false, true, true, false}, '┛': {true, false, true, false},
+	}
+	heavyBox = map[rune]bool{'━': true, '┃': true, '┏': true, '┓': true, '┗': true, '┛': true}
+)
+
+// glyphPixels calls lit for each lit pixel of g in a cellWidth by
+// cellHeight cell. Glyphs the font lacks are drawn from the blocks, box
+// lines and braille they are made of, and anything else as '?'.
+func glyphPixels(g rune, lit func(x, y int)) {
+	fill := func(x0, y0, x1, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				lit(x, y)
+			}
+		}
+	}
+	switch {
+	case g == ' ' || g == 0 || g == 0x2800:
+		return
+	case g >= 'a' && g <= 'z':
+		g -= 'a' - 'A'
+	case g == '█':
+		fill(0, 0, cellWidth, cellHeight)
+		return
+	case g == '▀':
+		fill(0, 0, cellWidth, cellHeight/2)
+		return
+	case g == '▄':
+		fill(0, cellHeight/2, cellWidth, cellHeight)
+		return
+	case g == '░':
+		for y := 0; y < cellHeight; y++ {
+			for x := y % 2; x < cellWidth; x += 2 {
+				lit(x, y)
+			}
+		}
+		return
+	case g == '·':
+		fill(2, 5, 4, 7)
+		return
+	case g == '❄':
+		g = '*'
+	case g > 0x2800 && g <= 0x28ff:
+		for dx := 0; dx < 2; dx++ {
+			for dy := 0; dy < 4; dy++ {
+				if (g-0x2800)&BrailleDot(dx, dy) != 0 {
+					fill(1+dx*3, 1+dy*3, 3+dx*3, 3+dy*3)
+				}
+			}
+		}
+		return
+	}
+	if arms, ok := boxArms[g]; ok {
+		cx, cy, w := cellWidth/2-1, cellHeight/2-1, 1
+		if heavyBox[g] {
+			w = 2
+		}
+		if arms[0] {
+			fill(0, cy, cx+w, cy+w)
+		}
+		if arms[1] {
+			fill(cx, cy, cellWidth, cy+w)
+		}
+		if arms[2] {
+			fill(cx, 0, cx+w, cy+w)
+		}
+		if arms[3] {
+			fill(cx, cy, cx+w, cellHeight)
+		}
+		return
+	}
+	rows, ok := font[g]
+	if !ok {
+		rows = font['?']
+	}
+	for y, row := range rows {
+		for x := 0; x < len(row); x++ {
+			if row[x] == '#' {
+				lit(x, fontTop+y)
+			}
+		}
+	}
+}
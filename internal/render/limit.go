@@ -0,0 +1,120 @@
+package render
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit ends an animation after it has run for a while or drawn a number of
+// frames, whichever comes first.
+type Limit struct {
+	ctx    context.Context
+	end    time.Time
+	frames int
+	max    int
+}
+
+// NewLimit starts the clock on a Limit of d and max frames. Zero (or less)
+// for either leaves that one off, so a zero Limit never ends. A frame held
+// back by PowerSave or MaxCPU goes ahead as soon as ctx is done, so the run
+// can see it and stop.
+func NewLimit(ctx context.Context, d time.Duration, max int) Limit {
+	l := Limit{ctx: ctx}
+	if d > 0 {
+		l.end = time.Now().Add(d)
+	}
+	if max > 0 {
+		l.max = max
+	}
+	return l
+}
+
+// Next reports whether there is time for another frame, and counts it. A
+// recording that has all its frames ends the run as well. With PowerSave or
+// MaxCPU on, it first holds the frame back as long as they ask.
+func (l *Limit) Next() bool {
+	if recorder != nil && recorder.Full() {
+		return false
+	}
+	if l.max > 0 && l.frames >= l.max {
+		return false
+	}
+	if !l.end.IsZero() && !time.Now().Before(l.end) {
+		return false
+	}
+	l.frames++
+	if power.on() {
+		power.wait(l.ctx)
+	}
+	stats.start()
+	return true
+}
+
+// maxCatchUp is the most frames a Clock makes up for at once, so a stall,
+// such as the process being suspended, doesn't fling everything ahead.
+// PowerSave raises it to cover its own pauses.
+const maxCatchUp = 8
+
+// Clock measures how far to move an animation each frame. It counts the time
+// since the last Tick in frames delay apart, rounded to whole frames so a run
+// that keeps to schedule moves exactly the same every frame, and gives that
+// in steps, the frame delay a mode's speeds are tuned for. A mode run at a
+// shorter delay then moves as fast in smaller steps, and one a slow terminal
+// makes miss ticks catches up.
+//
+// Going by the time makes a run depend on how the frames happen to be
+// scheduled, so a steady Clock moves exactly one frame's worth every Tick
+// instead, and a seeded, recorded or written out run repeats exactly.
+type Clock struct {
+	delay  time.Duration
+	scale  float64
+	last   time.Time
+	steady bool
+}
+
+// steadyClocks makes every Clock steady, as SteadyClocks asked.
+var steadyClocks bool
+
+// SteadyClocks makes every Clock move one frame's worth a Tick, whatever
+// the time, for a run that has to come out the same each time, such as one
+// cut short at a number of frames or written to a file. A run being
+// recorded is always steady. Call it before starting a mode.
+func SteadyClocks(on bool) {
+	steadyClocks = on
+}
+
+// NewClock returns a Clock for frames delay apart of a mode whose speeds
+// are per step, steady if set, as for a run given a seed.
+func NewClock(delay, step time.Duration, steady bool) *Clock {
+	return &Clock{delay: delay, scale: float64(delay) / float64(step), steady: steady}
+}
+
+// Tick is how many steps the animation moves on this frame: one frame's
+// worth the first time, or every time for a steady Clock, then however
+// many frames have passed since the last Tick.
+func (c *Clock) Tick() float64 {
+	if c.Steady() {
+		return c.scale
+	}
+	now := time.Now()
+	frames := 1.0
+	if !c.last.IsZero() {
+		frames = math.Round(float64(now.Sub(c.last)) / float64(c.delay))
+		frames = math.Max(1, math.Min(c.catchUp(), frames))
+	}
+	c.last = now
+	return frames * c.scale
+}
+
+// Steady reports whether Tick goes by frames rather than the time, so
+// anything else a mode would adjust by the time should stay put too.
+func (c *Clock) Steady() bool {
+	return c.steady || steadyClocks || recorder != nil
+}
+
+// catchUp is the most frames a Tick makes up for: maxCatchUp, or as many as
+// go by between the frames PowerSave slows an idle run to.
+func (c *Clock) catchUp() float64 {
+	return math.Max(maxCatchUp, math.Ceil(float64(power.idleDelay)/float64(c.delay)))
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"animinterminal/internal/term"
+)
+
+// wakeCheck is how often a frame held back for being idle looks for a key
+// press, so the first one brings back full speed straight away.
+const wakeCheck = 50 * time.Millisecond
+
+// maxHold is the longest MaxCPU holds a frame back, however slow the one
+// before was or however small a share it asked for.
+const maxHold = time.Second
+
+// power holds every Limit's frames back as PowerSave and MaxCPU asked.
+var power pacing
+
+// PowerSave slows every animation to fps frames a second once there has
+// been no key press or mouse report for after, and puts it back to full
+// speed on the next one. Modes that move by elapsed time keep moving at the
+// same pace, only in bigger steps; the rest slow down. fps 0 (or less)
+// turns it off. Call it before starting a mode.
+func PowerSave(after time.Duration, fps float64) {
+	power.idleAfter, power.idleDelay = 0, 0
+	if fps > 0 {
+		power.idleAfter = after
+		power.idleDelay = time.Duration(float64(time.Second) / fps)
+	}
+}
+
+// MaxCPU holds frames back so that working them out and writing them takes
+// up no more than percent of the time, for a machine too slow to keep up
+// with the frame delay. 0 (or 100 and above) leaves frames be.
+func MaxCPU(percent float64) {
+	power.share = 0
+	if percent > 0 && percent < 100 {
+		power.share = percent / 100
+	}
+}
+
+// pacing is what PowerSave and MaxCPU set up, and the timing of the frame
+// in hand.
+type pacing struct {
+	idleAfter, idleDelay time.Duration
+	share                float64
+	// begin is when the current frame started and busy how long the one
+	// before took from then until it was written.
+	begin time.Time
+	busy  time.Duration
+}
+
+// on reports whether there is anything to pace.
+func (p *pacing) on() bool {
+	return p.idleDelay > 0 || p.share > 0
+}
+
+// wait holds the next frame back for as long as is needed, or until ctx is
+// done, then marks its start.
+func (p *pacing) wait(ctx context.Context) {
+	if !p.begin.IsZero() {
+		held := true
+		if p.share > 0 && p.busy > 0 {
+			hold := time.Duration(math.Min(float64(p.busy)/p.share, float64(maxHold)))
+			held = sleep(ctx, hold-time.Since(p.begin))
+		}
+		for held && p.idleDelay > 0 && term.Idle() >= p.idleAfter {
+			left := p.idleDelay - time.Since(p.begin)
+			if left <= 0 {
+				break
+			}
+			held = sleep(ctx, min(left, wakeCheck))
+		}
+	}
+	p.begin = time.Now()
+}
+
+// sleep waits for d, cut short if ctx is done first, and reports whether
+// it waited the whole time.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// written notes that the current frame has been written.
+func (p *pacing) written() {
+	if !p.begin.IsZero() {
+		p.busy = time.Since(p.begin)
+	}
+}
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestIdleWaitCancelled slows an idle run to a frame every 100 seconds and
+// checks cancelling the run lets the held frame go straight away.
+func TestIdleWaitCancelled(t *testing.T) {
+	PowerSave(0, 0.01)
+	defer PowerSave(0, 0)
+	defer func() { power.begin = time.Time{} }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	limit := NewLimit(ctx, 0, 0)
+	limit.Next()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if !limit.Next() {
+		t.Fatal("Next ended the run instead of letting the frame go")
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("held the frame %v after the run was cancelled", waited)
+	}
+}
+
+// TestMaxCPUHoldCapped asks for so small a share of the time that the
+// hold-back would run for days, and checks it stops at maxHold.
+func TestMaxCPUHoldCapped(t *testing.T) {
+	MaxCPU(1e-9)
+	defer MaxCPU(0)
+	defer func() { power.begin, power.busy = time.Time{}, 0 }()
+
+	power.begin = time.Now()
+	power.busy = time.Millisecond
+	start := time.Now()
+	power.wait(context.Background())
+	if waited := time.Since(start); waited > maxHold+time.Second {
+		t.Errorf("held the frame %v, want at most %v", waited, maxHold)
+	}
+}
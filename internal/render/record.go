@@ -0,0 +1,268 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	stdcolor "image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"animinterminal/internal/color"
+)
+
+const (
+	// background is the palette index of black, under cells with no
+	// background color, and foreground the light gray of glyphs with no
+	// color.
+	background = 0
+	foreground = 7
+	// unchanged is the palette index left transparent, for pixels that are
+	// the same as the frame before. It would be black again, which index 0
+	// already is.
+	unchanged = 16
+)
+
+// palette is the 256-color set every mode's colors come from, which the
+// 24-bit ones are matched to the nearest of.
+var palette = func() stdcolor.Palette {
+	p := make(stdcolor.Palette, 256)
+	for n := range p {
+		c := color.Xterm(n)
+		p[n] = stdcolor.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+	}
+	p[unchanged] = stdcolor.RGBA{}
+	return p
+}()
+
+// recorder is where every Screen also sends its frames, if Record set one.
+var recorder *Recorder
+
+// Record has every Screen hand the frames it draws to r as well, and ends
+// the run once r has all it wants; nil turns that off. Call it before
+// starting a mode.
+func Record(r *Recorder) {
+	recorder = r
+}
+
+// Recorder turns frames into an animated GIF, drawing each cell as a block
+// of pixels with its glyph in a small bitmap font. It keeps a frame at most
+// so often, and only what changed in each.
+type Recorder struct {
+	path  string
+	max   int
+	every time.Duration
+
+	mu    sync.Mutex
+	count int
+	last  time.Time
+	// width and height are the GIF's size in pixels, set by the first
+	// frame; frames of another size are left out.
+	width, height int
+	prev          []uint8
+	images        []*image.Paletted
+	delays        []int
+	indexes       map[string][2]uint8
+	closed        bool
+}
+
+// NewRecorder returns a Recorder of frames frames, fps to the second, for
+// a GIF at path. Close writes it.
+func NewRecorder(path string, frames, fps int) *Recorder {
+	if frames <= 0 {
+		frames = 100
+	}
+	if fps <= 0 {
+		fps = 20
+	}
+	return &Recorder{path: path, max: frames, every: time.Second / time.Duration(fps), indexes: make(map[string][2]uint8)}
+}
+
+// Full reports whether the recording has all its frames.
+func (r *Recorder) Full() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count >= r.max
+}
+
+// Frames is how many frames have been recorded.
+func (r *Recorder) Frames() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// frame records a width by height frame if it is time for another, with
+// each cell in the colors the mode gave it.
+func (r *Recorder) frame(width, height int, cells []cell) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	pw, ph := width*cellWidth, height*cellHeight
+	if r.closed || r.count >= r.max {
+		return
+	}
+	if r.count > 0 {
+		if now.Sub(r.last) < r.every*9/10 || pw != r.width || ph != r.height {
+			return
+		}
+		r.delays[len(r.delays)-1] += centiseconds(now.Sub(r.last))
+	}
+	r.count++
+	r.last = now
+
+	pix := make([]uint8, pw*ph)
+	rasterize(pix, width, height, cells, r.inks)
+
+	bounds := image.Rect(0, 0, pw, ph)
+	if r.prev != nil {
+		bounds = changed(r.prev, pix, pw)
+		if bounds.Empty() {
+			// Nothing to show; the last frame just stays up longer.
+			return
+		}
+	}
+	img := image.NewPaletted(bounds, palette)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := y*pw + x
+			if r.prev != nil && r.prev[i] == pix[i] {
+				img.Pix[img.PixOffset(x, y)] = unchanged
+			} else {
+				img.Pix[img.PixOffset(x, y)] = pix[i]
+			}
+		}
+	}
+	r.prev, r.width, r.height = pix, pw, ph
+	r.images = append(r.images, img)
+	r.delays = append(r.delays, 0)
+}
+
+// inks is inksOf, remembered for each escape.
+func (r *Recorder) inks(escape string) [2]uint8 {
+	if inks, ok := r.indexes[escape]; ok {
+		return inks
+	}
+	inks := inksOf(escape)
+	r.indexes[escape] = inks
+	return inks
+}
+
+// inksOf is the palette index of the foreground and background escape
+// sets.
+func inksOf(escape string) [2]uint8 {
+	inks := [2]uint8{foreground, background}
+	fg, bg, hasFg, hasBg := color.Parse(escape)
+	if hasFg {
+		inks[0] = uint8(palette.Index(stdcolor.RGBA{R: fg.R, G: fg.G, B: fg.B, A: 255}))
+	}
+	if hasBg {
+		inks[1] = uint8(palette.Index(stdcolor.RGBA{R: bg.R, G: bg.G, B: bg.B, A: 255}))
+	}
+	return inks
+}
+
+// rasterize draws a width by height frame into pix, one palette index a
+// pixel, each cell a block of its background with its glyph over it in its
+// foreground.
+func rasterize(pix []uint8, width, height int, cells []cell, inks func(string) [2]uint8) {
+	pw := width * cellWidth
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := cells[y*width+x]
+			ink := inks(c.color)
+			left, top := x*cellWidth, y*cellHeight
+			if ink[1] != background {
+				for py := top; py < top+cellHeight; py++ {
+					for px := left; px < left+cellWidth; px++ {
+						pix[py*pw+px] = ink[1]
+					}
+				}
+			}
+			g, _ := utf8.DecodeRuneInString(c.glyph)
+			glyphPixels(g, func(gx, gy int) {
+				pix[(top+gy)*pw+left+gx] = ink[0]
+			})
+		}
+	}
+}
+
+// WritePNG writes a width by height frame to w as a PNG, drawn as a
+// recording draws it; at gives each cell's glyph and color as for
+// Screen.Draw, a cell with no color keeping the one before.
+func WritePNG(w io.Writer, width, height int, at func(x, y int) (glyph, color string)) error {
+	cells := make([]cell, width*height)
+	var carried string
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g, c := at(x, y)
+			if g == "" {
+				g = " "
+			}
+			if c != "" {
+				carried = c
+			}
+			cells[y*width+x] = cell{glyph: g, color: carried}
+		}
+	}
+	// Nothing is left unchanged in a still, so that index is black again.
+	still := append(stdcolor.Palette(nil), palette...)
+	still[unchanged] = still[background]
+	img := image.NewPaletted(image.Rect(0, 0, width*cellWidth, height*cellHeight), still)
+	rasterize(img.Pix, width, height, cells, inksOf)
+	return png.Encode(w, img)
+}
+
+// Close writes the GIF, once; calling it again does nothing.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if len(r.images) == 0 {
+		return fmt.Errorf("record %s: no frames were drawn", r.path)
+	}
+	// The last frame has no next one to time it by.
+	r.delays[len(r.delays)-1] += centiseconds(r.every)
+	f, err := os.Create(r.path)
+	if err != nil {
+		return err
+	}
+	disposal := make([]byte, len(r.images))
+	for i := range disposal {
+		disposal[i] = gif.DisposalNone
+	}
+	err = gif.EncodeAll(f, &gif.GIF{
+		Image:    r.images,
+		Delay:    r.delays,
+		Disposal: disposal,
+		Config:   image.Config{ColorModel: palette, Width: r.width, Height: r.height},
+	})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// changed is the smallest rectangle holding every pixel that differs
+// between a and b, rows width wide.
+func changed(a, b []uint8, width int) image.Rectangle {
+	var r image.Rectangle
+	for i := range a {
+		if a[i] != b[i] {
+			r = r.Union(image.Rect(i%width, i/width, i%width+1, i/width+1))
+		}
+	}
+	return r
+}
+
+// centiseconds is d in the hundredths of a second GIF delays count in.
+func centiseconds(d time.Duration) int {
+	return int((d + 5*time.Millisecond) / (10 * time.Millisecond))
+}
@@ -0,0 +1,237 @@
+// Package render draws frames of colored cells to a terminal. A Screen
+// remembers the last frame it sent and after the first one writes only the
+// cells that changed, which keeps a mostly still animation down to a few
+// hundred bytes a frame instead of the whole grid.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/term"
+)
+
+// maxGap is how many unchanged cells between two changes are rewritten
+// rather than jumped over; a cursor move costs about as much.
+const maxGap = 4
+
+// glyphs holds every ASCII glyph as a string, so grids of them can be drawn
+// without allocating.
+var glyphs [0x80]string
+
+func init() {
+	for i := range glyphs {
+		glyphs[i] = string(rune(i))
+	}
+	glyphs[0] = " "
+}
+
+// Rune returns r as a glyph, without allocating for the ASCII ones. A zero
+// rune, a cell nothing was drawn in, comes back as a space.
+func Rune(r rune) string {
+	if r >= 0 && r < 0x80 {
+		return glyphs[r]
+	}
+	return string(r)
+}
+
+// Framer is a writer that wants to know where each frame begins and how
+// big it is, such as one that times them. A Screen writing to one calls
+// Frame before every frame, including those with nothing to send.
+type Framer interface {
+	io.Writer
+	Frame(width, height int)
+}
+
+type cell struct {
+	glyph string
+	color string
+}
+
+// Screen sends frames to a writer, diffing each against the one before.
+type Screen struct {
+	out           io.Writer
+	width, height int
+	prev, next    []cell
+	// raw is the frame in the colors it was drawn in, for the recorder.
+	raw []cell
+	// stale forces the next frame to be drawn in full.
+	stale bool
+	sb    strings.Builder
+	// profile is what colors are written as, and colors caches each color
+	// escape rewritten for it.
+	profile color.Profile
+	colors  map[string]string
+}
+
+// NewScreen returns a Screen writing to w in the active color profile. Its
+// first frame is drawn in full.
+func NewScreen(w io.Writer) *Screen {
+	return &Screen{out: w, stale: true, profile: color.Active(), colors: make(map[string]string)}
+}
+
+// Invalidate makes the next frame redraw every cell, for when something
+// else has written to or cleared the terminal.
+func (s *Screen) Invalidate() {
+	s.stale = true
+}
+
+// Draw sends a width by height frame, asking at for the glyph and color of
+// each cell. An empty color carries on the one before it in the row, as it
+// always has when frames were printed whole, and colors the terminal cannot
+// show are brought down to the nearest it can. A change of size clears the
+// screen and redraws it.
+func (s *Screen) Draw(width, height int, at func(x, y int) (glyph, color string)) {
+	resized := s.prev != nil && (width != s.width || height != s.height)
+	if resized || s.prev == nil {
+		s.width, s.height = width, height
+		s.prev = make([]cell, width*height)
+		s.next = make([]cell, width*height)
+		s.raw = make([]cell, width*height)
+		s.stale = true
+	}
+	for y := 0; y < height; y++ {
+		carried := ""
+		for x := 0; x < width; x++ {
+			g, c := at(x, y)
+			if g == "" {
+				g = " "
+			}
+			if c != "" {
+				carried = c
+			}
+			s.raw[y*width+x] = cell{glyph: g, color: carried}
+			c = s.downgrade(carried)
+			// A blank shows no foreground, so its color can't matter.
+			if g == " " && !hasBackground(c) {
+				c = ""
+			}
+			s.next[y*width+x] = cell{glyph: g, color: c}
+		}
+	}
+
+	// The overlay goes over what is sent but not what is recorded.
+	drawn := time.Now()
+	if showStats {
+		stats.stamp(s.next, width, s.downgrade(statsColor))
+	}
+	if recorder != nil {
+		recorder.frame(width, height, s.raw)
+	}
+
+	s.sb.Reset()
+	if s.stale {
+		if resized {
+			s.sb.WriteString(term.ClearScreen)
+		}
+		s.full()
+		s.stale = false
+	} else {
+		s.changes()
+	}
+	s.prev, s.next = s.next, s.prev
+	if f, ok := s.out.(Framer); ok {
+		f.Frame(width, height)
+	}
+	if s.sb.Len() > 0 {
+		io.WriteString(s.out, s.sb.String())
+	}
+	stats.add(drawn, s.sb.Len())
+	if power.on() {
+		power.written()
+	}
+}
+
+// full writes the whole frame from the top left, a row to a line. There is
+// no newline after the last row, which on a terminal exactly as tall as the
+// frame would scroll it up a line every frame; the cursor is moved under the
+// frame instead, which such a terminal holds at its bottom row.
+func (s *Screen) full() {
+	s.sb.Grow((s.width+8)*s.height + 16)
+	s.sb.WriteString(term.Home)
+	for y := 0; y < s.height; y++ {
+		if y > 0 {
+			s.sb.WriteByte('\n')
+		}
+		active := ""
+		for _, c := range s.next[y*s.width : (y+1)*s.width] {
+			active = s.put(active, c)
+		}
+		s.sb.WriteString(term.Reset)
+	}
+	fmt.Fprintf(&s.sb, "\x1b[%d;1H", s.height+1)
+}
+
+// changes writes the cells that differ from the last frame, moving the
+// cursor only across gaps too long to repaint. It leaves the cursor under
+// the frame, where a full frame would.
+func (s *Screen) changes() {
+	active := ""
+	cx, cy := -1, -1
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			i := y*s.width + x
+			if s.next[i] == s.prev[i] {
+				continue
+			}
+			if cy == y && x >= cx && x-cx <= maxGap {
+				for _, c := range s.next[y*s.width+cx : i] {
+					active = s.put(active, c)
+				}
+			} else {
+				fmt.Fprintf(&s.sb, "\x1b[%d;%dH", y+1, x+1)
+			}
+			active = s.put(active, s.next[i])
+			cx, cy = x+1, y
+		}
+	}
+	if cy < 0 {
+		return
+	}
+	if active != "" {
+		s.sb.WriteString(term.Reset)
+	}
+	fmt.Fprintf(&s.sb, "\x1b[%d;1H", s.height+1)
+}
+
+// put writes c in the color active, switching color first unless c is a
+// blank that any foreground will do for, and returns the color left active.
+func (s *Screen) put(active string, c cell) string {
+	if c.color != "" || c.glyph != " " || hasBackground(active) {
+		active = s.setColor(active, c.color)
+	}
+	s.sb.WriteString(c.glyph)
+	return active
+}
+
+// setColor switches from the color active to next, writing nothing when
+// they match, and returns the new active color.
+func (s *Screen) setColor(active, next string) string {
+	if next == active {
+		return active
+	}
+	if next == "" || hasBackground(active) {
+		s.sb.WriteString(term.Reset)
+	}
+	s.sb.WriteString(next)
+	return next
+}
+
+func (s *Screen) downgrade(escape string) string {
+	if s.profile == color.TrueColor || escape == "" {
+		return escape
+	}
+	c, ok := s.colors[escape]
+	if !ok {
+		c = color.Downgrade(escape, s.profile)
+		s.colors[escape] = c
+	}
+	return c
+}
+
+func hasBackground(escape string) bool {
+	return strings.Contains(escape, "48;")
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"animinterminal/internal/color"
+	"animinterminal/internal/term"
+)
+
+// TestFullNoTrailingNewline checks a full frame puts a newline between rows
+// but none after the last, which would scroll a terminal exactly as tall as
+// the frame.
+func TestFullNoTrailingNewline(t *testing.T) {
+	const width, height = 5, 4
+	var out bytes.Buffer
+	s := NewScreen(&out)
+	s.Draw(width, height, func(x, y int) (string, string) {
+		return "#", "\x1b[31m"
+	})
+
+	frame := out.String()
+	at := strings.Index(frame, term.Home)
+	if at < 0 {
+		t.Fatalf("frame does not start at home: %q", frame)
+	}
+	frame = frame[at+len(term.Home):]
+	if n := strings.Count(frame, "\n"); n != height-1 {
+		t.Errorf("got %d newlines, want %d", n, height-1)
+	}
+	last := frame[strings.LastIndex(frame, "\n")+1:]
+	if !strings.HasPrefix(last, "\x1b[31m#####") {
+		t.Errorf("last row is not on the line after the last newline: %q", last)
+	}
+	if strings.HasSuffix(frame, "\n") {
+		t.Errorf("frame ends with a newline: %q", frame)
+	}
+}
+
+// TestWritePNG draws a full block in a foreground, a blank with only a
+// background, and a blank with no color of its own, which keeps the
+// background before it.
+func TestWritePNG(t *testing.T) {
+	cells := [][2]string{{"█", "\x1b[38;5;196m"}, {" ", "\x1b[48;5;21m"}, {" ", ""}}
+	var out bytes.Buffer
+	err := WritePNG(&out, len(cells), 1, func(x, y int) (string, string) {
+		return cells[x][0], cells[x][1]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != len(cells)*cellWidth || b.Dy() != cellHeight {
+		t.Fatalf("image is %v, want %dx%d", b, len(cells)*cellWidth, cellHeight)
+	}
+	for i, want := range []int{196, 21, 21} {
+		r, g, b, _ := img.At(i*cellWidth+cellWidth/2, cellHeight/2).RGBA()
+		got := color.RGB{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8)}
+		if got != color.Xterm(want) {
+			t.Errorf("cell %d is %v, want color %d: %v", i, got, want, color.Xterm(want))
+		}
+	}
+}
+
+// rows draws a frame from one string per row, every cell in color.
+func rows(frame []string, color string) func(x, y int) (string, string) {
+	return func(x, y int) (string, string) {
+		return string(frame[y][x]), color
+	}
+}
+
+// TestScreenChanges draws one frame and then another over it, and checks
+// what the second sends.
+func TestScreenChanges(t *testing.T) {
+	blank := []string{"........", "........"}
+	tests := []struct {
+		name       string
+		prev, next []string
+		// prevColor and nextColor color every cell of each frame.
+		prevColor, nextColor string
+		want                 string
+	}{
+		{
+			name: "one cell",
+			prev: blank,
+			next: []string{"...#....", "........"},
+			want: "\x1b[1;4H#\x1b[3;1H",
+		},
+		{
+			name: "short gap repainted",
+			prev: blank,
+			next: []string{"#...#...", "........"},
+			want: "\x1b[1;1H#...#\x1b[3;1H",
+		},
+		{
+			name: "gap of maxGap repainted",
+			prev: blank,
+			next: []string{"#....#..", "........"},
+			want: "\x1b[1;1H#....#\x1b[3;1H",
+		},
+		{
+			name: "longer gap jumped",
+			prev: blank,
+			next: []string{"#.....#.", "........"},
+			want: "\x1b[1;1H#\x1b[1;7H#\x1b[3;1H",
+		},
+		{
+			name: "next row moved to",
+			prev: blank,
+			next: []string{".......#", "#......."},
+			want: "\x1b[1;8H#\x1b[2;1H#\x1b[3;1H",
+		},
+		{
+			name: "unchanged",
+			prev: []string{"#.#.#.#.", ".#.#.#.#"},
+			next: []string{"#.#.#.#.", ".#.#.#.#"},
+			want: "",
+		},
+		{
+			name:      "recolored blanks",
+			prev:      []string{"        ", "        "},
+			next:      []string{"        ", "        "},
+			prevColor: "\x1b[31m",
+			nextColor: "\x1b[34m",
+			want:      "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := NewScreen(&out)
+			s.Draw(len(tc.prev[0]), len(tc.prev), rows(tc.prev, tc.prevColor))
+			out.Reset()
+			s.Draw(len(tc.next[0]), len(tc.next), rows(tc.next, tc.nextColor))
+			if got := out.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestScreenResize checks a frame of a new size clears the screen and is
+// drawn in full, as a first frame would be.
+func TestScreenResize(t *testing.T) {
+	small := []string{"abcd", "efgh"}
+	large := []string{"abcdef", "ghijkl", "mnopqr"}
+
+	var first bytes.Buffer
+	NewScreen(&first).Draw(6, 3, rows(large, ""))
+
+	var out bytes.Buffer
+	s := NewScreen(&out)
+	s.Draw(4, 2, rows(small, ""))
+	out.Reset()
+	s.Draw(6, 3, rows(large, ""))
+	if want := term.ClearScreen + first.String(); out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
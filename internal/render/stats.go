@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// statsEvery is how often the overlay's figures are brought up to date;
+	// each is the average over that long.
+	statsEvery = 500 * time.Millisecond
+	statsColor = "\x1b[38;5;231;48;5;236m"
+)
+
+var (
+	// stats times every Screen's frames, for FPS and for the overlay
+	// showStats has every Screen draw.
+	stats     frameStats
+	showStats bool
+)
+
+// ShowStats has every Screen draw the frame rate, how long frames take to
+// compute and to write, and how many bytes they come to in the top left
+// corner; false turns that off. Call it before starting a mode.
+func ShowStats(on bool) {
+	showStats = on
+}
+
+// FPS is how many frames a second the Screens have drawn, averaged over the
+// last half second or so; 0 until the first half second is up.
+func FPS() float64 {
+	return stats.fps
+}
+
+// frameStats adds up frame timings until it is time to show their averages.
+// A frame's compute time runs from Limit.Next to the cells being handed to
+// the Screen, and its write time from there until they are written.
+type frameStats struct {
+	// begin is when the current frame started.
+	begin time.Time
+	since time.Time
+	count int
+	// compute, write and bytes are the totals over the count frames since.
+	compute, write time.Duration
+	bytes          int
+	fps            float64
+	text           string
+}
+
+// start marks the beginning of a frame.
+func (f *frameStats) start() {
+	f.begin = time.Now()
+}
+
+// add counts a frame whose cells were ready at drawn and that wrote n bytes.
+func (f *frameStats) add(drawn time.Time, n int) {
+	now := time.Now()
+	if f.since.IsZero() {
+		f.since = now
+	}
+	if !f.begin.IsZero() {
+		f.compute += drawn.Sub(f.begin)
+	}
+	f.write += now.Sub(drawn)
+	f.bytes += n
+	f.count++
+	if elapsed := now.Sub(f.since); elapsed >= statsEvery {
+		per := time.Duration(f.count)
+		f.fps = float64(f.count) / elapsed.Seconds()
+		f.text = fmt.Sprintf(" %5.1f fps  compute %6s  write %6s  %7s/frame ",
+			f.fps, milliseconds(f.compute/per), milliseconds(f.write/per), size(f.bytes/f.count))
+		f.since, f.count, f.compute, f.write, f.bytes = now, 0, 0, 0, 0
+	}
+}
+
+// stamp writes the overlay over the top row of cells, width wide.
+func (f *frameStats) stamp(cells []cell, width int, color string) {
+	text := f.text
+	if text == "" {
+		text = " measuring... "
+	}
+	for i, r := range []rune(text) {
+		if i >= width || i >= len(cells) {
+			break
+		}
+		cells[i] = cell{glyph: Rune(r), color: color}
+	}
+}
+
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+func size(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.1fKB", float64(n)/1024)
+}
@@ -0,0 +1,100 @@
+package ripple
+
+import "math"
+
+const (
+	// dropHeight is how deep a drop pushes the surface at its center.
+	dropHeight = 64.0
+	// sponge is how many rows and columns along the border soak up waves
+	// when the edges absorb.
+	sponge = 6
+)
+
+// pond is the two-buffer water surface. The simulation runs at twice the
+// screen's rows so rings come out round on cells twice as tall as they are
+// wide.
+type pond struct {
+	width, height int
+	cur, prev     []float64
+	// keep is how much of its height each cell holds on to per step, which
+	// drops towards the border when the edges absorb.
+	keep    []float64
+	reflect bool
+}
+
+func newPond(width, height int, damping float64, reflect bool) *pond {
+	p := &pond{
+		width:   width,
+		height:  height,
+		cur:     make([]float64, width*height),
+		prev:    make([]float64, width*height),
+		keep:    make([]float64, width*height),
+		reflect: reflect,
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			k := 1 - damping
+			if !reflect {
+				edge := min(x, y, width-1-x, height-1-y)
+				if edge < sponge {
+					k *= 0.6 + 0.4*float64(edge)/sponge
+				}
+			}
+			p.keep[y*width+x] = k
+		}
+	}
+	return p
+}
+
+// drop pushes a small dimple into the surface around x, y, scaled by
+// strength.
+func (p *pond) drop(x, y int, strength float64) {
+	const radius = 3
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			d := math.Hypot(float64(dx), float64(dy))
+			if d > radius {
+				continue
+			}
+			px, py := x+dx, y+dy
+			if px < 0 || px >= p.width || py < 0 || py >= p.height {
+				continue
+			}
+			p.cur[py*p.width+px] -= strength * dropHeight * math.Cos(d/radius*math.Pi/2)
+		}
+	}
+}
+
+// step is the classic ripple update: each cell becomes half the sum of its
+// neighbours minus what it was the step before, then loses a little. The
+// new surface is written over the old one and the buffers swap. Off the
+// border, reflecting edges mirror the cell inside; absorbing ones are still
+// water and let the sponge take the energy out.
+func (p *pond) step() {
+	w, h := p.width, p.height
+	at := func(x, y int) float64 {
+		if x < 0 || x >= w || y < 0 || y >= h {
+			if !p.reflect {
+				return 0
+			}
+			x = min(max(x, 0), w-1)
+			y = min(max(y, 0), h-1)
+		}
+		return p.cur[y*w+x]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			sum := at(x-1, y) + at(x+1, y) + at(x, y-1) + at(x, y+1)
+			p.prev[i] = (sum/2 - p.prev[i]) * p.keep[i]
+		}
+	}
+	p.cur, p.prev = p.prev, p.cur
+}
+
+// level is the surface at screen cell x, y: the two simulation rows it
+// covers, averaged.
+func (p *pond) level(x, y int) float64 {
+	i := 2*y*p.width + x
+	return (p.cur[i] + p.cur[i+p.width]) / 2
+}
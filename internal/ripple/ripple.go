@@ -0,0 +1,189 @@
+package ripple
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// crest is the height that reads as a full white crest or the deepest
+	// trough.
+	crest = dropHeight / 3
+)
+
+var (
+	// waterPalette runs from deep troughs through the calm blue to white
+	// crests.
+	waterPalette = []string{
+		"\x1b[38;5;17m",
+		"\x1b[38;5;18m",
+		"\x1b[38;5;19m",
+		"\x1b[38;5;20m",
+		"\x1b[38;5;26m",
+		"\x1b[38;5;32m",
+		"\x1b[38;5;38m",
+		"\x1b[38;5;45m",
+		"\x1b[38;5;87m",
+		"\x1b[38;5;123m",
+		"\x1b[38;5;159m",
+		"\x1b[38;5;195m",
+		"\x1b[38;5;231m",
+	}
+	glyphPalette = []rune{' ', '.', '-', '~', ':', '=', '+', '*', '#', '@'}
+)
+
+// Config controls the ripple animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Damping is the share of a wave's height lost each frame; smaller
+	// values let ripples run for longer.
+	Damping float64
+	// Rain is how many raindrops fall per second on average; 0 leaves the
+	// pond still until you drop something in.
+	Rain float64
+	// Edges is reflect, where rings bounce off the sides, or absorb, where
+	// they fade away into them.
+	Edges string
+	// Mouse drags a ripple along behind the pointer.
+	Mouse bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Damping:    0.02,
+		Rain:       1.5,
+		Edges:      "reflect",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Damping <= 0 {
+		c.Damping = 0.02
+	}
+	if c.Damping > 0.5 {
+		c.Damping = 0.5
+	}
+	if c.Rain < 0 {
+		c.Rain = 0
+	}
+	if !IsEdges(c.Edges) {
+		c.Edges = "reflect"
+	}
+	return c
+}
+
+// IsEdges reports whether name is a ripple edge behaviour.
+func IsEdges(name string) bool {
+	return name == "reflect" || name == "absorb"
+}
+
+// Run launches the pond. 'd' drops a ripple at a random spot; with Mouse
+// set, moving the pointer trails ripples behind it.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	water := newPond(cfg.Width, cfg.Height*2, cfg.Damping, cfg.Edges == "reflect")
+	rain := cfg.Rain * cfg.FrameDelay.Seconds()
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	mouse := term.NoMouse
+	if cfg.Mouse {
+		mouse = term.MouseMotion
+	}
+	events := term.Events(mouse)
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		if rng.Float64() < rain {
+			water.drop(rng.Intn(water.width), rng.Intn(water.height), 0.5+rng.Float64()*0.5)
+		}
+		water.step()
+		drawPond(grid, water)
+		grid.Render(screen)
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				switch {
+				case ev.Mouse:
+					water.drop(ev.X, ev.Y*2+1, 0.3)
+				case ev.Key == 'd' || ev.Key == 'D':
+					water.drop(rng.Intn(water.width), rng.Intn(water.height), 1)
+				}
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+// drawPond picks the glyph from how far the surface is from calm and the
+// color from which way: troughs go dark, crests go white.
+func drawPond(grid canvas.Grid, p *pond) {
+	mid := len(waterPalette) / 2
+	for y := range grid {
+		for x := range grid[y] {
+			h := p.level(x, y) / crest
+			h = math.Max(-1, math.Min(1, h))
+			glyph := glyphPalette[int(math.Abs(h)*float64(len(glyphPalette)-1)+0.5)]
+			color := waterPalette[mid+int(math.Round(h*float64(mid)))]
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package sand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// spoutRate is the chance each frame that a spout lets a particle go.
+	spoutRate = 0.7
+)
+
+var (
+	// grainColors run from fresh sand to sand that has lain a long time.
+	grainColors = []string{"\x1b[38;5;229m", "\x1b[38;5;222m", "\x1b[38;5;221m", "\x1b[38;5;179m", "\x1b[38;5;137m"}
+	waterColors = []string{"\x1b[38;5;123m", "\x1b[38;5;45m", "\x1b[38;5;33m", "\x1b[38;5;27m"}
+	stoneColor  = "\x1b[38;5;245m"
+	spoutColor  = "\x1b[38;5;240m"
+	hudColor    = "\x1b[38;5;240m"
+	// ageSteps is how many frames each step down the color ramps takes.
+	ageSteps = 60
+)
+
+// Config controls the falling sand.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Spouts is how many spouts wander along the top pouring sand.
+	Spouts int
+	// Water has every other spout pour water instead of sand.
+	Water bool
+	// MaxParticles caps how many particles there can be; past it the
+	// floor slowly lets them out. 0 caps at half the screen.
+	MaxParticles int
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 40 * time.Millisecond,
+		Spouts:     3,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Spouts < 0 {
+		c.Spouts = 3
+	}
+	if c.MaxParticles <= 0 {
+		c.MaxParticles = c.Width * c.Height / 2
+	}
+	return c
+}
+
+// spout pours one material from the top row as it wanders to and fro.
+type spout struct {
+	x     float64
+	phase float64
+	speed float64
+	what  material
+}
+
+// emitter is the spout the arrow keys move; it pours whatever 'm' picked.
+type emitter struct {
+	x, y int
+	pick int
+}
+
+// Run launches the falling sand. The arrow keys move the emitter, 'm'
+// changes what it pours and 'c' clears the screen.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	// The bottom row is the HUD, so the sand stops above it.
+	w := newWorld(cfg.Width, cfg.Height-1, cfg.MaxParticles, rng)
+	spouts := make([]spout, cfg.Spouts)
+	for i := range spouts {
+		spouts[i] = spout{
+			x:     rng.Float64() * float64(cfg.Width),
+			phase: rng.Float64() * 2 * math.Pi,
+			speed: 0.01 + rng.Float64()*0.02,
+			what:  grain,
+		}
+		if cfg.Water && i%2 == 1 {
+			spouts[i].what = water
+		}
+	}
+	user := emitter{x: cfg.Width / 2, y: 1}
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	events := term.Events(term.NoMouse)
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for frame := 0; limit.Next(); frame++ {
+		for i := range spouts {
+			s := &spouts[i]
+			s.x = float64(cfg.Width) / 2 * (1 + math.Sin(s.phase+float64(frame)*s.speed)*0.9)
+			if rng.Float64() < spoutRate {
+				w.place(int(s.x), 0, s.what)
+			}
+		}
+		if events != nil {
+			w.place(user.x, user.y, materials[user.pick])
+		}
+		w.update()
+		w.drain()
+
+		grid.Clear()
+		drawWorld(grid, w)
+		for _, s := range spouts {
+			grid.Set(int(s.x), 0, 'v', spoutColor)
+		}
+		if events != nil {
+			drawEmitter(grid, user)
+		}
+		grid.Render(screen)
+
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				user.handle(ev, w)
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+	return nil
+}
+
+func (e *emitter) handle(ev term.Event, w *world) {
+	switch {
+	case ev.Arrow == term.Up:
+		e.y = max(0, e.y-1)
+	case ev.Arrow == term.Down:
+		e.y = min(w.height-1, e.y+1)
+	case ev.Arrow == term.Left:
+		e.x = max(0, e.x-1)
+	case ev.Arrow == term.Right:
+		e.x = min(w.width-1, e.x+1)
+	case ev.Key == 'm' || ev.Key == 'M':
+		e.pick = (e.pick + 1) % len(materials)
+	case ev.Key == 'c' || ev.Key == 'C':
+		w.clear()
+	}
+}
+
+func drawWorld(grid canvas.Grid, w *world) {
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			i := y*w.width + x
+			age := int(w.age[i]) / ageSteps
+			switch w.cur[i] {
+			case grain:
+				glyph := ':'
+				if uint32(x*73856093^y*19349663)%7 == 0 {
+					glyph = '.'
+				}
+				grid.Set(x, y, glyph, grainColors[min(age, len(grainColors)-1)])
+			case water:
+				grid.Set(x, y, '~', waterColors[min(age, len(waterColors)-1)])
+			case stone:
+				grid.Set(x, y, '#', stoneColor)
+			}
+		}
+	}
+	hud := fmt.Sprintf(" %d/%d ", w.count, w.limit)
+	grid.Text(0, len(grid)-1, hud, hudColor)
+}
+
+func drawEmitter(grid canvas.Grid, e emitter) {
+	color := map[material]string{grain: grainColors[0], water: waterColors[1], stone: stoneColor}[materials[e.pick]]
+	grid.Set(e.x, e.y, '@', color)
+	label := []string{"sand", "water", "stone"}[e.pick] + "  arrows/m/c"
+	x := len(grid[0]) - len(label) - 1
+	grid.Text(x, len(grid)-1, label, hudColor)
+}
@@ -0,0 +1,183 @@
+package sand
+
+import "math/rand"
+
+// material is what fills a cell.
+type material byte
+
+const (
+	empty material = iota
+	grain
+	water
+	stone
+)
+
+// materials is the order 'm' cycles the emitter through.
+var materials = []material{grain, water, stone}
+
+// maxAge is where a particle's age stops counting.
+const maxAge = 1 << 15
+
+// world is the falling sand simulation. It is double-buffered: each step
+// reads every particle from cur and writes it into next, so a particle that
+// has already moved is not moved again, and the scan alternates direction
+// every step so nothing drifts one way.
+type world struct {
+	width, height int
+	cur, next     []material
+	age, nextAge  []uint16
+	count         int
+	limit         int
+	step          int
+	rng           *rand.Rand
+}
+
+func newWorld(width, height, limit int, rng *rand.Rand) *world {
+	n := width * height
+	return &world{
+		width:   width,
+		height:  height,
+		cur:     make([]material, n),
+		next:    make([]material, n),
+		age:     make([]uint16, n),
+		nextAge: make([]uint16, n),
+		limit:   limit,
+		rng:     rng,
+	}
+}
+
+func (w *world) at(x, y int) material {
+	if x < 0 || x >= w.width || y < 0 || y >= w.height {
+		return stone
+	}
+	return w.cur[y*w.width+x]
+}
+
+// place drops m at x, y if the cell is free and the particle cap allows.
+func (w *world) place(x, y int, m material) {
+	if w.at(x, y) != empty || w.count >= w.limit {
+		return
+	}
+	w.cur[y*w.width+x] = m
+	w.age[y*w.width+x] = 0
+	w.count++
+}
+
+func (w *world) clear() {
+	for i := range w.cur {
+		w.cur[i] = empty
+	}
+	w.count = 0
+}
+
+// drain lets a few grains and drops out through the floor once the cap is
+// reached, so the spouts never stall for good.
+func (w *world) drain() {
+	if w.count < w.limit {
+		return
+	}
+	y := w.height - 1
+	for i := 0; i < w.width/8+1; i++ {
+		x := w.rng.Intn(w.width)
+		if m := w.at(x, y); m == grain || m == water {
+			w.cur[y*w.width+x] = empty
+			w.count--
+		}
+	}
+}
+
+// update moves every particle one step: sand falls, sinks through water
+// and slides off slopes; water falls and spreads sideways; stone stays put.
+// Water only spreads once everything has had its chance to fall, or it
+// would keep filling the gaps under the water above and never settle.
+func (w *world) update() {
+	copy(w.next, w.cur)
+	for i := range w.nextAge {
+		if w.age[i] < maxAge {
+			w.nextAge[i] = w.age[i] + 1
+		}
+	}
+	w.step++
+	w.sweep(func(x, y int) {
+		if m := w.cur[y*w.width+x]; m == grain || m == water {
+			w.fall(x, y, m)
+		}
+	})
+	w.sweep(func(x, y int) {
+		i := y*w.width + x
+		if w.cur[i] == water && w.next[i] == water {
+			w.spread(x, y)
+		}
+	})
+	w.cur, w.next = w.next, w.cur
+	w.age, w.nextAge = w.nextAge, w.age
+}
+
+// sweep visits every cell from the bottom up, alternating left to right and
+// right to left from step to step.
+func (w *world) sweep(visit func(x, y int)) {
+	for y := w.height - 1; y >= 0; y-- {
+		for i := 0; i < w.width; i++ {
+			x := i
+			if w.step%2 == 1 {
+				x = w.width - 1 - i
+			}
+			visit(x, y)
+		}
+	}
+}
+
+// free reports whether x, y is open in both buffers, so nothing moves into
+// a cell another particle is just leaving or has just taken.
+func (w *world) free(x, y int, through material) bool {
+	if x < 0 || x >= w.width || y < 0 || y >= w.height {
+		return false
+	}
+	i := y*w.width + x
+	return (w.cur[i] == empty || w.cur[i] == through) && w.next[i] == w.cur[i]
+}
+
+// move takes the particle at x, y in next to tx, ty, swapping with whatever
+// was there (water that sand sinks through).
+func (w *world) move(x, y, tx, ty int) {
+	from, to := y*w.width+x, ty*w.width+tx
+	if w.next[from] != w.cur[from] {
+		return
+	}
+	w.next[from], w.next[to] = w.next[to], w.next[from]
+	w.nextAge[from], w.nextAge[to] = w.nextAge[to], w.nextAge[from]
+}
+
+func (w *world) fall(x, y int, m material) {
+	through := empty
+	if m == grain {
+		through = water
+	}
+	if w.free(x, y+1, through) {
+		w.move(x, y, x, y+1)
+		return
+	}
+	dx := 1 - 2*w.rng.Intn(2)
+	for _, d := range []int{dx, -dx} {
+		if w.free(x+d, y+1, through) && w.free(x+d, y, through) {
+			w.move(x, y, x+d, y+1)
+			return
+		}
+	}
+}
+
+// spread moves water that could not fall a step or two sideways.
+func (w *world) spread(x, y int) {
+	dx := 1 - 2*w.rng.Intn(2)
+	for _, d := range []int{dx, -dx} {
+		if !w.free(x+d, y, empty) {
+			continue
+		}
+		tx := x + d
+		if w.free(tx+d, y, empty) {
+			tx += d
+		}
+		w.move(x, y, tx, y)
+		return
+	}
+}
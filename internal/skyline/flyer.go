@@ -0,0 +1,159 @@
+package skyline
+
+import (
+	"math/rand"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	blimpCrossTime = 20 * time.Second
+	blimpBody      = "(===)"
+	towLine        = "~-"
+)
+
+var (
+	hullColor   = "\x1b[38;5;245m"
+	rotorColor  = "\x1b[38;5;252m"
+	beaconColor = "\x1b[38;5;196m"
+	bannerColor = "\x1b[38;5;229m"
+	towColor    = "\x1b[38;5;240m"
+)
+
+type flyerKind int
+
+const (
+	flyerHelicopter flyerKind = iota
+	flyerBlimp
+)
+
+type flyer struct {
+	active bool
+	kind   flyerKind
+	x      float64
+	y      int
+	speed  float64
+	banner string
+}
+
+func newFlyer(cfg Config, rng *rand.Rand) flyer {
+	f := flyer{
+		active: true,
+		kind:   flyerHelicopter,
+		y:      2 + rng.Intn(max(1, cfg.Height/4-1)),
+	}
+	if cfg.Banner != "" && rng.Intn(2) == 0 {
+		f.kind = flyerBlimp
+		f.banner = truncateBanner(cfg.Banner, cfg.Width)
+	}
+
+	length := float64(f.length())
+	if f.kind == flyerBlimp {
+		frames := float64(blimpCrossTime) / float64(cfg.FrameDelay)
+		f.speed = (float64(cfg.Width) + length) / frames
+	} else {
+		f.speed = 0.45 + rng.Float64()*0.35
+	}
+
+	if rng.Intn(2) == 0 {
+		f.x = -length
+	} else {
+		f.x = float64(cfg.Width)
+		f.speed = -f.speed
+	}
+	return f
+}
+
+// truncateBanner keeps the towed banner within half of the screen width.
+func truncateBanner(text string, width int) string {
+	limit := width/2 - len(blimpBody) - len(towLine) - 2
+	if limit <= 0 {
+		return ""
+	}
+	if len(text) > limit {
+		return text[:limit]
+	}
+	return text
+}
+
+func (f flyer) length() int {
+	if f.kind == flyerBlimp {
+		n := len(blimpBody)
+		if f.banner != "" {
+			n += len(towLine) + len(f.banner) + 2
+		}
+		return n
+	}
+	return 3
+}
+
+func drawFlyer(grid canvas.Grid, f flyer, frame int) {
+	x := int(f.x)
+	switch f.kind {
+	case flyerBlimp:
+		drawBlimp(grid, f, x)
+	default:
+		drawHelicopter(grid, f, x, frame)
+	}
+}
+
+func drawHelicopter(grid canvas.Grid, f flyer, x int, frame int) {
+	body := "-=o"
+	if f.speed < 0 {
+		body = "o=-"
+	}
+	grid.Text(x, f.y, body, hullColor)
+
+	rotor := '-'
+	if frame%2 == 1 {
+		rotor = '+'
+	}
+	grid.Set(x+1, f.y-1, rotor, rotorColor)
+
+	if (frame/6)%2 == 0 {
+		tail := x
+		if f.speed < 0 {
+			tail = x + 2
+		}
+		grid.Set(tail, f.y, '.', beaconColor)
+	}
+}
+
+func drawBlimp(grid canvas.Grid, f flyer, x int) {
+	if f.banner == "" {
+		grid.Text(x, f.y, blimpBody, hullColor)
+		return
+	}
+	banner := "[" + f.banner + "]"
+	if f.speed > 0 {
+		grid.Text(x, f.y, banner, bannerColor)
+		x += len(banner)
+		grid.Text(x, f.y, towLine, towColor)
+		grid.Text(x+len(towLine), f.y, blimpBody, hullColor)
+		return
+	}
+	grid.Text(x, f.y, blimpBody, hullColor)
+	x += len(blimpBody)
+	grid.Text(x, f.y, reverse(towLine), towColor)
+	grid.Text(x+len(towLine), f.y, banner, bannerColor)
+}
+
+func updateFlyer(f *flyer, width int) {
+	f.x += f.speed
+	length := float64(f.length())
+	if f.speed > 0 && f.x > float64(width) {
+		f.active = false
+	}
+	if f.speed < 0 && f.x+length < 0 {
+		f.active = false
+	}
+}
+
+func reverse(s string) string {
+	out := []byte(s)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
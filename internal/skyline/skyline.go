@@ -1,12 +1,17 @@
 package skyline
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/snow"
 	"animinterminal/internal/term"
 )
 
@@ -45,21 +50,43 @@ var (
 
 // Config controls the skyline animation.
 type Config struct {
-	Width      int
-	Height     int
-	FrameDelay time.Duration
+	Width         int
+	Height        int
+	FrameDelay    time.Duration
+	Banner        string
+	FlyerInterval time.Duration
+	ShowHUD       bool
+	// Snow lets it snow over the city, piling up on the roofs and in the
+	// street.
+	Snow bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
 }
 
 // DefaultConfig returns a preset that works for most terminals.
 func DefaultConfig() Config {
 	return Config{
-		Width:      100,
-		Height:     34,
-		FrameDelay: 40 * time.Millisecond,
+		Width:         100,
+		Height:        34,
+		FrameDelay:    40 * time.Millisecond,
+		FlyerInterval: time.Minute,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < 60 {
 		c.Width = 60
 	}
@@ -69,14 +96,12 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 45 * time.Millisecond
 	}
+	if c.FlyerInterval <= 0 {
+		c.FlyerInterval = time.Minute
+	}
 	return c
 }
 
-type cell struct {
-	color string
-	glyph byte
-}
-
 type building struct {
 	x         int
 	width     int
@@ -85,55 +110,81 @@ type building struct {
 	layer     int
 	windowOn  []bool
 	outline   string
-	fillGlyph byte
+	fillGlyph rune
 }
 
 // Run starts the neon skyline animation.
-func Run(cfg Config) {
-	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
-
-	grid := newGrid(cfg.Width, cfg.Height)
-	buildings := makeBuildings(cfg)
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
 
-	cleanup := term.Start(true)
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	buildings := makeBuildings(cfg, rng)
+	spawnChance := float64(cfg.FrameDelay) / float64(cfg.FlyerInterval)
+	var craft flyer
+	var flakes *snow.Layer
+	if cfg.Snow {
+		flakes = snow.NewLayer(snow.Config{
+			Width:      cfg.Width,
+			Height:     cfg.Height,
+			FrameDelay: cfg.FrameDelay,
+			Density:    0.3,
+			Wind:       0.15,
+			Melt:       0.01,
+			Seed:       rng.Int63(),
+		})
+	}
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
 		drawSky(grid, frame)
 		drawStars(grid, frame)
 		drawHorizonGlow(grid, frame)
 		drawBuildings(grid, buildings, frame)
-		drawHUD(grid, frame)
-		render(grid)
-
-		updateBuildings(buildings, cfg.Width, frame)
-
-		<-ticker.C
-	}
-}
+		if craft.active {
+			drawFlyer(grid, craft, frame)
+		}
+		if flakes != nil {
+			flakes.SetFloor(rooftops(buildings, cfg.Width, len(grid)-3))
+			flakes.Update()
+			flakes.Draw(grid.Set)
+		}
+		if cfg.ShowHUD {
+			drawHUD(grid, buildings, render.FPS())
+		}
+		grid.Render(screen)
 
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
+		updateBuildings(buildings, cfg.Width, frame, rng)
+		if craft.active {
+			updateFlyer(&craft, cfg.Width)
+		} else if rng.Float64() < spawnChance {
+			craft = newFlyer(cfg, rng)
+		}
 
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		for x := range grid[y] {
-			grid[y][x] = cell{glyph: ' ', color: ""}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
-func drawSky(grid [][]cell, frame int) {
+func drawSky(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height/2; y++ {
@@ -141,27 +192,27 @@ func drawSky(grid [][]cell, frame int) {
 		index := (int(gradient*float64(len(skyPalette))) + frame/18) % len(skyPalette)
 		hue := skyPalette[index]
 		for x := 0; x < width; x++ {
-			grid[y][x] = cell{glyph: ' ', color: hue}
+			grid[y][x] = canvas.Cell{Glyph: ' ', Color: hue}
 		}
 	}
 	drawAurora(grid, frame)
 }
 
-func drawStars(grid [][]cell, frame int) {
+func drawStars(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for i := 0; i < width/6; i++ {
 		x := (i*13 + frame) % width
 		y := (i*7 + frame/3) % (height / 2)
 		if (x+y+frame)%11 == 0 {
-			grid[y][x] = cell{glyph: '.', color: "\x1b[38;5;231m"}
+			grid[y][x] = canvas.Cell{Glyph: '.', Color: "\x1b[38;5;231m"}
 		} else if (x*3+y+frame)%17 == 0 {
-			grid[y][x] = cell{glyph: '+', color: "\x1b[38;5;81m"}
+			grid[y][x] = canvas.Cell{Glyph: '+', Color: "\x1b[38;5;81m"}
 		}
 	}
 }
 
-func drawAurora(grid [][]cell, frame int) {
+func drawAurora(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	bandY := height / 3
@@ -172,14 +223,14 @@ func drawAurora(grid [][]cell, frame int) {
 			continue
 		}
 		color := glowPalette[(x/6+frame/8)%len(glowPalette)]
-		grid[y][x] = cell{glyph: '~', color: color}
+		grid[y][x] = canvas.Cell{Glyph: '~', Color: color}
 		if y+1 < height/2 {
-			grid[y+1][x] = cell{glyph: '~', color: color}
+			grid[y+1][x] = canvas.Cell{Glyph: '~', Color: color}
 		}
 	}
 }
 
-func drawHorizonGlow(grid [][]cell, frame int) {
+func drawHorizonGlow(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	horizon := height / 2
@@ -187,15 +238,15 @@ func drawHorizonGlow(grid [][]cell, frame int) {
 		falloff := float64(y-horizon) / float64(height-horizon)
 		color := horizonPalette[(int(falloff*float64(len(horizonPalette)))+frame/16)%len(horizonPalette)]
 		for x := 0; x < width; x++ {
-			if grid[y][x].glyph == ' ' {
-				grid[y][x] = cell{glyph: ' ', color: color}
+			if grid[y][x].Glyph == ' ' {
+				grid[y][x] = canvas.Cell{Glyph: ' ', Color: color}
 			}
 		}
 	}
 	drawLightBeams(grid, frame)
 }
 
-func drawLightBeams(grid [][]cell, frame int) {
+func drawLightBeams(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for i := 0; i < width/12; i++ {
@@ -203,29 +254,29 @@ func drawLightBeams(grid [][]cell, frame int) {
 		color := glowPalette[(i+frame/10)%len(glowPalette)]
 		for y := height / 2; y < height-3; y++ {
 			if (y+i)%4 == 0 {
-				grid[y][x] = cell{glyph: '|', color: color}
+				grid[y][x] = canvas.Cell{Glyph: '|', Color: color}
 			}
 		}
 	}
 }
 
-func makeBuildings(cfg Config) []building {
+func makeBuildings(cfg Config, rng *rand.Rand) []building {
 	layers := []int{3, 2, 1}
 	result := make([]building, 0, cfg.Width/2)
 	for _, layer := range layers {
-		x := rand.Intn(8)
+		x := rng.Intn(8)
 		for x < cfg.Width {
-			width := 4 + rand.Intn(6+layer*2)
-			height := cfg.Height/4 + rand.Intn(cfg.Height/4) + layer*3
-			palette := buildingPalettes[rand.Intn(len(buildingPalettes))]
+			width := 4 + rng.Intn(6+layer*2)
+			height := cfg.Height/4 + rng.Intn(cfg.Height/4) + layer*3
+			palette := buildingPalettes[rng.Intn(len(buildingPalettes))]
 			windowCount := width * height / 5
 			windows := make([]bool, windowCount)
 			for i := range windows {
 				chance := max(1, 3-layer)
-				windows[i] = rand.Intn(chance) == 0
+				windows[i] = rng.Intn(chance) == 0
 			}
-			fillGlyph := []byte{'=', '#', '%'}[min(layer, 3)-1]
-			outline := glowPalette[rand.Intn(len(glowPalette))]
+			fillGlyph := []rune{'=', '#', '%'}[min(layer, 3)-1]
+			outline := glowPalette[rng.Intn(len(glowPalette))]
 			result = append(result, building{
 				x:         x,
 				width:     width,
@@ -236,13 +287,13 @@ func makeBuildings(cfg Config) []building {
 				outline:   outline,
 				fillGlyph: fillGlyph,
 			})
-			x += width + rand.Intn(6)
+			x += width + rng.Intn(6)
 		}
 	}
 	return result
 }
 
-func drawBuildings(grid [][]cell, buildings []building, frame int) {
+func drawBuildings(grid canvas.Grid, buildings []building, frame int) {
 	baseLine := len(grid) - 3
 	for _, layer := range []int{3, 2, 1} {
 		for _, b := range buildings {
@@ -253,7 +304,7 @@ func drawBuildings(grid [][]cell, buildings []building, frame int) {
 	}
 }
 
-func drawBuilding(grid [][]cell, b building, baseLine int, frame int) {
+func drawBuilding(grid canvas.Grid, b building, baseLine int, frame int) {
 	height := b.height
 	top := baseLine - height
 	if top < 0 {
@@ -267,7 +318,7 @@ func drawBuilding(grid [][]cell, b building, baseLine int, frame int) {
 			if col < 0 || col >= len(grid[0]) {
 				continue
 			}
-			var glyph byte = b.fillGlyph
+			var glyph rune = b.fillGlyph
 			edgeColor := color
 			if x == 0 || x == b.width-1 {
 				glyph = '|'
@@ -276,14 +327,14 @@ func drawBuilding(grid [][]cell, b building, baseLine int, frame int) {
 				glyph = '_'
 				edgeColor = b.outline
 			}
-			grid[top+y][col] = cell{glyph: glyph, color: edgeColor}
+			grid[top+y][col] = canvas.Cell{Glyph: glyph, Color: edgeColor}
 		}
 	}
 	drawWindows(grid, b, baseLine, frame)
 	drawBillboard(grid, b, baseLine, frame)
 }
 
-func drawWindows(grid [][]cell, b building, baseLine int, frame int) {
+func drawWindows(grid canvas.Grid, b building, baseLine int, frame int) {
 	windowCols := max(1, b.width/2)
 	windowRows := max(2, b.height/4)
 	idx := 0
@@ -299,15 +350,15 @@ func drawWindows(grid [][]cell, b building, baseLine int, frame int) {
 			if b.windowOn[idx] || (frame/10+wx+wy)%6 == 0 {
 				x := b.x + 1 + wx*2
 				color := windowPalette[(wx+wy+frame/7)%len(windowPalette)]
-				setCell(grid, x, y, ':', color)
-				setCell(grid, x+1, y, ':', color)
+				grid.Set(x, y, ':', color)
+				grid.Set(x+1, y, ':', color)
 			}
 			idx++
 		}
 	}
 }
 
-func drawBillboard(grid [][]cell, b building, baseLine int, frame int) {
+func drawBillboard(grid canvas.Grid, b building, baseLine int, frame int) {
 	if b.width < 8 {
 		return
 	}
@@ -318,46 +369,80 @@ func drawBillboard(grid [][]cell, b building, baseLine int, frame int) {
 	x := b.x + b.width/2 - 4
 	for i := 0; i < 8; i++ {
 		color := glowPalette[(i+frame/6)%len(glowPalette)]
-		setCell(grid, x+i, y, '-', color)
-		setCell(grid, x+i, y+1, '-', color)
+		grid.Set(x+i, y, '-', color)
+		grid.Set(x+i, y+1, '-', color)
 	}
 	if (frame/40)%2 == 0 {
 		color := "\x1b[38;5;219m"
-		setCell(grid, x+2, y-1, '/', color)
-		setCell(grid, x+5, y-1, '\\', color)
+		grid.Set(x+2, y-1, '/', color)
+		grid.Set(x+5, y-1, '\\', color)
 	}
 }
 
-func drawHUD(grid [][]cell, frame int) {
+func drawHUD(grid canvas.Grid, buildings []building, fps float64) {
 	width := len(grid[0])
 	height := len(grid)
 	y := height - 2
 	barWidth := width / 2
 	start := (width - barWidth) / 2
-	fill := int(float64(barWidth) * (0.5 + 0.5*math.Sin(float64(frame)*0.02)))
+	lit := litWindowRatio(buildings)
+	fill := int(float64(barWidth) * lit)
 	for x := 0; x < barWidth; x++ {
 		color := "\x1b[38;5;244m"
-		var glyph byte = '-'
+		var glyph rune = '-'
 		if x < fill {
 			color = "\x1b[38;5;45m"
 			glyph = '='
 		}
-		setCell(grid, start+x, y, glyph, color)
+		grid.Set(start+x, y, glyph, color)
+	}
+	text := fmt.Sprintf("BLDG:%03d  LIT:%02d%%  FPS:%4.1f", len(buildings), int(lit*100), fps)
+	grid.Text(2, 1, text, "\x1b[38;5;111m")
+}
+
+// litWindowRatio reports the fraction of windows currently switched on.
+func litWindowRatio(buildings []building) float64 {
+	var on, total int
+	for _, b := range buildings {
+		for _, w := range b.windowOn {
+			if w {
+				on++
+			}
+		}
+		total += len(b.windowOn)
 	}
-	text := fmt.Sprintf("SKYLINE %dk  FRAME:%06d  SAT:%02d%%", width, frame, (frame/5)%100)
-	printText(grid, 2, 1, text, "\x1b[38;5;111m")
+	if total == 0 {
+		return 0
+	}
+	return float64(on) / float64(total)
+}
+
+// rooftops is the highest roof over each column, or the street where there
+// is none, for the snow to land on.
+func rooftops(buildings []building, width, baseLine int) []int {
+	tops := make([]int, width)
+	for x := range tops {
+		tops[x] = baseLine
+	}
+	for _, b := range buildings {
+		top := max(0, baseLine-b.height)
+		for x := max(0, b.x); x < min(width, b.x+b.width); x++ {
+			tops[x] = min(tops[x], top)
+		}
+	}
+	return tops
 }
 
-func updateBuildings(buildings []building, width int, frame int) {
+func updateBuildings(buildings []building, width int, frame int, rng *rand.Rand) {
 	for i := range buildings {
 		if frame%80 == 0 {
 			for j := range buildings[i].windowOn {
-				if rand.Intn(4) == 0 {
+				if rng.Intn(4) == 0 {
 					buildings[i].windowOn[j] = !buildings[i].windowOn[j]
 				}
 			}
 		}
-		if rand.Intn(120) == 0 {
+		if rng.Intn(120) == 0 {
 			buildings[i].x += 1
 			if buildings[i].x > width {
 				buildings[i].x = -buildings[i].width
@@ -365,44 +450,3 @@ func updateBuildings(buildings []building, width int, frame int) {
 		}
 	}
 }
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[0]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func printText(grid [][]cell, x, y int, text string, color string) {
-	for i := 0; i < len(text); i++ {
-		setCell(grid, x+i, y, text[i], color)
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
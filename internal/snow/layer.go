@@ -0,0 +1,196 @@
+package snow
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	// settle is how many rows a landing flake adds to the pile, per size.
+	settle = 0.06
+	// repose is the steepest step, in rows, a drift keeps between columns;
+	// past slide the excess goes all at once.
+	repose = 1.2
+	slide  = 3.0
+)
+
+var (
+	flakeColors = []string{
+		"\x1b[38;5;247m",
+		"\x1b[38;5;253m",
+		"\x1b[38;5;231m",
+	}
+	packColor    = "\x1b[38;5;255m"
+	packTopColor = "\x1b[38;5;231m"
+)
+
+type flake struct {
+	x, y  float64
+	speed float64
+	size  int
+	phase float64
+}
+
+// Layer is falling snow and the snowpack it builds, kept apart from any
+// scene so other modes can let it snow over their own picture. The floor
+// says where snow comes to rest in each column; without one it is the
+// bottom of the screen.
+type Layer struct {
+	width, height int
+	density       float64
+	wind          float64
+	melt          float64
+	unicode       bool
+	maxPack       float64
+	flakes        []flake
+	floor         []int
+	pack          []float64
+	frame         int
+	rng           *rand.Rand
+}
+
+// NewLayer builds the snow for a screen the size of cfg.
+func NewLayer(cfg Config) *Layer {
+	cfg = cfg.normalize()
+	l := &Layer{
+		width:   cfg.Width,
+		height:  cfg.Height,
+		density: cfg.Density,
+		wind:    cfg.Wind,
+		melt:    cfg.Melt * cfg.FrameDelay.Seconds(),
+		unicode: cfg.Unicode,
+		maxPack: float64(cfg.Height) / 4,
+		floor:   make([]int, cfg.Width),
+		pack:    make([]float64, cfg.Width),
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for x := range l.floor {
+		l.floor[x] = cfg.Height
+	}
+	count := int(float64(cfg.Width*cfg.Height) * cfg.Density / 12)
+	for i := 0; i < count; i++ {
+		l.flakes = append(l.flakes, l.newFlake(l.rng.Float64()*float64(cfg.Height)))
+	}
+	return l
+}
+
+// SetFloor sets the first solid row of each column, such as a roof top.
+func (l *Layer) SetFloor(floor []int) {
+	copy(l.floor, floor)
+}
+
+func (l *Layer) newFlake(y float64) flake {
+	size := 0
+	switch r := l.rng.Float64(); {
+	case r > 0.9:
+		size = 2
+	case r > 0.55:
+		size = 1
+	}
+	return flake{
+		x:     l.rng.Float64() * float64(l.width),
+		y:     y,
+		speed: 0.15 + 0.1*float64(size) + l.rng.Float64()*0.1,
+		size:  size,
+		phase: l.rng.Float64() * 2 * math.Pi,
+	}
+}
+
+// surface is the row the snow in column x is piled up to.
+func (l *Layer) surface(x int) float64 {
+	return float64(l.floor[x]) - l.pack[x]
+}
+
+// Update moves the flakes, lands the ones that reach the pile and lets the
+// pile settle and melt.
+func (l *Layer) Update() {
+	l.frame++
+	w := float64(l.width)
+	for i := range l.flakes {
+		f := &l.flakes[i]
+		// Big flakes catch more wind and sway wider.
+		sway := math.Sin(float64(l.frame)*0.05+f.phase) * (0.1 + 0.05*float64(f.size))
+		f.x = math.Mod(f.x+sway+l.wind*(0.3+0.2*float64(f.size))+w, w)
+		f.y += f.speed
+		x := int(f.x)
+		if f.y < l.surface(x) {
+			continue
+		}
+		if l.pack[x] < l.maxPack && f.y < float64(l.floor[x])+1 {
+			l.pack[x] += settle * float64(f.size+1)
+		}
+		*f = l.newFlake(-l.rng.Float64() * 3)
+	}
+	l.settle()
+	for x := range l.pack {
+		l.pack[x] = math.Max(0, l.pack[x]-l.melt)
+	}
+}
+
+// settle relaxes the pile so no step between neighbours is steeper than
+// repose; a much steeper one, like a drift at a roof edge, slides off in one
+// go. The sweep changes direction every frame so drifts do not lean one
+// way.
+func (l *Layer) settle() {
+	for i := 0; i < l.width-1; i++ {
+		x := i
+		if l.frame%2 == 1 {
+			x = l.width - 2 - i
+		}
+		for _, pair := range [2][2]int{{x, x + 1}, {x + 1, x}} {
+			from, to := pair[0], pair[1]
+			step := l.surface(to) - l.surface(from)
+			if step <= repose {
+				continue
+			}
+			move := (step - repose) / 2
+			if step > slide {
+				move = step - repose
+			}
+			move = math.Min(move, l.pack[from])
+			l.pack[from] -= move
+			l.pack[to] += move
+		}
+	}
+}
+
+// Draw hands every visible flake and snowpack cell to set.
+func (l *Layer) Draw(set func(x, y int, glyph rune, color string)) {
+	for x, depth := range l.pack {
+		full := int(depth)
+		for i := 0; i < full; i++ {
+			set(x, l.floor[x]-1-i, '#', packColor)
+		}
+		var glyph rune
+		switch rest := depth - float64(full); {
+		case rest > 0.66:
+			glyph = '='
+		case rest > 0.33:
+			glyph = '-'
+		case rest > 0.1:
+			glyph = '_'
+		}
+		if glyph != 0 {
+			set(x, l.floor[x]-1-full, glyph, packTopColor)
+		}
+	}
+	for _, f := range l.flakes {
+		if f.y < 0 {
+			continue
+		}
+		set(int(f.x), int(f.y), l.flakeGlyph(f.size), flakeColors[f.size])
+	}
+}
+
+func (l *Layer) flakeGlyph(size int) rune {
+	switch size {
+	case 2:
+		if l.unicode {
+			return '❄'
+		}
+		return '*'
+	case 1:
+		return '*'
+	}
+	return '.'
+}
@@ -0,0 +1,186 @@
+package snow
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+)
+
+var (
+	treeColor  = "\x1b[38;5;23m"
+	trunkColor = "\x1b[38;5;58m"
+	lampColor  = "\x1b[38;5;222m"
+)
+
+// Config controls the snowfall animation.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Density is how thickly it snows, 0-1.
+	Density float64
+	// Wind pushes the flakes sideways, -1 (left) to 1 (right).
+	Wind float64
+	// Melt is how many rows of snowpack melt away per second; 0 keeps it.
+	Melt float64
+	// Unicode draws the biggest flakes as snowflake symbols.
+	Unicode bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 50 * time.Millisecond,
+		Density:    0.4,
+		Wind:       0.2,
+		Melt:       0.01,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 50 * time.Millisecond
+	}
+	if c.Density <= 0 {
+		c.Density = 0.4
+	}
+	if c.Density > 1 {
+		c.Density = 1
+	}
+	if c.Wind < -1 {
+		c.Wind = -1
+	}
+	if c.Wind > 1 {
+		c.Wind = 1
+	}
+	if c.Melt < 0 {
+		c.Melt = 0
+	}
+	return c
+}
+
+// Run launches the snowfall animation: a quiet night with a few pines and a
+// street lamp for the drifts to pile up against.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	snow := NewLayer(cfg)
+	props, floor := newProps(rand.New(rand.NewSource(cfg.Seed)), cfg.Width, cfg.Height)
+	snow.SetFloor(floor)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	for limit.Next() {
+		grid.Clear()
+		props.draw(grid)
+		snow.Update()
+		snow.Draw(grid.Set)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// props are the pines and the lamp post; their tops are where snow rests.
+type props struct {
+	trees []int
+	lamp  int
+}
+
+func newProps(r *rand.Rand, width, height int) (props, []int) {
+	p := props{lamp: width * 2 / 3}
+	floor := make([]int, width)
+	for x := range floor {
+		floor[x] = height
+	}
+	for x := 4 + r.Intn(6); x < width-4; x += 10 + r.Intn(14) {
+		if x > p.lamp-5 && x < p.lamp+5 {
+			continue
+		}
+		p.trees = append(p.trees, x)
+		// Snow rests on the branches, so each tier is its own shelf.
+		for tier := 0; tier < 3; tier++ {
+			y := height - 8 + tier*2
+			floor[x] = min(floor[x], y)
+			for dx := 1; dx <= tier+1; dx++ {
+				floor[x-dx] = min(floor[x-dx], y+1)
+				floor[x+dx] = min(floor[x+dx], y+1)
+			}
+		}
+	}
+	floor[p.lamp] = height - 9
+	return p, floor
+}
+
+func (p props) draw(grid canvas.Grid) {
+	height := len(grid)
+	for _, x := range p.trees {
+		for tier := 0; tier < 3; tier++ {
+			y := height - 8 + tier*2
+			grid.Set(x, y, '^', treeColor)
+			for dx := 1; dx <= tier+1; dx++ {
+				grid.Set(x-dx, y+1, '/', treeColor)
+				grid.Set(x+dx, y+1, '\\', treeColor)
+			}
+			grid.Set(x, y+1, '^', treeColor)
+		}
+		grid.Set(x, height-2, '|', trunkColor)
+		grid.Set(x, height-1, '|', trunkColor)
+	}
+	grid.Set(p.lamp, height-9, 'o', lampColor)
+	for y := height - 8; y < height; y++ {
+		grid.Set(p.lamp, y, '|', trunkColor)
+	}
+}
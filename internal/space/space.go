@@ -0,0 +1,110 @@
+// Package space is the small bit of 3D vector math shared by the modes that
+// render solids.
+package space
+
+import "math"
+
+// Vec3 is a point or direction in 3D.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// Rotate turns v by ax around the X axis, then ay around Y, then az around Z.
+func Rotate(v Vec3, ax, ay, az float64) Vec3 {
+	sinX, cosX := math.Sin(ax), math.Cos(ax)
+	sinY, cosY := math.Sin(ay), math.Cos(ay)
+	sinZ, cosZ := math.Sin(az), math.Cos(az)
+
+	y := v.Y*cosX - v.Z*sinX
+	z := v.Y*sinX + v.Z*cosX
+
+	x := v.X*cosY + z*sinY
+	z = -v.X*sinY + z*cosY
+
+	x2 := x*cosZ - y*sinZ
+	y2 := x*sinZ + y*cosZ
+
+	return Vec3{X: x2, Y: y2, Z: z}
+}
+
+func Add(a, b Vec3) Vec3 {
+	return Vec3{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func Subtract(a, b Vec3) Vec3 {
+	return Vec3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+func Scale(v Vec3, s float64) Vec3 {
+	return Vec3{X: v.X * s, Y: v.Y * s, Z: v.Z * s}
+}
+
+func Cross(a, b Vec3) Vec3 {
+	return Vec3{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+func Dot(a, b Vec3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+// Normalize scales v to unit length; the zero vector stays zero.
+func Normalize(v Vec3) Vec3 {
+	mag := math.Sqrt(Dot(v, v))
+	if mag == 0 {
+		return Vec3{}
+	}
+	return Scale(v, 1/mag)
+}
+
+// Project puts v in perspective onto a width by height screen, seen from a
+// camera distance in front of the origin looking along +Z. A unit at depth
+// d spans scale/d columns, and aspect squashes rows for cells taller than
+// they are wide. It returns the cell and v's distance from the camera.
+func Project(v Vec3, distance, scale, aspect float64, width, height int) (int, int, float64) {
+	d := v.Z + distance
+	if d == 0 {
+		d = 0.001
+	}
+	f := scale / d
+	x := int(float64(width)/2 + v.X*f)
+	y := int(float64(height)/2 - v.Y*f*aspect)
+	return x, y, d
+}
+
+// DepthBuffer keeps the nearest depth drawn into each cell of a frame, so
+// surfaces can be plotted in any order.
+type DepthBuffer struct {
+	width, height int
+	depth         []float64
+}
+
+func NewDepthBuffer(width, height int) *DepthBuffer {
+	b := &DepthBuffer{width: width, height: height, depth: make([]float64, width*height)}
+	b.Clear()
+	return b
+}
+
+// Clear forgets every depth.
+func (b *DepthBuffer) Clear() {
+	for i := range b.depth {
+		b.depth[i] = math.MaxFloat64
+	}
+}
+
+// Closer reports whether depth at x, y is nearer than anything drawn there
+// yet, and if so records it. Points off the buffer are never closer.
+func (b *DepthBuffer) Closer(x, y int, depth float64) bool {
+	if x < 0 || x >= b.width || y < 0 || y >= b.height {
+		return false
+	}
+	i := y*b.width + x
+	if depth >= b.depth[i] {
+		return false
+	}
+	b.depth[i] = depth
+	return true
+}
@@ -0,0 +1,99 @@
+package spectrum
+
+import (
+	"math"
+
+	"animinterminal/internal/beat"
+	"animinterminal/internal/canvas"
+)
+
+const (
+	// lowFreq and highFreq bound the frequencies spread across the bars.
+	lowFreq  = 40.0
+	highFreq = 16000.0
+	// dynamicRange is how many dB below the loudest band a bar reaches
+	// the floor.
+	dynamicRange = 48.0
+)
+
+// analyzer turns the latest window of an audio input into bar levels on
+// request.
+type analyzer struct {
+	pcm  *beat.PCM
+	rate int
+
+	hann   []float64
+	re, im []float64
+	peak   float64
+}
+
+// listen starts reading audio from source in the background: "stdin", or
+// the path of a file or FIFO. It returns nil for "none" or a path that does
+// not exist.
+func listen(source string, rate, bins int) *analyzer {
+	if source == "none" {
+		return nil
+	}
+	pcm, err := beat.OpenPCMRate(source, rate)
+	if err != nil {
+		return nil
+	}
+	a := &analyzer{
+		pcm:  pcm,
+		rate: rate,
+		hann: make([]float64, bins),
+		re:   make([]float64, bins),
+		im:   make([]float64, bins),
+		peak: 1e-6,
+	}
+	for i := range a.hann {
+		a.hann[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(bins-1))
+	}
+	return a
+}
+
+// levels fills levels, one per bar from low to high frequencies, with the
+// loudness of the latest window between 0 and 1. It reports false, leaving
+// levels alone, while the input is stalled.
+func (a *analyzer) levels(levels []float64) bool {
+	if !a.pcm.Window(a.re) {
+		return false
+	}
+	n := len(a.re)
+	for i := range a.re {
+		a.re[i] *= a.hann[i]
+		a.im[i] = 0
+	}
+
+	fft(a.re, a.im)
+
+	// Bars are spaced evenly in pitch, so each one spans more bins than
+	// the one before.
+	high := math.Min(highFreq, float64(a.rate)/2)
+	binWidth := float64(a.rate) / float64(n)
+	loudest := 0.0
+	for i := range levels {
+		from := lowFreq * math.Pow(high/lowFreq, float64(i)/float64(len(levels)))
+		to := lowFreq * math.Pow(high/lowFreq, float64(i+1)/float64(len(levels)))
+		lo := max(1, int(from/binWidth))
+		hi := min(n/2, max(lo+1, int(math.Ceil(to/binWidth))))
+		power := 0.0
+		for k := lo; k < hi; k++ {
+			power += a.re[k]*a.re[k] + a.im[k]*a.im[k]
+		}
+		// Music has less energy the higher it goes; tilt by 3dB an octave
+		// so the treble bars move as much as the bass ones.
+		magnitude := math.Sqrt(power/float64(hi-lo)) * math.Sqrt(from/lowFreq)
+		levels[i] = magnitude
+		loudest = math.Max(loudest, magnitude)
+	}
+
+	// Loudness is relative to a slowly decaying peak so quiet tracks still
+	// fill the screen.
+	a.peak = math.Max(loudest, a.peak*0.995)
+	for i, magnitude := range levels {
+		db := 20 * math.Log10(magnitude/a.peak+1e-9)
+		levels[i] = canvas.Clamp(1+db/dynamicRange, 0, 1)
+	}
+	return true
+}
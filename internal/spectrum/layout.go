@@ -0,0 +1,78 @@
+package spectrum
+
+// layout is where the bars go: the line they grow from, which way and how
+// far, and the rows or columns they share between them.
+type layout struct {
+	horizontal bool
+	mirror     bool
+	// base is the row the bars grow up from, or with horizontal the column
+	// they grow right from; with mirror they grow both ways from it.
+	base int
+	// reach is the most cells a bar grows beyond base, and scale how many
+	// a bar at full loudness would.
+	reach int
+	scale float64
+	// first is the first row or column of the lanes the bars are spread
+	// over, lanes how many there are.
+	first, lanes int
+	// waveBottom is the lowest row the waveform may use, waveCenter the row
+	// it swings about and waveSwing how far.
+	waveCenter, waveBottom int
+	waveSwing              float64
+}
+
+// newLayout fits cfg's layout to a width by height grid.
+func newLayout(cfg Config, width, height int) layout {
+	l := layout{horizontal: cfg.Horizontal, mirror: cfg.Mirror}
+	switch {
+	case l.horizontal && l.mirror:
+		l.base, l.reach = width/2, width/2-3
+		l.first, l.lanes = 1, height-2
+	case l.horizontal:
+		l.base, l.reach = 2, width-5
+		l.first, l.lanes = 1, height-2
+	case l.mirror:
+		// The waveform gets the top quarter to itself, and the bars
+		// reach as far down as up.
+		l.base, l.reach = height/2, height/2-height/4
+		l.first, l.lanes = 0, width
+	default:
+		l.base, l.reach = height-2, height-4
+		l.first, l.lanes = 0, width
+	}
+	l.scale = float64(l.reach) * 1.15
+	if !l.horizontal && !l.mirror {
+		l.scale = float64(height) / 1.3
+	}
+
+	l.waveCenter, l.waveBottom, l.waveSwing = height/3, height-5, 2.3
+	if l.mirror && !l.horizontal {
+		// Above the bars and their peaks. The waveform's value stays
+		// within about 1.9 either way.
+		l.waveBottom = l.base - l.reach - 2
+		l.waveCenter = (1 + l.waveBottom) / 2
+		l.waveSwing = float64(l.waveBottom-1) / 2 / 1.9
+	}
+	return l
+}
+
+// count is how many bars there are: n if set, otherwise a third of the
+// columns or one for each row, either way no more than fit.
+func (l layout) count(n int) int {
+	if n > 0 {
+		return min(n, l.lanes)
+	}
+	if l.horizontal {
+		return l.lanes
+	}
+	return max(8, l.lanes/3)
+}
+
+// cell is the cell along cells out from the base in lane across; a
+// negative along is on the mirrored side.
+func (l layout) cell(along, across int) (x, y int) {
+	if l.horizontal {
+		return l.base + along, across
+	}
+	return across, l.base - along
+}
@@ -1,12 +1,16 @@
 package spectrum
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/beat"
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -42,18 +46,56 @@ type Config struct {
 	Width      int
 	Height     int
 	FrameDelay time.Duration
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+	// InputSource is real audio for the bars to follow: "stdin", or the
+	// path of a file or FIFO, carrying raw signed 16-bit little-endian mono
+	// PCM such as `ffmpeg -i song.mp3 -f s16le -ac 1 -ar 44100 -` writes.
+	// "none" keeps the synthetic animation, which also takes over whenever
+	// the input stalls.
+	InputSource string
+	// SampleRate is the input's samples per second.
+	SampleRate int
+	// Bins is how many samples each FFT takes, a power of two; more is
+	// finer in pitch but slower to follow the music.
+	Bins int
+	// BarCount is how many bars to draw, up to one per column (or row);
+	// 0 is a third of the columns, or with Horizontal every row.
+	BarCount int
+	// Mirror grows the bars both ways from a line through the middle,
+	// with the waveform moved above them. Horizontal lays the bars on
+	// their side, one per row, growing from the left, or from the middle
+	// column with Mirror as well.
+	Mirror     bool
+	Horizontal bool
 }
 
 // DefaultConfig returns a preset tuned for a faux-equalizer view.
 func DefaultConfig() Config {
 	return Config{
-		Width:      100,
-		Height:     34,
-		FrameDelay: 45 * time.Millisecond,
+		Width:       100,
+		Height:      34,
+		FrameDelay:  45 * time.Millisecond,
+		InputSource: "none",
+		SampleRate:  44100,
+		Bins:        2048,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidthSpectrum {
 		c.Width = minWidthSpectrum
 	}
@@ -63,190 +105,246 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 45 * time.Millisecond
 	}
+	if c.InputSource == "" {
+		c.InputSource = "none"
+	}
+	if c.SampleRate <= 0 {
+		c.SampleRate = 44100
+	}
+	// Round Bins down to a power of two the FFT can take.
+	bins := 256
+	for bins*2 <= min(c.Bins, beat.MaxWindow) {
+		bins *= 2
+	}
+	c.Bins = bins
+	if c.BarCount < 0 {
+		c.BarCount = 0
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
-}
-
 type bar struct {
 	phase      float64
 	speed      float64
 	offset     float64
 	colorShift int
 	peak       float64
+	// level is the bar's smoothed loudness from the audio input.
+	level float64
 }
 
 // Run launches the spectrum animation loop.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
-	bars := makeBars(max(8, cfg.Width/3))
+	lay := newLayout(cfg, cfg.Width, cfg.Height)
+	bars := makeBars(lay.count(cfg.BarCount), rng)
+	input := listen(cfg.InputSource, cfg.SampleRate, cfg.Bins)
+	levels := make([]float64, len(bars))
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
-	grid := newGrid(cfg.Width, cfg.Height)
-
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
-		drawGrid(grid, frame)
-		drawWaveform(grid, frame)
-		drawBars(grid, bars, frame)
-		drawScanBeam(grid, frame)
-		render(grid)
-		updateBars(bars)
-
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-		for x := range grid[y] {
-			grid[y][x] = cell{glyph: ' ', color: ""}
+	grid := canvas.New(cfg.Width, cfg.Height)
+
+	for frame := 0; limit.Next(); frame++ {
+		grid.Clear()
+		drawGrid(grid, frame, lay)
+		drawWaveform(grid, frame, lay)
+		live := input != nil && input.levels(levels)
+		if live {
+			followLevels(bars, levels)
 		}
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		row := grid[y]
-		for x := range row {
-			row[x] = cell{glyph: ' ', color: ""}
+		drawBars(grid, bars, frame, live, lay)
+		drawScanBeam(grid, frame, lay)
+		grid.Render(screen)
+		updateBars(bars, rng)
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
-func drawGrid(grid [][]cell, frame int) {
+// drawGrid draws the faint dotted graticule and the line the bars grow
+// from.
+func drawGrid(grid canvas.Grid, frame int, lay layout) {
 	height := len(grid)
 	width := len(grid[0])
-	base := height - 1
-	for x := 0; x < width; x++ {
-		setIfEmpty(grid, x, base, '_', gridColor)
-		if x%12 == frame%12 {
-			setIfEmpty(grid, x, base-6, '.', gridColor)
+	if lay.horizontal {
+		line := lay.base - 1
+		if lay.mirror {
+			line = lay.base
+		}
+		for y := 0; y < height; y++ {
+			grid.SetIfEmpty(line, y, '|', gridColor)
+			if y%12 == frame%12 {
+				grid.SetIfEmpty(line+6, y, '.', gridColor)
+				if lay.mirror {
+					grid.SetIfEmpty(line-6, y, '.', gridColor)
+				}
+			}
+		}
+	} else {
+		line := lay.base + 1
+		if lay.mirror {
+			line = lay.base
+		}
+		for x := 0; x < width; x++ {
+			grid.SetIfEmpty(x, line, '_', gridColor)
+			if x%12 == frame%12 {
+				grid.SetIfEmpty(x, line-6, '.', gridColor)
+				if lay.mirror {
+					grid.SetIfEmpty(x, line+6, '.', gridColor)
+				}
+			}
 		}
 	}
 
 	for y := 0; y < height; y += 6 {
 		for x := 0; x < width; x += 2 {
-			setIfEmpty(grid, x, y, '.', gridColor)
+			grid.SetIfEmpty(x, y, '.', gridColor)
 		}
 	}
 }
 
-func drawBars(grid [][]cell, bars []bar, frame int) {
-	height := len(grid)
-	width := len(grid[0])
-	base := height - 2
-	columnWidth := max(1, width/len(bars))
+// drawBars draws each bar at its audio level if live, or its synthetic one
+// otherwise, with a marker where its recent peak was.
+func drawBars(grid canvas.Grid, bars []bar, frame int, live bool, lay layout) {
+	laneWidth := max(1, lay.lanes/len(bars))
+	topGlyph, bottomGlyph := '_', '-'
+	if lay.horizontal {
+		topGlyph, bottomGlyph = '|', '|'
+	}
 
 	for i, b := range bars {
 		amp := barAmplitude(b)
-		barHeight := clampInt(int(amp*(float64(height)/1.3)), 2, height-4)
+		if live {
+			amp = canvas.Clamp(b.level, 0.05, 1.0)
+		}
+		barHeight := canvas.Clamp(int(amp*lay.scale), 2, lay.reach)
 		if float64(barHeight) > bars[i].peak {
 			bars[i].peak = float64(barHeight)
 		}
-		startX := i * columnWidth
+		start := lay.first + i*laneWidth
 
-		for x := startX; x < startX+columnWidth && x < width; x++ {
+		for across := start; across < start+laneWidth && across < lay.first+lay.lanes; across++ {
 			for step := 0; step < barHeight; step++ {
-				y := base - step
-				if y < 0 {
-					continue
-				}
 				color := barColor(step, barHeight, frame+b.colorShift)
 				glyph := barGlyph(step, barHeight)
-				setCell(grid, x, y, glyph, color)
+				x, y := lay.cell(step, across)
+				grid.Set(x, y, glyph, color)
+				if lay.mirror && step > 0 {
+					x, y = lay.cell(-step, across)
+					grid.Set(x, y, glyph, color)
+				}
 			}
 		}
 
-		peakY := base - clampInt(int(math.Round(bars[i].peak)), 1, height-3)
-		center := clampInt(startX+columnWidth/2, 0, width-1)
-		setCell(grid, center, peakY, '_', peakColor)
+		peak := canvas.Clamp(int(math.Round(bars[i].peak)), 1, lay.reach+1)
+		center := canvas.Clamp(start+laneWidth/2, lay.first, lay.first+lay.lanes-1)
+		x, y := lay.cell(peak, center)
+		grid.Set(x, y, topGlyph, peakColor)
+		if lay.mirror {
+			x, y = lay.cell(-peak, center)
+			grid.Set(x, y, bottomGlyph, peakColor)
+		}
 	}
 }
 
-func drawWaveform(grid [][]cell, frame int) {
+// drawWaveform draws the oscilloscope trace across the screen.
+func drawWaveform(grid canvas.Grid, frame int, lay layout) {
 	width := len(grid[0])
-	height := len(grid)
-	center := height / 3
 	for x := 0; x < width; x++ {
 		fx := float64(x)
 		value := math.Sin(fx*0.11+float64(frame)*0.08) +
 			0.6*math.Sin(fx*0.035+float64(frame)*0.025) +
 			0.3*math.Sin(fx*0.23+float64(frame)*0.12)
-		y := clampInt(center-int(value*2.3), 1, height-5)
+		y := canvas.Clamp(lay.waveCenter-int(value*lay.waveSwing), 1, lay.waveBottom)
 		color := tracePalette[(x/4+frame/5)%len(tracePalette)]
-		setCell(grid, x, y, '*', color)
-		if y+1 < height-4 {
-			setCell(grid, x, y+1, '-', color)
+		grid.Set(x, y, '*', color)
+		if y+1 <= lay.waveBottom {
+			grid.Set(x, y+1, '-', color)
 		}
 	}
 }
 
-func drawScanBeam(grid [][]cell, frame int) {
+// drawScanBeam sweeps a beam across the bars: left to right over upright
+// ones, top to bottom over ones on their side.
+func drawScanBeam(grid canvas.Grid, frame int, lay layout) {
 	width := len(grid[0])
 	height := len(grid)
 	if width == 0 {
 		return
 	}
+	if lay.horizontal {
+		beamY := (frame / 2) % height
+		for offset := -1; offset <= 1; offset++ {
+			row := canvas.Clamp(beamY+offset, 0, height-1)
+			color := beamPalette[(offset+len(beamPalette)+frame/8)%len(beamPalette)]
+			for x := 1; x < width-1; x++ {
+				glyph := '-'
+				if (x+frame/3)%4 == 0 {
+					glyph = ':'
+				}
+				grid.SetIfEmpty(x, row, glyph, color)
+			}
+		}
+		return
+	}
 	beamX := (frame / 2) % width
 	for offset := -1; offset <= 1; offset++ {
-		col := clampInt(beamX+offset, 0, width-1)
+		col := canvas.Clamp(beamX+offset, 0, width-1)
 		color := beamPalette[(offset+len(beamPalette)+frame/8)%len(beamPalette)]
 		for y := 1; y < height-2; y++ {
-			glyph := byte('|')
+			glyph := '|'
 			if (y+frame/3)%4 == 0 {
 				glyph = ':'
 			}
-			setIfEmpty(grid, col, y, glyph, color)
+			grid.SetIfEmpty(col, y, glyph, color)
 		}
 	}
 }
 
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
+func barAmplitude(b bar) float64 {
+	wave := math.Sin(b.phase) + 0.7*math.Sin(b.phase*0.5+b.offset)
+	return canvas.Clamp((wave+2.0)/2.7, 0.05, 1.0)
+}
 
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
+// followLevels moves each bar's level toward its new loudness: straight up
+// on a hit, easing back down after.
+func followLevels(bars []bar, levels []float64) {
+	for i := range bars {
+		if levels[i] > bars[i].level {
+			bars[i].level = levels[i]
+		} else {
+			bars[i].level += (levels[i] - bars[i].level) * 0.3
 		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
 	}
-
-	fmt.Print(sb.String())
 }
 
-func barAmplitude(b bar) float64 {
-	wave := math.Sin(b.phase) + 0.7*math.Sin(b.phase*0.5+b.offset)
-	return clampFloat((wave+2.0)/2.7, 0.05, 1.0)
-}
-
-func updateBars(bars []bar) {
+func updateBars(bars []bar, rng *rand.Rand) {
 	for i := range bars {
 		bars[i].phase += bars[i].speed
 		if bars[i].phase > math.Pi*2 {
 			bars[i].phase -= math.Pi * 2
 		}
-		bars[i].speed += (rand.Float64() - 0.5) * 0.005
-		bars[i].speed = clampFloat(bars[i].speed, 0.03, 0.18)
+		bars[i].speed += (rng.Float64() - 0.5) * 0.005
+		bars[i].speed = canvas.Clamp(bars[i].speed, 0.03, 0.18)
 		if bars[i].peak > 0 {
 			bars[i].peak -= 0.35
 			if bars[i].peak < 0 {
@@ -256,14 +354,14 @@ func updateBars(bars []bar) {
 	}
 }
 
-func makeBars(count int) []bar {
+func makeBars(count int, rng *rand.Rand) []bar {
 	result := make([]bar, count)
 	for i := range result {
 		result[i] = bar{
-			phase:      rand.Float64() * math.Pi * 2,
-			speed:      0.05 + rand.Float64()*0.08,
-			offset:     rand.Float64() * math.Pi,
-			colorShift: rand.Intn(len(barPalette)),
+			phase:      rng.Float64() * math.Pi * 2,
+			speed:      0.05 + rng.Float64()*0.08,
+			offset:     rng.Float64() * math.Pi,
+			colorShift: rng.Intn(len(barPalette)),
 		}
 	}
 	return result
@@ -274,11 +372,11 @@ func barColor(step int, total int, frame int) string {
 		return barPalette[0]
 	}
 	ratio := float64(step) / float64(total-1)
-	idx := clampInt(int(ratio*float64(len(barPalette))), 0, len(barPalette)-1)
+	idx := canvas.Clamp(int(ratio*float64(len(barPalette))), 0, len(barPalette)-1)
 	return barPalette[(idx+frame/12)%len(barPalette)]
 }
 
-func barGlyph(step int, total int) byte {
+func barGlyph(step int, total int) rune {
 	ratio := float64(step) / float64(max(1, total-1))
 	switch {
 	case ratio < 0.2:
@@ -289,52 +387,3 @@ func barGlyph(step int, total int) byte {
 		return '='
 	}
 }
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func clampInt(v, lo, hi int) int {
-	if v < lo {
-		return lo
-	}
-	if v > hi {
-		return hi
-	}
-	return v
-}
-
-func clampFloat(v, lo, hi float64) float64 {
-	if v < lo {
-		return lo
-	}
-	if v > hi {
-		return hi
-	}
-	return v
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
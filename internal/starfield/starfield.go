@@ -1,12 +1,15 @@
 package starfield
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
 	"math/rand"
-	"strings"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -17,6 +20,10 @@ const (
 	backdropStride = 4
 	ringCount      = 4
 	spokeCount     = 12
+	// maxOffset is how far the vanishing point may go from the middle, as a
+	// share of half the screen, and steerStep how far an arrow key moves it.
+	maxOffset = 0.85
+	steerStep = 0.08
 )
 
 var (
@@ -47,7 +54,7 @@ var (
 		"\x1b[38;5;117m",
 		"\x1b[38;5;195m",
 	}
-	glyphPalette = []byte{'.', '+', '*'}
+	glyphPalette = []rune{'.', '+', '*'}
 )
 
 // Config controls the starfield animation characteristics.
@@ -57,6 +64,23 @@ type Config struct {
 	FrameDelay time.Duration
 	Density    float64
 	WarpSpeed  float64
+	// Charset draws the stars in ascii, or finer in blocks or braille dots;
+	// the backdrop and tunnel stay ascii either way.
+	Charset string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+	// DriftAmplitude is how far the vanishing point wanders from the middle,
+	// as a share of half the screen up to 0.85, tracing a slow Lissajous
+	// curve DriftSpeed radians a frame; 0 keeps it dead center.
+	DriftAmplitude float64
+	DriftSpeed     float64
 }
 
 // DefaultConfig returns a sensible preset for most terminals.
@@ -67,10 +91,17 @@ func DefaultConfig() Config {
 		FrameDelay: 40 * time.Millisecond,
 		Density:    0.03,
 		WarpSpeed:  0.012,
+		DriftSpeed: 0.01,
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidth {
 		c.Width = minWidth
 	}
@@ -86,14 +117,16 @@ func (c Config) normalize() Config {
 	if c.WarpSpeed <= 0 {
 		c.WarpSpeed = 0.01
 	}
+	if !render.IsCharset(c.Charset) {
+		c.Charset = "ascii"
+	}
+	c.DriftAmplitude = canvas.Clamp(c.DriftAmplitude, 0, maxOffset)
+	if c.DriftSpeed <= 0 {
+		c.DriftSpeed = 0.01
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
-}
-
 type star struct {
 	x, y, z  float64
 	velocity float64
@@ -104,137 +137,172 @@ type star struct {
 	layer    int
 }
 
+// camera steers the vanishing point: where the arrow keys have moved it
+// to, as a share of half the screen each way from the middle.
+type camera struct {
+	steerX, steerY float64
+}
+
+// handle applies a key press: the arrows steer, c centers again.
+func (c *camera) handle(ev term.Event) {
+	switch {
+	case ev.Arrow == term.Left:
+		c.steerX -= steerStep
+	case ev.Arrow == term.Right:
+		c.steerX += steerStep
+	case ev.Arrow == term.Up:
+		c.steerY -= steerStep
+	case ev.Arrow == term.Down:
+		c.steerY += steerStep
+	case ev.Key == 'c' || ev.Key == 'C':
+		*c = camera{}
+	}
+	c.steerX = canvas.Clamp(c.steerX, -maxOffset, maxOffset)
+	c.steerY = canvas.Clamp(c.steerY, -maxOffset, maxOffset)
+}
+
+// center is the vanishing point in cells at t default frames in: the
+// middle of the screen, moved by the drift and the steering.
+func (c camera) center(cfg Config, t float64, width, height int) (float64, float64) {
+	t *= cfg.DriftSpeed
+	ox := canvas.Clamp(c.steerX+cfg.DriftAmplitude*math.Sin(t), -maxOffset, maxOffset)
+	oy := canvas.Clamp(c.steerY+cfg.DriftAmplitude*math.Sin(t*1.5+math.Pi/3), -maxOffset, maxOffset)
+	return float64(width) / 2 * (1 + ox), float64(height) / 2 * (1 + oy)
+}
+
 // Run launches the starfield warp animation.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	// A run given a seed goes a frame at a time, so it repeats exactly.
+	seeded := cfg.Seed != 0
 	cfg = cfg.normalize()
-	rand.Seed(time.Now().UnixNano())
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
 
-	stars := makeStars(cfg)
+	stars := makeStars(cfg, rng)
+	var cam camera
+	events := term.Events(term.NoMouse)
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
-	grid := newGrid(cfg.Width, cfg.Height)
-
-	for frame := 0; ; frame++ {
-		clearGrid(grid)
-		drawBackdrop(grid, frame)
-		drawWarpTunnel(grid, frame)
-		drawStars(grid, stars, cfg, frame)
-		render(grid)
-
-		<-ticker.C
+	grid := canvas.New(cfg.Width, cfg.Height)
+	var dots *render.Dots
+	if cfg.Charset != "ascii" {
+		dots = render.NewDots(cfg.Width, cfg.Height, cfg.Charset)
+	}
+
+	// t is the time in default frames.
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, seeded)
+	for t := 0.0; limit.Next(); {
+		frame := int(t)
+		dt := clock.Tick()
+		cx, cy := cam.center(cfg, t, cfg.Width, cfg.Height)
+		grid.Clear()
+		drawBackdrop(grid, frame, int(cx), int(cy))
+		drawWarpTunnel(grid, t, int(cx), int(cy))
+		drawStars(grid, dots, stars, cfg, frame, dt, cx, cy, rng)
+		grid.Render(screen)
+		t += dt
+
+		for waiting := true; waiting; {
+			select {
+			case ev := <-events:
+				cam.handle(ev)
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-ticker.C:
+				waiting = false
+			}
+		}
 	}
+	return nil
 }
 
-func makeStars(cfg Config) []star {
+func makeStars(cfg Config, rng *rand.Rand) []star {
 	count := int(float64(cfg.Width*cfg.Height) * cfg.Density)
 	if count < 32 {
 		count = 32
 	}
 	stars := make([]star, count)
 	for i := range stars {
-		resetStar(&stars[i], cfg)
+		resetStar(&stars[i], cfg, rng)
 	}
 	return stars
 }
 
-func resetStar(s *star, cfg Config) {
-	s.x = rand.Float64()*2 - 1
-	s.y = rand.Float64()*2 - 1
-	s.layer = rand.Intn(3)
+func resetStar(s *star, cfg Config, rng *rand.Rand) {
+	s.x = rng.Float64()*2 - 1
+	s.y = rng.Float64()*2 - 1
+	s.layer = rng.Intn(3)
 	layerBias := 0.4 + float64(s.layer)*0.18
-	s.z = rand.Float64()*0.9 + layerBias
-	speedVariance := 0.6 + float64(s.layer)*0.25 + rand.Float64()*0.4
+	s.z = rng.Float64()*0.9 + layerBias
+	speedVariance := 0.6 + float64(s.layer)*0.25 + rng.Float64()*0.4
 	s.velocity = cfg.WarpSpeed * speedVariance
-	s.twinkle = rand.Float64() * math.Pi * 2
+	s.twinkle = rng.Float64() * math.Pi * 2
 	s.hasPrev = false
 }
 
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
-	}
-	return grid
-}
-
-func clearGrid(grid [][]cell) {
-	for y := range grid {
-		row := grid[y]
-		for x := range row {
-			row[x] = cell{glyph: ' ', color: ""}
-		}
-	}
-}
-
-func drawBackdrop(grid [][]cell, frame int) {
+func drawBackdrop(grid canvas.Grid, frame, centerX, centerY int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height; y += backdropStride {
 		color := backdropPalette[(y/backdropStride+frame/20)%len(backdropPalette)]
 		for x := (y/2 + frame) % 6; x < width; x += 6 {
-			setIfEmpty(grid, x, y, '.', color)
+			grid.SetIfEmpty(x, y, '.', color)
 		}
 	}
-	centerX := width / 2
-	centerY := height / 2
-	setIfEmpty(grid, centerX, centerY, '+', "\x1b[38;5;238m")
+	grid.SetIfEmpty(centerX, centerY, '+', "\x1b[38;5;238m")
 }
 
-func drawWarpTunnel(grid [][]cell, frame int) {
+// drawWarpTunnel draws the rings and spokes around the vanishing point at
+// centerX, centerY.
+func drawWarpTunnel(grid canvas.Grid, t float64, centerX, centerY int) {
 	width := len(grid[0])
 	height := len(grid)
-	centerX := width / 2
-	centerY := height / 2
 	minDim := float64(min(width, height))
 	baseRadius := minDim * 0.12
 	if baseRadius < 2 {
 		return
 	}
-	pulse := 1 + 0.05*math.Sin(float64(frame)*0.07)
+	frame := int(t)
+	pulse := 1 + 0.05*math.Sin(t*0.07)
 
 	for ring := 1; ring <= ringCount; ring++ {
 		radius := float64(ring) * baseRadius * pulse
 		color := warpRingPalette[(ring+frame/8)%len(warpRingPalette)]
-		drawEllipse(grid, centerX, centerY, radius, radius*0.55, color)
+		grid.Ellipse(centerX, centerY, radius, radius*0.55, '-', color)
 	}
 
 	for spoke := 0; spoke < spokeCount; spoke++ {
-		angle := float64(spoke)/spokeCount*math.Pi*2 + float64(frame)*0.012
+		angle := float64(spoke)/spokeCount*math.Pi*2 + t*0.012
 		color := spokePalette[(spoke+frame/10)%len(spokePalette)]
 		drawSpoke(grid, centerX, centerY, angle, minDim*0.52, color)
 	}
 }
 
-func drawEllipse(grid [][]cell, cx, cy int, rx, ry float64, color string) {
-	steps := int(rx * 6)
-	if steps < 24 {
-		steps = 24
-	}
-	for i := 0; i < steps; i++ {
-		angle := float64(i) / float64(steps) * math.Pi * 2
-		x := cx + int(math.Cos(angle)*rx)
-		y := cy + int(math.Sin(angle)*ry)
-		setIfEmpty(grid, x, y, '-', color)
-	}
-}
-
-func drawSpoke(grid [][]cell, cx, cy int, angle float64, length float64, color string) {
+func drawSpoke(grid canvas.Grid, cx, cy int, angle float64, length float64, color string) {
 	endX := cx + int(math.Cos(angle)*length)
 	endY := cy + int(math.Sin(angle)*length*0.55)
-	points := linePoints(cx, cy, endX, endY)
+	points := canvas.LinePoints(cx, cy, endX, endY)
 	for i := 2; i < len(points); i += 2 {
 		p := points[i]
 		glyph := spokeGlyph(endX-cx, endY-cy)
-		setIfEmpty(grid, p[0], p[1], glyph, color)
+		grid.SetIfEmpty(p[0], p[1], glyph, color)
 	}
 }
 
-func spokeGlyph(dx, dy int) byte {
-	adx := abs(dx)
-	ady := abs(dy)
+func spokeGlyph(dx, dy int) rune {
+	adx := canvas.Abs(dx)
+	ady := canvas.Abs(dy)
 	switch {
 	case adx > ady*2:
 		return '-'
@@ -247,89 +315,149 @@ func spokeGlyph(dx, dy int) byte {
 	}
 }
 
-func drawStars(grid [][]cell, stars []star, cfg Config, frame int) {
+// drawStars moves the stars on and draws them, into dots rather than the
+// grid when there are dots, and then lays the dots over the grid. Stars fly
+// out from the vanishing point cx, cy, dt default frames' worth; any that
+// are off the screen, which a moving one can leave them, start again.
+func drawStars(grid canvas.Grid, dots *render.Dots, stars []star, cfg Config, frame int, dt float64, cx, cy float64, rng *rand.Rand) {
 	width := len(grid[0])
 	height := len(grid)
+	if dots != nil {
+		dots.Clear()
+	}
 	for i := range stars {
-		px, py, ok := projectStar(stars[i], width, height)
+		fx, fy, ok := projectStar(stars[i], width, height, cx, cy)
 		if !ok {
-			resetStar(&stars[i], cfg)
+			resetStar(&stars[i], cfg, rng)
 			continue
 		}
-
-		if stars[i].hasPrev {
-			drawTrail(grid, stars[i].prevX, stars[i].prevY, px, py, stars[i].z)
-		}
-
+		px, py := int(fx), int(fy)
 		color := starColor(stars[i].z, stars[i].twinkle, frame)
-		glyph := starGlyph(stars[i].z, stars[i].twinkle)
-		setCell(grid, px, py, glyph, color)
-		if stars[i].z < 0.4 {
-			drawFlare(grid, px, py, stars[i].z)
+
+		if dots != nil {
+			px, py = dots.Dot(fx, fy)
+			drawStarDots(dots, stars[i], px, py, color)
+		} else {
+			if stars[i].hasPrev {
+				drawTrail(grid, stars[i].prevX, stars[i].prevY, px, py, stars[i].z)
+			}
+			grid.Set(px, py, starGlyph(stars[i].z, stars[i].twinkle), color)
+			if stars[i].z < 0.4 {
+				drawFlare(grid, px, py, stars[i].z)
+			}
 		}
 
 		stars[i].prevX = px
 		stars[i].prevY = py
 		stars[i].hasPrev = true
 
-		stars[i].z -= stars[i].velocity
-		stars[i].twinkle += 0.18
+		stars[i].z -= stars[i].velocity * dt
+		stars[i].twinkle += 0.18 * dt
 		if stars[i].z <= minDepth {
-			resetStar(&stars[i], cfg)
+			resetStar(&stars[i], cfg, rng)
+		}
+	}
+	if dots == nil {
+		return
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if glyph, color, ok := dots.At(x, y); ok {
+				grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
+			}
+		}
+	}
+}
+
+// drawStarDots draws a star at dot x, y with its trail and flare, the star
+// first so the cell it is in takes its color. Near stars are two dots
+// across.
+func drawStarDots(dots *render.Dots, s star, x, y int, color string) {
+	size := 1
+	if s.z < 0.4 {
+		size = 2
+	}
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			dots.Set(x+dx, y+dy, color)
+		}
+	}
+	if s.hasPrev {
+		points := canvas.LinePoints(s.prevX, s.prevY, x, y)
+		trail := trailColor(s.z)
+		for _, p := range points[:len(points)-1] {
+			dots.Set(p[0], p[1], trail)
+		}
+	}
+	if s.z < 0.4 {
+		flare := flareColor(s.z)
+		for d := 2; d <= 3; d++ {
+			dots.Set(x-d, y, flare)
+			dots.Set(x+size-1+d, y, flare)
+			dots.Set(x, y-d, flare)
+			dots.Set(x, y+size-1+d, flare)
 		}
 	}
 }
 
-func projectStar(s star, width, height int) (int, int, bool) {
+// projectStar is where s lands on the screen, in cells, seen toward the
+// vanishing point cx, cy, and whether that is on it at all.
+func projectStar(s star, width, height int, cx, cy float64) (float64, float64, bool) {
 	scale := float64(min(width, height)) * 0.45
 	if s.z <= 0 {
 		return 0, 0, false
 	}
-	x := int(float64(width)/2 + s.x*scale/s.z)
-	y := int(float64(height)/2 + s.y*scale/(s.z*0.9))
-	if x < 0 || x >= width || y < 0 || y >= height {
+	x := cx + s.x*scale/s.z
+	y := cy + s.y*scale/(s.z*0.9)
+	if x < 0 || x >= float64(width) || y < 0 || y >= float64(height) {
 		return 0, 0, false
 	}
 	return x, y, true
 }
 
-func drawTrail(grid [][]cell, x0, y0, x1, y1 int, depth float64) {
-	points := linePoints(x0, y0, x1, y1)
+func drawTrail(grid canvas.Grid, x0, y0, x1, y1 int, depth float64) {
+	points := canvas.LinePoints(x0, y0, x1, y1)
 	if len(points) <= 1 {
 		return
 	}
-	colorIndex := clampInt(int((1-depth)*float64(len(trailPalette))), 0, len(trailPalette)-1)
-	color := trailPalette[colorIndex]
+	color := trailColor(depth)
 	glyph := drawTrailChar(depth)
 	for idx := 0; idx < len(points)-1; idx++ {
 		p := points[idx]
-		setIfEmpty(grid, p[0], p[1], glyph, color)
+		grid.SetIfEmpty(p[0], p[1], glyph, color)
 	}
 }
 
-func drawFlare(grid [][]cell, x, y int, depth float64) {
+func drawFlare(grid canvas.Grid, x, y int, depth float64) {
 	if depth > 0.45 {
 		return
 	}
-	index := clampInt(int((0.5-depth)*float64(len(flarePalette))*1.5), 0, len(flarePalette)-1)
-	color := flarePalette[index]
-	setIfEmpty(grid, x+1, y, '-', color)
-	setIfEmpty(grid, x-1, y, '-', color)
-	setIfEmpty(grid, x, y+1, '|', color)
-	setIfEmpty(grid, x, y-1, '|', color)
-	setIfEmpty(grid, x+1, y+1, '.', color)
-	setIfEmpty(grid, x-1, y-1, '.', color)
-	setIfEmpty(grid, x+1, y-1, '.', color)
-	setIfEmpty(grid, x-1, y+1, '.', color)
+	color := flareColor(depth)
+	grid.SetIfEmpty(x+1, y, '-', color)
+	grid.SetIfEmpty(x-1, y, '-', color)
+	grid.SetIfEmpty(x, y+1, '|', color)
+	grid.SetIfEmpty(x, y-1, '|', color)
+	grid.SetIfEmpty(x+1, y+1, '.', color)
+	grid.SetIfEmpty(x-1, y-1, '.', color)
+	grid.SetIfEmpty(x+1, y-1, '.', color)
+	grid.SetIfEmpty(x-1, y+1, '.', color)
+}
+
+func trailColor(depth float64) string {
+	return trailPalette[canvas.Clamp(int((1-depth)*float64(len(trailPalette))), 0, len(trailPalette)-1)]
+}
+
+func flareColor(depth float64) string {
+	return flarePalette[canvas.Clamp(int((0.5-depth)*float64(len(flarePalette))*1.5), 0, len(flarePalette)-1)]
 }
 
 func starColor(depth float64, twinkle float64, frame int) string {
 	if len(starPalette) == 0 {
 		return ""
 	}
-	intensity := clampFloat(1-depth, 0, 0.95)
+	intensity := canvas.Clamp(1-depth, 0, 0.95)
 	flicker := 0.12 * math.Sin(twinkle+float64(frame)*0.12)
-	ratio := clampFloat(intensity+flicker, 0, 0.95)
+	ratio := canvas.Clamp(intensity+flicker, 0, 0.95)
 	index := int(ratio / 0.35)
 	if index >= len(starPalette) {
 		index = len(starPalette) - 1
@@ -338,11 +466,11 @@ func starColor(depth float64, twinkle float64, frame int) string {
 	return starPalette[(index+offset)%len(starPalette)]
 }
 
-func starGlyph(depth float64, twinkle float64) byte {
+func starGlyph(depth float64, twinkle float64) rune {
 	if len(glyphPalette) == 0 {
 		return '*'
 	}
-	ratio := clampFloat(1-depth+0.1*math.Sin(twinkle), 0, 1)
+	ratio := canvas.Clamp(1-depth+0.1*math.Sin(twinkle), 0, 1)
 	index := int(ratio * float64(len(glyphPalette)))
 	if index >= len(glyphPalette) {
 		index = len(glyphPalette) - 1
@@ -350,7 +478,7 @@ func starGlyph(depth float64, twinkle float64) byte {
 	return glyphPalette[index]
 }
 
-func drawTrailChar(depth float64) byte {
+func drawTrailChar(depth float64) rune {
 	if depth > 0.6 {
 		return '.'
 	}
@@ -359,119 +487,3 @@ func drawTrailChar(depth float64) byte {
 	}
 	return '~'
 }
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func setIfEmpty(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	if grid[y][x].glyph == ' ' {
-		grid[y][x] = cell{glyph: glyph, color: color}
-	}
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			sb.WriteByte(c.glyph)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
-
-func linePoints(x0, y0, x1, y1 int) [][2]int {
-	points := make([][2]int, 0, max(abs(x1-x0), abs(y1-y0))+1)
-	dx := abs(x1 - x0)
-	sx := -1
-	if x0 < x1 {
-		sx = 1
-	}
-	dy := -abs(y1 - y0)
-	sy := -1
-	if y0 < y1 {
-		sy = 1
-	}
-	err := dx + dy
-
-	for {
-		points = append(points, [2]int{x0, y0})
-		if x0 == x1 && y0 == y1 {
-			break
-		}
-		e2 := 2 * err
-		if e2 >= dy {
-			err += dy
-			x0 += sx
-		}
-		if e2 <= dx {
-			err += dx
-			y0 += sy
-		}
-	}
-	return points
-}
-
-func abs(v int) int {
-	if v < 0 {
-		return -v
-	}
-	return v
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func clampFloat(v, minV, maxV float64) float64 {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
-}
-
-func clampInt(v, minV, maxV int) int {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
-}
@@ -0,0 +1,349 @@
+package storm
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"animinterminal/internal/bolt"
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/noise"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 16
+	// flashDecay is how much of the sky flash is left each frame after it.
+	flashDecay = 0.6
+	// afterglow is how many frames a channel keeps glowing after its last
+	// stroke.
+	afterglow = 10
+	// glowRadius is how far around a lit bolt the clouds light up when
+	// the whole-sky flash is toned down.
+	glowRadius = 5
+	// cloudDrift is how many columns a second the cloud texture moves.
+	cloudDrift = 0.6
+)
+
+var (
+	skyGlyphs   = []rune{'.', ':', '-', '=', '+', '*', '#', '%'}
+	skyColors   = []string{"\x1b[38;5;233m", "\x1b[38;5;235m", "\x1b[38;5;237m", "\x1b[38;5;60m", "\x1b[38;5;61m", "\x1b[38;5;103m", "\x1b[38;5;146m", "\x1b[38;5;189m"}
+	groundColor = "\x1b[38;5;232m"
+	// boltColors are the main channel, a branch and a branch of a branch.
+	boltColors    = []string{"\x1b[38;5;231m", "\x1b[38;5;195m", "\x1b[38;5;153m"}
+	distantColors = []string{"\x1b[38;5;146m", "\x1b[38;5;103m", "\x1b[38;5;60m"}
+	glowColors    = []string{"\x1b[38;5;153m", "\x1b[38;5;111m", "\x1b[38;5;68m", "\x1b[38;5;61m", "\x1b[38;5;60m", "\x1b[38;5;237m"}
+)
+
+// Config controls the storm.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Rate is the average number of close strikes a minute; dimmer distant
+	// ones come twice as often.
+	Rate float64
+	// Branchiness, 0 to 1, is how forked the bolts are.
+	Branchiness float64
+	// ReducedMotion lights the clouds around a bolt instead of flashing
+	// the whole sky.
+	ReducedMotion bool
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:       100,
+		Height:      34,
+		FrameDelay:  40 * time.Millisecond,
+		Rate:        8,
+		Branchiness: 0.5,
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 40 * time.Millisecond
+	}
+	if c.Rate <= 0 {
+		c.Rate = 8
+	}
+	if c.Branchiness < 0 {
+		c.Branchiness = 0.5
+	}
+	if c.Branchiness > 1 {
+		c.Branchiness = 1
+	}
+	return c
+}
+
+// strike is one lightning discharge: a bolt that flickers through a few
+// return strokes, then fades as the channel cools.
+type strike struct {
+	bolt    bolt.Bolt
+	strokes []bool
+	age     int
+	distant bool
+}
+
+// newStrike makes a flicker of two to four strokes, each lit for a frame
+// or two with a dark frame or more between.
+func newStrike(b bolt.Bolt, distant bool, rng *rand.Rand) *strike {
+	s := &strike{bolt: b, distant: distant}
+	strokes := 2 + rng.Intn(3)
+	for i := 0; i < strokes; i++ {
+		for on := 1 + rng.Intn(2); on > 0; on-- {
+			s.strokes = append(s.strokes, true)
+		}
+		if i < strokes-1 {
+			for off := 1 + rng.Intn(3); off > 0; off-- {
+				s.strokes = append(s.strokes, false)
+			}
+		}
+	}
+	return s
+}
+
+// lit reports whether the strike is in a stroke this frame.
+func (s *strike) lit() bool {
+	return s.age < len(s.strokes) && s.strokes[s.age]
+}
+
+// fade is how far into its afterglow the strike is, 0 to 1, or -1 while
+// it is still flickering.
+func (s *strike) fade() float64 {
+	if s.age < len(s.strokes) {
+		return -1
+	}
+	return float64(s.age-len(s.strokes)) / afterglow
+}
+
+func (s *strike) done() bool {
+	return s.age >= len(s.strokes)+afterglow
+}
+
+// Run launches the storm.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	ground := horizon(cfg.Width, cfg.Height, rng)
+	clouds := noise.NewPerlin(rng.Int63())
+	glow := make([]float64, cfg.Width*cfg.Height)
+	var strikes []*strike
+	flash := 0.0
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	dt := cfg.FrameDelay.Seconds()
+	for frame := 0; limit.Next(); frame++ {
+		if rng.Float64() < cfg.Rate/60*dt {
+			strikes = append(strikes, closeStrike(ground, cfg, rng))
+		}
+		if rng.Float64() < 2*cfg.Rate/60*dt {
+			strikes = append(strikes, distantStrike(ground, cfg, rng))
+		}
+
+		flash *= flashDecay
+		for i := range glow {
+			glow[i] = 0
+		}
+		for _, s := range strikes {
+			if !s.lit() {
+				continue
+			}
+			if s.distant {
+				addGlow(glow, cfg.Width, s.bolt, 0.25)
+				continue
+			}
+			if cfg.ReducedMotion {
+				addGlow(glow, cfg.Width, s.bolt, 0.8)
+			} else {
+				flash = 1
+			}
+		}
+
+		grid.Clear()
+		drawSky(grid, clouds, ground, glow, flash, float64(frame)*dt)
+		for _, s := range strikes {
+			if s.distant {
+				drawStrike(grid, s, distantColors)
+			}
+		}
+		drawGround(grid, ground)
+		for _, s := range strikes {
+			if !s.distant {
+				drawStrike(grid, s, boltColors)
+			}
+		}
+		grid.Render(screen)
+
+		alive := strikes[:0]
+		for _, s := range strikes {
+			s.age++
+			if !s.done() {
+				alive = append(alive, s)
+			}
+		}
+		strikes = alive
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// closeStrike brings a bolt down from the cloud base to the ridge.
+func closeStrike(ground []int, cfg Config, rng *rand.Rand) *strike {
+	width := len(ground)
+	x0 := float64(width)*0.1 + rng.Float64()*float64(width)*0.8
+	x1 := x0 + (rng.Float64()*2-1)*float64(width)*0.12
+	x1 = math.Max(0, math.Min(x1, float64(width-1)))
+	y0 := float64(cfg.Height) * (0.05 + rng.Float64()*0.15)
+	b := bolt.New(rng, x0, y0, x1, float64(ground[int(x1)]), cfg.Branchiness)
+	return newStrike(b, false, rng)
+}
+
+// distantStrike is a short, dim bolt far off behind the hills.
+func distantStrike(ground []int, cfg Config, rng *rand.Rand) *strike {
+	width := len(ground)
+	x0 := rng.Float64() * float64(width)
+	y1 := float64(cfg.Height) * 0.72
+	y0 := y1 - float64(cfg.Height)*(0.15+rng.Float64()*0.2)
+	x1 := x0 + (rng.Float64()*2-1)*float64(width)*0.04
+	b := bolt.New(rng, x0, y0, x1, y1, cfg.Branchiness*0.5)
+	return newStrike(b, true, rng)
+}
+
+// horizon is the first row of hill in each column: a low ridge with the
+// odd pine standing up out of it.
+func horizon(width, height int, rng *rand.Rand) []int {
+	ground := make([]int, width)
+	base := float64(height) * 0.8
+	p1, p2 := rng.Float64()*6, rng.Float64()*6
+	for x := range ground {
+		fx := float64(x)
+		h := math.Sin(fx*0.045+p1)*float64(height)*0.05 + math.Sin(fx*0.13+p2)*float64(height)*0.02
+		ground[x] = int(base - h)
+	}
+	for x := 2; x < width-2; x++ {
+		if rng.Float64() > 0.06 {
+			continue
+		}
+		tall := 2 + rng.Intn(3)
+		ground[x] -= tall
+		ground[x-1] = min(ground[x-1], ground[x]+tall/2+1)
+		ground[x+1] = min(ground[x+1], ground[x]+tall/2+1)
+		x += 3
+	}
+	return ground
+}
+
+// addGlow lights the cells around every point of b, brightest at the bolt.
+func addGlow(glow []float64, width int, b bolt.Bolt, strength float64) {
+	height := len(glow) / width
+	b.Draw(func(x, y int, _ rune, _ int) {
+		for gy := y - glowRadius/2; gy <= y+glowRadius/2; gy++ {
+			for gx := x - glowRadius; gx <= x+glowRadius; gx++ {
+				if gx < 0 || gx >= width || gy < 0 || gy >= height {
+					continue
+				}
+				d := math.Hypot(float64(gx-x), float64(gy-y)*2) / glowRadius
+				if d >= 1 {
+					continue
+				}
+				i := gy*width + gx
+				glow[i] = math.Max(glow[i], strength*(1-d))
+			}
+		}
+	})
+}
+
+// drawSky shades the clouds above the hills: barely there in the dark,
+// lit up by a flash, which reaches the thick cloud at the top most.
+func drawSky(grid canvas.Grid, clouds noise.Field, ground []int, glow []float64, flash, t float64) {
+	width := len(grid[0])
+	for x := 0; x < width; x++ {
+		for y := 0; y < ground[x] && y < len(grid); y++ {
+			depth := 1 - float64(y)/float64(ground[x])
+			thick := clouds.At((float64(x)+t*cloudDrift)*0.06, float64(y)*0.2) * (0.4 + 0.6*depth)
+			v := 0.3*thick*thick + flash*(0.2+0.55*thick) + glow[y*width+x]*(0.5+0.5*thick)
+			if v < 0.06 {
+				continue
+			}
+			v = math.Min(v, 0.999)
+			grid.Set(x, y, skyGlyphs[int(v*float64(len(skyGlyphs)))], skyColors[int(v*float64(len(skyColors)))])
+		}
+	}
+}
+
+// drawGround fills the hills in solid near-black, so they stand out as a
+// silhouette when the sky behind them lights up.
+func drawGround(grid canvas.Grid, ground []int) {
+	for x, top := range ground {
+		for y := max(0, top); y < len(grid); y++ {
+			grid.Set(x, y, '#', groundColor)
+		}
+	}
+}
+
+// drawStrike draws the bolt white hot during a stroke, and cooling through
+// the glow colors after the last one. It is dark between strokes.
+func drawStrike(grid canvas.Grid, s *strike, colors []string) {
+	fade := s.fade()
+	if fade < 0 && !s.lit() {
+		return
+	}
+	s.bolt.Draw(func(x, y int, glyph rune, depth int) {
+		color := colors[min(depth, len(colors)-1)]
+		if fade >= 0 {
+			if depth > 0 && fade > 0.3 {
+				return
+			}
+			color = glowColors[min(len(glowColors)-1, int(fade*float64(len(glowColors))))]
+		}
+		grid.Set(x, y, glyph, color)
+	})
+}
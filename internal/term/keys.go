@@ -0,0 +1,373 @@
+package term
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// readTimeout is how long a read of the terminal waits for a key
+	// before coming back empty, so the reader can see it has been stopped.
+	readTimeout = "1" // tenths of a second, for stty time
+	// endOfInput is how quickly an empty read has to come back for it to
+	// be the end of the input, not a read that timed out.
+	endOfInput = 50 * time.Millisecond
+)
+
+var (
+	// restoreInput undoes listen; Restore calls it so the terminal gets its
+	// echo back and the host program its stdin.
+	restoreInput func()
+	// input is the reader of stdin from listen until Restore, nil when
+	// stdin is not a terminal.
+	inputMu sync.Mutex
+	input   *reader
+	// lastInput is when stdin last had anything to read, in Unix
+	// nanoseconds, or zero before it first has; started stands in until
+	// then.
+	lastInput atomic.Int64
+	started   = time.Now()
+)
+
+// listen switches the terminal to unbuffered, no-echo input and starts
+// reading it. 'q' and a lone Esc call quit with ErrQuit, so every mode can
+// be left without a signal; all other keys go to Keys. Restore stops the
+// reader and puts the terminal back.
+func listen(quit context.CancelCauseFunc) {
+	if restoreInput != nil {
+		return
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return
+	}
+	// min 0 lets a read come back empty after the timeout, so the reader
+	// never sits in a read that would take the host's next key.
+	if _, err := stty("-icanon", "-echo", "min", "0", "time", readTimeout); err != nil {
+		return
+	}
+	r := newReader(os.Stdin, quit)
+	setInput(r)
+	restoreInput = func() {
+		setInput(nil)
+		r.stop()
+		stty(saved)
+	}
+}
+
+func setInput(r *reader) {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+	input = r
+}
+
+func currentInput() *reader {
+	inputMu.Lock()
+	defer inputMu.Unlock()
+	return input
+}
+
+// reader reads keys from a terminal for one run.
+type reader struct {
+	// keys is every key press but the quit keys; it is closed once the
+	// reader stops.
+	keys chan byte
+	quit context.CancelCauseFunc
+	// done is closed to stop the reader, and stopped once it has.
+	done, stopped chan struct{}
+	// events is keys decoded, for Events; decoding starts on the first
+	// call, and mouse is the most reporting asked for since.
+	decode sync.Once
+	events chan Event
+	mouse  Mouse
+}
+
+// newReader starts reading keys from in, calling quit for the quit keys.
+func newReader(in io.Reader, quit context.CancelCauseFunc) *reader {
+	r := &reader{
+		keys:    make(chan byte, 64),
+		quit:    quit,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	raw := make(chan byte, 64)
+	go r.read(in, raw)
+	go r.filterQuit(raw)
+	return r
+}
+
+// stop ends the reader, waiting for its last read to come back.
+func (r *reader) stop() {
+	close(r.done)
+	<-r.stopped
+}
+
+// read passes what in has on to raw until stopped or in ends.
+func (r *reader) read(in io.Reader, raw chan<- byte) {
+	defer close(r.stopped)
+	defer close(raw)
+	buf := make([]byte, 64)
+	for {
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+		asked := time.Now()
+		n, err := in.Read(buf)
+		if n > 0 {
+			lastInput.Store(time.Now().UnixNano())
+		}
+		for _, b := range buf[:n] {
+			select {
+			case raw <- b:
+			case <-r.done:
+				return
+			}
+		}
+		if err != nil {
+			// A terminal read that times out comes back empty, which
+			// reads as the end of the input; a real end comes at once.
+			if err == io.EOF && time.Since(asked) >= endOfInput {
+				continue
+			}
+			return
+		}
+	}
+}
+
+// filterQuit passes raw on to keys, quitting on 'q' or on an Esc that no
+// escape sequence follows.
+func (r *reader) filterQuit(raw <-chan byte) {
+	defer close(r.keys)
+	for b := range raw {
+		switch b {
+		case 'q', 'Q':
+			r.quit(ErrQuit)
+		case 0x1b:
+			seq, open := escapeSequence(raw)
+			if len(seq) == 1 {
+				r.quit(ErrQuit)
+			} else {
+				r.send(seq...)
+			}
+			if !open {
+				return
+			}
+		default:
+			r.send(b)
+		}
+	}
+}
+
+// escapeSequence reads what follows an ESC from raw: one more byte, or for
+// a CSI sequence everything up to its final byte. It returns the sequence,
+// ESC and all, and whether raw is still open. An ESC that nothing follows
+// within escapeWait comes back on its own.
+func escapeSequence(raw <-chan byte) ([]byte, bool) {
+	seq := []byte{0x1b}
+	for {
+		select {
+		case b, ok := <-raw:
+			if !ok {
+				return seq, false
+			}
+			seq = append(seq, b)
+			csi := seq[1] == '['
+			if !csi || len(seq) > 2 && b >= 0x40 && b <= 0x7e || len(seq) == maxEscape {
+				return seq, true
+			}
+		case <-time.After(escapeWait):
+			return seq, true
+		}
+	}
+}
+
+// send hands bs to Keys, dropping them if nobody is keeping up. They go
+// all together or not at all, so an escape sequence is never cut short.
+func (r *reader) send(bs ...byte) {
+	if cap(r.keys)-len(r.keys) < len(bs) {
+		return
+	}
+	// Only this goroutine sends, so the room can only grow.
+	for _, b := range bs {
+		r.keys <- b
+	}
+}
+
+// Keys delivers each key press on the returned channel, other than the
+// quit keys, until the terminal is restored, when it is closed. When stdin
+// is not a terminal it returns nil, which never receives.
+func Keys() <-chan byte {
+	r := currentInput()
+	if r == nil {
+		return nil
+	}
+	return r.keys
+}
+
+// Idle is how long it has been since the last key press or mouse report,
+// or since the program started if there has not been one.
+func Idle() time.Duration {
+	if last := lastInput.Load(); last != 0 {
+		return time.Since(time.Unix(0, last))
+	}
+	return time.Since(started)
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+const (
+	// mouseClicks asks for button presses and releases in SGR encoding,
+	// mouseMotion for every pointer motion as well; mouseOff undoes either.
+	mouseClicks = "\x1b[?1000h\x1b[?1006h"
+	mouseMotion = "\x1b[?1003h\x1b[?1006h"
+	mouseOff    = "\x1b[?1003l\x1b[?1000l\x1b[?1006l"
+	// escapeWait is how long to wait for the rest of an escape sequence
+	// before treating ESC as a key of its own.
+	escapeWait = 30 * time.Millisecond
+	// maxEscape is the longest escape sequence passed on whole; an SGR
+	// mouse report is at most about 16 bytes.
+	maxEscape = 32
+)
+
+// Mouse is how much of the mouse Events reports.
+type Mouse int
+
+const (
+	// NoMouse leaves mouse reporting off.
+	NoMouse Mouse = iota
+	// MouseClicks reports the buttons going down and up.
+	MouseClicks
+	// MouseMotion reports every move of the pointer too.
+	MouseMotion
+)
+
+// Arrow keys as reported in Event.Arrow.
+const (
+	Up    = 'A'
+	Down  = 'B'
+	Right = 'C'
+	Left  = 'D'
+)
+
+// Event is one decoded input: a plain key, an arrow key or, with mouse
+// reporting on, the pointer's cell (0-based). Click is set on a mouse event
+// for a button going down, rather than the pointer moving, a button coming
+// back up or the wheel turning.
+type Event struct {
+	Key   byte
+	Arrow byte
+	Mouse bool
+	Click bool
+	X, Y  int
+}
+
+// Events is Keys with escape sequences decoded into arrow keys and, as far
+// as mouse asks, pointer positions. Every call until the terminal is
+// restored returns the same channel, closed then, and once it has been
+// asked for Keys gets nothing more. Restore turns mouse reporting back off.
+func Events(mouse Mouse) <-chan Event {
+	r := currentInput()
+	if r == nil {
+		return nil
+	}
+	if mouse > r.mouse {
+		if r.mouse == NoMouse {
+			restoreKeys := restoreInput
+			restoreInput = func() {
+				fmt.Fprint(out, mouseOff)
+				restoreKeys()
+			}
+		}
+		r.mouse = mouse
+		if mouse == MouseMotion {
+			fmt.Fprint(out, mouseMotion)
+		} else {
+			fmt.Fprint(out, mouseClicks)
+		}
+	}
+	r.decode.Do(func() {
+		r.events = make(chan Event, 16)
+		go r.decodeKeys()
+	})
+	return r.events
+}
+
+// decodeKeys turns keys into events until the reader stops.
+func (r *reader) decodeKeys() {
+	defer close(r.events)
+	for b := range r.keys {
+		ev, ok := Event{Key: b}, true
+		if b == 0x1b {
+			ev, ok = decodeEscape(r.keys)
+		}
+		if !ok {
+			continue
+		}
+		select {
+		case r.events <- ev:
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// decodeEscape reads the rest of a CSI sequence after ESC.
+func decodeEscape(keys <-chan byte) (Event, bool) {
+	next := func() (byte, bool) {
+		select {
+		case b, ok := <-keys:
+			return b, ok
+		case <-time.After(escapeWait):
+			return 0, false
+		}
+	}
+	if b, ok := next(); !ok || b != '[' {
+		return Event{Key: 0x1b}, !ok
+	}
+	b, ok := next()
+	if !ok {
+		return Event{}, false
+	}
+	switch b {
+	case Up, Down, Right, Left:
+		return Event{Arrow: b}, true
+	case '<':
+		// SGR mouse report: ESC [ < button ; x ; y (M|m), M for a press
+		// or motion and m for a release. The button's 32 bit marks motion
+		// and 64 and up are the wheel.
+		var params []byte
+		var final byte
+		for {
+			c, ok := next()
+			if !ok {
+				return Event{}, false
+			}
+			if c == 'M' || c == 'm' {
+				final = c
+				break
+			}
+			params = append(params, c)
+		}
+		var button, x, y int
+		if _, err := fmt.Sscanf(string(params), "%d;%d;%d", &button, &x, &y); err != nil {
+			return Event{}, false
+		}
+		click := final == 'M' && button&32 == 0 && button < 64
+		return Event{Mouse: true, Click: click, X: x - 1, Y: y - 1}, true
+	}
+	return Event{}, false
+}
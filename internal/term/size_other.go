@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package term
+
+import "errors"
+
+// Size is not supported here; callers fall back to their own defaults.
+func Size() (int, int, error) {
+	return 0, 0, errors.New("terminal size not supported on this platform")
+}
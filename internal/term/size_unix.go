@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package term
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the kernel's struct winsize.
+type winsize struct {
+	rows, cols     uint16
+	xpixel, ypixel uint16
+}
+
+// Size reports how many columns and rows the terminal on stdout has. It
+// fails when stdout is not a terminal, such as when it is piped.
+func Size() (int, int, error) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	if ws.cols == 0 || ws.rows == 0 {
+		return 0, 0, errors.New("terminal reports no size")
+	}
+	return int(ws.cols), int(ws.rows), nil
+}
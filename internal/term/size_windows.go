@@ -0,0 +1,45 @@
+//go:build windows
+
+package term
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var getConsoleScreenBufferInfo = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleScreenBufferInfo")
+
+type coord struct {
+	x, y int16
+}
+
+type smallRect struct {
+	left, top, right, bottom int16
+}
+
+// consoleScreenBufferInfo is the Win32 CONSOLE_SCREEN_BUFFER_INFO.
+type consoleScreenBufferInfo struct {
+	size       coord
+	cursor     coord
+	attributes uint16
+	window     smallRect
+	maximum    coord
+}
+
+// Size reports how many columns and rows the console window on stdout
+// has. It fails when stdout is not a console, such as when it is piped.
+func Size() (int, int, error) {
+	var info consoleScreenBufferInfo
+	ok, _, err := getConsoleScreenBufferInfo.Call(os.Stdout.Fd(), uintptr(unsafe.Pointer(&info)))
+	if ok == 0 {
+		return 0, 0, err
+	}
+	cols := int(info.window.right-info.window.left) + 1
+	rows := int(info.window.bottom-info.window.top) + 1
+	if cols <= 0 || rows <= 0 {
+		return 0, 0, errors.New("console reports no size")
+	}
+	return cols, rows, nil
+}
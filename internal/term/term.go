@@ -1,7 +1,10 @@
 package term
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,30 +18,58 @@ const (
 	Home        = "\x1b[H"
 )
 
-// Start hides the cursor (and clears the screen if requested) and installs a SIGINT/SIGTERM
-// handler to restore terminal state. The returned cleanup must be deferred by callers.
-func Start(clear bool) func() {
-	fmt.Print(HideCursor)
+// ErrQuit is the cause of a run that 'q' or Esc ended, and ErrInterrupted
+// of one that SIGINT (Ctrl-C) or SIGTERM did.
+var (
+	ErrQuit        = errors.New("quit")
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// out is the writer frames go to, as given to Start; Restore writes there
+// too.
+var out io.Writer = os.Stdout
+
+// Start hides the cursor on w (and clears the screen if requested) and
+// starts reading keys. The context it returns is ctx, also cancelled when
+// 'q' or Esc is pressed, with the cause ErrQuit, or on SIGINT or SIGTERM,
+// with ErrInterrupted; a mode runs until it is done and returns
+// context.Cause. Nothing exits the program, so a mode can be embedded in
+// another one. The returned cleanup must be deferred by callers: it stops
+// reading keys and watching for signals and puts the terminal back.
+func Start(ctx context.Context, w io.Writer, clear bool) (context.Context, func()) {
+	out = w
+	fmt.Fprint(out, HideCursor)
 	if clear {
-		fmt.Print(ClearScreen)
+		fmt.Fprint(out, ClearScreen)
 	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	listen(cancel)
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
-
+	done := make(chan struct{})
 	go func() {
-		<-sig
-		Restore()
-		os.Exit(1)
+		select {
+		case <-sig:
+			cancel(ErrInterrupted)
+		case <-done:
+		}
 	}()
 
-	return func() {
+	return ctx, func() {
 		signal.Stop(sig)
+		close(done)
 		Restore()
+		cancel(nil)
 	}
 }
 
-// Restore shows the cursor and resets terminal attributes.
+// Restore shows the cursor, resets terminal attributes, stops reading keys
+// and turns line buffering and echo back on.
 func Restore() {
-	fmt.Print(ShowCursor, Reset)
+	fmt.Fprint(out, ShowCursor, Reset)
+	if restoreInput != nil {
+		restoreInput()
+		restoreInput = nil
+	}
 }
@@ -0,0 +1,156 @@
+package term
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartCancel(t *testing.T) {
+	var out bytes.Buffer
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, cleanup := Start(parent, &out, true)
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("cancelling the parent did not cancel Start's context")
+	}
+	cleanup()
+	if !errors.Is(context.Cause(ctx), context.Canceled) {
+		t.Errorf("cause is %v, want context.Canceled", context.Cause(ctx))
+	}
+	if !strings.HasSuffix(out.String(), ShowCursor+Reset) {
+		t.Errorf("terminal not put back: output ends %q", out.String())
+	}
+}
+
+func TestReaderQuit(t *testing.T) {
+	in, w := io.Pipe()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	r := newReader(in, cancel)
+	w.Write([]byte("aq"))
+	if k := <-r.keys; k != 'a' {
+		t.Errorf("got key %q, want 'a'", k)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("q did not quit")
+	}
+	if !errors.Is(context.Cause(ctx), ErrQuit) {
+		t.Errorf("cause is %v, want ErrQuit", context.Cause(ctx))
+	}
+
+	// Once stopped the reader lets go of its input, and Keys closes.
+	w.Close()
+	r.stop()
+	if _, ok := <-r.keys; ok {
+		t.Error("keys still open after stop")
+	}
+}
+
+func TestEventsShared(t *testing.T) {
+	defer setInput(nil)
+	session := func() (*reader, *io.PipeWriter) {
+		in, w := io.Pipe()
+		r := newReader(in, func(error) {})
+		setInput(r)
+		return r, w
+	}
+	expect := func(events <-chan Event, keys string) {
+		t.Helper()
+		for i := 0; i < len(keys); i++ {
+			select {
+			case ev := <-events:
+				if ev.Key != keys[i] {
+					t.Fatalf("got key %q, want %q", ev.Key, keys[i])
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("key %q lost", keys[i])
+			}
+		}
+	}
+
+	// A mode that asks twice gets every key on either channel.
+	r, w := session()
+	first := Events(NoMouse)
+	if Events(NoMouse) != first {
+		t.Fatal("second call to Events got a channel of its own")
+	}
+	w.Write([]byte("ab"))
+	expect(first, "ab")
+	w.Close()
+	r.stop()
+	if _, ok := <-first; ok {
+		t.Error("events still open after stop")
+	}
+
+	// Nothing left over from the run before takes the next run's keys.
+	r, w = session()
+	events := Events(NoMouse)
+	w.Write([]byte("xyz"))
+	expect(events, "xyz")
+	w.Close()
+	r.stop()
+}
+
+// TestSendWholeSequences fills Keys almost up and checks a mouse report
+// that no longer fits is left out whole rather than cut short.
+func TestSendWholeSequences(t *testing.T) {
+	in, w := io.Pipe()
+	r := newReader(in, func(error) {})
+	defer func() {
+		w.Close()
+		r.stop()
+	}()
+
+	room := cap(r.keys)
+	fill := strings.Repeat("a", room-4)
+	w.Write([]byte(fill + "\x1b[<0;5;5M" + "b"))
+	for deadline := time.Now().Add(time.Second); len(r.keys) < room-3 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	var got []byte
+	for len(r.keys) > 0 {
+		got = append(got, <-r.keys)
+	}
+	if want := fill + "b"; string(got) != want {
+		t.Errorf("got keys %q, want %q", got, want)
+	}
+
+	// With room again the report comes through in one piece.
+	w.Write([]byte("\x1b[<0;5;5M"))
+	for _, want := range []byte("\x1b[<0;5;5M") {
+		if k := <-r.keys; k != want {
+			t.Fatalf("got key %q, want %q", k, want)
+		}
+	}
+}
+
+// TestDecodeEscapeClosed cuts a mouse report short by closing the keys and
+// checks decodeEscape gives up on it rather than reading zeros forever.
+func TestDecodeEscapeClosed(t *testing.T) {
+	keys := make(chan byte, 8)
+	for _, b := range []byte("[<0;5") {
+		keys <- b
+	}
+	close(keys)
+	done := make(chan bool)
+	go func() {
+		_, ok := decodeEscape(keys)
+		done <- ok
+	}()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("decoded an event from a cut-off report")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decodeEscape did not return after keys closed")
+	}
+}
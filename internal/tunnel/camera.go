@@ -0,0 +1,40 @@
+package tunnel
+
+import "math"
+
+// camera is where the viewer looks down the tube: the vanishing point drifts
+// off the screen center on a slow winding path and the view banks into the
+// turns.
+type camera struct {
+	// fx, fy offset the vanishing point as a fraction of the half screen.
+	fx, fy float64
+	roll   float64
+}
+
+// cameraAt follows a sum of slow sines along the flight clock, so reversing
+// or slowing down winds back along the same path. amount scales the sway and
+// rate its frequency; amount 0 keeps the camera still and centered.
+func cameraAt(clock, amount, rate float64) camera {
+	c := clock * rate
+	return camera{
+		fx:   amount * 0.35 * (math.Sin(c*0.011) + 0.5*math.Sin(c*0.027+1.3)),
+		fy:   amount * 0.3 * (math.Sin(c*0.014+0.7) + 0.4*math.Sin(c*0.031)),
+		roll: amount * 0.35 * math.Cos(c*0.011),
+	}
+}
+
+// center is the vanishing point in cells.
+func (c camera) center(width, height int) (int, int) {
+	cx := float64(width) / 2 * (1 + c.fx)
+	cy := float64(height) / 2 * (1 + c.fy)
+	return int(math.Round(cx)), int(math.Round(cy))
+}
+
+// rotate turns an offset from the vanishing point into the banked frame.
+func (c camera) rotate(dx, dy float64) (float64, float64) {
+	if c.roll == 0 {
+		return dx, dy
+	}
+	sin, cos := math.Sincos(-c.roll)
+	return dx*cos - dy*sin, dx*sin + dy*cos
+}
@@ -0,0 +1,95 @@
+package tunnel
+
+import "time"
+
+const (
+	// slowFrames in a row over budget switch to half resolution.
+	slowFrames = 10
+	// fastFrames in a row with room to spare switch back.
+	fastFrames = 60
+	// halfCost is roughly how much cheaper a half resolution frame is, used
+	// to guess whether a full one would fit again.
+	halfCost = 3
+)
+
+// detail decides whether the wall field is computed for every cell or on a
+// half resolution grid and interpolated, from how long recent frames took
+// against the frame delay.
+type detail struct {
+	half   bool
+	budget time.Duration
+	streak int
+}
+
+func newDetail(frameDelay time.Duration) *detail {
+	return &detail{budget: frameDelay * 8 / 10}
+}
+
+func (d *detail) observe(elapsed time.Duration) {
+	var switching bool
+	if d.half {
+		switching = elapsed*halfCost < d.budget*7/10
+	} else {
+		switching = elapsed > d.budget
+	}
+	if !switching {
+		d.streak = 0
+		return
+	}
+	d.streak++
+	if (!d.half && d.streak >= slowFrames) || (d.half && d.streak >= fastFrames) {
+		d.half = !d.half
+		d.streak = 0
+	}
+}
+
+func newField(width, height int) [][]float64 {
+	field := make([][]float64, height)
+	for y := range field {
+		field[y] = make([]float64, width)
+	}
+	return field
+}
+
+func sampleFull(field [][]float64, value func(x, y int) float64) {
+	for y := range field {
+		for x := range field[y] {
+			field[y][x] = value(x, y)
+		}
+	}
+}
+
+// sampleHalf evaluates value on every other row and column, plus the last
+// ones, and fills the cells between by bilinear interpolation. It works on
+// the raw intensity so quantizing to glyphs afterwards stays crisp.
+func sampleHalf(field [][]float64, value func(x, y int) float64) {
+	height := len(field)
+	width := len(field[0])
+	for y := 0; y < height; y = nextSample(y, height) {
+		for x := 0; x < width; x = nextSample(x, width) {
+			field[y][x] = value(x, y)
+		}
+	}
+	for y := 0; y < height; y = nextSample(y, height) {
+		for x := 0; x+1 < width; x = nextSample(x, width) {
+			if nx := nextSample(x, width); nx-x == 2 {
+				field[y][x+1] = (field[y][x] + field[y][nx]) / 2
+			}
+		}
+	}
+	for y := 0; y+1 < height; y = nextSample(y, height) {
+		if ny := nextSample(y, height); ny-y == 2 {
+			for x := 0; x < width; x++ {
+				field[y+1][x] = (field[y][x] + field[ny][x]) / 2
+			}
+		}
+	}
+}
+
+// nextSample steps two cells but never skips the last one.
+func nextSample(i, n int) int {
+	if i+2 >= n && i != n-1 {
+		return n - 1
+	}
+	return i + 2
+}
@@ -0,0 +1,33 @@
+package tunnel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+// BenchmarkDraw times drawTunnel alone, at full and at half resolution, for
+// a few terminal sizes; nothing is written to a terminal.
+func BenchmarkDraw(b *testing.B) {
+	cfg := DefaultConfig()
+	cfg.FrameDelay = time.Second
+	for _, size := range []struct{ width, height int }{{100, 34}, {200, 60}, {300, 80}} {
+		for _, half := range []bool{false, true} {
+			resolution := "full"
+			if half {
+				resolution = "half"
+			}
+			b.Run(fmt.Sprintf("%dx%d/%s", size.width, size.height, resolution), func(b *testing.B) {
+				grid := canvas.New(size.width, size.height)
+				v := testView(cfg, size.width, size.height)
+				v.detail.half = half
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					drawTunnel(grid, i, float64(i), v)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tunnel
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	minSpeed = 0.25
+	maxSpeed = 4.0
+	// speedStep is how much one '[' or ']' press scales the target speed.
+	speedStep     = 1.25
+	indicatorTime = 2 * time.Second
+)
+
+var indicatorColor = "\x1b[38;5;159m"
+
+// flight is the motion through the tunnel. Every moving part reads clock
+// instead of the frame count, and clock advances by velocity each default
+// frame, so speed changes and reversals ease in without the pattern jumping.
+type flight struct {
+	clock    float64
+	velocity float64
+	speed    float64
+	dir      float64
+	shown    int
+	showFor  int
+}
+
+func newFlight(frameDelay time.Duration) *flight {
+	return &flight{
+		velocity: 1,
+		speed:    1,
+		dir:      1,
+		showFor:  int(indicatorTime / frameDelay),
+	}
+}
+
+// key handles 'r' to reverse and '[' / ']' to slow down or speed up.
+func (f *flight) key(k byte) {
+	switch k {
+	case 'r', 'R':
+		f.dir = -f.dir
+	case '[':
+		f.speed = math.Max(minSpeed, f.speed/speedStep)
+	case ']':
+		f.speed = math.Min(maxSpeed, f.speed*speedStep)
+	default:
+		return
+	}
+	f.shown = f.showFor
+}
+
+// advance eases the velocity toward the chosen speed, scaled by boost (1
+// unless the music is driving it), and moves the clock on by dt default
+// frames.
+func (f *flight) advance(boost, dt float64) {
+	f.velocity += (f.speed*f.dir*boost - f.velocity) * math.Min(1, 0.08*dt)
+	f.clock += f.velocity * dt
+	if f.shown > 0 {
+		f.shown--
+	}
+}
+
+// drawIndicator shows the target speed in the bottom left corner for a
+// couple of seconds after a key press.
+func (f *flight) drawIndicator(grid canvas.Grid) {
+	if f.shown == 0 {
+		return
+	}
+	arrow := ">>"
+	if f.dir < 0 {
+		arrow = "<<"
+	}
+	label := fmt.Sprintf(" %s SPEED %.2fx ", arrow, f.speed)
+	y := len(grid) - 2
+	grid.Text(2, y, label, indicatorColor)
+}
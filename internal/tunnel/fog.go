@@ -0,0 +1,78 @@
+package tunnel
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/color"
+)
+
+// fogLevels is how many fogged copies of the palette are prepared; truecolor
+// gets finer steps since every one of them is a distinct color.
+const (
+	fogLevels          = 8
+	fogLevelsTruecolor = 24
+)
+
+// fog mixes the wall colors toward a fog color the deeper they are.
+// palettes[level] is colorPalette mixed level/(len-1) of the way.
+type fog struct {
+	density  float64
+	palettes [][]string
+}
+
+// newFog prepares the fogged palettes; call it after usePalette.
+func newFog(density float64, tint color.RGB) *fog {
+	f := &fog{density: density}
+	if density <= 0 {
+		return f
+	}
+	profile := color.Active()
+	levels := fogLevels
+	if profile == color.TrueColor {
+		levels = fogLevelsTruecolor
+	}
+	f.palettes = make([][]string, levels)
+	for level := range f.palettes {
+		mix := float64(level) / float64(levels-1)
+		f.palettes[level] = make([]string, len(colorRamp))
+		for i, c := range colorRamp {
+			f.palettes[level][i] = color.Mix(c, tint, mix).Render(profile)
+		}
+	}
+	return f
+}
+
+// amount is how far to fog a cell with the given depth term; the center of
+// the tunnel is deepest.
+func (f *fog) amount(depth float64) float64 {
+	return f.density * canvas.Clamp((depth-0.35)/0.9, 0, 1)
+}
+
+func (f *fog) color(v, amount float64) string {
+	if len(f.palettes) == 0 {
+		return paletteForValue(v)
+	}
+	level := int(math.Round(amount * float64(len(f.palettes)-1)))
+	return f.palettes[level][paletteIndex(v)]
+}
+
+// IsFogColor reports whether s is a fog color written as #rrggbb.
+func IsFogColor(s string) bool {
+	_, ok := parseFogColor(s)
+	return ok
+}
+
+func parseFogColor(s string) (color.RGB, bool) {
+	var r, g, b uint8
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return color.RGB{}, false
+	}
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGB{}, false
+	}
+	return color.RGB{R: r, G: g, B: b}, true
+}
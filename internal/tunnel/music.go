@@ -0,0 +1,38 @@
+package tunnel
+
+import "animinterminal/internal/beat"
+
+var beatColor = "\x1b[38;5;231m"
+
+// beatRingSpeed is how many cells a beat ring grows per frame.
+const beatRingSpeed = 1.6
+
+// newMeter listens to the audio input if there is one, falls back to the
+// synthetic tempo, and returns nil when neither is set.
+func newMeter(cfg Config) beat.Meter {
+	if cfg.AudioInput != "" {
+		if pcm, err := beat.OpenPCM(cfg.AudioInput); err == nil {
+			return pcm
+		}
+	}
+	if cfg.BPM > 0 {
+		return beat.NewMetronome(cfg.BPM, cfg.FrameDelay)
+	}
+	return nil
+}
+
+// listen grows the beat rings, drops the ones past the screen edge and fires
+// a new one on a beat. The rings grow dt default frames' worth.
+func (v *view) listen(hit bool, width int, dt float64) {
+	alive := v.beats[:0]
+	for _, r := range v.beats {
+		r += beatRingSpeed * dt
+		if r < float64(width)/2 {
+			alive = append(alive, r)
+		}
+	}
+	v.beats = alive
+	if hit {
+		v.beats = append(v.beats, 1)
+	}
+}
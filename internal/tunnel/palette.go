@@ -0,0 +1,58 @@
+package tunnel
+
+import "animinterminal/internal/color"
+
+const (
+	// rampSteps is how many colors a ramp is evaluated into, one per stop
+	// of the built-in neon ramp.
+	rampSteps = 13
+	// rampStepsTruecolor is finer, since 24-bit color can show every step.
+	rampStepsTruecolor = 40
+)
+
+// ramps are gradients from the far, dark end of the tunnel to its bright
+// core. "neon" is the original blue ramp.
+var ramps = map[string][]color.RGB{
+	"neon": {
+		{R: 0, G: 0, B: 95}, {R: 0, G: 0, B: 135}, {R: 0, G: 0, B: 175}, {R: 0, G: 0, B: 215},
+		{R: 0, G: 95, B: 255}, {R: 0, G: 135, B: 255}, {R: 0, G: 175, B: 255}, {R: 0, G: 215, B: 255},
+		{R: 0, G: 255, B: 255}, {R: 95, G: 255, B: 255}, {R: 135, G: 255, B: 255}, {R: 175, G: 255, B: 255},
+		{R: 215, G: 255, B: 255},
+	},
+	"ice":       {{R: 0, G: 10, B: 40}, {R: 20, G: 70, B: 140}, {R: 90, G: 170, B: 220}, {R: 200, G: 240, B: 255}, {R: 255, G: 255, B: 255}},
+	"inferno":   {{R: 20, G: 0, B: 10}, {R: 100, G: 10, B: 20}, {R: 200, G: 50, B: 10}, {R: 250, G: 150, B: 20}, {R: 255, G: 240, B: 160}},
+	"toxic":     {{R: 0, G: 20, B: 5}, {R: 10, G: 80, B: 20}, {R: 60, G: 170, B: 30}, {R: 170, G: 240, B: 60}, {R: 230, G: 255, B: 180}},
+	"vaporwave": {{R: 30, G: 10, B: 60}, {R: 110, G: 30, B: 140}, {R: 230, G: 70, B: 180}, {R: 80, G: 200, B: 230}, {R: 220, G: 250, B: 255}},
+}
+
+// IsPalette reports whether name is a tunnel palette.
+func IsPalette(name string) bool {
+	_, ok := ramps[name]
+	return ok
+}
+
+// usePalette evaluates the named ramp into the wall palette before the
+// first frame. Other ramps than neon also give the accents the ramp's
+// brightest colors and the stars its middle, so rays and rings stay in the
+// same family.
+func usePalette(name string) {
+	stops, ok := ramps[name]
+	if !ok {
+		return
+	}
+	steps := rampSteps
+	if color.Active() == color.TrueColor {
+		steps = rampStepsTruecolor
+	}
+	colorRamp = color.Ramp(stops, steps)
+	colorPalette = make([]string, steps)
+	for i, c := range colorRamp {
+		colorPalette[i] = c.Render(color.Active())
+	}
+	if name == "neon" {
+		accentPalette, starPalette = neonAccents, neonStars
+		return
+	}
+	accentPalette = colorPalette[steps-4*steps/rampSteps:]
+	starPalette = colorPalette[3*steps/rampSteps : 9*steps/rampSteps]
+}
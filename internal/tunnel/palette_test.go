@@ -0,0 +1,43 @@
+package tunnel
+
+import (
+	"strings"
+	"testing"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/color"
+)
+
+// TestPalettesDistinct draws the same frame in every named palette and
+// checks no two come out in the same colors, and that going back to neon
+// after another palette gives neon's colors again.
+func TestPalettesDistinct(t *testing.T) {
+	color.Use(color.ANSI256)
+	colors := func(name string) string {
+		cfg := DefaultConfig()
+		cfg.Palette = name
+		cfg.Fog = 0
+		v := testView(cfg, 64, 24)
+		grid := canvas.New(64, 24)
+		drawTunnel(grid, 7, 40, v)
+		var sb strings.Builder
+		for _, row := range grid {
+			for _, c := range row {
+				sb.WriteString(c.Color)
+			}
+		}
+		return sb.String()
+	}
+
+	seen := map[string]string{}
+	for _, name := range []string{"neon", "ice", "inferno", "toxic", "vaporwave"} {
+		got := colors(name)
+		if other, ok := seen[got]; ok {
+			t.Errorf("%s draws in the same colors as %s", name, other)
+		}
+		seen[got] = name
+	}
+	if got := colors("neon"); seen[got] != "neon" {
+		t.Error("neon after vaporwave does not draw as neon did first")
+	}
+}
@@ -0,0 +1,36 @@
+package tunnel
+
+import "math"
+
+// metric is the radial distance that shapes the tunnel's cross-section: the
+// walls, pulse rings and center glow all follow its contours.
+type metric func(dx, dy float64) float64
+
+// IsShape reports whether name is a supported cross-section.
+func IsShape(name string) bool {
+	switch name {
+	case "circle", "square", "hex", "star":
+		return true
+	}
+	return false
+}
+
+func metricFor(shape string) metric {
+	switch shape {
+	case "square":
+		return func(dx, dy float64) float64 {
+			return math.Max(math.Abs(dx), math.Abs(dy))
+		}
+	case "hex":
+		return func(dx, dy float64) float64 {
+			ax, ay := math.Abs(dx), math.Abs(dy)
+			return math.Max(ay, ax*math.Sqrt(3)/2+ay/2)
+		}
+	case "star":
+		return func(dx, dy float64) float64 {
+			return math.Hypot(dx, dy) * (1 + 0.3*math.Cos(5*math.Atan2(dy, dx)))
+		}
+	default:
+		return math.Hypot
+	}
+}
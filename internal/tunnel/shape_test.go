@@ -0,0 +1,53 @@
+package tunnel
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/golden"
+)
+
+// testView is the view RunContext would start cfg with on a width by height
+// grid, its traffic seeded with 1.
+func testView(cfg Config, width, height int) *view {
+	usePalette(cfg.Palette)
+	fogColor, _ := parseFogColor(cfg.FogColor)
+	return &view{
+		dist:    metricFor(cfg.Shape),
+		texture: cfg.Texture,
+		cam:     cameraAt(0, cfg.Sway, cfg.SwayRate),
+		objects: newTraffic(cfg.Gates, cfg.FrameDelay, rand.New(rand.NewSource(1))),
+		field:   newField(width, height),
+		depth:   newField(width, height),
+		fog:     newFog(cfg.Fog, fogColor),
+		detail:  newDetail(cfg.FrameDelay),
+	}
+}
+
+// TestShapeSnapshots draws a frame of each cross-section and checks its
+// glyphs against testdata/shape-<name>.golden, so a change to a metric
+// shows up as a picture.
+func TestShapeSnapshots(t *testing.T) {
+	for _, shape := range []string{"circle", "square", "hex", "star"} {
+		t.Run(shape, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Shape = shape
+			grid := canvas.New(64, 24)
+			drawTunnel(grid, 0, 40, testView(cfg, 64, 24))
+
+			var sb strings.Builder
+			for _, row := range grid {
+				for _, c := range row {
+					if c.Glyph == 0 {
+						c.Glyph = ' '
+					}
+					sb.WriteRune(c.Glyph)
+				}
+				sb.WriteByte('\n')
+			}
+			golden.Check(t, "shape-"+shape+".golden", []byte(sb.String()))
+		})
+	}
+}
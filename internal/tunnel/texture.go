@@ -0,0 +1,63 @@
+package tunnel
+
+import (
+	"math"
+
+	"animinterminal/internal/canvas"
+)
+
+var (
+	checkerPalettes = [2][]string{
+		{
+			"\x1b[38;5;18m",
+			"\x1b[38;5;20m",
+			"\x1b[38;5;27m",
+			"\x1b[38;5;33m",
+			"\x1b[38;5;45m",
+			"\x1b[38;5;51m",
+		},
+		{
+			"\x1b[38;5;53m",
+			"\x1b[38;5;91m",
+			"\x1b[38;5;128m",
+			"\x1b[38;5;165m",
+			"\x1b[38;5;201m",
+			"\x1b[38;5;213m",
+		},
+	}
+	checkerGlyphs = [2][]rune{
+		{':', '%', '#', '#', '@', '@'},
+		{'.', '=', '=', '+', '+', '*'},
+	}
+)
+
+const (
+	// checkerSegments is how many tiles go around the tunnel.
+	checkerSegments = 16
+	// checkerDepth is how many tiles a unit of 1/r holds.
+	checkerDepth = 2.0
+)
+
+// IsTexture reports whether name is a wall texture.
+func IsTexture(name string) bool {
+	switch name {
+	case "smooth", "checker", "stripes":
+		return true
+	}
+	return false
+}
+
+// textureCell tiles the wall in angle and 1/r, the demo-scene way. Tiles
+// scroll inward as t grows and twist with swirl; depth (0-1, bright near the
+// viewer) picks the shade so the far end still fades out.
+func textureCell(texture string, r, angle, t, swirl, depth float64) canvas.Cell {
+	v := int(math.Floor(1/r*checkerDepth + t*2))
+	tile := v
+	if texture == "checker" {
+		u := int(math.Floor((angle/(2*math.Pi)+0.5)*checkerSegments + swirl))
+		tile = u + v
+	}
+	side := ((tile % 2) + 2) % 2
+	shade := int(canvas.Clamp(depth, 0, 0.9999) * float64(len(checkerPalettes[side])))
+	return canvas.Cell{Glyph: checkerGlyphs[side][shade], Color: checkerPalettes[side][shade]}
+}
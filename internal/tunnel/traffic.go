@@ -0,0 +1,180 @@
+package tunnel
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"animinterminal/internal/canvas"
+)
+
+const (
+	// farDepth is where objects appear; they pass the camera at depth 0.
+	farDepth = 1.0
+	// nearDepth is the closest an object is drawn.
+	nearDepth = 0.06
+	// approach is how much depth an object covers per unit of flight clock.
+	approach = 0.012
+	// nearMiss is the lateral offset under which an obstacle flashes the
+	// screen edge as it goes by.
+	nearMiss = 0.3
+)
+
+var (
+	gatePalette = []string{
+		"\x1b[38;5;208m",
+		"\x1b[38;5;214m",
+		"\x1b[38;5;220m",
+		"\x1b[38;5;228m",
+	}
+	obstacleColor = "\x1b[38;5;203m"
+	flashColor    = "\x1b[38;5;231m"
+)
+
+type objectKind int
+
+const (
+	objectGate objectKind = iota
+	objectObstacle
+)
+
+// object is a gate or obstacle somewhere down the tunnel. depth runs from
+// farDepth to 0 at the camera; an obstacle sits off to the side by offset
+// (a fraction of the tunnel radius) in direction angle, so it swells and
+// veers away as it comes close.
+type object struct {
+	kind   objectKind
+	depth  float64
+	angle  float64
+	offset float64
+	missed bool
+}
+
+// traffic is every object in flight plus the edge flash of the last near miss.
+type traffic struct {
+	objects []object
+	chance  float64
+	flash   int
+	side    float64
+	rng     *rand.Rand
+}
+
+func newTraffic(perMinute float64, frameDelay time.Duration, rng *rand.Rand) *traffic {
+	return &traffic{chance: perMinute * float64(frameDelay) / float64(time.Minute), rng: rng}
+}
+
+// update moves everything by the flight velocity, so reversing sends the
+// objects back down the tunnel. velocity is how far the flight went this
+// frame, in default frames at the cruising speed.
+func (t *traffic) update(velocity float64) {
+	if t.flash > 0 {
+		t.flash--
+	}
+	if velocity > 0 && t.rng.Float64() < t.chance*velocity {
+		o := object{kind: objectGate, depth: farDepth}
+		if t.rng.Intn(5) < 2 {
+			o.kind = objectObstacle
+			o.angle = t.rng.Float64() * 2 * math.Pi
+			o.offset = 0.15 + t.rng.Float64()*0.5
+		}
+		t.objects = append(t.objects, o)
+	}
+	alive := t.objects[:0]
+	for _, o := range t.objects {
+		o.depth -= approach * velocity
+		if o.kind == objectObstacle && !o.missed && o.depth < nearDepth*2 && o.offset < nearMiss {
+			o.missed = true
+			t.flash = 4
+			t.side = o.angle
+		}
+		if o.depth > nearDepth && o.depth <= farDepth+0.2 {
+			alive = append(alive, o)
+		}
+	}
+	t.objects = alive
+	// Far objects first so nearer ones cover them.
+	sort.Slice(t.objects, func(i, j int) bool { return t.objects[i].depth > t.objects[j].depth })
+}
+
+func (t *traffic) draw(grid canvas.Grid, frame int, dist metric, cam camera) {
+	width := len(grid[0])
+	maxR := float64(width) / 2
+	for _, o := range t.objects {
+		scale := nearDepth / o.depth
+		switch o.kind {
+		case objectGate:
+			drawGate(grid, frame, maxR*scale, dist, cam)
+		case objectObstacle:
+			drawObstacle(grid, o, maxR*scale, cam)
+		}
+	}
+	if t.flash > 0 {
+		drawEdgeFlash(grid, t.side)
+	}
+}
+
+// drawGate is a ring that hugs the tunnel walls at radius.
+func drawGate(grid canvas.Grid, frame int, radius float64, dist metric, cam camera) {
+	height := len(grid)
+	width := len(grid[0])
+	cx, cy := cam.center(width, height)
+	thickness := math.Max(0.6, radius*0.08)
+	color := gatePalette[min(len(gatePalette)-1, int(radius/float64(width)*2*float64(len(gatePalette))))]
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			band := math.Abs(dist(cam.rotate(float64(x-cx), float64(y-cy))) - radius)
+			if band > thickness {
+				continue
+			}
+			glyph := 'o'
+			if band < thickness/2 {
+				glyph = 'O'
+			}
+			if (x+y+frame/2)%9 == 0 {
+				glyph = '*'
+			}
+			grid.Set(x, y, glyph, color)
+		}
+	}
+}
+
+// drawObstacle is a clump whose size and distance from the center both grow
+// as it approaches.
+func drawObstacle(grid canvas.Grid, o object, radius float64, cam camera) {
+	height := len(grid)
+	width := len(grid[0])
+	cx, cy := cam.center(width, height)
+	r := radius * o.offset * 4
+	x := cx + int(math.Cos(o.angle+cam.roll)*r)
+	y := cy + int(math.Sin(o.angle+cam.roll)*r*0.6)
+	size := int(radius / float64(width) * 12)
+	for dy := -size / 2; dy <= size/2; dy++ {
+		for dx := -size; dx <= size; dx++ {
+			if float64(dx*dx)/4+float64(dy*dy) > float64(size*size)/4+0.5 {
+				continue
+			}
+			glyph := '#'
+			if (dx+dy)%3 == 0 {
+				glyph = '%'
+			}
+			grid.Set(x+dx, y+dy, glyph, obstacleColor)
+		}
+	}
+}
+
+// drawEdgeFlash lights the screen edge on the side an obstacle just passed.
+func drawEdgeFlash(grid canvas.Grid, angle float64) {
+	height := len(grid)
+	width := len(grid[0])
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			edge := x < 2 && dx < -0.5 || x >= width-2 && dx > 0.5 ||
+				y < 1 && dy < -0.5 || y >= height-1 && dy > 0.5
+			if edge {
+				grid.Set(x, y, '#', flashColor)
+			}
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package tunnel
 
 import (
-	"fmt"
+	"context"
+	"io"
 	"math"
-	"strings"
+	"math/rand"
+	"os"
 	"time"
 
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/color"
+	"animinterminal/internal/render"
 	"animinterminal/internal/term"
 )
 
@@ -15,23 +20,14 @@ const (
 )
 
 var (
-	colorPalette = []string{
-		"\x1b[38;5;17m",
-		"\x1b[38;5;18m",
-		"\x1b[38;5;19m",
-		"\x1b[38;5;20m",
-		"\x1b[38;5;27m",
-		"\x1b[38;5;33m",
-		"\x1b[38;5;39m",
-		"\x1b[38;5;45m",
-		"\x1b[38;5;51m",
-		"\x1b[38;5;87m",
-		"\x1b[38;5;123m",
-		"\x1b[38;5;159m",
-		"\x1b[38;5;195m",
-	}
-	glyphPalette = []byte{' ', '.', '.', ':', '-', '+', '*', 'x', 'X', '#', '@'}
-	starPalette  = []string{
+	// colorRamp and colorPalette are the wall colors, dark to bright, as
+	// evaluated by usePalette.
+	colorRamp    []color.RGB
+	colorPalette []string
+	glyphPalette = []rune{' ', '.', '.', ':', '-', '+', '*', 'x', 'X', '#', '@'}
+	// neonStars and neonAccents are the stars and the rays and rings of
+	// the neon ramp; other ramps have their own.
+	neonStars = []string{
 		"\x1b[38;5;25m",
 		"\x1b[38;5;31m",
 		"\x1b[38;5;33m",
@@ -39,12 +35,14 @@ var (
 		"\x1b[38;5;45m",
 		"\x1b[38;5;51m",
 	}
-	accentPalette = []string{
+	neonAccents = []string{
 		"\x1b[38;5;51m",
 		"\x1b[38;5;87m",
 		"\x1b[38;5;123m",
 		"\x1b[38;5;159m",
 	}
+	starPalette   = neonStars
+	accentPalette = neonAccents
 )
 
 // Config controls the tunnel animation behaviour.
@@ -52,6 +50,38 @@ type Config struct {
 	Width      int
 	Height     int
 	FrameDelay time.Duration
+	// Shape is the cross-section: circle, square, hex or star.
+	Shape string
+	// Texture is the wall pattern: smooth, checker or stripes.
+	Texture string
+	// Sway scales how far the tunnel winds and the view banks; 0 flies
+	// straight down the middle. SwayRate scales how quickly it winds.
+	Sway     float64
+	SwayRate float64
+	// Gates is the average number of gates and obstacles per minute at
+	// normal speed; 0 turns them off.
+	Gates float64
+	// AudioInput is a raw PCM stream (see beat.PCM) whose loudness drives
+	// the speed and depth pulse and whose beats fire extra pulse rings.
+	AudioInput string
+	// BPM beats a synthetic tempo instead when there is no audio; 0 leaves
+	// the tunnel unmodulated.
+	BPM float64
+	// Palette names the color ramp: neon, ice, inferno, toxic or vaporwave.
+	Palette string
+	// Fog is how thickly the far end of the tunnel fades into FogColor
+	// (#rrggbb), 0-1.
+	Fog      float64
+	FogColor string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
 }
 
 // DefaultConfig returns sane defaults for typical terminals.
@@ -60,10 +90,24 @@ func DefaultConfig() Config {
 		Width:      100,
 		Height:     34,
 		FrameDelay: 35 * time.Millisecond,
+		Shape:      "circle",
+		Texture:    "smooth",
+		Sway:       1,
+		SwayRate:   1,
+		Gates:      12,
+		Palette:    "neon",
+		Fog:        0.4,
+		FogColor:   "#0a0a1e",
 	}
 }
 
 func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
 	if c.Width < minWidth {
 		c.Width = minWidth
 	}
@@ -73,94 +117,225 @@ func (c Config) normalize() Config {
 	if c.FrameDelay <= 0 {
 		c.FrameDelay = 40 * time.Millisecond
 	}
+	if !IsShape(c.Shape) {
+		c.Shape = "circle"
+	}
+	if !IsTexture(c.Texture) {
+		c.Texture = "smooth"
+	}
+	if c.Sway < 0 {
+		c.Sway = 0
+	}
+	if c.Gates < 0 {
+		c.Gates = 0
+	}
+	if !IsPalette(c.Palette) {
+		c.Palette = "neon"
+	}
+	if c.Fog < 0 {
+		c.Fog = 0
+	}
+	if c.Fog > 1 {
+		c.Fog = 1
+	}
+	if !IsFogColor(c.FogColor) {
+		c.FogColor = "#0a0a1e"
+	}
+	if c.BPM < 0 {
+		c.BPM = 0
+	}
+	if c.SwayRate <= 0 {
+		c.SwayRate = 1
+	}
 	return c
 }
 
-type cell struct {
-	glyph byte
-	color string
+// view is everything besides the flight clock that shapes a frame.
+type view struct {
+	dist    metric
+	texture string
+	cam     camera
+	objects *traffic
+	// pulse is the music's loudness, 0-1, swelling the depth pulse.
+	pulse float64
+	// beats holds the radius of every ring fired by a beat.
+	beats []float64
+	// field holds the wall intensity of every cell before it is turned into
+	// a glyph and color.
+	field [][]float64
+	// depth holds the depth term of every cell for the fog.
+	depth  [][]float64
+	fog    *fog
+	detail *detail
 }
 
 // Run launches the neon tunnel animation.
-func Run(cfg Config) {
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	// A run given a seed goes a frame at a time, so it repeats exactly.
+	seeded := cfg.Seed != 0
 	cfg = cfg.normalize()
-	grid := newGrid(cfg.Width, cfg.Height)
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	usePalette(cfg.Palette)
+	fogColor, _ := parseFogColor(cfg.FogColor)
+	grid := canvas.New(cfg.Width, cfg.Height)
 
-	cleanup := term.Start(true)
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
 	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	keys := term.Keys()
+	motion := newFlight(cfg.FrameDelay)
+	v := &view{
+		dist:    metricFor(cfg.Shape),
+		texture: cfg.Texture,
+		objects: newTraffic(cfg.Gates, DefaultConfig().FrameDelay, rng),
+		field:   newField(cfg.Width, cfg.Height),
+		depth:   newField(cfg.Width, cfg.Height),
+		fog:     newFog(cfg.Fog, fogColor),
+		detail:  newDetail(cfg.FrameDelay),
+	}
+	meter := newMeter(cfg)
 
 	ticker := time.NewTicker(cfg.FrameDelay)
 	defer ticker.Stop()
 
-	for frame := 0; ; frame++ {
-		drawTunnel(grid, frame)
-		render(grid)
-		<-ticker.C
-	}
-}
-
-func newGrid(width, height int) [][]cell {
-	grid := make([][]cell, height)
-	for y := range grid {
-		grid[y] = make([]cell, width)
+	// t is the time in default frames, for the colors and twinkles.
+	clock := render.NewClock(cfg.FrameDelay, DefaultConfig().FrameDelay, seeded)
+	for t := 0.0; limit.Next(); {
+		dt := clock.Tick()
+		select {
+		case k := <-keys:
+			motion.key(k)
+		default:
+		}
+		boost := 1.0
+		if meter != nil {
+			level, hit := meter.Sample()
+			boost = 0.7 + 0.8*level
+			v.pulse = level
+			v.listen(hit, cfg.Width, dt)
+		}
+		motion.advance(boost, dt)
+		v.objects.update(motion.velocity * dt)
+		v.cam = cameraAt(motion.clock, cfg.Sway, cfg.SwayRate)
+		start := time.Now()
+		drawTunnel(grid, int(t), motion.clock, v)
+		motion.drawIndicator(grid)
+		grid.Render(screen)
+		// Dropping detail goes by how long frames take, which no two runs
+		// agree on, so a steady run keeps full detail.
+		if !clock.Steady() {
+			v.detail.observe(time.Since(start))
+		}
+		t += dt
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
 	}
-	return grid
+	return nil
 }
 
-func drawTunnel(grid [][]cell, frame int) {
+// drawTunnel renders one frame. clock drives everything that moves along the
+// tunnel; frame only cycles colors and twinkles.
+func drawTunnel(grid canvas.Grid, frame int, clock float64, v *view) {
 	height := len(grid)
 	if height == 0 {
 		return
 	}
 	width := len(grid[0])
 
-	t := float64(frame) * 0.045
-	swirl := float64(frame) * 0.02
-	depthPulse := 0.55 + 0.4*math.Sin(float64(frame)*0.05)
-
-	for y := 0; y < height; y++ {
-		ny := (float64(y)/float64(height) - 0.5) * 2
-		ny *= 0.72
-		for x := 0; x < width; x++ {
-			nx := (float64(x)/float64(width) - 0.5) * 2
-			nx *= 1.1
+	t := clock * 0.045
+	swirl := clock * 0.02
+	depthPulse := 0.55 + 0.4*math.Sin(clock*0.05) + 0.3*v.pulse
+	dist, cam := v.dist, v.cam
 
-			r := math.Hypot(nx, ny) + 0.0001
-			angle := math.Atan2(ny, nx)
+	radius := func(x, y int) (float64, float64) {
+		sx := (float64(x)/float64(width)-0.5)*2 - cam.fx
+		sy := (float64(y)/float64(height)-0.5)*2 - cam.fy
+		nx, ny := cam.rotate(sx*1.1, sy*0.72)
+		return dist(nx, ny) + 0.0001, math.Atan2(ny, nx)
+	}
+	depthAt := func(x, y int) float64 {
+		r, _ := radius(x, y)
+		return 1.0 / (r*2.2 + 0.5)
+	}
+	field := func(x, y int) float64 {
+		r, angle := radius(x, y)
 
-			depth := 1.0 / (r*2.2 + 0.5)
-			wave := math.Sin(1.5/r - t*1.7 + math.Cos(angle*3+swirl)*0.55)
-			spiral := math.Sin(angle*6 + t*2.1)
-			flow := math.Cos(r*14 - t*3.4 + angle*1.3)
-			band := math.Cos((r-depthPulse)*9 - t*1.2)
+		depth := 1.0 / (r*2.2 + 0.5)
+		wave := math.Sin(1.5/r - t*1.7 + math.Cos(angle*3+swirl)*0.55)
+		spiral := math.Sin(angle*6 + t*2.1)
+		flow := math.Cos(r*14 - t*3.4 + angle*1.3)
+		band := math.Cos((r-depthPulse)*9 - t*1.2)
 
-			value := wave*0.62 + spiral*0.24 + flow*0.28 + band*0.18 - r*0.95
-			intensity := value + depth*0.9
+		value := wave*0.62 + spiral*0.24 + flow*0.28 + band*0.18 - r*0.95
+		return value + depth*0.9
+	}
 
-			grid[y][x] = cell{
-				glyph: glyphForValue(intensity),
-				color: paletteForValue(intensity),
+	if v.texture != "smooth" {
+		for y := 0; y < height; y++ {
+			sy := (float64(y)/float64(height)-0.5)*2 - cam.fy
+			for x := 0; x < width; x++ {
+				sx := (float64(x)/float64(width)-0.5)*2 - cam.fx
+				nx, ny := cam.rotate(sx*1.1, sy*0.72)
+				r := dist(nx, ny) + 0.0001
+				grid[y][x] = textureCell(v.texture, r, math.Atan2(ny, nx), t, swirl, canvas.Clamp(r*1.4, 0, 1))
+			}
+		}
+	} else {
+		sample := sampleFull
+		if v.detail.half {
+			sample = sampleHalf
+		}
+		sample(v.field, field)
+		if v.fog.density > 0 {
+			sample(v.depth, depthAt)
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				intensity := v.field[y][x]
+				color := paletteForValue(intensity)
+				if v.fog.density > 0 {
+					color = v.fog.color(intensity, v.fog.amount(v.depth[y][x]))
+				}
+				grid[y][x] = canvas.Cell{
+					Glyph: glyphForValue(intensity),
+					Color: color,
+				}
 			}
 		}
 	}
 
 	drawBackgroundStars(grid, frame)
-	drawRays(grid, frame)
-	drawDebris(grid, frame)
-	drawPulseRings(grid, frame)
-	drawCenterGlow(grid, frame)
+	drawRays(grid, frame, clock, cam)
+	drawDebris(grid, frame, clock, cam)
+	drawPulseRings(grid, frame, clock, dist, cam)
+	for _, radius := range v.beats {
+		drawRing(grid, radius, 1.4, beatColor, dist, cam)
+	}
+	v.objects.draw(grid, frame, dist, cam)
+	drawCenterGlow(grid, clock, dist, cam)
 }
 
-func drawCenterGlow(grid [][]cell, frame int) {
+func drawCenterGlow(grid canvas.Grid, clock float64, dist metric, cam camera) {
 	height := len(grid)
 	if height == 0 {
 		return
 	}
 	width := len(grid[0])
-	cx := width / 2
-	cy := height / 2
+	cx, cy := cam.center(width, height)
 
-	radius := 1 + int(2*(0.5+0.5*math.Sin(float64(frame)*0.1+1.4)))
+	radius := 1 + int(2*(0.5+0.5*math.Sin(clock*0.1+1.4)))
 	for y := cy - radius; y <= cy+radius; y++ {
 		if y < 0 || y >= height {
 			continue
@@ -169,83 +344,89 @@ func drawCenterGlow(grid [][]cell, frame int) {
 			if x < 0 || x >= width {
 				continue
 			}
-			dist := math.Hypot(float64(x-cx), float64(y-cy))
-			if dist <= float64(radius) {
-				grid[y][x] = cell{glyph: '*', color: "\x1b[38;5;195m"}
+			if dist(cam.rotate(float64(x-cx), float64(y-cy))) <= float64(radius) {
+				grid[y][x] = canvas.Cell{Glyph: '*', Color: "\x1b[38;5;195m"}
 			}
 		}
 	}
 }
 
-func drawPulseRings(grid [][]cell, frame int) {
+func drawPulseRings(grid canvas.Grid, frame int, clock float64, dist metric, cam camera) {
 	height := len(grid)
 	if height == 0 {
 		return
 	}
 	width := len(grid[0])
-	cx := width / 2
-	cy := height / 2
 	maxR := float64(width)/2 - 1
 	if maxR < 2 {
 		return
 	}
 
-	aspect := 1.0
 	speed := 1.15
 	thickness := 1.8
 	gap := 10.0
 	cycle := maxR + thickness*2 + gap
-	phase := math.Mod(float64(frame)*speed, cycle)
+	phase := math.Mod(clock*speed, cycle)
+	if phase < 0 {
+		phase += cycle
+	}
 	if phase > maxR+thickness {
 		return
 	}
 	radius := math.Min(maxR, math.Max(1, phase))
 	color := accentPalette[(frame/7)%len(accentPalette)]
+	drawRing(grid, radius, thickness, color, dist, cam)
+}
 
+// drawRing draws one ring of the given radius around the vanishing point,
+// following the tunnel's cross-section.
+func drawRing(grid canvas.Grid, radius, thickness float64, color string, dist metric, cam camera) {
+	height := len(grid)
+	width := len(grid[0])
+	cx, cy := cam.center(width, height)
+	aspect := 1.0
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			dx := float64(x - cx)
 			dy := float64(y-cy) * aspect
-			dist := math.Hypot(dx, dy)
-			band := math.Abs(dist - radius)
+			band := math.Abs(dist(cam.rotate(dx, dy)) - radius)
 			if band > thickness {
 				continue
 			}
-			intensity := clamp(1-(band/thickness), 0, 1)
+			intensity := canvas.Clamp(1-(band/thickness), 0, 1)
 			glyph := '.'
 			if intensity > 0.65 {
 				glyph = '*'
 			}
-			grid[y][x] = cell{glyph: byte(glyph), color: color}
+			grid[y][x] = canvas.Cell{Glyph: glyph, Color: color}
 		}
 	}
 }
 
-func drawBackgroundStars(grid [][]cell, frame int) {
+func drawBackgroundStars(grid canvas.Grid, frame int) {
 	height := len(grid)
 	width := len(grid[0])
 	for y := 0; y < height; y += 2 {
 		for x := (y + frame/3) % 6; x < width; x += 6 {
 			color := starPalette[(x/3+y+frame/11)%len(starPalette)]
 			if ((x*37 + y*13 + frame) % 57) < 3 {
-				setCell(grid, x, y, '.', color)
+				grid.Set(x, y, '.', color)
 			} else if ((x*19 + y*7 + frame*2) % 71) == 0 {
-				setCell(grid, x, y, '+', color)
+				grid.Set(x, y, '+', color)
 			}
 		}
 	}
 }
 
-func drawRays(grid [][]cell, frame int) {
+func drawRays(grid canvas.Grid, frame int, clock float64, cam camera) {
 	height := len(grid)
 	width := len(grid[0])
-	cx := width / 2
-	cy := height / 2
+	cx, cy := cam.center(width, height)
 	count := 14
 	maxR := float64(width) / 2
 	for i := 0; i < count; i++ {
-		angle := float64(i)/float64(count)*math.Pi*2 + math.Sin(float64(frame)*0.012)*0.6
-		phase := math.Sin(float64(frame)*0.06+float64(i)) * 0.5
+		angle := float64(i)/float64(count)*math.Pi*2 + math.Sin(clock*0.012)*0.6 + cam.roll
+		phase := math.Sin(clock*0.06+float64(i)) * 0.5
 		length := maxR * (0.6 + 0.35*phase)
 		color := accentPalette[(i+frame/6)%len(accentPalette)]
 		for r := 1.0; r < length; r += 0.8 {
@@ -258,21 +439,25 @@ func drawRays(grid [][]cell, frame int) {
 			if i%2 == 0 {
 				glyph = '/'
 			}
-			setCell(grid, x, y, byte(glyph), color)
+			grid.Set(x, y, glyph, color)
 		}
 	}
 }
 
-func drawDebris(grid [][]cell, frame int) {
+func drawDebris(grid canvas.Grid, frame int, clock float64, cam camera) {
 	height := len(grid)
 	width := len(grid[0])
-	cx := width / 2
-	cy := height / 2
+	cx, cy := cam.center(width, height)
 	count := width / 2
 	for i := 0; i < count; i++ {
-		f := float64(i) + float64(frame)*0.9
-		theta := math.Sin(f*0.03+float64(frame)*0.001)*math.Pi + float64(i%7)*0.4
-		r := math.Mod(f*0.18, float64(width)/2) * (0.7 + 0.3*math.Sin(float64(frame)*0.02))
+		f := float64(i) + clock*0.9
+		theta := math.Sin(f*0.03+clock*0.001)*math.Pi + float64(i%7)*0.4 + cam.roll
+		span := float64(width) / 2
+		r := math.Mod(f*0.18, span)
+		if r < 0 {
+			r += span
+		}
+		r *= 0.7 + 0.3*math.Sin(clock*0.02)
 		x := cx + int(math.Cos(theta)*r)
 		y := cy + int(math.Sin(theta)*r*0.65)
 		if x < 0 || x >= width || y < 0 || y >= height {
@@ -280,7 +465,7 @@ func drawDebris(grid [][]cell, frame int) {
 		}
 		color := colorPalette[(i+frame/5)%len(colorPalette)]
 		glyph := glyphPalette[(i+frame)%len(glyphPalette)]
-		setCell(grid, x, y, glyph, color)
+		grid.Set(x, y, glyph, color)
 	}
 }
 
@@ -288,16 +473,19 @@ func paletteForValue(v float64) string {
 	if len(colorPalette) == 0 {
 		return ""
 	}
-	norm := clamp((v+1.3)/2.6, 0, 0.9999)
-	idx := int(norm * float64(len(colorPalette)))
-	return colorPalette[idx]
+	return colorPalette[paletteIndex(v)]
 }
 
-func glyphForValue(v float64) byte {
+func paletteIndex(v float64) int {
+	norm := canvas.Clamp((v+1.3)/2.6, 0, 0.9999)
+	return int(norm * float64(len(colorPalette)))
+}
+
+func glyphForValue(v float64) rune {
 	if len(glyphPalette) == 0 {
 		return '#'
 	}
-	norm := clamp((v+1.0)/2.0, 0, 0.9999)
+	norm := canvas.Clamp((v+1.0)/2.0, 0, 0.9999)
 	idx := int(norm * float64(len(glyphPalette)))
 	if idx < 0 {
 		idx = 0
@@ -307,51 +495,3 @@ func glyphForValue(v float64) byte {
 	}
 	return glyphPalette[idx]
 }
-
-func setCell(grid [][]cell, x, y int, glyph byte, color string) {
-	if y < 0 || y >= len(grid) {
-		return
-	}
-	if x < 0 || x >= len(grid[y]) {
-		return
-	}
-	grid[y][x] = cell{glyph: glyph, color: color}
-}
-
-func clamp(v, minV, maxV float64) float64 {
-	if v < minV {
-		return minV
-	}
-	if v > maxV {
-		return maxV
-	}
-	return v
-}
-
-func render(grid [][]cell) {
-	var sb strings.Builder
-	height := len(grid)
-	if height == 0 {
-		return
-	}
-	width := len(grid[0])
-	sb.Grow((width+8)*height + 16)
-	sb.WriteString(term.Home)
-
-	for _, row := range grid {
-		for _, c := range row {
-			if c.color != "" {
-				sb.WriteString(c.color)
-			}
-			g := c.glyph
-			if g == 0 {
-				g = ' '
-			}
-			sb.WriteByte(g)
-		}
-		sb.WriteString(term.Reset)
-		sb.WriteByte('\n')
-	}
-
-	fmt.Print(sb.String())
-}
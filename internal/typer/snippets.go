@@ -0,0 +1,130 @@
+package typer
+
+// snippet is a piece of code to type and the file it pretends to be.
+type snippet struct {
+	name string
+	text string
+}
+
+// snippets are typed in turn when no file is given.
+var snippets = []snippet{
+	{name: "server.go", text: `package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Store keeps the latest reading from every sensor.
+type Store struct {
+	mu       sync.RWMutex
+	readings map[string]Reading
+}
+
+type Reading struct {
+	Value float64   ` + "`json:\"value\"`" + `
+	At    time.Time ` + "`json:\"at\"`" + `
+}
+
+func (s *Store) Put(id string, r Reading) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readings[id] = r
+}
+
+func (s *Store) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.readings); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func main() {
+	store := &Store{readings: make(map[string]Reading)}
+	go func() {
+		for t := range time.Tick(2 * time.Second) {
+			store.Put("probe-1", Reading{Value: 20 + float64(t.Second()%7), At: t})
+		}
+	}()
+	log.Println("listening on :8080")
+	log.Fatal(http.ListenAndServe(":8080", store))
+}
+`},
+	{name: "stats.py", text: `import statistics
+from dataclasses import dataclass, field
+
+
+@dataclass
+class Window:
+    """A sliding window of the most recent samples."""
+
+    size: int = 32
+    samples: list = field(default_factory=list)
+
+    def push(self, value):
+        self.samples.append(value)
+        if len(self.samples) > self.size:
+            self.samples.pop(0)
+
+    def summary(self):
+        if not self.samples:
+            return None
+        return {
+            "mean": statistics.fmean(self.samples),
+            "stdev": statistics.pstdev(self.samples),
+            "max": max(self.samples),
+        }
+
+
+def detect_spikes(values, threshold=3.0):
+    window = Window()
+    for i, value in enumerate(values):
+        stats = window.summary()
+        if stats and stats["stdev"] > 0:
+            score = (value - stats["mean"]) / stats["stdev"]
+            if abs(score) > threshold:
+                yield i, value, round(score, 2)
+        window.push(value)
+
+
+if __name__ == "__main__":
+    data = [10, 11, 9, 10, 12, 48, 11, 10, 9, -20, 10]
+    for index, value, score in detect_spikes(data):
+        print(f"spike at {index}: {value} (z={score})")
+`},
+	{name: "debounce.js", text: `// Collapse bursts of calls into one, fired after things go quiet.
+export function debounce(fn, wait = 200) {
+  let timer = null;
+  return function (...args) {
+    clearTimeout(timer);
+    timer = setTimeout(() => {
+      timer = null;
+      fn.apply(this, args);
+    }, wait);
+  };
+}
+
+const search = document.querySelector("#search");
+const results = document.querySelector("#results");
+
+async function lookup(query) {
+  if (query.length < 2) {
+    results.innerHTML = "";
+    return;
+  }
+  const response = await fetch("/api/search?q=" + encodeURIComponent(query));
+  const items = await response.json();
+  results.innerHTML = items
+    .slice(0, 10)
+    .map((item) => "<li>" + item.title + "</li>")
+    .join("");
+}
+
+search.addEventListener("input", debounce((event) => lookup(event.target.value), 250));
+`},
+}
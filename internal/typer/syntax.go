@@ -0,0 +1,133 @@
+package typer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ruleset is what the highlighter knows about a language: its keywords,
+// its built-in types and how a line comment starts.
+type ruleset struct {
+	keywords map[string]bool
+	types    map[string]bool
+	comment  string
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var rulesets = map[string]ruleset{
+	"go": {
+		keywords: words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false"),
+		types:    words("bool byte error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 any"),
+		comment:  "//",
+	},
+	"python": {
+		keywords: words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		types:    words("int float str list dict set tuple bool bytes object"),
+		comment:  "#",
+	},
+	"js": {
+		keywords: words("async await break case catch class const continue default delete do else export extends finally for from function if import in instanceof let new of return switch this throw try typeof var void while yield null undefined true false"),
+		types:    words("Array Boolean Date Error JSON Map Math Number Object Promise Set String document window console"),
+		comment:  "//",
+	},
+	"c": {
+		keywords: words("auto break case const continue default do else enum extern for goto if inline register restrict return sizeof static struct switch typedef union volatile while NULL true false #include #define #ifdef #ifndef #endif"),
+		types:    words("char double float int long short signed unsigned void size_t bool uint8_t uint16_t uint32_t uint64_t int32_t int64_t"),
+		comment:  "//",
+	},
+	"plain": {},
+}
+
+// IsLanguage reports whether name is a highlighting ruleset, or auto.
+func IsLanguage(name string) bool {
+	_, ok := rulesets[name]
+	return ok || name == "auto"
+}
+
+// languageFor guesses the ruleset from a file name.
+func languageFor(name string) string {
+	switch filepath.Ext(name) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".js", ".mjs", ".ts", ".jsx", ".tsx":
+		return "js"
+	case ".c", ".h", ".cc", ".cpp", ".hpp":
+		return "c"
+	}
+	return "plain"
+}
+
+// Token kinds, which pick the color.
+const (
+	plainToken = iota
+	keywordToken
+	typeToken
+	stringToken
+	numberToken
+	commentToken
+	callToken
+)
+
+// highlight gives every byte of line a token kind. It is line by line and
+// rough: a string or comment that spans lines is only caught on its first.
+func highlight(line string, rules ruleset) []int {
+	kinds := make([]int, len(line))
+	for i := 0; i < len(line); {
+		ch := line[i]
+		switch {
+		case rules.comment != "" && strings.HasPrefix(line[i:], rules.comment):
+			for ; i < len(line); i++ {
+				kinds[i] = commentToken
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			j := i + 1
+			for j < len(line) && line[j] != ch {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(line))
+			for ; i < j; i++ {
+				kinds[i] = stringToken
+			}
+		case ch >= '0' && ch <= '9':
+			for ; i < len(line) && isWord(line[i]); i++ {
+				kinds[i] = numberToken
+			}
+		case isWord(ch) || ch == '#':
+			j := i + 1
+			for j < len(line) && isWord(line[j]) {
+				j++
+			}
+			kind := plainToken
+			switch w := line[i:j]; {
+			case rules.keywords[w]:
+				kind = keywordToken
+			case rules.types[w]:
+				kind = typeToken
+			case j < len(line) && line[j] == '(':
+				kind = callToken
+			}
+			for ; i < j; i++ {
+				kinds[i] = kind
+			}
+		default:
+			i++
+		}
+	}
+	return kinds
+}
+
+func isWord(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,319 @@
+package typer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"animinterminal/internal/canvas"
+	"animinterminal/internal/render"
+	"animinterminal/internal/term"
+)
+
+const (
+	minWidth  = 40
+	minHeight = 12
+	// holdTime is how long a finished file stays up before the next one.
+	holdTime = 2.5
+	// tabWidth is how many spaces a tab is typed as.
+	tabWidth = 4
+)
+
+var (
+	barColor    = "\x1b[38;5;252;48;5;237m"
+	gutterColor = "\x1b[38;5;240m"
+	cursorColor = "\x1b[38;5;231m"
+	tokenColors = map[int]string{
+		plainToken:   "\x1b[38;5;252m",
+		keywordToken: "\x1b[38;5;170m",
+		typeToken:    "\x1b[38;5;80m",
+		stringToken:  "\x1b[38;5;114m",
+		numberToken:  "\x1b[38;5;215m",
+		commentToken: "\x1b[38;5;244m",
+		callToken:    "\x1b[38;5;75m",
+	}
+	// keyRows is the keyboard a typo slips to a neighbour on.
+	keyRows = []string{"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}
+)
+
+// Config controls the typing simulator.
+type Config struct {
+	Width      int
+	Height     int
+	FrameDelay time.Duration
+	// Text is typed over and over instead of the built-in snippets, shown
+	// as Name.
+	Text string
+	Name string
+	// WPM is the typing speed in words (five characters) per minute.
+	WPM float64
+	// Errors is the chance each letter is a typo that gets noticed and
+	// backspaced a few keys later.
+	Errors float64
+	// Language picks the highlighting: go, python, js, c, plain, or auto to
+	// go by the file name.
+	Language string
+	// Output is where frames are written; nil means os.Stdout.
+	Output io.Writer
+	// Duration stops the animation after that long, and MaxFrames after
+	// that many frames; zero runs forever.
+	Duration  time.Duration
+	MaxFrames int
+	// Seed makes a run repeatable: the same seed draws the same frames. 0
+	// picks a new one each run.
+	Seed int64
+}
+
+// DefaultConfig returns a preset tuned for most terminals.
+func DefaultConfig() Config {
+	return Config{
+		Width:      100,
+		Height:     34,
+		FrameDelay: 20 * time.Millisecond,
+		WPM:        70,
+		Errors:     0.02,
+		Language:   "auto",
+	}
+}
+
+func (c Config) normalize() Config {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	if c.Width < minWidth {
+		c.Width = minWidth
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.FrameDelay <= 0 {
+		c.FrameDelay = 20 * time.Millisecond
+	}
+	if c.WPM <= 0 {
+		c.WPM = 70
+	}
+	c.WPM = math.Min(c.WPM, 1000)
+	if c.Errors < 0 {
+		c.Errors = 0.02
+	}
+	c.Errors = math.Min(c.Errors, 0.5)
+	if !IsLanguage(c.Language) {
+		c.Language = "auto"
+	}
+	if c.Name == "" {
+		c.Name = "untitled"
+	}
+	return c
+}
+
+// typist types out one text a key at a time. A typo goes into extra along
+// with the few keys typed after it before it is noticed; then extra is
+// backspaced away and typing picks up from pos again.
+type typist struct {
+	text   string
+	pos    int
+	extra  []byte
+	notice int
+	fixing bool
+	typos  int
+	// wait is how long until the next key.
+	wait float64
+	rng  *rand.Rand
+}
+
+func newTypist(text string, rng *rand.Rand) *typist {
+	return &typist{text: strings.ReplaceAll(text, "\t", strings.Repeat(" ", tabWidth)), rng: rng}
+}
+
+func (t *typist) done() bool {
+	return t.pos >= len(t.text) && len(t.extra) == 0
+}
+
+// typed is everything on screen so far.
+func (t *typist) typed() string {
+	return t.text[:t.pos] + string(t.extra)
+}
+
+// key presses the next key and sets how long until the one after it: a
+// little random around the typing speed, a touch longer after a word and
+// longer again at the end of a line or statement.
+func (t *typist) key(cfg Config) {
+	perKey := 60 / (cfg.WPM * 5)
+	t.wait = perKey * math.Exp(t.rng.NormFloat64()*0.4)
+	switch {
+	case t.fixing:
+		t.extra = t.extra[:len(t.extra)-1]
+		t.wait = perKey * 0.6
+		if len(t.extra) == 0 {
+			t.fixing = false
+		}
+	case len(t.extra) > 0:
+		next := t.pos + len(t.extra)
+		if t.notice == 0 || next >= len(t.text) || t.text[next] == '\n' {
+			// Notice the slip, pause, then start backspacing.
+			t.fixing = true
+			t.wait = perKey * (2 + t.rng.Float64()*2)
+			return
+		}
+		t.extra = append(t.extra, t.text[next])
+		t.notice--
+	default:
+		ch := t.text[t.pos]
+		if ch == ' ' && (t.pos == 0 || t.text[t.pos-1] == '\n') {
+			// The editor indents the new line at once.
+			for t.pos < len(t.text) && t.text[t.pos] == ' ' {
+				t.pos++
+			}
+			t.wait = 0
+			return
+		}
+		if isLetter(ch) && t.rng.Float64() < cfg.Errors {
+			t.extra = append(t.extra, slip(ch, t.rng))
+			t.notice = t.rng.Intn(4)
+			t.typos++
+			return
+		}
+		t.pos++
+		switch ch {
+		case '\n':
+			t.wait += perKey * (2 + t.rng.Float64()*5)
+		case ' ':
+			t.wait += perKey * 0.5
+		case ';', '{', '}', ':', ')':
+			t.wait += perKey * (0.5 + t.rng.Float64())
+		}
+	}
+}
+
+func isLetter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// slip is a key next to ch on the keyboard, in the same case.
+func slip(ch byte, rng *rand.Rand) byte {
+	lower := ch | 0x20
+	for _, row := range keyRows {
+		i := strings.IndexByte(row, lower)
+		if i < 0 {
+			continue
+		}
+		j := i - 1
+		if i == 0 || i < len(row)-1 && rng.Intn(2) == 0 {
+			j = i + 1
+		}
+		out := row[j]
+		if ch != lower && isLetter(out) {
+			out &^= 0x20
+		}
+		return out
+	}
+	return ch
+}
+
+// Run launches the typing simulator.
+func Run(cfg Config) error {
+	return RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run until ctx is cancelled or the run is quit, when it puts
+// the terminal back and returns why: ctx's cause, or term.ErrQuit or
+// term.ErrInterrupted. It returns nil once Duration or MaxFrames is up.
+func RunContext(ctx context.Context, cfg Config) error {
+	cfg = cfg.normalize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	grid := canvas.New(cfg.Width, cfg.Height)
+	files := snippets
+	if cfg.Text != "" {
+		files = []snippet{{name: cfg.Name, text: cfg.Text}}
+	}
+	file := 0
+	t := newTypist(files[file].text, rng)
+
+	ctx, cleanup := term.Start(ctx, cfg.Output, true)
+	defer cleanup()
+	screen := render.NewScreen(cfg.Output)
+	limit := render.NewLimit(ctx, cfg.Duration, cfg.MaxFrames)
+
+	ticker := time.NewTicker(cfg.FrameDelay)
+	defer ticker.Stop()
+
+	step := cfg.FrameDelay.Seconds()
+	held := 0.0
+	for frame := 0; limit.Next(); frame++ {
+		t.wait -= step
+		for t.wait <= 0 && !t.done() {
+			t.key(cfg)
+		}
+		if t.done() {
+			if held += step; held > holdTime {
+				held = 0
+				file = (file + 1) % len(files)
+				t = newTypist(files[file].text, rng)
+			}
+		}
+		lang := cfg.Language
+		if lang == "auto" {
+			lang = languageFor(files[file].name)
+		}
+		drawEditor(grid, t, files[file].name, lang, cfg.WPM, frame)
+		grid.Render(screen)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// drawEditor lays out a title bar, the typed text with line numbers,
+// scrolled to keep the cursor on screen, and a status line.
+func drawEditor(grid canvas.Grid, t *typist, name, lang string, wpm float64, frame int) {
+	height := len(grid)
+	width := len(grid[0])
+	lines := strings.Split(t.typed(), "\n")
+	row, col := len(lines)-1, len(lines[len(lines)-1])
+	rows := height - 2
+	top := max(0, row-rows+1)
+
+	grid.Clear()
+	drawBar(grid, 0, fmt.Sprintf(" %s  [%s]", name, lang))
+	rules := rulesets[lang]
+	for i := 0; i < rows && top+i < len(lines); i++ {
+		y := 1 + i
+		grid.Text(0, y, fmt.Sprintf("%4d ", top+i+1), gutterColor)
+		line := lines[top+i]
+		kinds := highlight(line, rules)
+		for x := 0; x < len(line) && 6+x < width; x++ {
+			grid.Set(6+x, y, rune(line[x]), tokenColors[kinds[x]])
+		}
+	}
+	if !t.done() || frame/20%2 == 0 {
+		grid.Set(6+col, 1+row-top, '_', cursorColor)
+	}
+	mode := "INSERT"
+	if t.done() {
+		mode = "SAVED"
+	}
+	drawBar(grid, height-1, fmt.Sprintf(" %s  Ln %d, Col %d  %.0f wpm  %d typos", mode, row+1, col+1, wpm, t.typos))
+}
+
+// drawBar fills row y with text on the bar color.
+func drawBar(grid canvas.Grid, y int, text string) {
+	for x := range grid[y] {
+		glyph := ' '
+		if x < len(text) {
+			glyph = rune(text[x])
+		}
+		grid.Set(x, y, glyph, barColor)
+	}
+}